@@ -9,6 +9,7 @@ import (
 	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"github.com/pentops/j5build/internal/builder"
 	"github.com/pentops/j5build/internal/source"
+	"github.com/pentops/j5build/internal/structure"
 )
 
 type RegistryClient interface {
@@ -42,11 +43,19 @@ type Builder struct {
 }
 
 func (b *Builder) RunPublishBuild(ctx context.Context, pc PluginContext, input *source_j5pb.SourceImage, build *config_j5pb.PublishConfig) error {
-	return b.impl.RunPublishBuild(ctx, pc.toBuilder(), input, build)
+	builderContext, err := pc.toBuilder()
+	if err != nil {
+		return err
+	}
+	return b.impl.RunPublishBuild(ctx, builderContext, input, build)
 }
 
 func (b *Builder) RunGenerateBuild(ctx context.Context, pc PluginContext, input *source_j5pb.SourceImage, build *config_j5pb.GenerateConfig) error {
-	return b.impl.RunGenerateBuild(ctx, pc.toBuilder(), input, build)
+	builderContext, err := pc.toBuilder()
+	if err != nil {
+		return err
+	}
+	return b.impl.RunGenerateBuild(ctx, builderContext, input, build)
 }
 
 func (b *Builder) MutateImageWithMods(img *source_j5pb.SourceImage, mods []*config_j5pb.ImageMod) error {
@@ -65,14 +74,23 @@ type PluginContext struct {
 	Variables map[string]string
 	ErrOut    io.Writer
 	Dest      Dest
+
+	// Structure is the bundle's structure config, used to classify services
+	// for J5_CLIENT plugins.
+	Structure *config_j5pb.StructureConfig
 }
 
-func (pc PluginContext) toBuilder() builder.PluginContext {
+func (pc PluginContext) toBuilder() (builder.PluginContext, error) {
+	serviceRules, err := structure.RulesFromConfig(pc.Structure)
+	if err != nil {
+		return builder.PluginContext{}, err
+	}
 	return builder.PluginContext{
 		Variables: pc.Variables,
 		ErrOut:    pc.ErrOut,
 		Dest:      pc.Dest,
-	}
+		Services:  serviceRules,
+	}, nil
 }
 
 type Dest interface {
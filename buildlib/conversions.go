@@ -5,32 +5,71 @@ import (
 
 	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
 	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"github.com/pentops/j5build/internal/export"
 	"github.com/pentops/j5build/internal/j5client"
 	"github.com/pentops/j5build/internal/structure"
 )
 
 func DescriptorFromSource(img *source_j5pb.SourceImage) (*client_j5pb.API, error) {
-	sourceAPI, err := structure.APIFromImage(img)
+	return DescriptorFromSourceWithConfig(img, nil)
+}
+
+// DescriptorFromSourceWithConfig builds the client API for the image. The
+// structure config, usually from the bundle's j5.yaml, classifies services
+// which do not follow the naming conventions.
+func DescriptorFromSourceWithConfig(img *source_j5pb.SourceImage, structureConfig *config_j5pb.StructureConfig) (*client_j5pb.API, error) {
+	clientAPI, _, err := descriptorWithEvents(img, structureConfig)
+	return clientAPI, err
+}
+
+func descriptorWithEvents(img *source_j5pb.SourceImage, structureConfig *config_j5pb.StructureConfig) (*client_j5pb.API, []*export.EventService, error) {
+	serviceRules, err := structure.RulesFromConfig(structureConfig)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	sourceAPI, err := structure.APIFromImage(img, serviceRules...)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	clientAPI, err := j5client.APIFromSource(sourceAPI)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	err = structure.ResolveProse(img, clientAPI)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return clientAPI, nil
+	return clientAPI, export.EventsFromStructure(sourceAPI.Events), nil
 }
 
+// SwaggerFromDescriptor builds the Swagger document for the client API. Event
+// services are not part of the client API, use SwaggerFromImage to include
+// them.
 func SwaggerFromDescriptor(descriptorAPI *client_j5pb.API) ([]byte, error) {
-	swaggerDoc, err := export.BuildSwagger(descriptorAPI)
+	return swaggerJSON(descriptorAPI)
+}
+
+func SwaggerFromImage(img *source_j5pb.SourceImage) ([]byte, error) {
+	return SwaggerFromImageWithConfig(img, nil)
+}
+
+// SwaggerFromImageWithConfig classifies services with the structure config, see
+// DescriptorFromSourceWithConfig.
+func SwaggerFromImageWithConfig(img *source_j5pb.SourceImage, structureConfig *config_j5pb.StructureConfig) ([]byte, error) {
+	descriptorAPI, events, err := descriptorWithEvents(img, structureConfig)
+	if err != nil {
+		return nil, err
+	}
+	return swaggerJSON(descriptorAPI, events...)
+}
+
+func swaggerJSON(descriptorAPI *client_j5pb.API, events ...*export.EventService) ([]byte, error) {
+	swaggerDoc, err := export.BuildSwagger(descriptorAPI, events...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,8 +82,28 @@ func SwaggerFromDescriptor(descriptorAPI *client_j5pb.API) ([]byte, error) {
 	return asJson, nil
 }
 
+// JDefFromDescriptor builds the jdef document for the client API. Event
+// services are not part of the client API, use JDefFromImage to include them.
 func JDefFromDescriptor(descriptorAPI *client_j5pb.API) ([]byte, error) {
-	jDefJSON, err := export.FromProto(descriptorAPI)
+	return jDefJSON(descriptorAPI)
+}
+
+func JDefFromImage(img *source_j5pb.SourceImage) ([]byte, error) {
+	return JDefFromImageWithConfig(img, nil)
+}
+
+// JDefFromImageWithConfig classifies services with the structure config, see
+// DescriptorFromSourceWithConfig.
+func JDefFromImageWithConfig(img *source_j5pb.SourceImage, structureConfig *config_j5pb.StructureConfig) ([]byte, error) {
+	descriptorAPI, events, err := descriptorWithEvents(img, structureConfig)
+	if err != nil {
+		return nil, err
+	}
+	return jDefJSON(descriptorAPI, events...)
+}
+
+func jDefJSON(descriptorAPI *client_j5pb.API, events ...*export.EventService) ([]byte, error) {
+	jDefJSON, err := export.FromProto(descriptorAPI, events...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,3 +115,42 @@ func JDefFromDescriptor(descriptorAPI *client_j5pb.API) ([]byte, error) {
 
 	return jDefJSONBytes, nil
 }
+
+func AsyncAPIFromImage(img *source_j5pb.SourceImage) ([]byte, error) {
+	return AsyncAPIFromImageWithConfig(img, nil)
+}
+
+// AsyncAPIFromImageWithConfig classifies services with the structure config,
+// see DescriptorFromSourceWithConfig.
+func AsyncAPIFromImageWithConfig(img *source_j5pb.SourceImage, structureConfig *config_j5pb.StructureConfig) ([]byte, error) {
+	serviceRules, err := structure.RulesFromConfig(structureConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	asyncDoc, err := export.BuildAsyncAPI(img, serviceRules...)
+	if err != nil {
+		return nil, err
+	}
+
+	asJson, err := json.Marshal(asyncDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	return asJson, nil
+}
+
+func JSONSchemaFromDescriptor(descriptorAPI *client_j5pb.API) ([]byte, error) {
+	schemaDoc, err := export.BuildJSONSchema(descriptorAPI)
+	if err != nil {
+		return nil, err
+	}
+
+	asJson, err := json.Marshal(schemaDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	return asJson, nil
+}
@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/breaking"
+	"github.com/pentops/j5build/internal/source"
+	"github.com/pentops/j5build/internal/structure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func runBreaking(ctx context.Context, cfg struct {
+	SourceConfig
+	Against string `flag:"against" default:"" description:"Baseline to compare against: registry:owner/name[@version], git:<ref>, an image file or a directory. Defaults to the latest version of the bundle's registry"`
+}) error {
+
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	err = cfg.EachBundle(ctx, func(bundle source.Bundle) error {
+		bundleConfig, err := bundle.J5Config()
+		if err != nil {
+			return err
+		}
+
+		breakingConfig, err := breaking.ConfigFromFile(bundleConfig.Breaking)
+		if err != nil {
+			return fmt.Errorf("bundle %s: %w", bundle.DebugName(), err)
+		}
+
+		breakingConfig.Services, err = structure.RulesFromConfig(bundleConfig.GetStructure())
+		if err != nil {
+			return fmt.Errorf("bundle %s: %w", bundle.DebugName(), err)
+		}
+
+		baseline, err := baselineImage(ctx, cfg.Source, cfg.Against, bundle, bundleConfig)
+		if err != nil {
+			return fmt.Errorf("bundle %s baseline: %w", bundle.DebugName(), err)
+		}
+
+		img, err := bundle.SourceImage(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		changes, err := breaking.Compare(baseline, img, breakingConfig)
+		if err != nil {
+			return fmt.Errorf("bundle %s: %w", bundle.DebugName(), err)
+		}
+
+		if len(changes) == 0 {
+			fmt.Printf("%s: no breaking changes\n", bundle.DebugName())
+			return nil
+		}
+		found = true
+
+		for _, set := range []breaking.RuleSet{breaking.WireRules, breaking.JSONRules} {
+			header := false
+			for _, change := range changes {
+				if change.Set != set {
+					continue
+				}
+				if !header {
+					fmt.Printf("%s: %s breaking changes\n", bundle.DebugName(), set)
+					header = true
+				}
+				fmt.Printf("  %s\n", change)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if found {
+		return errors.New("breaking changes found")
+	}
+	return nil
+}
+
+func baselineImage(ctx context.Context, sourceDir string, against string, bundle source.Bundle, bundleConfig *config_j5pb.BundleConfigFile) (*source_j5pb.SourceImage, error) {
+	switch {
+	case against == "":
+		if bundleConfig.Registry == nil {
+			return nil, fmt.Errorf("no --against baseline and no registry in bundle config")
+		}
+		return remoteBaseline(ctx, &config_j5pb.Input_Registry{
+			Owner: bundleConfig.Registry.Owner,
+			Name:  bundleConfig.Registry.Name,
+		})
+
+	case strings.HasPrefix(against, "registry:"):
+		ref := strings.TrimPrefix(against, "registry:")
+		input := &config_j5pb.Input_Registry{}
+		if name, version, ok := strings.Cut(ref, "@"); ok {
+			ref = name
+			input.Version = &version
+		}
+		owner, name, ok := strings.Cut(ref, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid registry baseline %q, expecting registry:owner/name[@version]", against)
+		}
+		input.Owner = owner
+		input.Name = name
+		return remoteBaseline(ctx, input)
+
+	case strings.HasPrefix(against, "git:"):
+		dir, err := os.MkdirTemp("", "j5-breaking-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+
+		treeish := strings.TrimPrefix(against, "git:") + ":./"
+		if err := source.ExtractGitTree(ctx, sourceDir, treeish, dir); err != nil {
+			return nil, err
+		}
+		return dirBaseline(ctx, dir, bundle)
+	}
+
+	stat, err := os.Stat(against)
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir() {
+		return dirBaseline(ctx, against, bundle)
+	}
+	return readImageFile(against)
+}
+
+func remoteBaseline(ctx context.Context, input *config_j5pb.Input_Registry) (*source_j5pb.SourceImage, error) {
+	resolver, err := source.NewEnvResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	return resolver.GetRemoteDependency(ctx, &config_j5pb.Input{
+		Type: &config_j5pb.Input_Registry_{
+			Registry: input,
+		},
+	}, nil)
+}
+
+// dirBaseline builds the bundle at the same path in another copy of the repo.
+func dirBaseline(ctx context.Context, dir string, bundle source.Bundle) (*source_j5pb.SourceImage, error) {
+	resolver, err := source.NewEnvResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := source.NewFSRepoRoot(ctx, os.DirFS(dir), resolver.WithLocalRoot(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, baseBundle := range root.AllBundles() {
+		if baseBundle.DirInRepo() == bundle.DirInRepo() {
+			return baseBundle.SourceImage(ctx, root)
+		}
+	}
+	return nil, fmt.Errorf("bundle %q not found in %s", bundle.DirInRepo(), dir)
+}
+
+// readImageFile reads a SourceImage in either the binary or JSON encoding.
+func readImageFile(filename string) (*source_j5pb.SourceImage, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	img := &source_j5pb.SourceImage{}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		err = protojson.Unmarshal(data, img)
+	} else {
+		err = proto.Unmarshal(data, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading image %s: %w", filename, err)
+	}
+	return img, nil
+}
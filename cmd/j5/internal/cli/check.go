@@ -0,0 +1,214 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// OutputFS is a Dest at the root of the repo, which can remove the files it
+// replaces.
+type OutputFS interface {
+	Dest
+
+	// Clean removes the directories, relative to the root.
+	Clean(paths []string) error
+
+	// RemoveFiles removes the files in the directory, relative to the root,
+	// where match returns true for the path relative to dir.
+	RemoveFiles(ctx context.Context, dir string, match func(string) bool) error
+}
+
+var _ OutputFS = &LocalFS{}
+var _ OutputFS = &MemFS{}
+
+// MemFS is an OutputFS which keeps the output in memory, for --check to
+// compare with the files on disk under root. Sub shares the files with the
+// parent.
+type MemFS struct {
+	root   string
+	prefix string
+
+	written map[string][]byte
+	removed map[string]bool
+}
+
+func NewMemFS(root string) *MemFS {
+	return &MemFS{
+		root:    root,
+		written: map[string][]byte{},
+		removed: map[string]bool{},
+	}
+}
+
+func (mem *MemFS) Sub(subPath string) Dest {
+	return &MemFS{
+		root:    mem.root,
+		prefix:  path.Join(mem.prefix, filepath.ToSlash(subPath)),
+		written: mem.written,
+		removed: mem.removed,
+	}
+}
+
+func (mem *MemFS) PutFile(ctx context.Context, subPath string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	mem.written[path.Join(mem.prefix, filepath.ToSlash(subPath))] = data
+	return nil
+}
+
+func (mem *MemFS) Clean(paths []string) error {
+	for _, dir := range paths {
+		if err := mem.RemoveFiles(context.Background(), dir, func(string) bool { return true }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveFiles records the files on disk which would be removed, files written
+// later replace them.
+func (mem *MemFS) RemoveFiles(ctx context.Context, dir string, match func(string) bool) error {
+	dir = path.Join(mem.prefix, filepath.ToSlash(dir))
+	for filename := range mem.written {
+		if rel, ok := relativeTo(dir, filename); ok && match(rel) {
+			delete(mem.written, filename)
+		}
+	}
+
+	root := filepath.Join(mem.root, filepath.FromSlash(dir))
+	err := filepath.WalkDir(root, func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, pathname)
+		if err != nil {
+			return err
+		}
+		if match(filepath.ToSlash(rel)) {
+			mem.removed[path.Join(dir, filepath.ToSlash(rel))] = true
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func relativeTo(dir, filename string) (string, bool) {
+	if dir == "." || dir == "" {
+		return filename, true
+	}
+	return strings.CutPrefix(filename, dir+"/")
+}
+
+// WriteDiff writes a unified diff from the files on disk to the output, for
+// each file which would be changed, added or removed. Returns the number of
+// files which differ.
+func (mem *MemFS) WriteDiff(out io.Writer) (int, error) {
+	filenames := make([]string, 0, len(mem.written)+len(mem.removed))
+	for filename := range mem.written {
+		filenames = append(filenames, filename)
+	}
+	for filename := range mem.removed {
+		if _, ok := mem.written[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+
+	changed := 0
+	for _, filename := range filenames {
+		current, err := os.ReadFile(filepath.Join(mem.root, filepath.FromSlash(filename)))
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+
+		want, written := mem.written[filename]
+		if exists && written && sameOutput(current, want) {
+			continue
+		}
+		changed++
+
+		diff := difflib.UnifiedDiff{
+			A:        splitLines(string(current)),
+			B:        splitLines(string(want)),
+			FromFile: "a/" + filename,
+			ToFile:   "b/" + filename,
+			Context:  3,
+		}
+		if !exists {
+			diff.A = nil
+			diff.FromFile = "/dev/null"
+		}
+		if !written {
+			diff.B = nil
+			diff.ToFile = "/dev/null"
+		}
+		if err := difflib.WriteUnifiedDiff(out, diff); err != nil {
+			return 0, err
+		}
+	}
+	return changed, nil
+}
+
+// splitLines splits the text after each newline. Unlike difflib.SplitLines
+// there is no empty line after a trailing newline, a missing trailing
+// newline is added so the diff lines stay separate.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
+
+// genHeader matches the first line written by genproto, which only differs
+// between files generated by different versions of j5build.
+var genHeader = regexp.MustCompile(`^// Generated by j5build \S+\. DO NOT EDIT\n`)
+
+func sameOutput(current, want []byte) bool {
+	if bytes.Equal(current, want) {
+		return true
+	}
+	if genHeader.Match(current) && genHeader.Match(want) {
+		return bytes.Equal(genHeader.ReplaceAll(current, nil), genHeader.ReplaceAll(want, nil))
+	}
+	return false
+}
+
+// checkOutput prints the drift between the files on disk and the output
+// held in memory to out, failing when there is any.
+func checkOutput(mem *MemFS, out io.Writer, command string) error {
+	changed, err := mem.WriteDiff(out)
+	if err != nil {
+		return err
+	}
+	if changed > 0 {
+		return fmt.Errorf("%d files are out of date, run %s", changed, command)
+	}
+	fmt.Fprintln(os.Stderr, "Generated files are up to date")
+	return nil
+}
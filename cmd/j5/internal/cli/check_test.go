@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckOutput(t *testing.T) {
+	const header = "// Generated by j5build v1.0.0. DO NOT EDIT\n"
+
+	for _, tc := range []struct {
+		name    string
+		disk    map[string]string
+		clean   []string
+		written map[string]string
+		diff    string
+		err     string
+	}{{
+		name: "no change",
+		disk: map[string]string{
+			"gen/a.txt": "a\n",
+		},
+		clean: []string{"gen"},
+		written: map[string]string{
+			"gen/a.txt": "a\n",
+		},
+	}, {
+		name: "header only",
+		disk: map[string]string{
+			"gen/a.proto": header + "a\n",
+		},
+		clean: []string{"gen"},
+		written: map[string]string{
+			"gen/a.proto": strings.Replace(header, "v1.0.0", "v1.1.0", 1) + "a\n",
+		},
+	}, {
+		name: "added",
+		written: map[string]string{
+			"gen/a.txt": "a\n",
+		},
+		diff: strings.Join([]string{
+			"--- /dev/null",
+			"+++ b/gen/a.txt",
+			"@@ -0,0 +1 @@",
+			"+a",
+			"",
+		}, "\n"),
+		err: "1 files are out of date, run j5 test",
+	}, {
+		name: "changed",
+		disk: map[string]string{
+			"gen/a.txt": "a\nb\n",
+		},
+		written: map[string]string{
+			"gen/a.txt": "a\nc\n",
+		},
+		diff: strings.Join([]string{
+			"--- a/gen/a.txt",
+			"+++ b/gen/a.txt",
+			"@@ -1,2 +1,2 @@",
+			" a",
+			"-b",
+			"+c",
+			"",
+		}, "\n"),
+		err: "1 files are out of date, run j5 test",
+	}, {
+		name: "header and content",
+		disk: map[string]string{
+			"gen/a.proto": header + "a\n",
+		},
+		written: map[string]string{
+			"gen/a.proto": header + "b\n",
+		},
+		diff: strings.Join([]string{
+			"--- a/gen/a.proto",
+			"+++ b/gen/a.proto",
+			"@@ -1,2 +1,2 @@",
+			" " + strings.TrimSuffix(header, "\n"),
+			"-a",
+			"+b",
+			"",
+		}, "\n"),
+		err: "1 files are out of date, run j5 test",
+	}, {
+		name: "removed from managed paths",
+		disk: map[string]string{
+			"gen/a.txt":   "a\n",
+			"gen/old.txt": "old\n",
+			"other/b.txt": "b\n",
+		},
+		clean: []string{"gen"},
+		written: map[string]string{
+			"gen/a.txt": "a\n",
+		},
+		diff: strings.Join([]string{
+			"--- a/gen/old.txt",
+			"+++ /dev/null",
+			"@@ -1 +0,0 @@",
+			"-old",
+			"",
+		}, "\n"),
+		err: "1 files are out of date, run j5 test",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			root := t.TempDir()
+			for filename, content := range tc.disk {
+				fullPath := filepath.Join(root, filepath.FromSlash(filename))
+				if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			mem := NewMemFS(root)
+			if err := mem.Clean(tc.clean); err != nil {
+				t.Fatal(err)
+			}
+			for filename, content := range tc.written {
+				if err := mem.PutFile(ctx, filename, strings.NewReader(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out := &bytes.Buffer{}
+			err := checkOutput(mem, out, "j5 test")
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			} else if err == nil || err.Error() != tc.err {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			if out.String() != tc.diff {
+				t.Errorf("unexpected diff:\n%s\nwant:\n%s", out.String(), tc.diff)
+			}
+		})
+	}
+}
+
+func TestMemFSSub(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "gen", "go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.pb.go", "a.txt"} {
+		if err := os.WriteFile(filepath.Join(root, "gen", "go", name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mem := NewMemFS(root)
+	sub := mem.Sub("gen").(*MemFS)
+	if err := sub.PutFile(ctx, "go/b.pb.go", strings.NewReader("b\n")); err != nil {
+		t.Fatal(err)
+	}
+	// only matching files are removed, written files are replaced too
+	err := sub.RemoveFiles(ctx, "go", func(name string) bool {
+		return strings.HasSuffix(name, ".pb.go")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mem.written) != 0 {
+		t.Errorf("expected written files to be removed, got %v", mem.written)
+	}
+	if !mem.removed["gen/go/a.pb.go"] || mem.removed["gen/go/a.txt"] || len(mem.removed) != 1 {
+		t.Errorf("unexpected removed files %v", mem.removed)
+	}
+}
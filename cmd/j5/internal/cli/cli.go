@@ -2,8 +2,10 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
@@ -15,6 +17,7 @@ import (
 	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"github.com/pentops/j5build/internal/builder"
 	"github.com/pentops/j5build/internal/source"
+	"github.com/pentops/log.go/log"
 	"github.com/pentops/runner/commander"
 )
 
@@ -41,13 +44,17 @@ func CommandSet() *commander.CommandSet {
 	cmdGroup.Add("genproto", commander.NewCommand(runJ5sGenProto))
 
 	cmdGroup.Add("verify", commander.NewCommand(runVerify))
+	cmdGroup.Add("breaking", commander.NewCommand(runBreaking))
 	cmdGroup.Add("publish", commander.NewCommand(runPublish))
+	cmdGroup.Add("push", commander.NewCommand(runPush))
 
 	cmdGroup.Add("schema", schemaSet())
 	cmdGroup.Add("protoc", protocSet())
 	cmdGroup.Add("j5s", j5sSet())
 
 	cmdGroup.Add("latest-deps", commander.NewCommand(runLatestDeps))
+	cmdGroup.Add("vendor", commander.NewCommand(runVendor))
+	cmdGroup.Add("registry", registrySet())
 
 	cmdGroup.Add("lsp", commander.NewCommand(runLSP))
 
@@ -61,6 +68,7 @@ func runVersion(ctx context.Context, cfg struct{}) error {
 
 func runLatestDeps(ctx context.Context, cfg struct {
 	SourceConfig
+	UpdateHashes bool `flag:"update-hashes" default:"false" description:"Keep the locked versions and record the hash of their current content"`
 }) error {
 	src, err := cfg.GetSource(ctx)
 	if err != nil {
@@ -77,9 +85,24 @@ func runLatestDeps(ctx context.Context, cfg struct {
 		return err
 	}
 
-	newLockFile, err := resolver.LatestLocks(ctx, allDeps)
-	if err != nil {
-		return err
+	resolver = resolver.WithLocalRoot(cfg.Source)
+
+	var newLockFile *config_j5pb.LockFile
+	if cfg.UpdateHashes {
+		newLockFile, err = resolver.UpdateHashes(ctx, src.LockFile())
+		if err != nil {
+			return err
+		}
+	} else {
+		newLockFile, err = resolver.LatestLocks(ctx, allDeps)
+		if err != nil {
+			return err
+		}
+
+		newLockFile.Plugins, err = latestPluginLocks(ctx, src)
+		if err != nil {
+			return err
+		}
 	}
 
 	data, err := protoyaml.MarshalOptions{}.Marshal(newLockFile)
@@ -89,10 +112,41 @@ func runLatestDeps(ctx context.Context, cfg struct {
 	return cfg.WriteFile("j5-lock.yaml", data)
 }
 
+func runVendor(ctx context.Context, cfg struct {
+	SourceConfig
+}) error {
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return err
+	}
+
+	return src.Vendor(ctx, filepath.Join(cfg.Source, source.VendorDir))
+}
+
+func latestPluginLocks(ctx context.Context, src *source.RepoRoot) ([]*config_j5pb.PluginLock, error) {
+	images, err := src.ListAllPluginImages()
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	dockerWrapper, err := builder.NewRunner(builder.DefaultRegistryAuths)
+	if err != nil {
+		return nil, err
+	}
+	defer dockerWrapper.Close()
+
+	return dockerWrapper.LockPlugins(ctx, images)
+}
+
 type SourceConfig struct {
 	Source string `flag:"dir" default:"." description:"Source / working directory containing j5.yaml and buf.lock.yaml"`
 	Bundle string `flag:"bundle" default:"" description:"When the bundle j5.yaml is in a subdirectory"`
 
+	Offline bool `flag:"offline" default:"false" description:"Only use vendored and cached dependencies, never the registry. Also set by $J5_OFFLINE"`
+
 	_resolved *source.RepoRoot
 }
 
@@ -110,6 +164,11 @@ func (cfg *SourceConfig) GetSource(ctx context.Context) (*source.RepoRoot, error
 		return nil, err
 	}
 
+	resolver = resolver.WithLocalRoot(cfg.Source)
+	if cfg.Offline {
+		resolver = resolver.WithOffline()
+	}
+
 	fsRoot := os.DirFS(cfg.Source)
 	root, err := source.NewFSRepoRoot(ctx, fsRoot, resolver)
 	if err != nil {
@@ -119,6 +178,22 @@ func (cfg *SourceConfig) GetSource(ctx context.Context) (*source.RepoRoot, error
 	return root, nil
 }
 
+// NewRunner builds the plugin runner, with docker images pinned by the lock
+// file.
+func (cfg *SourceConfig) NewRunner(ctx context.Context) (*builder.Runner, error) {
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dockerWrapper, err := builder.NewRunner(builder.DefaultRegistryAuths)
+	if err != nil {
+		return nil, err
+	}
+	dockerWrapper.SetPluginLocks(src.LockFile().Plugins)
+	return dockerWrapper, nil
+}
+
 func (cfg SourceConfig) EachBundle(ctx context.Context, fn func(source.Bundle) error) error {
 	src, err := cfg.GetSource(ctx)
 	if err != nil {
@@ -130,12 +205,12 @@ func (cfg SourceConfig) EachBundle(ctx context.Context, fn func(source.Bundle) e
 		if err != nil {
 			return err
 		}
-		return fn(bundle)
+		return inBundle(bundle.DirInRepo(), fn(bundle))
 	}
 
 	for _, bundle := range src.AllBundles() {
 		if err := fn(bundle); err != nil {
-			return err
+			return inBundle(bundle.DirInRepo(), err)
 		}
 	}
 	return nil
@@ -225,6 +300,31 @@ func (local *LocalFS) Clean(paths []string) error {
 	return nil
 }
 
+func (local *LocalFS) RemoveFiles(ctx context.Context, dir string, match func(string) bool) error {
+	root := filepath.Join(local.root, dir)
+	err := filepath.WalkDir(root, func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, pathname)
+		if err != nil {
+			return err
+		}
+		if !match(filepath.ToSlash(rel)) {
+			return nil
+		}
+		log.WithField(ctx, "file", pathname).Debug("Deleting file")
+		return os.Remove(pathname)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
 func (local *LocalFS) PutFile(ctx context.Context, subPath string, body io.Reader) error {
 	key := filepath.Join(local.root, subPath)
 	err := os.MkdirAll(filepath.Dir(key), 0755)
@@ -256,3 +356,7 @@ func (f *fileWriter) PutFile(ctx context.Context, filename string, data []byte)
 	}
 	return os.WriteFile(path.Join(f.dir, filename), data, 0644)
 }
+
+func (f *fileWriter) RemoveFile(ctx context.Context, filename string) error {
+	return os.Remove(path.Join(f.dir, filename))
+}
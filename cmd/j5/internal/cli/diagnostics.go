@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+)
+
+type DiagnosticConfig struct {
+	Format string `flag:"format" default:"text" description:"Error output format: text, json, sarif or github. Machine formats are written to stdout"`
+}
+
+// diagnosticOutput collects errors for the machine readable formats, which are
+// written as a single document at the end of the command.
+type diagnosticOutput struct {
+	format errpos.Format
+	rule   string
+	diags  []errpos.Diagnostic
+}
+
+// newDiagnosticOutput builds the output for the configured format, rule is
+// the ID given to errors from the command.
+func (cfg DiagnosticConfig) newDiagnosticOutput(rule string) (*diagnosticOutput, error) {
+	format, err := errpos.ParseFormat(cfg.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &diagnosticOutput{
+		format: format,
+		rule:   rule,
+		diags:  []errpos.Diagnostic{},
+	}, nil
+}
+
+func (do *diagnosticOutput) isText() bool {
+	return do.format == errpos.FormatText
+}
+
+// add records the error. Text output is not collected, callers print
+// HumanString as they always have, so add returns false.
+func (do *diagnosticOutput) add(err error) bool {
+	if do.isText() {
+		return false
+	}
+
+	dir := ""
+	var inBundle *bundleError
+	if errors.As(err, &inBundle) {
+		dir = inBundle.dir
+	}
+
+	do.diags = append(do.diags, errpos.Diagnostics(err, dir, do.rule)...)
+	return true
+}
+
+// flush writes the collected diagnostics, then resets for the next run.
+func (do *diagnosticOutput) flush() error {
+	if do.isText() {
+		return nil
+	}
+	err := errpos.WriteDiagnostics(os.Stdout, do.format, "j5", do.diags)
+	do.diags = []errpos.Diagnostic{}
+	return err
+}
+
+// finish reports the result of a command, err may be nil. Machine formats
+// are always written, so an empty document means no errors.
+func (do *diagnosticOutput) finish(err error, contextLines int) error {
+	if do.isText() {
+		if err != nil {
+			printError(err, contextLines)
+		}
+		return err
+	}
+
+	if err != nil {
+		do.add(err)
+	}
+	if flushErr := do.flush(); flushErr != nil {
+		return flushErr
+	}
+	return err
+}
+
+// bundleError records the bundle directory for errors with filenames relative
+// to the bundle, so diagnostics are relative to the repo.
+type bundleError struct {
+	dir string
+	err error
+}
+
+func (e *bundleError) Error() string {
+	return e.err.Error()
+}
+
+func (e *bundleError) Unwrap() error {
+	return e.err
+}
+
+func inBundle(dir string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &bundleError{dir: dir, err: err}
+}
+
+// printError prints errors with source positions for humans.
+func printError(err error, contextLines int) {
+	if e, ok := errpos.AsErrorsWithSource(err); ok {
+		fmt.Fprintln(os.Stderr, e.HumanString(contextLines))
+	}
+}
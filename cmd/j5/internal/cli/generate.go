@@ -3,56 +3,104 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"github.com/pentops/j5build/internal/builder"
 	"github.com/pentops/j5build/internal/source"
+	"github.com/pentops/j5build/internal/structure"
 	"github.com/pentops/log.go/log"
 )
 
 func runGenerate(ctx context.Context, cfg struct {
 	SourceConfig
+	DiagnosticConfig
 	NoClean bool `flag:"no-clean" description:"Do not remove the directories in config as 'managedPaths' before generating"`
 	NoJ5s   bool `flag:"no-j5s" description:"Do not convert J5s source files to proto"`
+	NoCache bool `flag:"no-cache" description:"Run every plugin, rather than reusing responses cached for unchanged inputs"`
+	Watch   bool `flag:"watch" default:"false" description:"Keep running, regenerating the bundles and generate blocks affected by each change. managedPaths are only cleaned on the first run"`
+	Check   bool `flag:"check" default:"false" description:"Write nothing, print a diff of the files which are out of date, including those removed from managedPaths, and fail if there are any"`
 }) error {
 
-	if !cfg.NoJ5s {
-		if err := runJ5sGenProto(ctx, j5sGenProtoConfig{
-			SourceConfig: cfg.SourceConfig,
-		}); err != nil {
-			return err
-		}
-	}
-
-	src, err := cfg.GetSource(ctx)
+	diagnostics, err := cfg.newDiagnosticOutput("GENERATE")
 	if err != nil {
 		return err
 	}
 
-	dockerWrapper, err := builder.NewRunner(builder.DefaultRegistryAuths)
+	dockerWrapper, err := cfg.NewRunner(ctx)
 	if err != nil {
 		return err
 	}
 	bb := builder.NewBuilder(dockerWrapper)
 
-	outRoot, err := NewLocalFS(cfg.Source)
-	if err != nil {
-		return err
+	if !cfg.NoCache {
+		cache, err := builder.NewEnvCache()
+		if err != nil {
+			return err
+		}
+		bb.SetCache(cache)
 	}
 
-	if !cfg.NoClean {
-		repoConfig := src.RepoConfig()
-		if err := outRoot.Clean(repoConfig.ManagedPaths); err != nil {
+	if cfg.Watch && cfg.Check {
+		return fmt.Errorf("--check cannot be used with --watch")
+	}
+
+	var outRoot OutputFS
+	var checkRoot *MemFS
+	if cfg.Check {
+		checkRoot = NewMemFS(cfg.Source)
+		outRoot = checkRoot
+	} else {
+		outRoot, err = NewLocalFS(cfg.Source)
+		if err != nil {
 			return err
 		}
 	}
 
-	j5Config := src.RepoConfig()
-	for _, generator := range j5Config.Generate {
-		if err := runGeneratePlugin(ctx, bb, src, generator, outRoot); err != nil {
-			return err
+	generate := func(ctx context.Context, src *source.RepoRoot, changed []string) error {
+		if !cfg.NoJ5s {
+			err := cfg.EachAffectedBundle(ctx, changed, func(bundle source.Bundle) error {
+				return genProtoBundle(ctx, src, bundle, outRoot)
+			})
+			if err != nil {
+				return err
+			}
+		}
 
+		j5Config := src.RepoConfig()
+		generators := j5Config.Generate
+		if changed != nil {
+			generators = src.AffectedGenerators(changed)
+		} else if !cfg.NoClean {
+			if err := outRoot.Clean(j5Config.ManagedPaths); err != nil {
+				return err
+			}
 		}
+
+		for _, generator := range generators {
+			if err := runGeneratePlugin(ctx, bb, src, generator, outRoot); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if cfg.Watch {
+		return watchSource(ctx, &cfg.SourceConfig, diagnostics, generate)
+	}
+
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = generate(ctx, src, nil)
+	if err := diagnostics.finish(err, 3); err != nil {
+		return err
+	}
+
+	if checkRoot != nil {
+		return checkOutput(checkRoot, os.Stdout, "j5 generate")
 	}
 	return nil
 }
@@ -64,6 +112,15 @@ func runGeneratePlugin(ctx context.Context, bb *builder.Builder, src *source.Rep
 		return err
 	}
 
+	structureConfig, err := src.CombinedStructure(generator.Inputs)
+	if err != nil {
+		return err
+	}
+	serviceRules, err := structure.RulesFromConfig(structureConfig)
+	if err != nil {
+		return err
+	}
+
 	errOut := &lineWriter{
 		writeLine: func(line string) {
 			log.WithField(ctx, "generator", generator.Name).Info(line)
@@ -76,6 +133,7 @@ func runGeneratePlugin(ctx context.Context, bb *builder.Builder, src *source.Rep
 		Variables: map[string]string{},
 		Dest:      dest,
 		ErrOut:    errOut,
+		Services:  serviceRules,
 	}
 
 	if err := builder.MutateImageWithMods(img, generator.Mods); err != nil {
@@ -7,10 +7,13 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pentops/j5build/internal/bcl"
 	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/j5s/fromproto"
+	"github.com/pentops/j5build/internal/j5s/j5lint"
 	"github.com/pentops/j5build/internal/j5s/protobuild"
 	"github.com/pentops/j5build/internal/j5s/protoprint"
 	"github.com/pentops/j5build/internal/source"
@@ -23,14 +26,30 @@ func j5sSet() *commander.CommandSet {
 	genGroup.Add("fmt", commander.NewCommand(runJ5sFmt))
 	genGroup.Add("lint", commander.NewCommand(runJ5sLint))
 	genGroup.Add("genproto", commander.NewCommand(runJ5sGenProto))
+	genGroup.Add("fromproto", commander.NewCommand(runJ5sFromProto))
 	return genGroup
 }
 
 func runJ5sLint(ctx context.Context, cfg struct {
-	Dir  string `flag:"dir" required:"false" description:"Source / working directory containing j5.yaml"`
-	File string `flag:"file" required:"false" description:"Single file to format"`
+	DiagnosticConfig
+	Dir    string `flag:"dir" required:"false" description:"Source / working directory containing j5.yaml"`
+	File   string `flag:"file" required:"false" description:"Single file to format"`
+	Strict bool   `flag:"strict" default:"false" description:"Fail on warnings as well as errors"`
+	Fix    bool   `flag:"fix" default:"false" description:"Apply the suggested fixes, then report what remains"`
+	FixAPI bool   `flag:"fix-api" default:"false" description:"With --fix, also apply fixes which rename parts of the API, e.g. JSON field names and enum values"`
 }) error {
 
+	// lintFailed is true when the result should fail the command, warnings
+	// only fail in strict mode.
+	lintFailed := func(lintErr *errpos.ErrorsWithSource) bool {
+		return cfg.Strict || lintErr.Errors.HasErrors()
+	}
+
+	diagnostics, err := cfg.newDiagnosticOutput("LINT")
+	if err != nil {
+		return err
+	}
+
 	resolver, err := source.NewEnvResolver()
 	if err != nil {
 		return err
@@ -43,7 +62,7 @@ func runJ5sLint(ctx context.Context, cfg struct {
 		}
 	}
 	fsRoot := os.DirFS(cfg.Dir)
-	srcRoot, err := source.NewFSRepoRoot(ctx, fsRoot, resolver)
+	srcRoot, err := source.NewFSRepoRoot(ctx, fsRoot, resolver.WithLocalRoot(cfg.Dir))
 	if err != nil {
 		return err
 	}
@@ -73,76 +92,178 @@ func runJ5sLint(ctx context.Context, cfg struct {
 			return fmt.Errorf("File %s not found in any bundle", cfg.File)
 		}
 
-		deps, err := bundle.GetDependencies(ctx, srcRoot)
-		if err != nil {
-			return err
-		}
-
-		localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
-		if err != nil {
-			return err
-		}
+		lintErr, err := withLintFixes(cfg.Fix, cfg.FixAPI, filepath.Join(cfg.Dir, bundle.DirInRepo()), func() (*errpos.ErrorsWithSource, error) {
+			compiler, err := bundlePackageSet(ctx, srcRoot, bundle)
+			if err != nil {
+				return nil, err
+			}
 
-		compiler, err := protobuild.NewPackageSet(deps, localFiles)
-		if err != nil {
-			return err
-		}
+			data, err := fs.ReadFile(bundle.FS(), relToBundle)
+			if err != nil {
+				return nil, err
+			}
 
-		data, err := fs.ReadFile(bundle.FS(), relToBundle)
-		if err != nil {
-			return err
-		}
+			rules, err := bundleLintRules(bundle)
+			if err != nil {
+				return nil, err
+			}
 
-		lintErr, err := protobuild.LintFile(ctx, compiler, relToBundle, string(data))
+			return protobuild.LintFile(ctx, compiler, relToBundle, string(data), rules...)
+		})
 		if err != nil {
 			return err
 		}
 		if lintErr == nil {
 			fmt.Fprintln(os.Stderr, "No linting errors")
+			return diagnostics.flush()
+		}
+		if !diagnostics.add(inBundle(bundle.DirInRepo(), lintErr)) {
+			fmt.Fprintln(os.Stderr, lintErr.HumanString(2))
+		}
+		if err := diagnostics.flush(); err != nil {
+			return err
+		}
+		if !lintFailed(lintErr) {
+			fmt.Fprintln(os.Stderr, "No linting errors, with warnings")
 			return nil
 		}
-		fmt.Fprintln(os.Stderr, lintErr.HumanString(2))
 		return fmt.Errorf("Linting failed")
 	}
 
 	hadErrors := false
+	hadWarnings := false
 
 	for _, bundle := range bundles {
 
-		deps, err := bundle.GetDependencies(ctx, srcRoot)
-		if err != nil {
-			return err
-		}
-
-		localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
-		if err != nil {
-			return err
-		}
+		lintErr, err := withLintFixes(cfg.Fix, cfg.FixAPI, filepath.Join(cfg.Dir, bundle.DirInRepo()), func() (*errpos.ErrorsWithSource, error) {
+			compiler, err := bundlePackageSet(ctx, srcRoot, bundle)
+			if err != nil {
+				return nil, err
+			}
 
-		compiler, err := protobuild.NewPackageSet(deps, localFiles)
-		if err != nil {
-			return err
-		}
+			rules, err := bundleLintRules(bundle)
+			if err != nil {
+				return nil, err
+			}
 
-		lintErr, err := protobuild.LintAll(ctx, compiler)
+			return protobuild.LintAll(ctx, compiler, rules...)
+		})
 		if err != nil {
 			return err
 		}
 		if lintErr == nil {
 			continue
 		}
-		hadErrors = true
-		fmt.Fprintln(os.Stderr, lintErr.HumanString(2))
+		if lintFailed(lintErr) {
+			hadErrors = true
+		} else {
+			hadWarnings = true
+		}
+		if !diagnostics.add(inBundle(bundle.DirInRepo(), lintErr)) {
+			fmt.Fprintln(os.Stderr, lintErr.HumanString(2))
+		}
+	}
+
+	if err := diagnostics.flush(); err != nil {
+		return err
 	}
 
 	if hadErrors {
 		return fmt.Errorf("Linting failed")
 	}
 
+	if hadWarnings {
+		fmt.Fprintln(os.Stderr, "No linting errors, with warnings")
+		return nil
+	}
 	fmt.Fprintln(os.Stderr, "No linting errors")
 	return nil
 }
 
+func bundlePackageSet(ctx context.Context, srcRoot *source.RepoRoot, bundle source.Bundle) (*protobuild.PackageSet, error) {
+	deps, err := bundle.GetDependencies(ctx, srcRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return protobuild.NewPackageSet(deps, localFiles)
+}
+
+// withLintFixes runs lint, and when fix is set, applies the suggested fixes to
+// the files in bundleDir and runs lint again to report what remains. Fixes
+// which change the API are only applied with fixAPI. Fixes which overlap
+// another are left for the next run.
+func withLintFixes(fix, fixAPI bool, bundleDir string, lint func() (*errpos.ErrorsWithSource, error)) (*errpos.ErrorsWithSource, error) {
+	lintErr, err := lint()
+	if err != nil || lintErr == nil || !fix {
+		return lintErr, err
+	}
+
+	byFile := map[string][]*errpos.Fix{}
+	filenames := []string{}
+	skippedAPI := 0
+	for _, finding := range lintErr.Errors {
+		if finding.Fix == nil || finding.Pos == nil || finding.Pos.Filename == nil {
+			continue
+		}
+		if finding.Fix.ChangesAPI && !fixAPI {
+			skippedAPI++
+			continue
+		}
+		filename := *finding.Pos.Filename
+		if _, ok := byFile[filename]; !ok {
+			filenames = append(filenames, filename)
+		}
+		byFile[filename] = append(byFile[filename], finding.Fix)
+	}
+	if skippedAPI > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d fixes which change the API, use --fix-api to apply them\n", skippedAPI)
+	}
+	if len(filenames) == 0 {
+		return lintErr, nil
+	}
+
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		fullPath := filepath.Join(bundleDir, filename)
+		data, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, err
+		}
+		fixed, applied, err := errpos.ApplyFixes(string(data), byFile[filename])
+		if err != nil {
+			return nil, fmt.Errorf("fixing %s: %w", filename, err)
+		}
+		if applied == 0 {
+			continue
+		}
+		if err := os.WriteFile(fullPath, []byte(fixed), 0644); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "Fixed %d in %s\n", applied, fullPath)
+	}
+
+	return lint()
+}
+
+// bundleLintRules returns the style rules configured for the bundle.
+func bundleLintRules(bundle source.Bundle) ([]j5lint.Rule, error) {
+	bundleConfig, err := bundle.J5Config()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := j5lint.RulesFromConfig(bundleConfig.GetLint())
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", bundle.DirInRepo(), err)
+	}
+	return rules, nil
+}
+
 func runJ5sFmt(ctx context.Context, cfg struct {
 	Dir   string `flag:"dir" required:"false" description:"Source / working directory containing j5.yaml and buf.lock.yaml"`
 	File  string `flag:"file" required:"false" description:"Single file to format"`
@@ -214,38 +335,150 @@ func runForJ5Files(ctx context.Context, root fs.FS, doFile func(ctx context.Cont
 
 type j5sGenProtoConfig struct {
 	SourceConfig
+	DiagnosticConfig
 	Verbose bool `flag:"verbose" env:"BCL_VERBOSE" default:"false" desc:"Verbose output"`
+	Watch   bool `flag:"watch" default:"false" desc:"Keep running, regenerating the bundles affected by each change"`
+	Check   bool `flag:"check" default:"false" desc:"Write nothing, print a diff of the .j5s.proto files which are out of date and fail if there are any"`
 }
 
 func runJ5sGenProto(ctx context.Context, cfg j5sGenProtoConfig) error {
+	diagnostics, err := cfg.newDiagnosticOutput("COMPILE")
+	if err != nil {
+		return err
+	}
+
+	if cfg.Watch && cfg.Check {
+		return fmt.Errorf("--check cannot be used with --watch")
+	}
+
+	var outRoot OutputFS
+	var checkRoot *MemFS
+	if cfg.Check {
+		checkRoot = NewMemFS(cfg.Source)
+		outRoot = checkRoot
+	} else {
+		outRoot, err = NewLocalFS(cfg.Source)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.Watch {
+		return watchSource(ctx, &cfg.SourceConfig, diagnostics, func(ctx context.Context, src *source.RepoRoot, changed []string) error {
+			return cfg.EachAffectedBundle(ctx, changed, func(bundle source.Bundle) error {
+				return genProtoBundle(ctx, src, bundle, outRoot)
+			})
+		})
+	}
+
 	src, err := cfg.GetSource(ctx)
 	if err != nil {
 		return err
 	}
 
+	err = cfg.EachBundle(ctx, func(bundle source.Bundle) error {
+		return genProtoBundle(ctx, src, bundle, outRoot)
+	})
+	if err := diagnostics.finish(err, 3); err != nil {
+		return err
+	}
+
+	if checkRoot != nil {
+		return checkOutput(checkRoot, os.Stdout, "j5 genproto")
+	}
+	return nil
+}
+
+// genProtoBundle replaces the .j5s.proto files in the bundle directory with
+// the output for the j5s files. outRoot is at the root of the repo.
+func genProtoBundle(ctx context.Context, src *source.RepoRoot, bundle source.Bundle, outRoot OutputFS) error {
 	genComment := fmt.Sprintf("Generated by j5build %s. DO NOT EDIT", Version)
 
-	err = cfg.EachBundle(ctx, func(bundle source.Bundle) error {
+	ctx = log.WithField(ctx, "bundle", bundle.DebugName())
+	log.Debug(ctx, "GenProto for Bundle")
 
-		ctx = log.WithField(ctx, "bundle", bundle.DebugName())
-		log.Debug(ctx, "GenProto for Bundle")
+	deps, err := bundle.GetDependencies(ctx, src)
+	if err != nil {
+		return err
+	}
 
-		deps, err := bundle.GetDependencies(ctx, src)
+	localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
+	if err != nil {
+		return err
+	}
+
+	compiler, err := protobuild.NewPackageSet(deps, localFiles)
+	if err != nil {
+		return err
+	}
+
+	err = outRoot.RemoveFiles(ctx, bundle.DirInRepo(), func(filename string) bool {
+		// not using path.Ext because it returns .proto
+		return strings.HasSuffix(filename, ".j5s.proto")
+	})
+	if err != nil {
+		return err
+	}
+
+	outWriter := outRoot.Sub(bundle.DirInRepo())
+
+	for _, pkg := range localFiles.ListPackages() {
+
+		out, err := compiler.CompilePackage(ctx, pkg)
 		if err != nil {
-			return err
+			return fmt.Errorf("compile package %q: %w", pkg, err)
 		}
 
-		localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
-		if err != nil {
-			return err
+		for _, file := range out {
+			filename := file.Path()
+			if !strings.HasSuffix(filename, ".j5s.proto") {
+				continue
+			}
+
+			out, err := protoprint.PrintFile(ctx, file, genComment)
+			if err != nil {
+				log.WithFields(ctx, map[string]interface{}{
+					"error":    err.Error(),
+					"filename": file.Path(),
+				}).Error("Error printing file")
+				return err
+			}
+
+			err = outWriter.PutFile(ctx, filename, strings.NewReader(out))
+			if err != nil {
+				return err
+			}
+
 		}
 
-		compiler, err := protobuild.NewPackageSet(deps, localFiles)
+	}
+
+	return nil
+}
+
+type j5sFromProtoConfig struct {
+	SourceConfig
+	Package string `flag:"package" required:"false" description:"Single package to convert"`
+	Write   bool   `flag:"write" default:"false" desc:"Write the j5s files and delete the proto files they replace"`
+}
+
+func runJ5sFromProto(ctx context.Context, cfg j5sFromProtoConfig) error {
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.EachBundle(ctx, func(bundle source.Bundle) error {
+
+		ctx = log.WithField(ctx, "bundle", bundle.DebugName())
+		log.Debug(ctx, "FromProto for Bundle")
+
+		deps, err := bundle.GetDependencies(ctx, src)
 		if err != nil {
 			return err
 		}
 
-		err = deleteJ5sProto(ctx, bundle.DirInRepo())
+		localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
 		if err != nil {
 			return err
 		}
@@ -256,34 +489,34 @@ func runJ5sGenProto(ctx context.Context, cfg j5sGenProtoConfig) error {
 		}
 
 		for _, pkg := range localFiles.ListPackages() {
+			if cfg.Package != "" && pkg != cfg.Package {
+				continue
+			}
 
-			out, err := compiler.CompilePackage(ctx, pkg)
+			files, err := fromproto.ConvertLocalPackage(ctx, deps, localFiles, pkg)
 			if err != nil {
-				return fmt.Errorf("compile package %q: %w", pkg, err)
+				return err
 			}
 
-			for _, file := range out {
-				filename := file.Path()
-				if !strings.HasSuffix(filename, ".j5s.proto") {
+			for _, file := range files {
+				if !cfg.Write {
+					fmt.Printf("Converted: %s (from %s)\n", file.Filename, strings.Join(file.Replaces, ", "))
+					fmt.Println(file.Content)
 					continue
 				}
 
-				out, err := protoprint.PrintFile(ctx, file, genComment)
+				err = outWriter.PutFile(ctx, file.Filename, []byte(file.Content))
 				if err != nil {
-					log.WithFields(ctx, map[string]interface{}{
-						"error":    err.Error(),
-						"filename": file.Path(),
-					}).Error("Error printing file")
 					return err
 				}
-
-				err = outWriter.PutFile(ctx, filename, []byte(out))
-				if err != nil {
-					return err
+				for _, replaced := range file.Replaces {
+					log.WithField(ctx, "file", replaced).Debug("Deleting file")
+					err = outWriter.RemoveFile(ctx, replaced)
+					if err != nil {
+						return err
+					}
 				}
-
 			}
-
 		}
 
 		return nil
@@ -301,26 +534,3 @@ func runJ5sGenProto(ctx context.Context, cfg j5sGenProtoConfig) error {
 
 	return err
 }
-
-func deleteJ5sProto(ctx context.Context, dir string) error {
-	err := fs.WalkDir(os.DirFS(dir), ".", func(pathname string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-
-		if !strings.HasSuffix(pathname, ".j5s.proto") {
-			// not using path.Ext because it returns .proto
-			return nil
-		}
-
-		log.WithField(ctx, "file", pathname).Debug("Deleting file")
-		return os.Remove(filepath.Join(dir, pathname))
-	})
-	if err != nil {
-		return err
-	}
-	return nil
-}
@@ -2,11 +2,12 @@ package cli
 
 import (
 	"context"
-	"log"
 	"os"
 
 	"github.com/pentops/j5build/internal/bcl/genlsp"
+	"github.com/pentops/j5build/internal/j5s/j5lsp"
 	"github.com/pentops/j5build/internal/j5s/j5parse"
+	"github.com/pentops/log.go/log"
 )
 
 func runLSP(ctx context.Context, cfg struct {
@@ -21,11 +22,22 @@ func runLSP(ctx context.Context, cfg struct {
 		cfg.Dir = pwd
 	}
 
-	log.Printf("ARGS: %+v", os.Args)
-
-	return genlsp.RunLSP(ctx, genlsp.Config{
+	lspConfig := genlsp.Config{
 		ProjectRoot: cfg.Dir,
 		Schema:      j5parse.J5SchemaSpec,
 		FileFactory: j5parse.FileStub,
-	})
+	}
+
+	// Outside of a repo which loads, files are still formatted and linted
+	// alone, without navigation or project diagnostics.
+	workspace, err := j5lsp.NewWorkspace(ctx, cfg.Dir)
+	if err != nil {
+		log.WithError(ctx, err).Warn("loading workspace, running without project features")
+	} else {
+		lspConfig.Navigator = workspace
+		lspConfig.ProjectLinter = workspace
+		lspConfig.Completer = workspace
+	}
+
+	return genlsp.RunLSP(ctx, lspConfig)
 }
@@ -7,6 +7,7 @@ import (
 
 	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"github.com/pentops/j5build/internal/builder"
+	"github.com/pentops/j5build/internal/structure"
 )
 
 func runPublish(ctx context.Context, cfg struct {
@@ -42,7 +43,12 @@ func runPublish(ctx context.Context, cfg struct {
 		return fmt.Errorf("MutateImageWithMods: %w", err)
 	}
 
-	dockerWrapper, err := builder.NewRunner(builder.DefaultRegistryAuths)
+	serviceRules, err := structure.RulesFromConfig(inputConfig.GetStructure())
+	if err != nil {
+		return err
+	}
+
+	dockerWrapper, err := cfg.NewRunner(ctx)
 	if err != nil {
 		return err
 	}
@@ -57,6 +63,7 @@ func runPublish(ctx context.Context, cfg struct {
 		Variables: map[string]string{},
 		Dest:      outRoot,
 		ErrOut:    os.Stderr,
+		Services:  serviceRules,
 	}
 
 	return bb.RunPublishBuild(ctx, pc, img, publish)
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/internal/source"
+	"github.com/pentops/log.go/log"
+)
+
+func runPush(ctx context.Context, cfg struct {
+	SourceConfig
+	Version    string   `flag:"version" optional:"true" description:"Version to push, defaults to the git tag at HEAD, or the commit hash"`
+	Branch     []string `flag:"branch" optional:"true" description:"Branches to point at the version, defaults to the current git branch"`
+	AllowDirty bool     `flag:"allow-dirty" default:"false" description:"Push when the git checkout has uncommitted changes"`
+	DryRun     bool     `flag:"dry-run" default:"false" description:"Build and stamp the images without pushing them"`
+}) error {
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return err
+	}
+
+	version := cfg.Version
+	branches := cfg.Branch
+	if version == "" || len(branches) == 0 {
+		gitVersion, err := source.ReadGitVersion(ctx, cfg.Source)
+		if err != nil {
+			return fmt.Errorf("reading git version, set --version and --branch to push without git: %w", err)
+		}
+		if gitVersion.Dirty && !cfg.AllowDirty {
+			return errors.New("uncommitted changes, commit them or use --allow-dirty")
+		}
+		if version == "" {
+			version = gitVersion.Commit
+			if gitVersion.Tag != "" {
+				version = gitVersion.Tag
+			}
+		}
+		if len(branches) == 0 && gitVersion.Branch != "" {
+			branches = []string{gitVersion.Branch}
+		}
+	}
+
+	var client interface {
+		PushImage(ctx context.Context, owner, repoName, version string, branches []string, img *source_j5pb.SourceImage) error
+	}
+	if !cfg.DryRun {
+		client, err = source.NewEnvRegistryClient()
+		if err != nil {
+			return err
+		}
+	}
+
+	pushed := 0
+	err = cfg.EachBundle(ctx, func(bundle source.Bundle) error {
+		bundleConfig, err := bundle.J5Config()
+		if err != nil {
+			return err
+		}
+		if bundleConfig.Registry == nil {
+			if cfg.Bundle != "" {
+				return fmt.Errorf("no registry in bundle config")
+			}
+			log.WithField(ctx, "bundle", bundle.DebugName()).Debug("no registry, skipping")
+			return nil
+		}
+		owner := bundleConfig.Registry.Owner
+		name := bundleConfig.Registry.Name
+
+		img, err := bundle.SourceImage(ctx, src)
+		if err != nil {
+			return err
+		}
+		img.SourceName = fmt.Sprintf("registry/%s/%s", owner, name)
+		img.Version = &version
+
+		if len(img.Packages) == 0 {
+			return fmt.Errorf("no packages in bundle %s", bundle.DebugName())
+		}
+
+		pushed++
+		if cfg.DryRun {
+			fmt.Printf("%s: would push %s@%s to %v (%d packages, %d files)\n", bundle.DebugName(), img.SourceName, version, branches, len(img.Packages), len(img.SourceFilenames))
+			return nil
+		}
+
+		if err := client.PushImage(ctx, owner, name, version, branches, img); err != nil {
+			return err
+		}
+		fmt.Printf("%s: pushed %s@%s to %v\n", bundle.DebugName(), img.SourceName, version, branches)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if pushed == 0 {
+		return errors.New("no bundles with a registry config")
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pentops/j5build/internal/registry"
+	"github.com/pentops/log.go/log"
+	"github.com/pentops/runner/commander"
+)
+
+func registrySet() *commander.CommandSet {
+	group := commander.NewCommandSet()
+	group.Add("serve", commander.NewCommand(runRegistryServe))
+	return group
+}
+
+func runRegistryServe(ctx context.Context, cfg struct {
+	Dir   string `flag:"dir" description:"Directory to store images in"`
+	Addr  string `flag:"addr" default:":8081" description:"Address to listen on"`
+	Token string `flag:"token" optional:"true" description:"Bearer token required for every request, defaults to $J5_REGISTRY_TOKEN, open when neither is set"`
+}) error {
+	store, err := registry.NewFileStore(cfg.Dir)
+	if err != nil {
+		return err
+	}
+
+	server := registry.NewServer(store)
+	server.Token = cfg.Token
+	if server.Token == "" {
+		server.Token = os.Getenv("J5_REGISTRY_TOKEN")
+	}
+	if server.Token == "" {
+		log.Warn(ctx, "no token set, the registry accepts pushes from anyone")
+	}
+
+	httpServer := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.WithFields(ctx, map[string]interface{}{
+		"addr": cfg.Addr,
+		"dir":  cfg.Dir,
+	}).Info("Serving registry")
+
+	err = httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -21,6 +21,8 @@ func schemaSet() *commander.CommandSet {
 	genGroup.Add("source", commander.NewCommand(RunSource))
 	genGroup.Add("client", commander.NewCommand(RunClient))
 	genGroup.Add("swagger", commander.NewCommand(RunSwagger))
+	genGroup.Add("asyncapi", commander.NewCommand(RunAsyncAPI))
+	genGroup.Add("jsonschema", commander.NewCommand(RunJSONSchema))
 	return genGroup
 }
 
@@ -31,26 +33,38 @@ type BuildConfig struct {
 }
 
 func (cfg BuildConfig) descriptorAPI(ctx context.Context) (*client_j5pb.API, error) {
-	image, _, err := cfg.GetBundleImage(ctx)
+	descriptorAPI, _, err := cfg.descriptorAPIWithEvents(ctx)
+	return descriptorAPI, err
+}
+
+// descriptorAPIWithEvents also returns the event services, which are not part
+// of the client API.
+func (cfg BuildConfig) descriptorAPIWithEvents(ctx context.Context) (*client_j5pb.API, []*export.EventService, error) {
+	image, bundleConfig, err := cfg.GetBundleImage(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	reflectionAPI, err := structure.APIFromImage(image)
+	serviceRules, err := structure.RulesFromConfig(bundleConfig.GetStructure())
 	if err != nil {
-		return nil, fmt.Errorf("ReflectFromSource: %w", err)
+		return nil, nil, err
+	}
+
+	reflectionAPI, err := structure.APIFromImage(image, serviceRules...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ReflectFromSource: %w", err)
 	}
 
 	descriptorAPI, err := j5client.APIFromSource(reflectionAPI)
 	if err != nil {
-		return nil, fmt.Errorf("DescriptorFromReflection: %w", err)
+		return nil, nil, fmt.Errorf("DescriptorFromReflection: %w", err)
 	}
 
 	if err := structure.ResolveProse(image, descriptorAPI); err != nil {
-		return nil, fmt.Errorf("ResolveProse: %w", err)
+		return nil, nil, fmt.Errorf("ResolveProse: %w", err)
 	}
 
-	return descriptorAPI, nil
+	return descriptorAPI, export.EventsFromStructure(reflectionAPI.Events), nil
 }
 
 func RunImage(ctx context.Context, cfg BuildConfig) error {
@@ -71,15 +85,21 @@ func RunSource(ctx context.Context, cfg struct {
 	Package []string `flag:"package" default:"" description:"Package to show"`
 	Schema  string   `flag:"schema" default:"" description:"Schema to show"`
 }) error {
-	image, _, err := cfg.GetBundleImage(ctx)
+	image, bundleConfig, err := cfg.GetBundleImage(ctx)
 	if err != nil {
 		return err
 	}
 
-	sourceAPI, err := structure.APIFromImage(image)
+	serviceRules, err := structure.RulesFromConfig(bundleConfig.GetStructure())
 	if err != nil {
 		return err
 	}
+
+	api, err := structure.APIFromImage(image, serviceRules...)
+	if err != nil {
+		return err
+	}
+	sourceAPI := api.Source
 	out := sourceAPI.ProtoReflect()
 
 	if len(cfg.Package) > 0 {
@@ -151,12 +171,12 @@ func filterPackages[T packageLike](packages []T, filter []string) ([]T, error) {
 }
 
 func RunSwagger(ctx context.Context, cfg BuildConfig) error {
-	descriptorAPI, err := cfg.descriptorAPI(ctx)
+	descriptorAPI, events, err := cfg.descriptorAPIWithEvents(ctx)
 	if err != nil {
 		return err
 	}
 
-	swaggerDoc, err := export.BuildSwagger(descriptorAPI)
+	swaggerDoc, err := export.BuildSwagger(descriptorAPI, events...)
 	if err != nil {
 		return err
 	}
@@ -170,6 +190,58 @@ func RunSwagger(ctx context.Context, cfg BuildConfig) error {
 
 }
 
+func RunAsyncAPI(ctx context.Context, cfg BuildConfig) error {
+	image, bundleConfig, err := cfg.GetBundleImage(ctx)
+	if err != nil {
+		return err
+	}
+
+	serviceRules, err := structure.RulesFromConfig(bundleConfig.GetStructure())
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Package) > 0 {
+		image.Packages, err = filterPackages(image.Packages, cfg.Package)
+		if err != nil {
+			return err
+		}
+	}
+
+	asyncDoc, err := export.BuildAsyncAPI(image, serviceRules...)
+	if err != nil {
+		return err
+	}
+
+	asJson, err := json.Marshal(asyncDoc)
+	if err != nil {
+		return err
+	}
+
+	return writeBytes(cfg.Output, asJson)
+}
+
+func RunJSONSchema(ctx context.Context, cfg BuildConfig) error {
+	descriptorAPI, err := cfg.descriptorAPI(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Schemas referenced from other packages are still included, so the
+	// filter is applied by the export rather than to the API.
+	schemaDoc, err := export.BuildJSONSchema(descriptorAPI, cfg.Package...)
+	if err != nil {
+		return err
+	}
+
+	asJson, err := json.Marshal(schemaDoc)
+	if err != nil {
+		return err
+	}
+
+	return writeBytes(cfg.Output, asJson)
+}
+
 func writeBytes(to string, data []byte) error {
 	if to == "-" {
 		os.Stdout.Write(data)
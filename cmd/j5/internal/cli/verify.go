@@ -16,14 +16,25 @@ import (
 
 func runVerify(ctx context.Context, cfg struct {
 	SourceConfig
+	DiagnosticConfig
 }) error {
 
+	diagnostics, err := cfg.newDiagnosticOutput("VERIFY")
+	if err != nil {
+		return err
+	}
+
+	return diagnostics.finish(verifySource(ctx, cfg.SourceConfig), 3)
+}
+
+func verifySource(ctx context.Context, cfg SourceConfig) error {
+
 	src, err := cfg.GetSource(ctx)
 	if err != nil {
 		return err
 	}
 
-	dockerWrapper, err := builder.NewRunner(builder.DefaultRegistryAuths)
+	dockerWrapper, err := cfg.NewRunner(ctx)
 	if err != nil {
 		return err
 	}
@@ -41,7 +52,12 @@ func runVerify(ctx context.Context, cfg struct {
 			return err
 		}
 
-		sourceAPI, err := structure.APIFromImage(img)
+		serviceRules, err := structure.RulesFromConfig(bundleConfig.GetStructure())
+		if err != nil {
+			return err
+		}
+
+		sourceAPI, err := structure.APIFromImage(img, serviceRules...)
 		if err != nil {
 			return fmt.Errorf("Source API From Image: %w", err)
 		}
@@ -55,7 +71,7 @@ func runVerify(ctx context.Context, cfg struct {
 			return fmt.Errorf("ResolveProse: %w", err)
 		}
 
-		_, err = j5schema.PackageSetFromSourceAPI(sourceAPI.Packages)
+		_, err = j5schema.PackageSetFromSourceAPI(sourceAPI.Source.Packages)
 		if err != nil {
 			return fmt.Errorf("building reflection from descriptor: %w", err)
 		}
@@ -72,6 +88,7 @@ func runVerify(ctx context.Context, cfg struct {
 				Variables: map[string]string{},
 				ErrOut:    os.Stderr,
 				Dest:      NewDiscardFS(),
+				Services:  serviceRules,
 			}, img, publish); err != nil {
 				return err
 			}
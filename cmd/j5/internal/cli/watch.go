@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/source"
+	"github.com/pentops/log.go/log"
+)
+
+const (
+	watchInterval = 250 * time.Millisecond
+
+	// watchDebounce is the quiet period after the last change before
+	// rebuilding, so a burst of saves builds once.
+	watchDebounce = 500 * time.Millisecond
+)
+
+// watchSource runs fn for every bundle, then again after each change to the
+// source files, until the context is done. Changed files are slash separated
+// paths relative to the source root, nil on the first run. Errors are printed
+// rather than returned, so the watch continues.
+func watchSource(ctx context.Context, cfg *SourceConfig, diagnostics *diagnosticOutput, fn func(ctx context.Context, src *source.RepoRoot, changed []string) error) error {
+	root := os.DirFS(cfg.Source)
+	last, err := watchSnapshot(root, cfg.ignoredPaths(ctx))
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for {
+		// The repo root is read again each time, as the config may have changed.
+		cfg._resolved = nil
+		runWatch(ctx, cfg, diagnostics, fn, changed)
+
+		changed, last, err = waitForChanges(ctx, root, last, cfg.ignoredPaths(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func runWatch(ctx context.Context, cfg *SourceConfig, diagnostics *diagnosticOutput, fn func(ctx context.Context, src *source.RepoRoot, changed []string) error, changed []string) {
+	if changed != nil {
+		fmt.Fprintf(os.Stderr, "Changed: %s\n", strings.Join(changed, ", "))
+	}
+
+	err := func() error {
+		src, err := cfg.GetSource(ctx)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, src, changed)
+	}()
+
+	// each build writes a complete document in the machine formats
+	err = diagnostics.finish(err, 3)
+	if err == nil {
+		fmt.Fprintln(os.Stderr, "Build complete, watching for changes")
+		return
+	}
+	if diagnostics.isText() {
+		if _, ok := errpos.AsErrorsWithSource(err); !ok {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Build failed, watching for changes")
+}
+
+// waitForChanges polls the source until files have changed, and then not
+// changed again for the debounce period.
+func waitForChanges(ctx context.Context, root fs.FS, last map[string]fileStamp, ignored []string) ([]string, map[string]fileStamp, error) {
+	var changed map[string]bool
+	var lastChange time.Time
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		current, err := watchSnapshot(root, ignored)
+		if err != nil {
+			log.WithError(ctx, err).Error("watching source")
+			continue
+		}
+
+		diff := diffSnapshots(last, current)
+		last = current
+		if len(diff) > 0 {
+			if changed == nil {
+				changed = map[string]bool{}
+			}
+			for _, filename := range diff {
+				changed[filename] = true
+			}
+			lastChange = time.Now()
+			continue
+		}
+
+		if changed != nil && time.Since(lastChange) >= watchDebounce {
+			out := make([]string, 0, len(changed))
+			for filename := range changed {
+				out = append(out, filename)
+			}
+			sort.Strings(out)
+			return out, last, nil
+		}
+	}
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// isWatchedFile matches the source files which are inputs to a build. The
+// .j5s.proto files are outputs of genproto, so would trigger another build.
+func isWatchedFile(filename string) bool {
+	if strings.HasSuffix(filename, ".j5s.proto") {
+		return false
+	}
+	switch path.Ext(filename) {
+	case ".j5s", ".proto", ".yaml", ".yml", ".md":
+		return true
+	}
+	return false
+}
+
+func watchSnapshot(root fs.FS, ignored []string) (map[string]fileStamp, error) {
+	files := map[string]fileStamp{}
+	err := fs.WalkDir(root, ".", func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if pathname == "." {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			for _, ignore := range ignored {
+				if pathname == ignore {
+					return fs.SkipDir
+				}
+			}
+			return nil
+		}
+		if !isWatchedFile(pathname) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[pathname] = fileStamp{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func diffSnapshots(last, current map[string]fileStamp) []string {
+	diff := make([]string, 0)
+	for filename, stamp := range current {
+		if lastStamp, ok := last[filename]; !ok || lastStamp != stamp {
+			diff = append(diff, filename)
+		}
+	}
+	for filename := range last {
+		if _, ok := current[filename]; !ok {
+			diff = append(diff, filename)
+		}
+	}
+	return diff
+}
+
+// ignoredPaths are the directories written by generate, which are not
+// watched.
+func (cfg *SourceConfig) ignoredPaths(ctx context.Context) []string {
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		// The source is broken, so watch everything until it is fixed.
+		return nil
+	}
+	repoConfig := src.RepoConfig()
+	ignored := make([]string, 0, len(repoConfig.ManagedPaths)+len(repoConfig.Generate))
+	for _, managed := range repoConfig.ManagedPaths {
+		ignored = append(ignored, path.Clean(managed))
+	}
+	for _, generator := range repoConfig.Generate {
+		ignored = append(ignored, path.Clean(generator.Output))
+	}
+	return ignored
+}
+
+// EachAffectedBundle runs fn for the bundles affected by the changed files,
+// or every bundle when changed is nil.
+func (cfg SourceConfig) EachAffectedBundle(ctx context.Context, changed []string, fn func(source.Bundle) error) error {
+	if changed == nil {
+		return cfg.EachBundle(ctx, fn)
+	}
+
+	src, err := cfg.GetSource(ctx)
+	if err != nil {
+		return err
+	}
+
+	var only source.Bundle
+	if cfg.Bundle != "" {
+		only, err = src.BundleSource(cfg.Bundle)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, bundle := range src.AffectedBundles(changed) {
+		if only != nil && only != source.Bundle(bundle) {
+			continue
+		}
+		if err := fn(bundle); err != nil {
+			return inBundle(bundle.DirInRepo(), err)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,62 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ServiceKind int32
+
+const (
+	ServiceKind_SERVICE_KIND_UNSPECIFIED ServiceKind = 0
+	ServiceKind_SERVICE_KIND_SERVICE     ServiceKind = 1
+	ServiceKind_SERVICE_KIND_TOPIC       ServiceKind = 2
+	ServiceKind_SERVICE_KIND_EVENTS      ServiceKind = 3
+	// The service is not included in the API.
+	ServiceKind_SERVICE_KIND_IGNORE ServiceKind = 4
+)
+
+// Enum value maps for ServiceKind.
+var (
+	ServiceKind_name = map[int32]string{
+		0: "SERVICE_KIND_UNSPECIFIED",
+		1: "SERVICE_KIND_SERVICE",
+		2: "SERVICE_KIND_TOPIC",
+		3: "SERVICE_KIND_EVENTS",
+		4: "SERVICE_KIND_IGNORE",
+	}
+	ServiceKind_value = map[string]int32{
+		"SERVICE_KIND_UNSPECIFIED": 0,
+		"SERVICE_KIND_SERVICE":     1,
+		"SERVICE_KIND_TOPIC":       2,
+		"SERVICE_KIND_EVENTS":      3,
+		"SERVICE_KIND_IGNORE":      4,
+	}
+)
+
+func (x ServiceKind) Enum() *ServiceKind {
+	p := new(ServiceKind)
+	*p = x
+	return p
+}
+
+func (x ServiceKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ServiceKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_j5_config_v1_bundle_proto_enumTypes[0].Descriptor()
+}
+
+func (ServiceKind) Type() protoreflect.EnumType {
+	return &file_j5_config_v1_bundle_proto_enumTypes[0]
+}
+
+func (x ServiceKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ServiceKind.Descriptor instead.
+func (ServiceKind) EnumDescriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{0}
+}
+
 // BundleConfigFile represents j5.bundle.yaml
 type BundleConfigFile struct {
 	state         protoimpl.MessageState
@@ -34,8 +90,11 @@ type BundleConfigFile struct {
 	// Includes the image of the input in the output of the bundle, republishing
 	// it. The included input will also be used in resolving dependencies.
 	// All packages from the included input will be included in the output.
-	Includes []*Include     `protobuf:"bytes,7,rep,name=includes,proto3" json:"includes,omitempty"`
-	Plugins  []*BuildPlugin `protobuf:"bytes,6,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	Includes  []*Include       `protobuf:"bytes,7,rep,name=includes,proto3" json:"includes,omitempty"`
+	Plugins   []*BuildPlugin   `protobuf:"bytes,6,rep,name=plugins,proto3" json:"plugins,omitempty"`
+	Breaking  *BreakingConfig  `protobuf:"bytes,8,opt,name=breaking,proto3" json:"breaking,omitempty"`
+	Structure *StructureConfig `protobuf:"bytes,9,opt,name=structure,proto3" json:"structure,omitempty"`
+	Lint      *LintConfig      `protobuf:"bytes,10,opt,name=lint,proto3" json:"lint,omitempty"`
 }
 
 func (x *BundleConfigFile) Reset() {
@@ -119,6 +178,280 @@ func (x *BundleConfigFile) GetPlugins() []*BuildPlugin {
 	return nil
 }
 
+func (x *BundleConfigFile) GetBreaking() *BreakingConfig {
+	if x != nil {
+		return x.Breaking
+	}
+	return nil
+}
+
+func (x *BundleConfigFile) GetStructure() *StructureConfig {
+	if x != nil {
+		return x.Structure
+	}
+	return nil
+}
+
+func (x *BundleConfigFile) GetLint() *LintConfig {
+	if x != nil {
+		return x.Lint
+	}
+	return nil
+}
+
+// BreakingConfig configures the checks run by `j5 breaking`.
+type BreakingConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Rule sets to check, WIRE and/or JSON. Defaults to both.
+	Use []string `protobuf:"bytes,1,rep,name=use,proto3" json:"use,omitempty"`
+	// Rules which are not reported, e.g. FIELD_REMOVED.
+	Except []string `protobuf:"bytes,2,rep,name=except,proto3" json:"except,omitempty"`
+	// Packages which are not checked, e.g. while still unstable.
+	IgnorePackages []string `protobuf:"bytes,3,rep,name=ignore_packages,json=ignorePackages,proto3" json:"ignore_packages,omitempty"`
+}
+
+func (x *BreakingConfig) Reset() {
+	*x = BreakingConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BreakingConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BreakingConfig) ProtoMessage() {}
+
+func (x *BreakingConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BreakingConfig.ProtoReflect.Descriptor instead.
+func (*BreakingConfig) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BreakingConfig) GetUse() []string {
+	if x != nil {
+		return x.Use
+	}
+	return nil
+}
+
+func (x *BreakingConfig) GetExcept() []string {
+	if x != nil {
+		return x.Except
+	}
+	return nil
+}
+
+func (x *BreakingConfig) GetIgnorePackages() []string {
+	if x != nil {
+		return x.IgnorePackages
+	}
+	return nil
+}
+
+// StructureConfig configures how services are mapped into the API structure.
+type StructureConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Rules are checked in order, the first matching rule classifies the
+	// service. Services matching no rule fall back to the name suffix
+	// conventions (Service, Sandbox, Events, Topic).
+	Services []*ServiceRule `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+}
+
+func (x *StructureConfig) Reset() {
+	*x = StructureConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StructureConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StructureConfig) ProtoMessage() {}
+
+func (x *StructureConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StructureConfig.ProtoReflect.Descriptor instead.
+func (*StructureConfig) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StructureConfig) GetServices() []*ServiceRule {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+// ServiceRule matches services by exactly one of suffix, option or name.
+type ServiceRule struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Matches the end of the service name, e.g. API
+	Suffix string `protobuf:"bytes,1,opt,name=suffix,proto3" json:"suffix,omitempty"`
+	// Matches services with the option (extension) set, by full name, e.g.
+	// foo.v1.service
+	Option string `protobuf:"bytes,2,opt,name=option,proto3" json:"option,omitempty"`
+	// Matches a single service by full name, e.g. foo.v1.FooAPI
+	Name string      `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Kind ServiceKind `protobuf:"varint,4,opt,name=kind,proto3,enum=j5.config.v1.ServiceKind" json:"kind,omitempty"`
+}
+
+func (x *ServiceRule) Reset() {
+	*x = ServiceRule{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ServiceRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceRule) ProtoMessage() {}
+
+func (x *ServiceRule) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceRule.ProtoReflect.Descriptor instead.
+func (*ServiceRule) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ServiceRule) GetSuffix() string {
+	if x != nil {
+		return x.Suffix
+	}
+	return ""
+}
+
+func (x *ServiceRule) GetOption() string {
+	if x != nil {
+		return x.Option
+	}
+	return ""
+}
+
+func (x *ServiceRule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ServiceRule) GetKind() ServiceKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ServiceKind_SERVICE_KIND_UNSPECIFIED
+}
+
+// LintConfig configures the style rules checked by `j5 j5s lint`, on top of
+// the compile errors which are always reported.
+type LintConfig struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Optional rules to check, e.g. MISSING_DESCRIPTION.
+	Enable []string `protobuf:"bytes,1,rep,name=enable,proto3" json:"enable,omitempty"`
+	// Rules which are not checked, e.g. FIELD_NAME_CASE.
+	Disable []string `protobuf:"bytes,2,rep,name=disable,proto3" json:"disable,omitempty"`
+}
+
+func (x *LintConfig) Reset() {
+	*x = LintConfig{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LintConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LintConfig) ProtoMessage() {}
+
+func (x *LintConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LintConfig.ProtoReflect.Descriptor instead.
+func (*LintConfig) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LintConfig) GetEnable() []string {
+	if x != nil {
+		return x.Enable
+	}
+	return nil
+}
+
+func (x *LintConfig) GetDisable() []string {
+	if x != nil {
+		return x.Disable
+	}
+	return nil
+}
+
 type Include struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -130,7 +463,7 @@ type Include struct {
 func (x *Include) Reset() {
 	*x = Include{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[1]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -143,7 +476,7 @@ func (x *Include) String() string {
 func (*Include) ProtoMessage() {}
 
 func (x *Include) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[1]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -156,7 +489,7 @@ func (x *Include) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Include.ProtoReflect.Descriptor instead.
 func (*Include) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{1}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Include) GetInput() *Input {
@@ -178,7 +511,7 @@ type RegistryConfig struct {
 func (x *RegistryConfig) Reset() {
 	*x = RegistryConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[2]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -191,7 +524,7 @@ func (x *RegistryConfig) String() string {
 func (*RegistryConfig) ProtoMessage() {}
 
 func (x *RegistryConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[2]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -204,7 +537,7 @@ func (x *RegistryConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RegistryConfig.ProtoReflect.Descriptor instead.
 func (*RegistryConfig) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{2}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *RegistryConfig) GetOwner() string {
@@ -234,7 +567,7 @@ type PackageConfig struct {
 func (x *PackageConfig) Reset() {
 	*x = PackageConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[3]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -247,7 +580,7 @@ func (x *PackageConfig) String() string {
 func (*PackageConfig) ProtoMessage() {}
 
 func (x *PackageConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[3]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -260,7 +593,7 @@ func (x *PackageConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PackageConfig.ProtoReflect.Descriptor instead.
 func (*PackageConfig) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{3}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *PackageConfig) GetLabel() string {
@@ -299,7 +632,7 @@ type PublishConfig struct {
 func (x *PublishConfig) Reset() {
 	*x = PublishConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[4]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -312,7 +645,7 @@ func (x *PublishConfig) String() string {
 func (*PublishConfig) ProtoMessage() {}
 
 func (x *PublishConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[4]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -325,7 +658,7 @@ func (x *PublishConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PublishConfig.ProtoReflect.Descriptor instead.
 func (*PublishConfig) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{4}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *PublishConfig) GetName() string {
@@ -374,7 +707,7 @@ type PackageOptions struct {
 func (x *PackageOptions) Reset() {
 	*x = PackageOptions{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[5]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -387,7 +720,7 @@ func (x *PackageOptions) String() string {
 func (*PackageOptions) ProtoMessage() {}
 
 func (x *PackageOptions) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[5]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -400,7 +733,7 @@ func (x *PackageOptions) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PackageOptions.ProtoReflect.Descriptor instead.
 func (*PackageOptions) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{5}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *PackageOptions) GetSubPackages() []*SubPackageType {
@@ -421,7 +754,7 @@ type SubPackageType struct {
 func (x *SubPackageType) Reset() {
 	*x = SubPackageType{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[6]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -434,7 +767,7 @@ func (x *SubPackageType) String() string {
 func (*SubPackageType) ProtoMessage() {}
 
 func (x *SubPackageType) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[6]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -447,7 +780,7 @@ func (x *SubPackageType) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubPackageType.ProtoReflect.Descriptor instead.
 func (*SubPackageType) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{6}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *SubPackageType) GetName() string {
@@ -465,13 +798,14 @@ type OutputType struct {
 	// Types that are assignable to Type:
 	//
 	//	*OutputType_GoProxy_
+	//	*OutputType_Npm_
 	Type isOutputType_Type `protobuf_oneof:"type"`
 }
 
 func (x *OutputType) Reset() {
 	*x = OutputType{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[7]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -484,7 +818,7 @@ func (x *OutputType) String() string {
 func (*OutputType) ProtoMessage() {}
 
 func (x *OutputType) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[7]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -497,7 +831,7 @@ func (x *OutputType) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputType.ProtoReflect.Descriptor instead.
 func (*OutputType) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{7}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{11}
 }
 
 func (m *OutputType) GetType() isOutputType_Type {
@@ -514,6 +848,13 @@ func (x *OutputType) GetGoProxy() *OutputType_GoProxy {
 	return nil
 }
 
+func (x *OutputType) GetNpm() *OutputType_Npm {
+	if x, ok := x.GetType().(*OutputType_Npm_); ok {
+		return x.Npm
+	}
+	return nil
+}
+
 type isOutputType_Type interface {
 	isOutputType_Type()
 }
@@ -522,8 +863,14 @@ type OutputType_GoProxy_ struct {
 	GoProxy *OutputType_GoProxy `protobuf:"bytes,10,opt,name=go_proxy,json=goProxy,proto3,oneof"`
 }
 
+type OutputType_Npm_ struct {
+	Npm *OutputType_Npm `protobuf:"bytes,11,opt,name=npm,proto3,oneof"`
+}
+
 func (*OutputType_GoProxy_) isOutputType_Type() {}
 
+func (*OutputType_Npm_) isOutputType_Type() {}
+
 // GoProxy serves a go module using the go module proxy protocol
 // https://golang.org/cmd/go/#hdr-Module_proxy_protocol
 // The 'canonical' URL of the module should be a HTTP page which redirects
@@ -544,7 +891,7 @@ type OutputType_GoProxy struct {
 func (x *OutputType_GoProxy) Reset() {
 	*x = OutputType_GoProxy{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[9]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -557,7 +904,7 @@ func (x *OutputType_GoProxy) String() string {
 func (*OutputType_GoProxy) ProtoMessage() {}
 
 func (x *OutputType_GoProxy) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[9]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -570,7 +917,7 @@ func (x *OutputType_GoProxy) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputType_GoProxy.ProtoReflect.Descriptor instead.
 func (*OutputType_GoProxy) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{7, 0}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{11, 0}
 }
 
 func (x *OutputType_GoProxy) GetPath() string {
@@ -594,6 +941,101 @@ func (x *OutputType_GoProxy) GetDeps() []*OutputType_GoProxy_Dep {
 	return nil
 }
 
+// Npm wraps the plugin output, e.g. TypeScript clients, as an npm package.
+// The version is the version of the image when it is semver, otherwise
+// 0.0.0-{version}.
+type OutputType_Npm struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // e.g. @pentops/o5-client
+	// Entry points of the package, as paths in the plugin output, default to
+	// index.js and index.d.ts.
+	Main  string `protobuf:"bytes,2,opt,name=main,proto3" json:"main,omitempty"`
+	Types string `protobuf:"bytes,3,opt,name=types,proto3" json:"types,omitempty"`
+	// Subpath exports in addition to '.', which uses main and types.
+	Exports []*OutputType_Npm_Export `protobuf:"bytes,4,rep,name=exports,proto3" json:"exports,omitempty"`
+	Deps    []*OutputType_Npm_Dep    `protobuf:"bytes,5,rep,name=deps,proto3" json:"deps,omitempty"`
+	// Pack writes the package as a single {name}-{version}.tgz, in the layout
+	// of npm pack, rather than as files.
+	Pack bool `protobuf:"varint,6,opt,name=pack,proto3" json:"pack,omitempty"`
+}
+
+func (x *OutputType_Npm) Reset() {
+	*x = OutputType_Npm{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutputType_Npm) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputType_Npm) ProtoMessage() {}
+
+func (x *OutputType_Npm) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputType_Npm.ProtoReflect.Descriptor instead.
+func (*OutputType_Npm) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{11, 1}
+}
+
+func (x *OutputType_Npm) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OutputType_Npm) GetMain() string {
+	if x != nil {
+		return x.Main
+	}
+	return ""
+}
+
+func (x *OutputType_Npm) GetTypes() string {
+	if x != nil {
+		return x.Types
+	}
+	return ""
+}
+
+func (x *OutputType_Npm) GetExports() []*OutputType_Npm_Export {
+	if x != nil {
+		return x.Exports
+	}
+	return nil
+}
+
+func (x *OutputType_Npm) GetDeps() []*OutputType_Npm_Dep {
+	if x != nil {
+		return x.Deps
+	}
+	return nil
+}
+
+func (x *OutputType_Npm) GetPack() bool {
+	if x != nil {
+		return x.Pack
+	}
+	return false
+}
+
 type OutputType_GoProxy_Dep struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -606,7 +1048,7 @@ type OutputType_GoProxy_Dep struct {
 func (x *OutputType_GoProxy_Dep) Reset() {
 	*x = OutputType_GoProxy_Dep{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_j5_config_v1_bundle_proto_msgTypes[10]
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -619,7 +1061,7 @@ func (x *OutputType_GoProxy_Dep) String() string {
 func (*OutputType_GoProxy_Dep) ProtoMessage() {}
 
 func (x *OutputType_GoProxy_Dep) ProtoReflect() protoreflect.Message {
-	mi := &file_j5_config_v1_bundle_proto_msgTypes[10]
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -632,7 +1074,7 @@ func (x *OutputType_GoProxy_Dep) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OutputType_GoProxy_Dep.ProtoReflect.Descriptor instead.
 func (*OutputType_GoProxy_Dep) Descriptor() ([]byte, []int) {
-	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{7, 0, 0}
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{11, 0, 0}
 }
 
 func (x *OutputType_GoProxy_Dep) GetPath() string {
@@ -649,6 +1091,124 @@ func (x *OutputType_GoProxy_Dep) GetVersion() string {
 	return ""
 }
 
+type OutputType_Npm_Export struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path  string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"` // e.g. ./foo
+	Main  string `protobuf:"bytes,2,opt,name=main,proto3" json:"main,omitempty"`
+	Types string `protobuf:"bytes,3,opt,name=types,proto3" json:"types,omitempty"`
+}
+
+func (x *OutputType_Npm_Export) Reset() {
+	*x = OutputType_Npm_Export{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutputType_Npm_Export) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputType_Npm_Export) ProtoMessage() {}
+
+func (x *OutputType_Npm_Export) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputType_Npm_Export.ProtoReflect.Descriptor instead.
+func (*OutputType_Npm_Export) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{11, 1, 0}
+}
+
+func (x *OutputType_Npm_Export) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *OutputType_Npm_Export) GetMain() string {
+	if x != nil {
+		return x.Main
+	}
+	return ""
+}
+
+func (x *OutputType_Npm_Export) GetTypes() string {
+	if x != nil {
+		return x.Types
+	}
+	return ""
+}
+
+type OutputType_Npm_Dep struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`       // e.g. @pentops/j5-client
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"` // e.g. ^1.2.0
+}
+
+func (x *OutputType_Npm_Dep) Reset() {
+	*x = OutputType_Npm_Dep{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_bundle_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *OutputType_Npm_Dep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputType_Npm_Dep) ProtoMessage() {}
+
+func (x *OutputType_Npm_Dep) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_bundle_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputType_Npm_Dep.ProtoReflect.Descriptor instead.
+func (*OutputType_Npm_Dep) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_bundle_proto_rawDescGZIP(), []int{11, 1, 1}
+}
+
+func (x *OutputType_Npm_Dep) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *OutputType_Npm_Dep) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
 var File_j5_config_v1_bundle_proto protoreflect.FileDescriptor
 
 var file_j5_config_v1_bundle_proto_rawDesc = []byte{
@@ -659,7 +1219,7 @@ var file_j5_config_v1_bundle_proto_rawDesc = []byte{
 	0x6f, 0x74, 0x6f, 0x1a, 0x17, 0x6a, 0x35, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76,
 	0x31, 0x2f, 0x6d, 0x6f, 0x64, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x19, 0x6a, 0x35,
 	0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x31, 0x2f, 0x70, 0x6c, 0x75, 0x67, 0x69,
-	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x95, 0x03, 0x0a, 0x10, 0x42, 0x75, 0x6e, 0x64,
+	0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xba, 0x04, 0x0a, 0x10, 0x42, 0x75, 0x6e, 0x64,
 	0x6c, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x38, 0x0a, 0x08,
 	0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c,
 	0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65,
@@ -684,67 +1244,134 @@ var file_j5_config_v1_bundle_proto_rawDesc = []byte{
 	0x52, 0x08, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x12, 0x33, 0x0a, 0x07, 0x70, 0x6c,
 	0x75, 0x67, 0x69, 0x6e, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6a, 0x35,
 	0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64,
-	0x50, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x52, 0x07, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x73, 0x22,
-	0x34, 0x0a, 0x07, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x12, 0x29, 0x0a, 0x05, 0x69, 0x6e,
-	0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x6a, 0x35, 0x2e, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x05,
-	0x69, 0x6e, 0x70, 0x75, 0x74, 0x22, 0x3a, 0x0a, 0x0e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72,
-	0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a,
-	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x22, 0x4f, 0x0a, 0x0d, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05,
-	0x70, 0x72, 0x6f, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x70, 0x72, 0x6f,
-	0x73, 0x65, 0x22, 0xb7, 0x02, 0x0a, 0x0d, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x3d, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70,
-	0x75, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x18, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4f,
-	0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0c, 0x6f, 0x75, 0x74, 0x70, 0x75,
-	0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x39, 0x0a, 0x04, 0x6f, 0x70, 0x74, 0x73, 0x18,
-	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c, 0x69, 0x73, 0x68, 0x43, 0x6f, 0x6e, 0x66,
-	0x69, 0x67, 0x2e, 0x4f, 0x70, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x04, 0x6f, 0x70,
-	0x74, 0x73, 0x12, 0x33, 0x0a, 0x07, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x73, 0x18, 0x04, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
-	0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x50, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x52, 0x07,
-	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x73, 0x12, 0x2a, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x73, 0x18,
-	0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69,
-	0x67, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67, 0x65, 0x4d, 0x6f, 0x64, 0x52, 0x04, 0x6d,
-	0x6f, 0x64, 0x73, 0x1a, 0x37, 0x0a, 0x09, 0x4f, 0x70, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
-	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x51, 0x0a, 0x0e,
-	0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3f,
-	0x0a, 0x0c, 0x73, 0x75, 0x62, 0x5f, 0x70, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x18, 0x01,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
-	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x54, 0x79,
-	0x70, 0x65, 0x52, 0x0b, 0x73, 0x75, 0x62, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x22,
-	0x24, 0x0a, 0x0e, 0x53, 0x75, 0x62, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70,
-	0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x81, 0x02, 0x0a, 0x0a, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74,
-	0x54, 0x79, 0x70, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x67, 0x6f, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79,
-	0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
+	0x50, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x52, 0x07, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x73, 0x12,
+	0x38, 0x0a, 0x08, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1c, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31,
+	0x2e, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52,
+	0x08, 0x62, 0x72, 0x65, 0x61, 0x6b, 0x69, 0x6e, 0x67, 0x12, 0x3b, 0x0a, 0x09, 0x73, 0x74, 0x72,
+	0x75, 0x63, 0x74, 0x75, 0x72, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x6a,
+	0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x75,
+	0x63, 0x74, 0x75, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x09, 0x73, 0x74, 0x72,
+	0x75, 0x63, 0x74, 0x75, 0x72, 0x65, 0x12, 0x2c, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x74, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67,
+	0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x6e, 0x74, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x04,
+	0x6c, 0x69, 0x6e, 0x74, 0x22, 0x63, 0x0a, 0x0e, 0x42, 0x72, 0x65, 0x61, 0x6b, 0x69, 0x6e, 0x67,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x10, 0x0a, 0x03, 0x75, 0x73, 0x65, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x03, 0x75, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x78, 0x63, 0x65,
+	0x70, 0x74, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x65, 0x78, 0x63, 0x65, 0x70, 0x74,
+	0x12, 0x27, 0x0a, 0x0f, 0x69, 0x67, 0x6e, 0x6f, 0x72, 0x65, 0x5f, 0x70, 0x61, 0x63, 0x6b, 0x61,
+	0x67, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x69, 0x67, 0x6e, 0x6f, 0x72,
+	0x65, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x22, 0x48, 0x0a, 0x0f, 0x53, 0x74, 0x72,
+	0x75, 0x63, 0x74, 0x75, 0x72, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x35, 0x0a, 0x08,
+	0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x52, 0x75, 0x6c, 0x65, 0x52, 0x08, 0x73, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x73, 0x22, 0x80, 0x01, 0x0a, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x52,
+	0x75, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x75, 0x66, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x75, 0x66, 0x66, 0x69, 0x78, 0x12, 0x16, 0x0a, 0x06, 0x6f,
+	0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6f, 0x70, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4b, 0x69, 0x6e, 0x64,
+	0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x22, 0x3e, 0x0a, 0x0a, 0x4c, 0x69, 0x6e, 0x74, 0x43, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x65, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x64, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x64,
+	0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x34, 0x0a, 0x07, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x64,
+	0x65, 0x12, 0x29, 0x0a, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e,
+	0x49, 0x6e, 0x70, 0x75, 0x74, 0x52, 0x05, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x22, 0x3a, 0x0a, 0x0e,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x14,
+	0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f,
+	0x77, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x4f, 0x0a, 0x0d, 0x50, 0x61, 0x63, 0x6b,
+	0x61, 0x67, 0x65, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x61, 0x62,
+	0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x73, 0x65, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x70, 0x72, 0x6f, 0x73, 0x65, 0x22, 0xb7, 0x02, 0x0a, 0x0d, 0x50, 0x75,
+	0x62, 0x6c, 0x69, 0x73, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12,
+	0x3d, 0x0a, 0x0d, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66,
 	0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65,
-	0x2e, 0x47, 0x6f, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x48, 0x00, 0x52, 0x07, 0x67, 0x6f, 0x50, 0x72,
-	0x6f, 0x78, 0x79, 0x1a, 0xab, 0x01, 0x0a, 0x07, 0x47, 0x6f, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x12,
-	0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70,
-	0x61, 0x74, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x67, 0x6f, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
-	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x67, 0x6f, 0x56, 0x65, 0x72, 0x73, 0x69,
-	0x6f, 0x6e, 0x12, 0x38, 0x0a, 0x04, 0x64, 0x65, 0x70, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x24, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e,
-	0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65, 0x2e, 0x47, 0x6f, 0x50, 0x72, 0x6f,
-	0x78, 0x79, 0x2e, 0x44, 0x65, 0x70, 0x52, 0x04, 0x64, 0x65, 0x70, 0x73, 0x1a, 0x33, 0x0a, 0x03,
-	0x44, 0x65, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
-	0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
-	0x6e, 0x42, 0x06, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74,
-	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f,
-	0x6a, 0x35, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f,
-	0x6a, 0x35, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x0c, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x39,
+	0x0a, 0x04, 0x6f, 0x70, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x25, 0x2e, 0x6a,
+	0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x75, 0x62, 0x6c,
+	0x69, 0x73, 0x68, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4f, 0x70, 0x74, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x52, 0x04, 0x6f, 0x70, 0x74, 0x73, 0x12, 0x33, 0x0a, 0x07, 0x70, 0x6c, 0x75,
+	0x67, 0x69, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6a, 0x35, 0x2e,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x50,
+	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x52, 0x07, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x73, 0x12, 0x2a,
+	0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x6a,
+	0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6d, 0x61, 0x67,
+	0x65, 0x4d, 0x6f, 0x64, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x73, 0x1a, 0x37, 0x0a, 0x09, 0x4f, 0x70,
+	0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a,
+	0x02, 0x38, 0x01, 0x22, 0x51, 0x0a, 0x0e, 0x50, 0x61, 0x63, 0x6b, 0x61, 0x67, 0x65, 0x4f, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x3f, 0x0a, 0x0c, 0x73, 0x75, 0x62, 0x5f, 0x70, 0x61, 0x63,
+	0x6b, 0x61, 0x67, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6a, 0x35,
+	0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x75, 0x62, 0x50, 0x61,
+	0x63, 0x6b, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0b, 0x73, 0x75, 0x62, 0x50, 0x61,
+	0x63, 0x6b, 0x61, 0x67, 0x65, 0x73, 0x22, 0x24, 0x0a, 0x0e, 0x53, 0x75, 0x62, 0x50, 0x61, 0x63,
+	0x6b, 0x61, 0x67, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0xff, 0x04, 0x0a,
+	0x0a, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x67,
+	0x6f, 0x5f, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65, 0x2e, 0x47, 0x6f, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x48,
+	0x00, 0x52, 0x07, 0x67, 0x6f, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x12, 0x30, 0x0a, 0x03, 0x6e, 0x70,
+	0x6d, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x79, 0x70,
+	0x65, 0x2e, 0x4e, 0x70, 0x6d, 0x48, 0x00, 0x52, 0x03, 0x6e, 0x70, 0x6d, 0x1a, 0xab, 0x01, 0x0a,
+	0x07, 0x47, 0x6f, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x1d, 0x0a, 0x0a,
+	0x67, 0x6f, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x67, 0x6f, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x38, 0x0a, 0x04, 0x64,
+	0x65, 0x70, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x6a, 0x35, 0x2e, 0x63,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54,
+	0x79, 0x70, 0x65, 0x2e, 0x47, 0x6f, 0x50, 0x72, 0x6f, 0x78, 0x79, 0x2e, 0x44, 0x65, 0x70, 0x52,
+	0x04, 0x64, 0x65, 0x70, 0x73, 0x1a, 0x33, 0x0a, 0x03, 0x44, 0x65, 0x70, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0xc9, 0x02, 0x0a, 0x03, 0x4e,
+	0x70, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x79,
+	0x70, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65, 0x73,
+	0x12, 0x3d, 0x0a, 0x07, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x23, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31,
+	0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65, 0x2e, 0x4e, 0x70, 0x6d, 0x2e,
+	0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x07, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x12,
+	0x34, 0x0a, 0x04, 0x64, 0x65, 0x70, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e,
+	0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74,
+	0x70, 0x75, 0x74, 0x54, 0x79, 0x70, 0x65, 0x2e, 0x4e, 0x70, 0x6d, 0x2e, 0x44, 0x65, 0x70, 0x52,
+	0x04, 0x64, 0x65, 0x70, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x63, 0x6b, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x04, 0x70, 0x61, 0x63, 0x6b, 0x1a, 0x46, 0x0a, 0x06, 0x45, 0x78, 0x70,
+	0x6f, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x61, 0x69, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6d, 0x61, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x74,
+	0x79, 0x70, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x79, 0x70, 0x65,
+	0x73, 0x1a, 0x33, 0x0a, 0x03, 0x44, 0x65, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x06, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x2a, 0x8f,
+	0x01, 0x0a, 0x0b, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x1c,
+	0x0a, 0x18, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x55,
+	0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14,
+	0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x53, 0x45, 0x52,
+	0x56, 0x49, 0x43, 0x45, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43,
+	0x45, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x54, 0x4f, 0x50, 0x49, 0x43, 0x10, 0x02, 0x12, 0x17,
+	0x0a, 0x13, 0x53, 0x45, 0x52, 0x56, 0x49, 0x43, 0x45, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x45,
+	0x56, 0x45, 0x4e, 0x54, 0x53, 0x10, 0x03, 0x12, 0x17, 0x0a, 0x13, 0x53, 0x45, 0x52, 0x56, 0x49,
+	0x43, 0x45, 0x5f, 0x4b, 0x49, 0x4e, 0x44, 0x5f, 0x49, 0x47, 0x4e, 0x4f, 0x52, 0x45, 0x10, 0x04,
+	0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x70,
+	0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x6a, 0x35, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x2f, 0x67,
+	0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x31, 0x2f,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x6a, 0x35, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -759,44 +1386,61 @@ func file_j5_config_v1_bundle_proto_rawDescGZIP() []byte {
 	return file_j5_config_v1_bundle_proto_rawDescData
 }
 
-var file_j5_config_v1_bundle_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_j5_config_v1_bundle_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_j5_config_v1_bundle_proto_msgTypes = make([]protoimpl.MessageInfo, 18)
 var file_j5_config_v1_bundle_proto_goTypes = []any{
-	(*BundleConfigFile)(nil),       // 0: j5.config.v1.BundleConfigFile
-	(*Include)(nil),                // 1: j5.config.v1.Include
-	(*RegistryConfig)(nil),         // 2: j5.config.v1.RegistryConfig
-	(*PackageConfig)(nil),          // 3: j5.config.v1.PackageConfig
-	(*PublishConfig)(nil),          // 4: j5.config.v1.PublishConfig
-	(*PackageOptions)(nil),         // 5: j5.config.v1.PackageOptions
-	(*SubPackageType)(nil),         // 6: j5.config.v1.SubPackageType
-	(*OutputType)(nil),             // 7: j5.config.v1.OutputType
-	nil,                            // 8: j5.config.v1.PublishConfig.OptsEntry
-	(*OutputType_GoProxy)(nil),     // 9: j5.config.v1.OutputType.GoProxy
-	(*OutputType_GoProxy_Dep)(nil), // 10: j5.config.v1.OutputType.GoProxy.Dep
-	(*Input)(nil),                  // 11: j5.config.v1.Input
-	(*BuildPlugin)(nil),            // 12: j5.config.v1.BuildPlugin
-	(*ImageMod)(nil),               // 13: j5.config.v1.ImageMod
+	(ServiceKind)(0),               // 0: j5.config.v1.ServiceKind
+	(*BundleConfigFile)(nil),       // 1: j5.config.v1.BundleConfigFile
+	(*BreakingConfig)(nil),         // 2: j5.config.v1.BreakingConfig
+	(*StructureConfig)(nil),        // 3: j5.config.v1.StructureConfig
+	(*ServiceRule)(nil),            // 4: j5.config.v1.ServiceRule
+	(*LintConfig)(nil),             // 5: j5.config.v1.LintConfig
+	(*Include)(nil),                // 6: j5.config.v1.Include
+	(*RegistryConfig)(nil),         // 7: j5.config.v1.RegistryConfig
+	(*PackageConfig)(nil),          // 8: j5.config.v1.PackageConfig
+	(*PublishConfig)(nil),          // 9: j5.config.v1.PublishConfig
+	(*PackageOptions)(nil),         // 10: j5.config.v1.PackageOptions
+	(*SubPackageType)(nil),         // 11: j5.config.v1.SubPackageType
+	(*OutputType)(nil),             // 12: j5.config.v1.OutputType
+	nil,                            // 13: j5.config.v1.PublishConfig.OptsEntry
+	(*OutputType_GoProxy)(nil),     // 14: j5.config.v1.OutputType.GoProxy
+	(*OutputType_Npm)(nil),         // 15: j5.config.v1.OutputType.Npm
+	(*OutputType_GoProxy_Dep)(nil), // 16: j5.config.v1.OutputType.GoProxy.Dep
+	(*OutputType_Npm_Export)(nil),  // 17: j5.config.v1.OutputType.Npm.Export
+	(*OutputType_Npm_Dep)(nil),     // 18: j5.config.v1.OutputType.Npm.Dep
+	(*Input)(nil),                  // 19: j5.config.v1.Input
+	(*BuildPlugin)(nil),            // 20: j5.config.v1.BuildPlugin
+	(*ImageMod)(nil),               // 21: j5.config.v1.ImageMod
 }
 var file_j5_config_v1_bundle_proto_depIdxs = []int32{
-	2,  // 0: j5.config.v1.BundleConfigFile.registry:type_name -> j5.config.v1.RegistryConfig
-	3,  // 1: j5.config.v1.BundleConfigFile.packages:type_name -> j5.config.v1.PackageConfig
-	4,  // 2: j5.config.v1.BundleConfigFile.publish:type_name -> j5.config.v1.PublishConfig
-	5,  // 3: j5.config.v1.BundleConfigFile.options:type_name -> j5.config.v1.PackageOptions
-	11, // 4: j5.config.v1.BundleConfigFile.dependencies:type_name -> j5.config.v1.Input
-	1,  // 5: j5.config.v1.BundleConfigFile.includes:type_name -> j5.config.v1.Include
-	12, // 6: j5.config.v1.BundleConfigFile.plugins:type_name -> j5.config.v1.BuildPlugin
-	11, // 7: j5.config.v1.Include.input:type_name -> j5.config.v1.Input
-	7,  // 8: j5.config.v1.PublishConfig.output_format:type_name -> j5.config.v1.OutputType
-	8,  // 9: j5.config.v1.PublishConfig.opts:type_name -> j5.config.v1.PublishConfig.OptsEntry
-	12, // 10: j5.config.v1.PublishConfig.plugins:type_name -> j5.config.v1.BuildPlugin
-	13, // 11: j5.config.v1.PublishConfig.mods:type_name -> j5.config.v1.ImageMod
-	6,  // 12: j5.config.v1.PackageOptions.sub_packages:type_name -> j5.config.v1.SubPackageType
-	9,  // 13: j5.config.v1.OutputType.go_proxy:type_name -> j5.config.v1.OutputType.GoProxy
-	10, // 14: j5.config.v1.OutputType.GoProxy.deps:type_name -> j5.config.v1.OutputType.GoProxy.Dep
-	15, // [15:15] is the sub-list for method output_type
-	15, // [15:15] is the sub-list for method input_type
-	15, // [15:15] is the sub-list for extension type_name
-	15, // [15:15] is the sub-list for extension extendee
-	0,  // [0:15] is the sub-list for field type_name
+	7,  // 0: j5.config.v1.BundleConfigFile.registry:type_name -> j5.config.v1.RegistryConfig
+	8,  // 1: j5.config.v1.BundleConfigFile.packages:type_name -> j5.config.v1.PackageConfig
+	9,  // 2: j5.config.v1.BundleConfigFile.publish:type_name -> j5.config.v1.PublishConfig
+	10, // 3: j5.config.v1.BundleConfigFile.options:type_name -> j5.config.v1.PackageOptions
+	19, // 4: j5.config.v1.BundleConfigFile.dependencies:type_name -> j5.config.v1.Input
+	6,  // 5: j5.config.v1.BundleConfigFile.includes:type_name -> j5.config.v1.Include
+	20, // 6: j5.config.v1.BundleConfigFile.plugins:type_name -> j5.config.v1.BuildPlugin
+	2,  // 7: j5.config.v1.BundleConfigFile.breaking:type_name -> j5.config.v1.BreakingConfig
+	3,  // 8: j5.config.v1.BundleConfigFile.structure:type_name -> j5.config.v1.StructureConfig
+	5,  // 9: j5.config.v1.BundleConfigFile.lint:type_name -> j5.config.v1.LintConfig
+	4,  // 10: j5.config.v1.StructureConfig.services:type_name -> j5.config.v1.ServiceRule
+	0,  // 11: j5.config.v1.ServiceRule.kind:type_name -> j5.config.v1.ServiceKind
+	19, // 12: j5.config.v1.Include.input:type_name -> j5.config.v1.Input
+	12, // 13: j5.config.v1.PublishConfig.output_format:type_name -> j5.config.v1.OutputType
+	13, // 14: j5.config.v1.PublishConfig.opts:type_name -> j5.config.v1.PublishConfig.OptsEntry
+	20, // 15: j5.config.v1.PublishConfig.plugins:type_name -> j5.config.v1.BuildPlugin
+	21, // 16: j5.config.v1.PublishConfig.mods:type_name -> j5.config.v1.ImageMod
+	11, // 17: j5.config.v1.PackageOptions.sub_packages:type_name -> j5.config.v1.SubPackageType
+	14, // 18: j5.config.v1.OutputType.go_proxy:type_name -> j5.config.v1.OutputType.GoProxy
+	15, // 19: j5.config.v1.OutputType.npm:type_name -> j5.config.v1.OutputType.Npm
+	16, // 20: j5.config.v1.OutputType.GoProxy.deps:type_name -> j5.config.v1.OutputType.GoProxy.Dep
+	17, // 21: j5.config.v1.OutputType.Npm.exports:type_name -> j5.config.v1.OutputType.Npm.Export
+	18, // 22: j5.config.v1.OutputType.Npm.deps:type_name -> j5.config.v1.OutputType.Npm.Dep
+	23, // [23:23] is the sub-list for method output_type
+	23, // [23:23] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_j5_config_v1_bundle_proto_init() }
@@ -821,7 +1465,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[1].Exporter = func(v any, i int) any {
-			switch v := v.(*Include); i {
+			switch v := v.(*BreakingConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -833,7 +1477,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[2].Exporter = func(v any, i int) any {
-			switch v := v.(*RegistryConfig); i {
+			switch v := v.(*StructureConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -845,7 +1489,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[3].Exporter = func(v any, i int) any {
-			switch v := v.(*PackageConfig); i {
+			switch v := v.(*ServiceRule); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -857,7 +1501,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[4].Exporter = func(v any, i int) any {
-			switch v := v.(*PublishConfig); i {
+			switch v := v.(*LintConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -869,7 +1513,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[5].Exporter = func(v any, i int) any {
-			switch v := v.(*PackageOptions); i {
+			switch v := v.(*Include); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -881,7 +1525,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[6].Exporter = func(v any, i int) any {
-			switch v := v.(*SubPackageType); i {
+			switch v := v.(*RegistryConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -893,7 +1537,19 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[7].Exporter = func(v any, i int) any {
-			switch v := v.(*OutputType); i {
+			switch v := v.(*PackageConfig); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_bundle_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*PublishConfig); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -905,7 +1561,7 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[9].Exporter = func(v any, i int) any {
-			switch v := v.(*OutputType_GoProxy); i {
+			switch v := v.(*PackageOptions); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -917,6 +1573,54 @@ func file_j5_config_v1_bundle_proto_init() {
 			}
 		}
 		file_j5_config_v1_bundle_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*SubPackageType); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_bundle_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputType); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_bundle_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputType_GoProxy); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_bundle_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputType_Npm); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_bundle_proto_msgTypes[15].Exporter = func(v any, i int) any {
 			switch v := v.(*OutputType_GoProxy_Dep); i {
 			case 0:
 				return &v.state
@@ -928,22 +1632,48 @@ func file_j5_config_v1_bundle_proto_init() {
 				return nil
 			}
 		}
+		file_j5_config_v1_bundle_proto_msgTypes[16].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputType_Npm_Export); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_bundle_proto_msgTypes[17].Exporter = func(v any, i int) any {
+			switch v := v.(*OutputType_Npm_Dep); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_j5_config_v1_bundle_proto_msgTypes[7].OneofWrappers = []any{
+	file_j5_config_v1_bundle_proto_msgTypes[11].OneofWrappers = []any{
 		(*OutputType_GoProxy_)(nil),
+		(*OutputType_Npm_)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_j5_config_v1_bundle_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      1,
+			NumMessages:   18,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
 		GoTypes:           file_j5_config_v1_bundle_proto_goTypes,
 		DependencyIndexes: file_j5_config_v1_bundle_proto_depIdxs,
+		EnumInfos:         file_j5_config_v1_bundle_proto_enumTypes,
 		MessageInfos:      file_j5_config_v1_bundle_proto_msgTypes,
 	}.Build()
 	File_j5_config_v1_bundle_proto = out.File
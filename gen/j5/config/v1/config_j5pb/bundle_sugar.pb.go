@@ -3,6 +3,8 @@
 package config_j5pb
 
 import (
+	driver "database/sql/driver"
+	fmt "fmt"
 	proto "google.golang.org/protobuf/proto"
 )
 
@@ -11,12 +13,15 @@ type OutputTypeKey string
 
 const (
 	Output_GoProxy OutputTypeKey = "goProxy"
+	Output_Npm     OutputTypeKey = "npm"
 )
 
 func (x *OutputType) TypeKey() (OutputTypeKey, bool) {
 	switch x.Type.(type) {
 	case *OutputType_GoProxy_:
 		return Output_GoProxy, true
+	case *OutputType_Npm_:
+		return Output_Npm, true
 	default:
 		return "", false
 	}
@@ -31,12 +36,16 @@ func (x *OutputType) Set(val IsOutputTypeWrappedType) {
 	switch v := val.(type) {
 	case *OutputType_GoProxy:
 		x.Type = &OutputType_GoProxy_{GoProxy: v}
+	case *OutputType_Npm:
+		x.Type = &OutputType_Npm_{Npm: v}
 	}
 }
 func (x *OutputType) Get() IsOutputTypeWrappedType {
 	switch v := x.Type.(type) {
 	case *OutputType_GoProxy_:
 		return v.GoProxy
+	case *OutputType_Npm_:
+		return v.Npm
 	default:
 		return nil
 	}
@@ -44,5 +53,68 @@ func (x *OutputType) Get() IsOutputTypeWrappedType {
 func (x *OutputType_GoProxy) TypeKey() OutputTypeKey {
 	return Output_GoProxy
 }
+func (x *OutputType_Npm) TypeKey() OutputTypeKey {
+	return Output_Npm
+}
 
 type IsOutputType_Type = isOutputType_Type
+
+// ServiceKind
+const (
+	ServiceKind_UNSPECIFIED ServiceKind = 0
+	ServiceKind_SERVICE     ServiceKind = 1
+	ServiceKind_TOPIC       ServiceKind = 2
+	ServiceKind_EVENTS      ServiceKind = 3
+	ServiceKind_IGNORE      ServiceKind = 4
+)
+
+var (
+	ServiceKind_name_short = map[int32]string{
+		0: "UNSPECIFIED",
+		1: "SERVICE",
+		2: "TOPIC",
+		3: "EVENTS",
+		4: "IGNORE",
+	}
+	ServiceKind_value_short = map[string]int32{
+		"UNSPECIFIED": 0,
+		"SERVICE":     1,
+		"TOPIC":       2,
+		"EVENTS":      3,
+		"IGNORE":      4,
+	}
+	ServiceKind_value_either = map[string]int32{
+		"UNSPECIFIED":              0,
+		"SERVICE_KIND_UNSPECIFIED": 0,
+		"SERVICE":                  1,
+		"SERVICE_KIND_SERVICE":     1,
+		"TOPIC":                    2,
+		"SERVICE_KIND_TOPIC":       2,
+		"EVENTS":                   3,
+		"SERVICE_KIND_EVENTS":      3,
+		"IGNORE":                   4,
+		"SERVICE_KIND_IGNORE":      4,
+	}
+)
+
+// ShortString returns the un-prefixed string representation of the enum value
+func (x ServiceKind) ShortString() string {
+	return ServiceKind_name_short[int32(x)]
+}
+func (x ServiceKind) Value() (driver.Value, error) {
+	return []uint8(x.ShortString()), nil
+}
+func (x *ServiceKind) Scan(value interface{}) error {
+	var strVal string
+	switch vt := value.(type) {
+	case []uint8:
+		strVal = string(vt)
+	case string:
+		strVal = vt
+	default:
+		return fmt.Errorf("invalid type %T", value)
+	}
+	val := ServiceKind_value_either[strVal]
+	*x = ServiceKind(val)
+	return nil
+}
@@ -29,6 +29,8 @@ type Input struct {
 	//
 	//	*Input_Local
 	//	*Input_Registry_
+	//	*Input_Dir_
+	//	*Input_Git_
 	Type isInput_Type `protobuf_oneof:"type"`
 }
 
@@ -85,6 +87,20 @@ func (x *Input) GetRegistry() *Input_Registry {
 	return nil
 }
 
+func (x *Input) GetDir() *Input_Dir {
+	if x, ok := x.GetType().(*Input_Dir_); ok {
+		return x.Dir
+	}
+	return nil
+}
+
+func (x *Input) GetGit() *Input_Git {
+	if x, ok := x.GetType().(*Input_Git_); ok {
+		return x.Git
+	}
+	return nil
+}
+
 type isInput_Type interface {
 	isInput_Type()
 }
@@ -97,10 +113,22 @@ type Input_Registry_ struct {
 	Registry *Input_Registry `protobuf:"bytes,3,opt,name=registry,proto3,oneof"`
 }
 
+type Input_Dir_ struct {
+	Dir *Input_Dir `protobuf:"bytes,4,opt,name=dir,proto3,oneof"` // a bundle in another j5 repo on the filesystem
+}
+
+type Input_Git_ struct {
+	Git *Input_Git `protobuf:"bytes,5,opt,name=git,proto3,oneof"` // a bundle in a local git repository at a ref
+}
+
 func (*Input_Local) isInput_Type() {}
 
 func (*Input_Registry_) isInput_Type() {}
 
+func (*Input_Dir_) isInput_Type() {}
+
+func (*Input_Git_) isInput_Type() {}
+
 type Input_Registry struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -172,32 +200,182 @@ func (x *Input_Registry) GetReference() string {
 	return ""
 }
 
+type Input_Dir struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path to the root of the other repo, relative paths are from the root
+	// of this repo.
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// Name of the bundle in the other repo, empty for the inline bundle or
+	// when the repo has a single bundle.
+	Bundle string `protobuf:"bytes,2,opt,name=bundle,proto3" json:"bundle,omitempty"`
+}
+
+func (x *Input_Dir) Reset() {
+	*x = Input_Dir{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_input_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Input_Dir) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Input_Dir) ProtoMessage() {}
+
+func (x *Input_Dir) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_input_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Input_Dir.ProtoReflect.Descriptor instead.
+func (*Input_Dir) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_input_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *Input_Dir) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Input_Dir) GetBundle() string {
+	if x != nil {
+		return x.Bundle
+	}
+	return ""
+}
+
+type Input_Git struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Path to the git repository, relative paths are from the root of this
+	// repo.
+	Repo string `protobuf:"bytes,1,opt,name=repo,proto3" json:"repo,omitempty"`
+	// Branch, tag or commit to build, defaults to HEAD. The commit is
+	// recorded in the lock file.
+	Ref string `protobuf:"bytes,2,opt,name=ref,proto3" json:"ref,omitempty"`
+	// Directory within the git repository containing the j5 repo config.
+	Dir string `protobuf:"bytes,3,opt,name=dir,proto3" json:"dir,omitempty"`
+	// Name of the bundle, as for Dir.
+	Bundle string `protobuf:"bytes,4,opt,name=bundle,proto3" json:"bundle,omitempty"`
+}
+
+func (x *Input_Git) Reset() {
+	*x = Input_Git{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_j5_config_v1_input_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Input_Git) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Input_Git) ProtoMessage() {}
+
+func (x *Input_Git) ProtoReflect() protoreflect.Message {
+	mi := &file_j5_config_v1_input_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Input_Git.ProtoReflect.Descriptor instead.
+func (*Input_Git) Descriptor() ([]byte, []int) {
+	return file_j5_config_v1_input_proto_rawDescGZIP(), []int{0, 2}
+}
+
+func (x *Input_Git) GetRepo() string {
+	if x != nil {
+		return x.Repo
+	}
+	return ""
+}
+
+func (x *Input_Git) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *Input_Git) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *Input_Git) GetBundle() string {
+	if x != nil {
+		return x.Bundle
+	}
+	return ""
+}
+
 var File_j5_config_v1_input_proto protoreflect.FileDescriptor
 
 var file_j5_config_v1_input_proto_rawDesc = []byte{
 	0x0a, 0x18, 0x6a, 0x35, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x31, 0x2f, 0x69,
 	0x6e, 0x70, 0x75, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0c, 0x6a, 0x35, 0x2e, 0x63,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x22, 0xf6, 0x01, 0x0a, 0x05, 0x49, 0x6e, 0x70,
+	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x22, 0xda, 0x03, 0x0a, 0x05, 0x49, 0x6e, 0x70,
 	0x75, 0x74, 0x12, 0x16, 0x0a, 0x05, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x09, 0x48, 0x00, 0x52, 0x05, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x12, 0x3a, 0x0a, 0x08, 0x72, 0x65,
 	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x6a,
 	0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e, 0x49, 0x6e, 0x70, 0x75,
 	0x74, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x48, 0x00, 0x52, 0x08, 0x72, 0x65,
-	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x1a, 0x90, 0x01, 0x0a, 0x08, 0x52, 0x65, 0x67, 0x69, 0x73,
-	0x74, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a,
-	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00,
-	0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x09,
-	0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48,
-	0x01, 0x52, 0x09, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x88, 0x01, 0x01, 0x42,
-	0x0a, 0x0a, 0x08, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f,
-	0x72, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x42, 0x06, 0x0a, 0x04, 0x74, 0x79, 0x70,
-	0x65, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x6a, 0x35, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x2f,
-	0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2f, 0x76, 0x31,
-	0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x6a, 0x35, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x33,
+	0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x2b, 0x0a, 0x03, 0x64, 0x69, 0x72, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
+	0x76, 0x31, 0x2e, 0x49, 0x6e, 0x70, 0x75, 0x74, 0x2e, 0x44, 0x69, 0x72, 0x48, 0x00, 0x52, 0x03,
+	0x64, 0x69, 0x72, 0x12, 0x2b, 0x0a, 0x03, 0x67, 0x69, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x17, 0x2e, 0x6a, 0x35, 0x2e, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x76, 0x31, 0x2e,
+	0x49, 0x6e, 0x70, 0x75, 0x74, 0x2e, 0x47, 0x69, 0x74, 0x48, 0x00, 0x52, 0x03, 0x67, 0x69, 0x74,
+	0x1a, 0x90, 0x01, 0x0a, 0x08, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x72, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6f, 0x77,
+	0x6e, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a, 0x09, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65,
+	0x6e, 0x63, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x48, 0x01, 0x52, 0x09, 0x72, 0x65, 0x66,
+	0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x72, 0x65, 0x66, 0x65, 0x72, 0x65,
+	0x6e, 0x63, 0x65, 0x1a, 0x31, 0x0a, 0x03, 0x44, 0x69, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x16,
+	0x0a, 0x06, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x1a, 0x55, 0x0a, 0x03, 0x47, 0x69, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x72, 0x65, 0x70, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x72, 0x65, 0x70,
+	0x6f, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x65, 0x66, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
+	0x72, 0x65, 0x66, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x69, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x64, 0x69, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x42, 0x06, 0x0a,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x70, 0x65, 0x6e, 0x74, 0x6f, 0x70, 0x73, 0x2f, 0x6a, 0x35, 0x62, 0x75,
+	0x69, 0x6c, 0x64, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x6a, 0x35, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69,
+	0x67, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x5f, 0x6a, 0x35, 0x70, 0x62,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -212,18 +390,22 @@ func file_j5_config_v1_input_proto_rawDescGZIP() []byte {
 	return file_j5_config_v1_input_proto_rawDescData
 }
 
-var file_j5_config_v1_input_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_j5_config_v1_input_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_j5_config_v1_input_proto_goTypes = []any{
 	(*Input)(nil),          // 0: j5.config.v1.Input
 	(*Input_Registry)(nil), // 1: j5.config.v1.Input.Registry
+	(*Input_Dir)(nil),      // 2: j5.config.v1.Input.Dir
+	(*Input_Git)(nil),      // 3: j5.config.v1.Input.Git
 }
 var file_j5_config_v1_input_proto_depIdxs = []int32{
 	1, // 0: j5.config.v1.Input.registry:type_name -> j5.config.v1.Input.Registry
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2, // 1: j5.config.v1.Input.dir:type_name -> j5.config.v1.Input.Dir
+	3, // 2: j5.config.v1.Input.git:type_name -> j5.config.v1.Input.Git
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_j5_config_v1_input_proto_init() }
@@ -256,10 +438,36 @@ func file_j5_config_v1_input_proto_init() {
 				return nil
 			}
 		}
+		file_j5_config_v1_input_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Input_Dir); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_j5_config_v1_input_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Input_Git); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_j5_config_v1_input_proto_msgTypes[0].OneofWrappers = []any{
 		(*Input_Local)(nil),
 		(*Input_Registry_)(nil),
+		(*Input_Dir_)(nil),
+		(*Input_Git_)(nil),
 	}
 	file_j5_config_v1_input_proto_msgTypes[1].OneofWrappers = []any{}
 	type x struct{}
@@ -268,7 +476,7 @@ func file_j5_config_v1_input_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_j5_config_v1_input_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
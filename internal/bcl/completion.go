@@ -0,0 +1,188 @@
+package bcl
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/bcl/gen/j5/bcl/v1/bcl_j5pb"
+	"github.com/pentops/j5build/internal/bcl/internal/parser"
+	"github.com/pentops/j5build/internal/bcl/internal/walker"
+	"github.com/pentops/j5build/internal/bcl/internal/walker/schema"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type CompletionKind int
+
+const (
+	// CompleteBlock is a block keyword valid in the current body.
+	CompleteBlock CompletionKind = iota
+
+	// CompleteAttribute is a key which can be assigned in the current body.
+	CompleteAttribute
+
+	// CompleteTypeSelect is a type which can be selected by a block's tag.
+	CompleteTypeSelect
+
+	// CompleteValue is a value for the key of the current assignment.
+	CompleteValue
+)
+
+type Completion struct {
+	Label string
+	Kind  CompletionKind
+}
+
+var (
+	reStatementStart = regexp.MustCompile(`^\s*[A-Za-z0-9_]*$`)
+	reAssignment     = regexp.MustCompile(`^\s*([A-Za-z0-9_.]+)\s*\+?=\s*[A-Za-z0-9_]*$`)
+)
+
+// CompletionsAt returns the names which are valid at the point in the file,
+// based on the schema of the blocks enclosing the point. The column counts
+// runes, as in the lexer. The line containing the point is ignored when
+// parsing, as it is usually incomplete. msg is only used to build the schema
+// scope, it is not fully populated.
+func (p *Parser) CompletionsAt(data string, msg protoreflect.Message, point errpos.Point) ([]Completion, error) {
+	lines := strings.Split(data, "\n")
+	if point.Line < 0 || point.Line >= len(lines) {
+		return nil, nil
+	}
+
+	line := []rune(lines[point.Line])
+	prefix := string(line[:min(max(point.Column, 0), len(line))])
+	lines[point.Line] = ""
+
+	tree, err := parser.ParseFile(strings.Join(lines, "\n"), false)
+	if err != nil && err != parser.HadErrors {
+		return nil, err
+	}
+
+	path := blocksAt(tree.Body, point)
+	for _, block := range path {
+		if block.Directive {
+			// Partials are checked where they are included, there is no
+			// scope to complete from.
+			return nil, nil
+		}
+	}
+
+	obj, err := p.refl.NewObject(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := schema.NewRootSchemaWalker(p.schema, obj, &bcl_j5pb.SourceLocation{})
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := walker.ScopeAt(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if reStatementStart.MatchString(prefix) {
+		completions := []Completion{}
+		for _, name := range scope.ListBlocks() {
+			completions = append(completions, Completion{Label: name, Kind: CompleteBlock})
+		}
+		for _, name := range scope.ListAttributes() {
+			completions = append(completions, Completion{Label: name, Kind: CompleteAttribute})
+		}
+		return completions, nil
+	}
+
+	if match := reAssignment.FindStringSubmatch(prefix); match != nil {
+		return assignmentCompletions(scope, strings.Split(match[1], ".")), nil
+	}
+
+	return headerCompletions(scope, prefix), nil
+}
+
+func assignmentCompletions(scope *schema.Scope, key []string) []Completion {
+	for _, name := range key[:len(key)-1] {
+		child, err := scope.ChildBlock(name, schema.SourceLocation{})
+		if err != nil {
+			return nil
+		}
+		scope = child
+	}
+
+	options, ok := scope.EnumOptions(key[len(key)-1])
+	if !ok {
+		return nil
+	}
+
+	completions := make([]Completion, 0, len(options))
+	for _, option := range options {
+		completions = append(completions, Completion{Label: option, Kind: CompleteValue})
+	}
+	return completions
+}
+
+// headerCompletions completes the tags of a block header, currently only the
+// type-select tag, e.g. the type in `field foo string`.
+func headerCompletions(scope *schema.Scope, prefix string) []Completion {
+	words := strings.Fields(prefix)
+	if len(words) == 0 {
+		return nil
+	}
+
+	tagIndex := len(words) - 1 // complete tags after the block keyword
+	if !strings.HasSuffix(prefix, " ") {
+		tagIndex--
+		if strings.Contains(words[len(words)-1], ":") {
+			// qualified tags are references, not a type-select.
+			return nil
+		}
+	}
+
+	blockScope, err := scope.ChildBlock(words[0], schema.SourceLocation{})
+	if err != nil {
+		return nil
+	}
+
+	spec := blockScope.CurrentBlock().Spec()
+	typeSelectIndex := 0
+	if spec.Name != nil {
+		typeSelectIndex = 1
+	}
+	if tagIndex != typeSelectIndex {
+		return nil
+	}
+
+	options := blockScope.TypeSelectOptions()
+	completions := make([]Completion, 0, len(options))
+	for _, option := range options {
+		completions = append(completions, Completion{Label: option, Kind: CompleteTypeSelect})
+	}
+	return completions
+}
+
+// blocksAt returns the chain of open blocks enclosing the point, outermost
+// first.
+func blocksAt(body parser.Body, point errpos.Point) []*parser.Block {
+	for _, stmt := range body.Statements {
+		block, ok := stmt.(*parser.Block)
+		if !ok || !block.Open {
+			continue
+		}
+		if !pointBefore(block.End, point) {
+			continue
+		}
+		// Unclosed blocks run to the end of the file.
+		if block.Close != nil && pointBefore(block.Close.Start, point) {
+			continue
+		}
+		return append([]*parser.Block{block}, blocksAt(block.Body, point)...)
+	}
+	return nil
+}
+
+func pointBefore(a, b errpos.Point) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
@@ -110,6 +110,17 @@ func (e Errors) Append(err error) Errors {
 	})
 }
 
+// HasErrors is true when any of the errors has SeverityError, i.e. is not only
+// warnings or hints.
+func (e Errors) HasErrors() bool {
+	for _, err := range e {
+		if err.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
 func (e Errors) Error() string {
 	if len(e) > 0 {
 		return e[0].Error()
@@ -183,12 +194,58 @@ func AsErrors(err error) (Errors, bool) {
 	return nil, false
 }
 
+// Severity of an Err, the zero value is an error, so errors which do not set
+// a severity fail the build.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+	SeverityHint
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// LSPSeverity is the DiagnosticSeverity in the Language Server Protocol,
+// which numbers from 1 in the same order.
+func (s Severity) LSPSeverity() float64 {
+	return float64(s + 1)
+}
+
 // Error wraps it all together.
 // Short names are annoying but - duck typing.
 type Err struct {
 	Pos *Position
 	Ctx Context
 	Err error
+
+	Severity Severity
+
+	// Rule is the code for the check which raised the error, e.g.
+	// UNUSED_IMPORT. Empty uses the default of the tool reporting it.
+	Rule string
+
+	// Fix is a suggested change which resolves the error, may be nil.
+	Fix *Fix
+
+	// Via lists the positions which lead to Pos when the source at Pos was
+	// merged in from elsewhere, e.g. .include statements for a partial,
+	// innermost first.
+	Via []Position
 }
 
 var _ HasPosition = &Err{}
@@ -281,6 +338,33 @@ func AddPosition(err error, pos Position) error {
 	return existing
 }
 
+// AddVia records that the error was reached through the statement at pos,
+// e.g. the error is within a partial and pos is where it was included.
+// The filename is set on the existing position when it has none, as the
+// source for the error may not be the file which reports it.
+// If the error is nil, returns nil.
+func AddVia(err error, pos Position, filename string) error {
+	if err == nil {
+		return nil
+	}
+
+	existing := &Err{}
+	if !errors.As(err, &existing) {
+		existing = &Err{
+			Pos: GetErrorPosition(err),
+			Err: err,
+		}
+		err = existing
+	}
+
+	if filename != "" && existing.Pos != nil && existing.Pos.Filename == nil {
+		existing.Pos.Filename = &filename
+	}
+
+	existing.Via = append(existing.Via, pos)
+	return err
+}
+
 func AddFilename(err error, filename string) error {
 	if err == nil {
 		return nil
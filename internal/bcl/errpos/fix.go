@@ -0,0 +1,166 @@
+package errpos
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Edit replaces the text from Start up to End. Unlike Position, End is
+// exclusive, so an insert has Start equal to End. Columns count runes, as in
+// the BCL lexer.
+type Edit struct {
+	Start   Point
+	End     Point
+	NewText string
+}
+
+// Fix is a suggested change which resolves an error. The edits are in the
+// file of the error.
+type Fix struct {
+	Title string
+	Edits []Edit
+
+	// ChangesAPI marks a fix which renames part of the API, e.g. a JSON field
+	// name or an enum value, so breaks clients. These are only applied when
+	// asked for explicitly.
+	ChangesAPI bool
+}
+
+// ReplaceFix replaces the text at the position, where the end of the position
+// is inclusive, as from the BCL parser.
+func ReplaceFix(title string, pos Position, newText string) *Fix {
+	return &Fix{
+		Title: title,
+		Edits: []Edit{{
+			Start:   pos.Start,
+			End:     Point{Line: pos.End.Line, Column: pos.End.Column + 1},
+			NewText: newText,
+		}},
+	}
+}
+
+type HasFix interface {
+	error
+	ErrorFix() *Fix
+}
+
+var _ HasFix = &Err{}
+
+func GetErrorFix(err error) *Fix {
+	var fixErr HasFix
+	if errors.As(err, &fixErr) {
+		return fixErr.ErrorFix()
+	}
+	return nil
+}
+
+func (e *Err) ErrorFix() *Fix {
+	return e.Fix
+}
+
+// AddFix adds a suggested fix to an error.
+// If the error is nil, returns nil.
+func AddFix(err error, fix *Fix) error {
+	if err == nil {
+		return nil
+	}
+
+	existing := &Err{}
+	if !errors.As(err, &existing) {
+		return &Err{
+			Pos: GetErrorPosition(err),
+			Err: err,
+			Fix: fix,
+		}
+	}
+
+	existing.mergeErr(err, "Fix")
+	existing.Fix = fix
+	return existing
+}
+
+// ApplyFixes applies the fixes to the file data, returning the new data and
+// the number of fixes applied. A fix with an edit overlapping an earlier fix
+// is skipped, so running again after fixing the rest can apply it.
+func ApplyFixes(data string, fixes []*Fix) (string, int, error) {
+	lines := strings.Split(data, "\n")
+	offset := func(pt Point) (int, error) {
+		if pt.Line < 0 || pt.Line > len(lines) {
+			return 0, fmt.Errorf("line %d out of range", pt.Line+1)
+		}
+		if pt.Line == len(lines) {
+			// the end of the last line with a newline
+			return len([]rune(data)), nil
+		}
+		line := []rune(lines[pt.Line])
+		if pt.Column < 0 || pt.Column > len(line) {
+			return 0, fmt.Errorf("column %d out of range on line %d", pt.Column+1, pt.Line+1)
+		}
+		start := 0
+		for _, before := range lines[:pt.Line] {
+			start += len([]rune(before)) + 1
+		}
+		return start + pt.Column, nil
+	}
+
+	type runeEdit struct {
+		start, end int
+		text       string
+	}
+
+	accepted := []runeEdit{}
+	overlaps := func(edit runeEdit) bool {
+		for _, other := range accepted {
+			if edit.start < other.end && other.start < edit.end {
+				return true
+			}
+			if edit.start == other.start && edit.end == other.end {
+				// two inserts at the same point
+				return true
+			}
+		}
+		return false
+	}
+
+	applied := 0
+	for _, fix := range fixes {
+		edits := make([]runeEdit, 0, len(fix.Edits))
+		conflict := false
+		for _, edit := range fix.Edits {
+			start, err := offset(edit.Start)
+			if err != nil {
+				return "", 0, fmt.Errorf("fix %q: %w", fix.Title, err)
+			}
+			end, err := offset(edit.End)
+			if err != nil {
+				return "", 0, fmt.Errorf("fix %q: %w", fix.Title, err)
+			}
+			if end < start {
+				return "", 0, fmt.Errorf("fix %q: edit ends before it starts", fix.Title)
+			}
+			re := runeEdit{start: start, end: end, text: edit.NewText}
+			if overlaps(re) {
+				conflict = true
+				break
+			}
+			edits = append(edits, re)
+		}
+		if conflict {
+			continue
+		}
+		accepted = append(accepted, edits...)
+		applied++
+	}
+
+	// apply from the end, so earlier offsets are not moved.
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].start > accepted[j].start
+	})
+	runes := []rune(data)
+	for _, edit := range accepted {
+		runes = append(runes[:edit.start], append([]rune(edit.text), runes[edit.end:]...)...)
+	}
+	return string(runes), applied, nil
+}
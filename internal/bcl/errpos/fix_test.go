@@ -0,0 +1,63 @@
+package errpos
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestApplyFixes(t *testing.T) {
+	data := "package foo.v1\n\nimport bar.v1\n\nobject foo_bar {\n  field barId string\n}\n"
+
+	fixes := []*Fix{{
+		Title: "Remove import",
+		Edits: []Edit{{
+			Start: Point{Line: 2, Column: 0},
+			End:   Point{Line: 3, Column: 0},
+		}},
+	},
+		ReplaceFix("Rename object", Position{
+			Start: Point{Line: 4, Column: 7},
+			End:   Point{Line: 4, Column: 13},
+		}, "FooBar"),
+		ReplaceFix("Rename field", Position{
+			Start: Point{Line: 5, Column: 8},
+			End:   Point{Line: 5, Column: 12},
+		}, "bar_id"),
+		// overlaps the object rename
+		ReplaceFix("Rename again", Position{
+			Start: Point{Line: 4, Column: 7},
+			End:   Point{Line: 4, Column: 9},
+		}, "Baz"),
+	}
+
+	got, applied, err := ApplyFixes(data, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 3 {
+		t.Errorf("applied %d, want 3", applied)
+	}
+	want := "package foo.v1\n\n\nobject FooBar {\n  field bar_id string\n}\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	_, _, err = ApplyFixes(data, []*Fix{ReplaceFix("Out of range", Position{
+		Start: Point{Line: 20, Column: 0},
+	}, "")})
+	if err == nil {
+		t.Error("expected error for edit out of range")
+	}
+}
+
+func TestAddFix(t *testing.T) {
+	fix := &Fix{Title: "Fix"}
+	err := AddPosition(errors.New("bad"), Position{Start: Point{Line: 1}})
+	err = AddFix(err, fix)
+	if GetErrorFix(err) != fix {
+		t.Error("fix not found")
+	}
+	if GetErrorPosition(err) == nil {
+		t.Error("position lost")
+	}
+}
@@ -7,8 +7,9 @@ import (
 )
 
 type ErrorsWithSource struct {
-	lines  []string
-	Errors Errors
+	filename string
+	lines    []string
+	Errors   Errors
 }
 
 func (e ErrorsWithSource) HumanString(contextLines int) string {
@@ -24,7 +25,13 @@ func (e ErrorsWithSource) HumanString(contextLines int) string {
 			lines = append(lines, "-----")
 		}
 
-		str := humanString(err, e.lines, contextLines)
+		sourceLines := e.lines
+		if e.filename != "" && err.Pos != nil && err.Pos.Filename != nil && *err.Pos.Filename != e.filename {
+			// The error is in another file, e.g. a partial, the source is not
+			// available.
+			sourceLines = nil
+		}
+		str := humanString(err, sourceLines, contextLines)
 		lines = append(lines, str)
 	}
 
@@ -129,11 +136,22 @@ func humanString(err *Err, lines []string, context int) string {
 		out.WriteString("^\n")
 
 	}()
+	for _, via := range err.Via {
+		out.WriteString(fmt.Sprintf("Via: %s\n", via.String()))
+	}
 	if err.Ctx != nil {
 		out.WriteString("Context: ")
 		out.WriteString(err.Ctx.String())
 		out.WriteString("\n")
 	}
+	if err.Severity != SeverityError || err.Rule != "" {
+		out.WriteString("Severity: ")
+		out.WriteString(err.Severity.String())
+		if err.Rule != "" {
+			out.WriteString(" [" + err.Rule + "]")
+		}
+		out.WriteString("\n")
+	}
 	if err.Err != nil {
 		out.WriteString("Message: ")
 		out.WriteString(err.Err.Error())
@@ -178,9 +196,15 @@ func setFilenames(input Errors, filename string) Errors {
 			err.Pos = &Position{
 				Filename: &filename,
 			}
-		} else {
+		} else if err.Pos.Filename == nil {
+			// Errors from partials already carry the filename of the partial.
 			err.Pos.Filename = &filename
 		}
+		for viaIdx := range err.Via {
+			if err.Via[viaIdx].Filename == nil {
+				err.Via[viaIdx].Filename = &filename
+			}
+		}
 		input[idx] = err
 	}
 
@@ -191,8 +215,9 @@ func AddSourceFile(err error, filename string, fileData string) error {
 	if withSource, ok := AsErrorsWithSource(err); ok {
 		errors := setFilenames(withSource.Errors, filename)
 		return &ErrorsWithSource{
-			lines:  strings.Split(fileData, "\n"),
-			Errors: errors,
+			filename: filename,
+			lines:    strings.Split(fileData, "\n"),
+			Errors:   errors,
 		}
 	}
 
@@ -205,8 +230,9 @@ func AddSourceFile(err error, filename string, fileData string) error {
 	input = setFilenames(input, filename)
 
 	return &ErrorsWithSource{
-		lines:  strings.Split(fileData, "\n"),
-		Errors: input,
+		filename: filename,
+		lines:    strings.Split(fileData, "\n"),
+		Errors:   input,
 	}
 }
 
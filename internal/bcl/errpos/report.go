@@ -0,0 +1,335 @@
+package errpos
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Diagnostic is the machine readable form of an Err, for CI and review bots.
+// Lines and columns are 1 based, and zero when the error has no position.
+type Diagnostic struct {
+	File        string `json:"file,omitempty"`
+	StartLine   int    `json:"startLine,omitempty"`
+	StartColumn int    `json:"startColumn,omitempty"`
+	EndLine     int    `json:"endLine,omitempty"`
+	EndColumn   int    `json:"endColumn,omitempty"`
+	Severity    string `json:"severity"`
+	Rule        string `json:"rule"`
+	Message     string `json:"message"`
+	Context     string `json:"context,omitempty"`
+}
+
+// Diagnostic converts the error, rule is the ID of the check which raised it.
+// dir is prefixed to the filename, e.g. the bundle directory when the
+// filename is relative to the bundle.
+func (e *Err) Diagnostic(dir string, rule string) Diagnostic {
+	diag := Diagnostic{
+		Severity: e.Severity.String(),
+		Rule:     rule,
+		Context:  e.Ctx.String(),
+	}
+	if e.Rule != "" {
+		diag.Rule = e.Rule
+	}
+	if e.Err == nil {
+		diag.Message = "<nil error>"
+	} else {
+		diag.Message = e.Err.Error()
+	}
+
+	if e.Pos == nil {
+		return diag
+	}
+	if e.Pos.Filename != nil {
+		diag.File = path.Join(dir, *e.Pos.Filename)
+	}
+	if e.Pos.Start.isEmpty() {
+		return diag
+	}
+
+	start, end := e.Pos.Start, e.Pos.End
+	if end.Line < start.Line || (end.Line == start.Line && end.Column < start.Column) {
+		// End is optional, and often left as the zero value.
+		end = start
+	}
+	diag.StartLine = start.Line + 1
+	diag.StartColumn = start.Column + 1
+	diag.EndLine = end.Line + 1
+	diag.EndColumn = end.Column + 1
+	return diag
+}
+
+// Diagnostics converts any error, errors without positions become a single
+// diagnostic with only the message.
+func Diagnostics(err error, dir string, rule string) []Diagnostic {
+	if withSource, ok := AsErrorsWithSource(err); ok {
+		return withSource.Errors.Diagnostics(dir, rule)
+	}
+	if errs, ok := AsErrors(err); ok {
+		return errs.Diagnostics(dir, rule)
+	}
+	return []Diagnostic{{
+		Severity: SeverityError.String(),
+		Rule:     rule,
+		Message:  err.Error(),
+	}}
+}
+
+func (e Errors) Diagnostics(dir string, rule string) []Diagnostic {
+	out := make([]Diagnostic, 0, len(e))
+	for _, err := range e {
+		out = append(out, err.Diagnostic(dir, rule))
+	}
+	return out
+}
+
+// SortDiagnostics orders by position then content, so output is stable between
+// runs.
+func SortDiagnostics(diags []Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		a, b := diags[i], diags[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.StartLine != b.StartLine {
+			return a.StartLine < b.StartLine
+		}
+		if a.StartColumn != b.StartColumn {
+			return a.StartColumn < b.StartColumn
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+}
+
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatSARIF  Format = "sarif"
+	FormatGithub Format = "github"
+)
+
+func ParseFormat(str string) (Format, error) {
+	switch format := Format(strings.ToLower(str)); format {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON, FormatSARIF, FormatGithub:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expecting text, json, sarif or github", str)
+	}
+}
+
+// WriteDiagnostics writes the diagnostics in one of the machine readable
+// formats. Text output is from ErrorsWithSource.HumanString, which has the
+// source lines.
+func WriteDiagnostics(w io.Writer, format Format, tool string, diags []Diagnostic) error {
+	SortDiagnostics(diags)
+	switch format {
+	case FormatJSON:
+		return writeJSON(w, struct {
+			Diagnostics []Diagnostic `json:"diagnostics"`
+		}{
+			Diagnostics: diags,
+		})
+	case FormatSARIF:
+		return writeJSON(w, buildSARIF(tool, diags))
+	case FormatGithub:
+		return writeGithub(w, diags)
+	default:
+		return fmt.Errorf("format %q has no diagnostic writer", format)
+	}
+}
+
+func writeJSON(w io.Writer, val any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(val)
+}
+
+// writeGithub writes GitHub Actions workflow commands, which annotate the
+// files in the PR.
+func writeGithub(w io.Writer, diags []Diagnostic) error {
+	for _, diag := range diags {
+		props := make([]string, 0)
+		if diag.File != "" {
+			props = append(props, "file="+githubProperty(diag.File))
+		}
+		if diag.StartLine > 0 {
+			props = append(props,
+				fmt.Sprintf("line=%d", diag.StartLine),
+				fmt.Sprintf("col=%d", diag.StartColumn),
+				fmt.Sprintf("endLine=%d", diag.EndLine),
+				fmt.Sprintf("endColumn=%d", diag.EndColumn),
+			)
+		}
+		props = append(props, "title="+githubProperty(diag.Rule))
+
+		message := diag.Message
+		if diag.Context != "" {
+			message = fmt.Sprintf("in %s: %s", diag.Context, message)
+		}
+
+		command := githubCommand(diag.Severity)
+		if _, err := fmt.Fprintf(w, "::%s %s::%s\n", command, strings.Join(props, ","), githubData(message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func githubCommand(severity string) string {
+	switch severity {
+	case SeverityError.String():
+		return "error"
+	case SeverityWarning.String():
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+var githubDataEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+var githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+
+func githubData(str string) string {
+	return githubDataEscaper.Replace(str)
+}
+
+func githubProperty(str string) string {
+	return githubPropertyEscaper.Replace(str)
+}
+
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+func buildSARIF(tool string, diags []Diagnostic) *sarifLog {
+	rules := make([]sarifRule, 0)
+	seenRules := map[string]bool{}
+	results := make([]sarifResult, 0, len(diags))
+
+	for _, diag := range diags {
+		if !seenRules[diag.Rule] {
+			seenRules[diag.Rule] = true
+			rules = append(rules, sarifRule{ID: diag.Rule})
+		}
+
+		message := diag.Message
+		if diag.Context != "" {
+			message = fmt.Sprintf("in %s: %s", diag.Context, message)
+		}
+		result := sarifResult{
+			RuleID:  diag.Rule,
+			Level:   sarifLevel(diag.Severity),
+			Message: sarifMessage{Text: message},
+		}
+		if diag.File != "" {
+			loc := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: diag.File},
+				},
+			}
+			if diag.StartLine > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   diag.StartLine,
+					StartColumn: diag.StartColumn,
+					EndLine:     diag.EndLine,
+					EndColumn:   diag.EndColumn,
+				}
+			}
+			result.Locations = []sarifLocation{loc}
+		}
+		results = append(results, result)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return &sarifLog{
+		Schema:  sarifSchema,
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{
+				Driver: sarifDriver{
+					Name:  tool,
+					Rules: rules,
+				},
+			},
+			Results: results,
+		}},
+	}
+}
+
+func sarifLevel(severity string) string {
+	switch severity {
+	case SeverityError.String():
+		return "error"
+	case SeverityWarning.String():
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,131 @@
+package errpos
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func testReportErrors() Errors {
+	filename := "foo/v1/foo.j5s"
+	return Errors{{
+		Pos: &Position{
+			Filename: &filename,
+			Start:    Point{Line: 9, Column: 4},
+			End:      Point{Line: 9, Column: 12},
+		},
+		Ctx: Context{"Foo", "bar"},
+		Err: errors.New("unknown type, expecting: a, b"),
+	}, {
+		Pos: &Position{
+			Filename: &filename,
+			Start:    Point{Line: 2, Column: 0},
+		},
+		Err: errors.New("line\nbreak 100%"),
+	}}
+}
+
+func TestDiagnostics(t *testing.T) {
+	diags := Diagnostics(testReportErrors(), "proto", "LINT")
+	SortDiagnostics(diags)
+
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d", len(diags))
+	}
+
+	// sorted by line, end defaults to start
+	want := Diagnostic{
+		File:        "proto/foo/v1/foo.j5s",
+		StartLine:   3,
+		StartColumn: 1,
+		EndLine:     3,
+		EndColumn:   1,
+		Severity:    "error",
+		Rule:        "LINT",
+		Message:     "line\nbreak 100%",
+	}
+	if diags[0] != want {
+		t.Errorf("got %+v, want %+v", diags[0], want)
+	}
+	if diags[1].Context != "Foo.bar" || diags[1].EndColumn != 13 {
+		t.Errorf("unexpected %+v", diags[1])
+	}
+
+	plain := Diagnostics(errors.New("plain"), "proto", "VERIFY")
+	if len(plain) != 1 || plain[0].File != "" || plain[0].Message != "plain" {
+		t.Errorf("unexpected %+v", plain)
+	}
+}
+
+func TestWriteGithub(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := WriteDiagnostics(out, FormatGithub, "j5", Diagnostics(testReportErrors(), "", "LINT")); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=foo/v1/foo.j5s,line=3,col=1,endLine=3,endColumn=1,title=LINT::line%0Abreak 100%25\n" +
+		"::error file=foo/v1/foo.j5s,line=10,col=5,endLine=10,endColumn=13,title=LINT::in Foo.bar: unknown type, expecting: a, b\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	out := &bytes.Buffer{}
+	if err := WriteDiagnostics(out, FormatSARIF, "j5", Diagnostics(testReportErrors(), "", "LINT")); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &sarifLog{}
+	if err := json.Unmarshal(out.Bytes(), doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Version != "2.1.0" || len(doc.Runs) != 1 {
+		t.Fatalf("unexpected doc %s", out.String())
+	}
+	run := doc.Runs[0]
+	if len(run.Tool.Driver.Rules) != 1 || run.Tool.Driver.Rules[0].ID != "LINT" {
+		t.Errorf("unexpected rules %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(run.Results))
+	}
+	region := run.Results[1].Locations[0].PhysicalLocation.Region
+	if region.StartLine != 10 || region.EndColumn != 13 {
+		t.Errorf("unexpected region %+v", region)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, str := range []string{"", "text", "JSON", "sarif", "github"} {
+		if _, err := ParseFormat(str); err != nil {
+			t.Errorf("%q: %s", str, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error for xml")
+	}
+}
+
+func TestWarningDiagnostics(t *testing.T) {
+	errs := testReportErrors()
+	errs[0].Severity = SeverityWarning
+	errs[0].Rule = "UNUSED_IMPORT"
+
+	if !errs.HasErrors() {
+		t.Error("expected HasErrors with one error")
+	}
+	if (Errors{errs[0]}).HasErrors() {
+		t.Error("expected no errors with only a warning")
+	}
+
+	out := &bytes.Buffer{}
+	if err := WriteDiagnostics(out, FormatGithub, "j5", Diagnostics(errs, "", "LINT")); err != nil {
+		t.Fatal(err)
+	}
+	want := "::error file=foo/v1/foo.j5s,line=3,col=1,endLine=3,endColumn=1,title=LINT::line%0Abreak 100%25\n" +
+		"::warning file=foo/v1/foo.j5s,line=10,col=5,endLine=10,endColumn=13,title=UNUSED_IMPORT::in Foo.bar: unknown type, expecting: a, b\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,99 @@
+package genlsp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"go.lsp.dev/protocol"
+)
+
+// DiagnosticFix is set as the Data of a diagnostic with a suggested fix. The
+// client sends it back in the code action request, so fixes need no state in
+// the server.
+type DiagnosticFix struct {
+	Title      string              `json:"title"`
+	Edits      []protocol.TextEdit `json:"edits"`
+	ChangesAPI bool                `json:"changesApi,omitempty"`
+}
+
+// FixData converts the fix of an error for the Data of a diagnostic in the
+// same file, with the text of the file to convert the rune columns of the
+// edits. Returns nil for a nil fix, so the Data is left unset.
+func FixData(text string, fix *errpos.Fix) interface{} {
+	if fix == nil {
+		return nil
+	}
+	edits := make([]protocol.TextEdit, 0, len(fix.Edits))
+	for _, edit := range fix.Edits {
+		edits = append(edits, protocol.TextEdit{
+			Range: protocol.Range{
+				Start: ToPosition(text, edit.Start),
+				End:   ToPosition(text, edit.End),
+			},
+			NewText: edit.NewText,
+		})
+	}
+	return &DiagnosticFix{
+		Title:      fix.Title,
+		Edits:      edits,
+		ChangesAPI: fix.ChangesAPI,
+	}
+}
+
+// diagnosticFix reads the fix back from the Data of a diagnostic in a request,
+// where it has been decoded as a generic map.
+func diagnosticFix(diag protocol.Diagnostic) (*DiagnosticFix, bool) {
+	if diag.Data == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(diag.Data)
+	if err != nil {
+		return nil, false
+	}
+	fix := &DiagnosticFix{}
+	if err := json.Unmarshal(data, fix); err != nil {
+		return nil, false
+	}
+	if fix.Title == "" || len(fix.Edits) == 0 {
+		return nil, false
+	}
+	return fix, true
+}
+
+func (h *serverStream) CodeAction(_ context.Context, params *protocol.CodeActionParams) ([]protocol.CodeAction, error) {
+	actions := []protocol.CodeAction{}
+
+	if len(params.Context.Only) > 0 {
+		wantsQuickFix := false
+		for _, kind := range params.Context.Only {
+			if kind == protocol.QuickFix {
+				wantsQuickFix = true
+			}
+		}
+		if !wantsQuickFix {
+			return actions, nil
+		}
+	}
+
+	for _, diag := range params.Context.Diagnostics {
+		fix, ok := diagnosticFix(diag)
+		if !ok {
+			continue
+		}
+		actions = append(actions, protocol.CodeAction{
+			Title:       fix.Title,
+			Kind:        protocol.QuickFix,
+			Diagnostics: []protocol.Diagnostic{diag},
+			// not applied by 'fix all' in editors, as it breaks clients
+			IsPreferred: !fix.ChangesAPI,
+			Edit: &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+					params.TextDocument.URI: fix.Edits,
+				},
+			},
+		})
+	}
+
+	return actions, nil
+}
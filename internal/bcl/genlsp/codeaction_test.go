@@ -0,0 +1,33 @@
+package genlsp
+
+import (
+	"testing"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"go.lsp.dev/protocol"
+)
+
+func TestFixDataUTF16(t *testing.T) {
+	// the emoji is two UTF-16 code units and one rune
+	text := "object Foo {\n  | 😀 field foo_bar string\n}\n"
+
+	data := FixData(text, &errpos.Fix{
+		Title: "rename",
+		Edits: []errpos.Edit{{
+			Start:   errpos.Point{Line: 1, Column: 12},
+			End:     errpos.Point{Line: 1, Column: 19},
+			NewText: "fooBar",
+		}},
+	})
+	fix, ok := data.(*DiagnosticFix)
+	if !ok {
+		t.Fatalf("expected a fix, got %v", data)
+	}
+	want := protocol.Range{
+		Start: protocol.Position{Line: 1, Character: 13},
+		End:   protocol.Position{Line: 1, Character: 20},
+	}
+	if len(fix.Edits) != 1 || fix.Edits[0].Range != want {
+		t.Errorf("unexpected edits %v", fix.Edits)
+	}
+}
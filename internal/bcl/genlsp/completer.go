@@ -0,0 +1,51 @@
+package genlsp
+
+import (
+	"context"
+
+	"github.com/pentops/j5build/internal/bcl"
+	"github.com/pentops/log.go/log"
+	"go.lsp.dev/protocol"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemaCompleter suggests block keywords, attributes, types and values from
+// the schema of the blocks enclosing the cursor.
+type schemaCompleter struct {
+	parser      *bcl.Parser
+	fileFactory func(filename string) protoreflect.Message
+}
+
+func (c *schemaCompleter) Completion(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position) ([]protocol.CompletionItem, error) {
+	msg := c.fileFactory(doc.URI.Filename())
+	completions, err := c.parser.CompletionsAt(doc.Text, msg, ToPoint(doc.Text, pos))
+	if err != nil {
+		// Documents are often incomplete while typing, no suggestions.
+		log.WithError(ctx, err).Debug("no completion scope")
+		return []protocol.CompletionItem{}, nil
+	}
+
+	items := make([]protocol.CompletionItem, 0, len(completions))
+	for _, completion := range completions {
+		items = append(items, protocol.CompletionItem{
+			Label: completion.Label,
+			Kind:  itemKind(completion.Kind),
+		})
+	}
+	return items, nil
+}
+
+func itemKind(kind bcl.CompletionKind) protocol.CompletionItemKind {
+	switch kind {
+	case bcl.CompleteBlock:
+		return protocol.CompletionItemKindKeyword
+	case bcl.CompleteAttribute:
+		return protocol.CompletionItemKindProperty
+	case bcl.CompleteTypeSelect:
+		return protocol.CompletionItemKindTypeParameter
+	case bcl.CompleteValue:
+		return protocol.CompletionItemKindEnumMember
+	default:
+		return protocol.CompletionItemKindText
+	}
+}
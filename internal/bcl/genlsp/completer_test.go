@@ -0,0 +1,51 @@
+package genlsp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pentops/j5build/internal/bcl"
+	"github.com/pentops/j5build/internal/j5s/j5parse"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+func TestSchemaCompleterUTF16(t *testing.T) {
+	ctx := context.Background()
+	parser, err := bcl.NewParser(j5parse.J5SchemaSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := &schemaCompleter{
+		parser:      parser,
+		fileFactory: j5parse.FileStub,
+	}
+
+	// each emoji is two UTF-16 code units, four bytes and one rune, the
+	// cursor is before the qualified tag, which would not complete.
+	doc := &protocol.TextDocumentItem{
+		URI: uri.File("/foo/v1/foo.j5s"),
+		Text: strings.Join([]string{
+			"package foo.v1",
+			"",
+			"entity Foo {",
+			"  data 😀😀 a:b",
+			"}",
+		}, "\n"),
+	}
+
+	items, err := sc.Completion(ctx, doc, protocol.Position{Line: 3, Character: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]bool{}
+	for _, item := range items {
+		labels[item.Label] = true
+	}
+	for _, name := range []string{"string", "object", "key", "array"} {
+		if !labels[name] {
+			t.Errorf("missing type completion %q, got %v", name, labels)
+		}
+	}
+}
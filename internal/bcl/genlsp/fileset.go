@@ -19,6 +19,7 @@ type fileSet struct {
 	prefix string
 
 	onChange func(context.Context, *protocol.TextDocumentItem)
+	onClose  func(context.Context, protocol.DocumentURI)
 }
 
 //var _ fs.FS = &FileSet{}
@@ -115,6 +116,10 @@ func (fs *fileSet) DidClose(ctx context.Context, params *protocol.DidCloseTextDo
 		"local": local,
 	}).Debug("DidClose")
 	delete(fs.files, local)
+
+	if fs.onClose != nil {
+		fs.onClose(ctx, params.TextDocument.URI)
+	}
 	return nil
 }
 
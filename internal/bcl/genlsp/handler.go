@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"sync"
 
 	"github.com/pentops/log.go/log"
 	"go.lsp.dev/jsonrpc2"
@@ -19,11 +21,42 @@ type ChangeHandler interface {
 	FileChanged(context.Context, *protocol.TextDocumentItem) ([]protocol.Diagnostic, error)
 }
 
+// ProjectLinter lints a document in the context of the project it belongs to,
+// the result may include diagnostics for other files.
+type ProjectLinter interface {
+	LintProject(context.Context, *protocol.TextDocumentItem) (map[protocol.DocumentURI][]protocol.Diagnostic, error)
+}
+
+// DocumentCloser is optionally implemented by a ProjectLinter which keeps the
+// text of open documents, to drop it when the document is closed.
+type DocumentCloser interface {
+	CloseDocument(context.Context, protocol.DocumentURI)
+}
+
+// Navigator resolves symbols in a document for definition, hover and
+// references requests.
+type Navigator interface {
+	Definition(context.Context, *protocol.TextDocumentItem, protocol.Position) ([]protocol.Location, error)
+	Hover(context.Context, *protocol.TextDocumentItem, protocol.Position) (*protocol.Hover, error)
+	References(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position, includeDeclaration bool) ([]protocol.Location, error)
+}
+
+// Completer suggests text to insert at a position in a document.
+type Completer interface {
+	Completion(context.Context, *protocol.TextDocumentItem, protocol.Position) ([]protocol.CompletionItem, error)
+}
+
 type lspConfig struct {
 	ProjectRoot string
 
 	Formatter Formatter
 	OnChange  ChangeHandler
+	Navigator Navigator
+
+	ProjectLinter ProjectLinter
+
+	// Completers are all asked for completions, the results are combined.
+	Completers []Completer
 }
 
 type serverStream struct {
@@ -32,6 +65,15 @@ type serverStream struct {
 
 	Formatter     Formatter
 	ChangeHandler ChangeHandler
+	ProjectLinter ProjectLinter
+	Navigator     Navigator
+	Completers    []Completer
+
+	// published holds the diagnostics last produced by linting each source
+	// document, by the file they are for. Linting a document only replaces
+	// its own diagnostics, files are sent those of every source combined.
+	publishLock sync.Mutex
+	published   map[protocol.DocumentURI]map[protocol.DocumentURI][]protocol.Diagnostic
 }
 
 type replyServer interface {
@@ -47,10 +89,17 @@ func newServerStream(cfg lspConfig) (*serverStream, error) {
 		files:         files,
 		Formatter:     cfg.Formatter,
 		ChangeHandler: cfg.OnChange,
+		ProjectLinter: cfg.ProjectLinter,
+		Navigator:     cfg.Navigator,
+		Completers:    cfg.Completers,
+		published:     map[protocol.DocumentURI]map[protocol.DocumentURI][]protocol.Diagnostic{},
 	}
 
 	dbchange := newDebounce(500, ss.fileDidChange)
 	files.onChange = dbchange.request
+	if closer, ok := cfg.ProjectLinter.(DocumentCloser); ok {
+		files.onClose = closer.CloseDocument
+	}
 
 	return ss, nil
 }
@@ -63,6 +112,23 @@ func (ss *serverStream) fileDidChange(ctx context.Context, doc *protocol.TextDoc
 }
 
 func (ss *serverStream) fileDidChangeErr(ctx context.Context, doc *protocol.TextDocumentItem) error {
+	byFile := map[protocol.DocumentURI][]protocol.Diagnostic{
+		doc.URI: {},
+	}
+
+	if ss.ProjectLinter != nil {
+		projectDiagnostics, err := ss.ProjectLinter.LintProject(ctx, doc)
+		if err == nil {
+			for uri, diagnostics := range projectDiagnostics {
+				byFile[uri] = append(byFile[uri], diagnostics...)
+			}
+			return ss.publishDiagnostics(ctx, doc.URI, byFile)
+		}
+		// e.g. the file is not part of the project, fall back to the file
+		// alone.
+		log.WithError(ctx, err).Warn("failed to lint project")
+	}
+
 	if ss.ChangeHandler == nil {
 		return nil
 	}
@@ -70,14 +136,67 @@ func (ss *serverStream) fileDidChangeErr(ctx context.Context, doc *protocol.Text
 	if err != nil {
 		return err
 	}
-	if diagnostics == nil {
-		// clear the diagnostics
-		diagnostics = []protocol.Diagnostic{}
+	byFile[doc.URI] = diagnostics
+	return ss.publishDiagnostics(ctx, doc.URI, byFile)
+}
+
+// publishDiagnostics replaces the diagnostics produced by linting the source
+// document, then sends each file which had or now has diagnostics from it
+// the combined diagnostics of all sources.
+func (ss *serverStream) publishDiagnostics(ctx context.Context, source protocol.DocumentURI, byFile map[protocol.DocumentURI][]protocol.Diagnostic) error {
+	ss.publishLock.Lock()
+	defer ss.publishLock.Unlock()
+
+	affected := map[protocol.DocumentURI]struct{}{}
+	for uri := range ss.published[source] {
+		affected[uri] = struct{}{}
+	}
+
+	produced := map[protocol.DocumentURI][]protocol.Diagnostic{}
+	for uri, diagnostics := range byFile {
+		affected[uri] = struct{}{}
+		if len(diagnostics) > 0 {
+			produced[uri] = diagnostics
+		}
 	}
-	return ss.dispatcher.Notify(ctx, protocol.MethodTextDocumentPublishDiagnostics, &protocol.PublishDiagnosticsParams{
-		URI:         doc.URI,
-		Diagnostics: diagnostics,
-	})
+	if len(produced) > 0 {
+		ss.published[source] = produced
+	} else {
+		delete(ss.published, source)
+	}
+
+	for uri := range affected {
+		err := ss.dispatcher.Notify(ctx, protocol.MethodTextDocumentPublishDiagnostics, &protocol.PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: ss.combinedDiagnostics(uri),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// combinedDiagnostics merges the diagnostics for the file from every source,
+// dropping those reported by more than one.
+func (ss *serverStream) combinedDiagnostics(uri protocol.DocumentURI) []protocol.Diagnostic {
+	sources := make([]protocol.DocumentURI, 0, len(ss.published))
+	for source := range ss.published {
+		sources = append(sources, source)
+	}
+	slices.Sort(sources)
+
+	combined := []protocol.Diagnostic{}
+	for _, source := range sources {
+		for _, diagnostic := range ss.published[source][uri] {
+			if !slices.ContainsFunc(combined, func(existing protocol.Diagnostic) bool {
+				return existing.Range == diagnostic.Range && existing.Message == diagnostic.Message
+			}) {
+				combined = append(combined, diagnostic)
+			}
+		}
+	}
+	return combined
 }
 
 func (ss *serverStream) Run(ctx context.Context, rwc io.ReadWriteCloser) error {
@@ -130,14 +249,36 @@ func (h *serverStream) handle(ctx context.Context, reply jsonrpc2.Replier, req j
 		return doReq(ctx, reply, req, h.files.DidSave)
 	case protocol.MethodTextDocumentFormatting:
 		return doReqRes(ctx, reply, req, h.Formatting)
+	case protocol.MethodTextDocumentDefinition:
+		return doReqRes(ctx, reply, req, h.Definition)
+	case protocol.MethodTextDocumentHover:
+		return doReqRes(ctx, reply, req, h.Hover)
+	case protocol.MethodTextDocumentReferences:
+		return doReqRes(ctx, reply, req, h.References)
+	case protocol.MethodTextDocumentCompletion:
+		return doReqRes(ctx, reply, req, h.Completion)
+	case protocol.MethodTextDocumentCodeAction:
+		return doReqRes(ctx, reply, req, h.CodeAction)
 	default:
 		return jsonrpc2.MethodNotFoundHandler(ctx, reply, req)
 	}
 
 }
 func (h *serverStream) Initialize(_ context.Context, req *protocol.InitializeParams) (*protocol.InitializeResult, error) {
+	hasNavigator := h.Navigator != nil
+	var completionProvider *protocol.CompletionOptions
+	if len(h.Completers) > 0 {
+		completionProvider = &protocol.CompletionOptions{
+			TriggerCharacters: []string{":", "="},
+		}
+	}
 	return &protocol.InitializeResult{
 		Capabilities: protocol.ServerCapabilities{
+			CompletionProvider:         completionProvider,
+			CodeActionProvider:         true,
+			DefinitionProvider:         hasNavigator,
+			HoverProvider:              hasNavigator,
+			ReferencesProvider:         hasNavigator,
 			DocumentFormattingProvider: true,
 			TextDocumentSync: protocol.TextDocumentSyncOptions{
 				OpenClose: true,
@@ -170,3 +311,66 @@ func (h *serverStream) Formatting(ctx context.Context, params *protocol.Document
 
 	return h.Formatter.Format(ctx, doc)
 }
+
+func (h *serverStream) Definition(ctx context.Context, params *protocol.DefinitionParams) ([]protocol.Location, error) {
+	if h.Navigator == nil {
+		return nil, fmt.Errorf("navigator not available")
+	}
+
+	doc, err := h.files.getDocument(ctx, params.TextDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return h.Navigator.Definition(ctx, doc, params.Position)
+}
+
+func (h *serverStream) Hover(ctx context.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
+	if h.Navigator == nil {
+		return nil, fmt.Errorf("navigator not available")
+	}
+
+	doc, err := h.files.getDocument(ctx, params.TextDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return h.Navigator.Hover(ctx, doc, params.Position)
+}
+
+func (h *serverStream) References(ctx context.Context, params *protocol.ReferenceParams) ([]protocol.Location, error) {
+	if h.Navigator == nil {
+		return nil, fmt.Errorf("navigator not available")
+	}
+
+	doc, err := h.files.getDocument(ctx, params.TextDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	return h.Navigator.References(ctx, doc, params.Position, params.Context.IncludeDeclaration)
+}
+
+func (h *serverStream) Completion(ctx context.Context, params *protocol.CompletionParams) (*protocol.CompletionList, error) {
+	if len(h.Completers) == 0 {
+		return nil, fmt.Errorf("completion not available")
+	}
+
+	doc, err := h.files.getDocument(ctx, params.TextDocument)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get document: %w", err)
+	}
+
+	items := []protocol.CompletionItem{}
+	for _, completer := range h.Completers {
+		completerItems, err := completer.Completion(ctx, doc, params.Position)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, completerItems...)
+	}
+
+	return &protocol.CompletionList{
+		Items: items,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package genlsp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.lsp.dev/protocol"
+)
+
+type notifyRecorder struct {
+	latest map[protocol.DocumentURI][]protocol.Diagnostic
+}
+
+func (nr *notifyRecorder) Notify(ctx context.Context, method string, params interface{}) error {
+	publish := params.(*protocol.PublishDiagnosticsParams)
+	nr.latest[publish.URI] = publish.Diagnostics
+	return nil
+}
+
+// mapLinter returns the diagnostics set for the text of each document.
+type mapLinter map[string]map[protocol.DocumentURI][]protocol.Diagnostic
+
+func (ml mapLinter) LintProject(ctx context.Context, doc *protocol.TextDocumentItem) (map[protocol.DocumentURI][]protocol.Diagnostic, error) {
+	return ml[doc.Text], nil
+}
+
+func TestPublishDiagnostics(t *testing.T) {
+	ctx := context.Background()
+
+	const uriA = protocol.DocumentURI("file:///a.j5s")
+	const uriB = protocol.DocumentURI("file:///b.j5s")
+	const uriC = protocol.DocumentURI("file:///c.j5s")
+
+	diag := func(message string) protocol.Diagnostic {
+		return protocol.Diagnostic{Message: message}
+	}
+
+	linter := mapLinter{
+		"a broken": {
+			uriA: {diag("a error")},
+			uriC: {diag("c error"), diag("c from a")},
+		},
+		"a fixed": {
+			uriA: {},
+		},
+		"b broken": {
+			uriB: {diag("b warning")},
+			uriC: {diag("c error")},
+		},
+	}
+
+	recorder := &notifyRecorder{latest: map[protocol.DocumentURI][]protocol.Diagnostic{}}
+	ss, err := newServerStream(lspConfig{
+		ProjectRoot:   t.TempDir(),
+		ProjectLinter: linter,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ss.dispatcher = recorder
+
+	lint := func(uri protocol.DocumentURI, text string) {
+		t.Helper()
+		if err := ss.fileDidChangeErr(ctx, &protocol.TextDocumentItem{URI: uri, Text: text}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lint(uriA, "a broken")
+	lint(uriB, "b broken")
+	assert.Equal(t, []protocol.Diagnostic{diag("a error")}, recorder.latest[uriA])
+	assert.Equal(t, []protocol.Diagnostic{diag("b warning")}, recorder.latest[uriB])
+	assert.Equal(t, []protocol.Diagnostic{diag("c error"), diag("c from a")}, recorder.latest[uriC], "duplicates are dropped")
+
+	// editing A keeps the diagnostics of B
+	lint(uriA, "a fixed")
+	assert.Equal(t, []protocol.Diagnostic{}, recorder.latest[uriA])
+	assert.Equal(t, []protocol.Diagnostic{diag("b warning")}, recorder.latest[uriB])
+	assert.Equal(t, []protocol.Diagnostic{diag("c error")}, recorder.latest[uriC])
+}
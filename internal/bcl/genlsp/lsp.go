@@ -16,11 +16,23 @@ type Config struct {
 	Schema      *bcl_j5pb.Schema
 	FileFactory func(filename string) protoreflect.Message
 	OnChange    func(filename string, parsed protoreflect.Message) error
+
+	// Navigator is optional, enables definition, hover and references.
+	Navigator Navigator
+
+	// ProjectLinter is optional, when set it replaces the single file lint for
+	// files in the project.
+	ProjectLinter ProjectLinter
+
+	// Completer is optional, adding to the completions from the Schema.
+	Completer Completer
 }
 
 func BuildLSPHandler(config Config) (*lspConfig, error) {
 	lspc := lspConfig{
-		ProjectRoot: config.ProjectRoot,
+		ProjectRoot:   config.ProjectRoot,
+		Navigator:     config.Navigator,
+		ProjectLinter: config.ProjectLinter,
 	}
 
 	if config.ProjectRoot == "" {
@@ -37,10 +49,18 @@ func BuildLSPHandler(config Config) (*lspConfig, error) {
 			return nil, err
 		}
 		lspc.OnChange = linter.New(parser, config.FileFactory, config.OnChange)
+		lspc.Completers = append(lspc.Completers, &schemaCompleter{
+			parser:      parser,
+			fileFactory: config.FileFactory,
+		})
 	} else {
 		lspc.OnChange = linter.NewGeneric()
 	}
 
+	if config.Completer != nil {
+		lspc.Completers = append(lspc.Completers, config.Completer)
+	}
+
 	lspc.Formatter = astFormatter{}
 
 	return &lspc, nil
@@ -0,0 +1,85 @@
+package genlsp
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"go.lsp.dev/protocol"
+)
+
+// LineOffset converts the Character of an LSP position, which counts UTF-16
+// code units, to a byte offset in the line. Positions past the end of the
+// line are clamped to the end.
+func LineOffset(line string, character uint32) int {
+	units := 0
+	for offset, r := range line {
+		if units >= int(character) {
+			return offset
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+// RuneColumn converts the Character of an LSP position, which counts UTF-16
+// code units, to a column counting runes, as in errpos and the BCL lexer.
+// Past the end of the line each unit is one column.
+func RuneColumn(line string, character uint32) int {
+	units := 0
+	column := 0
+	for _, r := range line {
+		if units >= int(character) {
+			return column
+		}
+		units += utf16.RuneLen(r)
+		column++
+	}
+	return column + int(character) - units
+}
+
+// Character converts a column counting runes to the Character of an LSP
+// position, the reverse of RuneColumn.
+func Character(line string, column int) uint32 {
+	units := 0
+	for _, r := range line {
+		if column <= 0 {
+			return uint32(units)
+		}
+		units += utf16.RuneLen(r)
+		column--
+	}
+	return uint32(units + max(column, 0))
+}
+
+// ToPoint converts an LSP position in the text to an errpos point.
+func ToPoint(text string, pos protocol.Position) errpos.Point {
+	return errpos.Point{
+		Line:   int(pos.Line),
+		Column: RuneColumn(lineAt(text, int(pos.Line)), pos.Character),
+	}
+}
+
+// ToPosition converts an errpos point in the text to an LSP position.
+func ToPosition(text string, point errpos.Point) protocol.Position {
+	return protocol.Position{
+		Line:      uint32(point.Line),
+		Character: Character(lineAt(text, point.Line), point.Column),
+	}
+}
+
+// lineAt returns the line of the text, without the newline, or an empty
+// string past the end.
+func lineAt(text string, line int) string {
+	for ; line > 0; line-- {
+		idx := strings.IndexByte(text, '\n')
+		if idx < 0 {
+			return ""
+		}
+		text = text[idx+1:]
+	}
+	if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+		return text[:idx]
+	}
+	return text
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/pentops/j5build/internal/bcl"
+	"github.com/pentops/j5build/internal/bcl/errpos"
 	"github.com/pentops/j5build/internal/bcl/gen/j5/bcl/v1/bcl_j5pb"
 	"github.com/pentops/j5build/internal/bcl/gen/test/v1/test_j5pb"
 	"github.com/stretchr/testify/assert"
@@ -89,6 +90,235 @@ func TestEndToEnd(t *testing.T) {
 
 }
 
+func TestModules(t *testing.T) {
+
+	schema := &bcl_j5pb.Schema{
+		Blocks: []*bcl_j5pb.Block{{
+			SchemaName: "test.v1.File",
+			Alias: []*bcl_j5pb.Alias{{
+				Name: "foo",
+				Path: &bcl_j5pb.Path{Path: []string{"elements", "foo"}},
+			}},
+		}},
+	}
+
+	pp, err := bcl.NewParser(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partials := bcl.PartialSet{}
+	pp.Partials = partials
+
+	if err := partials.AddFile("base/common/partials.bcl", fb(
+		`.partial foo flagged {`,
+		`  .export`,
+		`  flags += "exported"`,
+		`}`,
+		`.partial foo hidden {`,
+		`  label = "hidden"`,
+		`}`,
+	)); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(t testing.TB, input string) *test_j5pb.File {
+		t.Helper()
+		msg := &test_j5pb.File{}
+		_, err := pp.ParseFile("local/in.bcl", input, msg.ProtoReflect())
+		if err != nil {
+			logErr(t, err)
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	runErr := func(t testing.TB, input string) *errpos.Err {
+		t.Helper()
+		msg := &test_j5pb.File{}
+		_, err := pp.ParseFile("local/in.bcl", input, msg.ProtoReflect())
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		logErr(t, err)
+		withSource, ok := errpos.AsErrorsWithSource(err)
+		if !ok || len(withSource.Errors) != 1 {
+			t.Fatalf("expected one position error, got %T %s", err, err)
+		}
+		return withSource.Errors[0]
+	}
+
+	t.Run("local include", func(t *testing.T) {
+		msg := run(t, fb(
+			`.partial foo labeled {`,
+			`  label = "partial"`,
+			`  flags += "a"`,
+			`}`,
+			`foo Name {`,
+			`  .include labeled`,
+			`  flags += "b"`,
+			`}`,
+		))
+
+		foo := msg.Elements[0].GetFoo()
+		assert.Equal(t, "Name", foo.Name)
+		assert.Equal(t, "partial", foo.Label)
+		assert.Equal(t, []string{"a", "b"}, foo.Flags)
+	})
+
+	t.Run("null unsets", func(t *testing.T) {
+		msg := run(t, fb(
+			`.partial foo labeled {`,
+			`  label = "partial"`,
+			`  flags += "a"`,
+			`}`,
+			`foo Name {`,
+			`  .include labeled`,
+			`  label = null`,
+			`}`,
+		))
+
+		foo := msg.Elements[0].GetFoo()
+		assert.Equal(t, "", foo.Label)
+		assert.Equal(t, []string{"a"}, foo.Flags)
+	})
+
+	t.Run("null before include", func(t *testing.T) {
+		msg := run(t, fb(
+			`.partial foo labeled {`,
+			`  label = "partial"`,
+			`  flags += "a"`,
+			`}`,
+			`foo Name {`,
+			`  label = null`,
+			`  .include labeled`,
+			`}`,
+		))
+
+		foo := msg.Elements[0].GetFoo()
+		assert.Equal(t, "", foo.Label)
+		assert.Equal(t, []string{"a"}, foo.Flags)
+	})
+
+	t.Run("set after null", func(t *testing.T) {
+		msg := run(t, fb(
+			`.partial foo labeled {`,
+			`  label = "partial"`,
+			`}`,
+			`foo Name {`,
+			`  .include labeled`,
+			`  label = null`,
+			`  label = "block"`,
+			`}`,
+		))
+
+		foo := msg.Elements[0].GetFoo()
+		assert.Equal(t, "block", foo.Label)
+	})
+
+	t.Run("null in partial", func(t *testing.T) {
+		msg := run(t, fb(
+			`.partial foo unlabeled {`,
+			`  label = null`,
+			`}`,
+			`foo Name {`,
+			`  label = "block"`,
+			`  .include unlabeled`,
+			`}`,
+		))
+
+		foo := msg.Elements[0].GetFoo()
+		assert.Equal(t, "", foo.Label)
+	})
+
+	t.Run("null unsets nothing", func(t *testing.T) {
+		err := runErr(t, fb(
+			`foo Name {`,
+			`  flags += "a"`,
+			`  label = null`,
+			`}`,
+		))
+		assert.Contains(t, err.Error(), "does not un-set a value")
+		assert.Equal(t, 2, err.Pos.Start.Line)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		msg := run(t, fb(
+			`.import base.common as baz`,
+			`foo Name {`,
+			`  .include baz.flagged`,
+			`}`,
+		))
+
+		foo := msg.Elements[0].GetFoo()
+		assert.Equal(t, []string{"exported"}, foo.Flags)
+	})
+
+	t.Run("not exported", func(t *testing.T) {
+		err := runErr(t, fb(
+			`.import base.common`,
+			`foo Name {`,
+			`  .include common.hidden`,
+			`}`,
+		))
+		assert.Contains(t, err.Error(), "not exported")
+		assert.Equal(t, 2, err.Pos.Start.Line)
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		err := runErr(t, fb(
+			`.partial bar named {`,
+			`  name = "partial"`,
+			`}`,
+			`foo Name {`,
+			`  .include named`,
+			`}`,
+		))
+		assert.Contains(t, err.Error(), "is for \"bar\" blocks")
+	})
+
+	t.Run("error in partial", func(t *testing.T) {
+		err := runErr(t, fb(
+			`.partial foo broken {`,
+			`  unknown = "value"`,
+			`}`,
+			`foo Name {`,
+			`  .include broken`,
+			`}`,
+		))
+
+		// The error is in the partial definition, and via the include
+		assert.Equal(t, 1, err.Pos.Start.Line)
+		if assert.Len(t, err.Via, 1) {
+			assert.Equal(t, 4, err.Via[0].Start.Line)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		err := runErr(t, fb(
+			`.partial foo a {`,
+			`  .include b`,
+			`}`,
+			`.partial foo b {`,
+			`  .include a`,
+			`}`,
+			`foo Name {`,
+			`  .include a`,
+			`}`,
+		))
+		assert.Contains(t, err.Error(), "includes itself")
+	})
+}
+
+func logErr(t testing.TB, err error) {
+	t.Helper()
+	if withSource, ok := errpos.AsErrorsWithSource(err); ok {
+		t.Log(withSource.HumanString(2))
+		return
+	}
+	t.Log(err.Error())
+}
+
 func assertLoc(t *testing.T, walk *bcl_j5pb.SourceLocation, name string, startLine int32) {
 	parts := strings.Split(name, ".")
 	for _, part := range parts {
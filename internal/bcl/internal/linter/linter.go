@@ -47,6 +47,10 @@ func errorToDiagnostics(ctx context.Context, mainError error) ([]protocol.Diagno
 			"pos":   err.Pos.String(),
 			"error": err.Err.Error(),
 		}).Debug("Lint Diagnostic")
+		code := "LINT"
+		if err.Rule != "" {
+			code = err.Rule
+		}
 		diagnostics = append(diagnostics, protocol.Diagnostic{
 			Range: protocol.Range{
 				Start: protocol.Position{
@@ -58,9 +62,9 @@ func errorToDiagnostics(ctx context.Context, mainError error) ([]protocol.Diagno
 					Character: uint32(err.Pos.End.Column),
 				},
 			},
-			Code:     ptr("LINT"),
+			Code:     ptr(code),
 			Message:  err.Err.Error(),
-			Severity: protocol.DiagnosticSeverityError,
+			Severity: protocol.DiagnosticSeverity(err.Severity.LSPSeverity()),
 			Source:   "bcl",
 		})
 	}
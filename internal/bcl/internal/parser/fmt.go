@@ -207,6 +207,9 @@ func newToken(ty TokenType, value string) Token {
 func (p *fmter) doBlockHeader(block BlockHeader) {
 
 	nameParts := referenceTokens(block.Type)
+	if block.Directive {
+		nameParts = append([]Token{newToken(DOT, ".")}, nameParts...)
+	}
 	for _, val := range block.Tags {
 		nameParts = append(nameParts, newToken(SPACE, " "))
 		nameParts = append(nameParts, tagString(val)...)
@@ -253,6 +253,18 @@ func TestFmt(t *testing.T) {
 		},
 	})
 
+	run("directive", fmtCase{
+		expected: s(
+			`.partial field cusip {`,
+			`	validate.regex = null`,
+			`}`,
+		),
+		inputs: []string{
+			s(`.partial  field cusip{`, `validate.regex=null`, `}`),
+			s(`.partial field cusip {`, `  validate.regex = null`, `}`),
+		},
+	})
+
 	run("fmt.bcl", fmtCase{
 		testdata.FmtInput,
 		[]string{
@@ -232,6 +232,14 @@ func (l *Lexer) NextToken() (Token, error) {
 						Lit:   lit,
 					}, nil
 				}
+				if lit == "null" {
+					return Token{
+						Type:  NULL,
+						Start: startPos,
+						End:   l.getPosition(),
+						Lit:   lit,
+					}, nil
+				}
 
 				return Token{
 					Type:  IDENT,
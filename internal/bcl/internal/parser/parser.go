@@ -39,9 +39,13 @@ type Walker struct {
 }
 
 func (w *Walker) addError(err *unexpectedTokenError) {
+	msg := err.msg()
+	if err.context != "" {
+		msg = msg + " " + err.context
+	}
 	w.errors = append(w.errors, &errpos.Err{
 		Pos: err.ErrorPosition(),
-		Err: errors.New(err.msg()),
+		Err: errors.New(msg),
 	})
 }
 
@@ -69,6 +73,7 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 
 	type walkingBlock struct {
 		parent *walkingBlock
+		block  *Block
 		body   *Body
 	}
 
@@ -98,6 +103,7 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 
 			newBlock := &walkingBlock{
 				parent: currentBlock,
+				block:  block,
 				body:   &block.Body,
 			}
 			currentBlock = newBlock
@@ -120,6 +126,8 @@ func fragmentsToFile(fragments []Fragment) (*File, error) {
 				})
 				continue
 			}
+			closeNode := s.SourceNode
+			currentBlock.block.Close = &closeNode
 			currentBlock = currentBlock.parent
 
 		default:
@@ -260,15 +268,40 @@ func (ww *Walker) nextFragment() (Fragment, *unexpectedTokenError) {
 		}
 		return stmt, nil
 
-	case IDENT, BOOL: // bool looks like an ident.
-		stmt, err := ww.walkStatement()
+	case IDENT, BOOL, NULL: // bool and null look like an ident.
+		stmt, err := ww.walkStatement(false)
 		if err != nil {
 			return nil, err
 		}
 		return stmt, nil
 
+	case DOT:
+		// .include foo
+		// Directives are built in to the language, and are handled
+		// before the schema is applied.
+		dot := ww.popToken()
+		if ww.nextType() != IDENT {
+			return nil, unexpectedToken(ww.popToken(), IDENT)
+		}
+		if next := ww.tokens[ww.offset].Start; next.Line != dot.Start.Line || next.Column != dot.Start.Column+1 {
+			err := unexpectedToken(dot, IDENT)
+			err.context = "directive names follow the \".\" without a space"
+			return nil, err
+		}
+		stmt, err := ww.walkStatement(true)
+		if err != nil {
+			return nil, err
+		}
+		hdr, ok := stmt.(BlockHeader)
+		if !ok {
+			return nil, unexpectedToken(dot, IDENT)
+		}
+		// Include the '.' in the source of the statement
+		hdr.Start = dot.Start
+		return hdr, nil
+
 	default:
-		return nil, unexpectedToken(ww.popToken(), IDENT, COMMENT, DESCRIPTION, RBRACE, EOL)
+		return nil, unexpectedToken(ww.popToken(), IDENT, DOT, COMMENT, DESCRIPTION, RBRACE, EOL)
 	}
 
 }
@@ -436,7 +469,7 @@ func (ww *Walker) popTag() (TagValue, *unexpectedTokenError) {
 	}
 
 	switch ww.nextType() {
-	case IDENT, BOOL:
+	case IDENT, BOOL, NULL:
 
 		// Build the name parts
 		// <reference> <ident>
@@ -471,12 +504,14 @@ func (ww *Walker) popTag() (TagValue, *unexpectedTokenError) {
 
 	default:
 
-		return TagValue{}, unexpectedToken(ww.popToken(), IDENT, BOOL, STRING)
+		return TagValue{}, unexpectedToken(ww.popToken(), IDENT, BOOL, NULL, STRING)
 	}
 
 }
 
-func (ww *Walker) walkStatement() (Fragment, *unexpectedTokenError) {
+// walkStatement reads an assignment or block header. When isDirective is set,
+// the leading '.' has already been popped, and only block headers are valid.
+func (ww *Walker) walkStatement(isDirective bool) (Fragment, *unexpectedTokenError) {
 
 	// Read all dot separated idents continuing from the first token
 	// a.b.c.d
@@ -488,6 +523,14 @@ func (ww *Walker) walkStatement() (Fragment, *unexpectedTokenError) {
 
 	start := ref.SourceNode.Start
 
+	if isDirective {
+		if ww.nextType() == ASSIGN || ww.nextType() == PLUS {
+			err := unexpectedToken(ww.popToken(), LBRACE, EOL, IDENT)
+			err.context = fmt.Sprintf("directive \".%s\" cannot be assigned", ref.String())
+			return nil, err
+		}
+	}
+
 	// Assignments can only take one LHS argument
 	if ww.nextType() == ASSIGN {
 		// <reference> = ...
@@ -513,8 +556,9 @@ func (ww *Walker) walkStatement() (Fragment, *unexpectedTokenError) {
 	nameParts := []TagValue{}
 
 	hdr := BlockHeader{
-		Type: ref,
-		Tags: nameParts,
+		Type:      ref,
+		Tags:      nameParts,
+		Directive: isDirective,
 		SourceNode: SourceNode{
 			Start: start,
 		},
@@ -236,6 +236,61 @@ func TestDirectives(t *testing.T) {
 	)
 }
 
+func TestDotDirectives(t *testing.T) {
+	input := strings.Join([]string{
+		`.import base.baz as qux`,
+		`.partial field cusip {`,
+		`  .export`,
+		`  validate.regex = "^[A-Z0-9]{9}$"`,
+		`}`,
+		`field foo string {`,
+		`  .include qux.cusip`,
+		`  validate.regex = null`,
+		`}`,
+	}, "\n")
+
+	file := tParseFile(t, input)
+
+	logBody(t, "file", "", file.Body)
+
+	assertStatements(t, file.Body.Statements,
+		tBlock(tDirective("import"), tBlockTags("base.baz", "as", "qux")),
+		tBlock(
+			tDirective("partial"), tBlockTags("field", "cusip"),
+			tBlockBody(
+				tBlock(tDirective("export")),
+				tAssign("validate.regex", tString("^[A-Z0-9]{9}$")),
+			),
+		),
+		tBlock(
+			tBlockType("field"), tBlockTags("foo", "string"),
+			tBlockBody(
+				tBlock(tDirective("include"), tBlockTags("qux.cusip")),
+				tAssignNull("validate.regex"),
+			),
+		),
+	)
+
+	partial := file.Body.Statements[1].(*Block)
+	if partial.Start.Column != 0 {
+		t.Errorf("expected directive to start at column 0, got %d", partial.Start.Column)
+	}
+
+	t.Run("assign", func(t *testing.T) {
+		assertErr(t, `.foo = "bar"`, errSet(
+			errContains("cannot be assigned"),
+			errPos(1, 6),
+		))
+	})
+
+	t.Run("space after dot", func(t *testing.T) {
+		assertErr(t, `. partial field cusip {}`, errSet(
+			errContains("without a space"),
+			errPos(1, 1),
+		))
+	})
+}
+
 func TestMultilineDescription(t *testing.T) {
 	input := strings.Join([]string{
 		`block Foo {`,
@@ -370,6 +425,23 @@ func tAssign(key string, value ASTValue) tAssertion {
 	}
 }
 
+func tAssignNull(key string) tAssertion {
+	return func(t *testing.T, s Statement) {
+		assign, ok := s.(*Assignment)
+		if !ok {
+			t.Fatalf("expected Assignment, got %T", s)
+		}
+
+		if assign.Key.String() != key {
+			t.Fatalf("expected key %q, got %#v", key, assign.Key)
+		}
+
+		if !assign.Value.IsNull() {
+			t.Fatalf("expected null, got %#v", assign.Value)
+		}
+	}
+}
+
 func valuesEqual(a, b ASTValue) bool {
 	aa, aIs := a.AsArray()
 	bb, bIs := b.AsArray()
@@ -402,6 +474,17 @@ func tBlockType(part string) blockAssertion {
 	}
 }
 
+func tDirective(part string) blockAssertion {
+	return func(t *testing.T, block *Block) {
+		if !block.Directive {
+			t.Fatalf("expected directive %q, got block", part)
+		}
+		if block.Type.String() != part {
+			t.Fatalf("expected directive %q, got %q", part, block.Type)
+		}
+	}
+}
+
 func tBlockTags(parts ...string) blockAssertion {
 	return func(t *testing.T, block *Block) {
 		if len(block.Tags) != len(parts) {
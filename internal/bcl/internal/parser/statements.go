@@ -30,6 +30,9 @@ const (
 	AssignmentStatement  StatementType = "assignment"
 	CommentStatement     StatementType = "comment"
 	DescriptionStatement StatementType = "description"
+
+	// Statements merged in from a partial by a module directive
+	IncludeStatement StatementType = "include"
 )
 
 type Statement interface {
@@ -125,6 +128,7 @@ type BlockHeader struct {
 	Qualifiers  []TagValue   // Any of the `:qualifier` tags at the end
 	Description *Description // A single | description block
 	Open        bool         // 'block' is opened with a {
+	Directive   bool         // The statement was prefixed with a '.', e.g. .include
 
 	SourceNode
 }
@@ -145,6 +149,9 @@ func (bh BlockHeader) DescriptionString() string {
 }
 
 func (bs BlockHeader) GoString() string {
+	if bs.Directive {
+		return fmt.Sprintf("directive(%s, %#v)", bs.Type, bs.Tags)
+	}
 	if bs.Open {
 		return fmt.Sprintf("block(%s, %#v) <OpenBlock>", bs.Type, bs.Tags)
 	}
@@ -158,6 +165,10 @@ func (bs BlockHeader) RootName() string {
 type Block struct {
 	BlockHeader
 	Body Body
+
+	// Close is the closing brace of an open block, nil when the block is not
+	// open or was not closed.
+	Close *SourceNode
 }
 
 var _ Statement = &Block{}
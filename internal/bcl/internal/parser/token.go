@@ -23,6 +23,7 @@ const (
 	INT           // 123
 	DECIMAL       // 123.45
 	BOOL          // true or false
+	NULL          // null
 	COMMENT       // // ...
 	BLOCK_COMMENT // /* ... */
 	DESCRIPTION   // | ...
@@ -66,6 +67,7 @@ var tokens = [...]string{
 	INT:           "INT",
 	DECIMAL:       "DECIMAL",
 	BOOL:          "BOOL",
+	NULL:          "NULL",
 	COMMENT:       "COMMENT",
 	BLOCK_COMMENT: "BLOCK_COMMENT",
 	DESCRIPTION:   "DESCRIPTION",
@@ -109,7 +111,7 @@ func (tok Token) AsIdent() (Token, bool) {
 	switch tok.Type {
 	case IDENT:
 		return tok, true
-	case BOOL:
+	case BOOL, NULL:
 		nt := tok.Clone()
 		nt.Type = IDENT
 		return nt, true
@@ -195,7 +197,7 @@ func (tok TokenType) IsLiteral() bool { return literal_beg < tok && tok < litera
 func (tok TokenType) IsOperator() bool { return operator_beg < tok && tok < operator_end }
 
 func (tok TokenType) CanStartTag() bool {
-	return tok == IDENT || tok == STRING || tok == REGEX || tok == BANG || tok == QUESTION || tok == BOOL
+	return tok == IDENT || tok == STRING || tok == REGEX || tok == BANG || tok == QUESTION || tok == BOOL || tok == NULL
 }
 
 // IsKeyword reports whether name is a Go keyword, such as "func" or "return".
@@ -92,6 +92,12 @@ func (v Value) AsArray() ([]ASTValue, bool) {
 	return out, true
 }
 
+// IsNull returns true when the value is the literal null, used to un-set
+// values merged in from partials.
+func (v Value) IsNull() bool {
+	return v.array == nil && v.token.Type == NULL
+}
+
 func (v Value) Position() errpos.Position {
 	return v.SourceNode.Position()
 }
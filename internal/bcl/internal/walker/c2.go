@@ -79,8 +79,23 @@ func doBody(sc Context, body parser.Body) error {
 			}
 			sc.Logf("Assign OK")
 
+		case *Included:
+			sc.Logf("Include Statement %s.%s", decl.Partial.Package, decl.Partial.Name)
+			err := doBody(sc, parser.Body{Statements: decl.Statements})
+			if err != nil {
+				return errpos.AddVia(err, decl.Site, decl.Partial.Filename)
+			}
+			sc.Logf("Include OK")
+
+		case *scopeProbe:
+			decl.scope = sc.currentScope()
+
 		case *parser.Block:
 			sc.Logf("Block Statement %#v", decl.BlockHeader)
+			if decl.Directive {
+				err := fmt.Errorf("unexpected directive .%s", decl.Type.String())
+				return errpos.AddPosition(err, decl.Position())
+			}
 			err := doFullBlock(sc, decl)
 			if err != nil {
 				err = errpos.AddPosition(err, decl.Position())
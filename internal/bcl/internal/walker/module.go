@@ -0,0 +1,538 @@
+package walker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/bcl/internal/parser"
+)
+
+// Partial is a block declared with `.partial` at the root of a file, which is
+// merged into other blocks of the same type with `.include`.
+type Partial struct {
+	Package  string
+	Filename string
+
+	Type     string // The block type the partial merges into, e.g. `field`
+	Name     string
+	Exported bool // Set by an `.export` directive in the partial body
+
+	Position errpos.Position // The declaration of the partial
+	Body     parser.Body
+
+	// imports of the file declaring the partial, for nested includes.
+	imports map[string]string
+}
+
+// PartialSource supplies partials declared outside of the file being walked,
+// from other files in the same package and from imported packages.
+type PartialSource interface {
+	// PackagePartials returns all partials declared in the package, exported or
+	// not.
+	PackagePartials(pkg string) ([]*Partial, error)
+}
+
+// Module places the file being walked within its package.
+type Module struct {
+	Package  string
+	Filename string
+	Partials PartialSource // Optional, only partials in the file are used when nil.
+}
+
+// Included is the body of a partial merged in at an `.include` directive.
+type Included struct {
+	Site       errpos.Position
+	Partial    *Partial
+	Statements []parser.Statement
+}
+
+var _ parser.Statement = &Included{}
+
+func (inc *Included) StatementType() parser.StatementType {
+	return parser.IncludeStatement
+}
+
+func (inc *Included) Source() parser.SourceNode {
+	return parser.SourceNode{
+		Start: inc.Site.Start,
+		End:   inc.Site.End,
+	}
+}
+
+const (
+	directiveImport  = "import"
+	directiveExport  = "export"
+	directivePartial = "partial"
+	directiveInclude = "include"
+)
+
+// ReadPartials returns the partials declared at the root of the body, without
+// resolving the includes within them.
+func ReadPartials(mod Module, body parser.Body) ([]*Partial, error) {
+	partials, _, _, err := readModuleRoot(mod, body)
+	if err != nil {
+		return nil, err
+	}
+	return partials, nil
+}
+
+// ResolveModule applies the module directives in the body, returning a new
+// body with partials merged into the blocks which include them, and the
+// module level directives removed.
+func ResolveModule(mod Module, body parser.Body) (parser.Body, error) {
+	partials, imports, remaining, err := readModuleRoot(mod, body)
+	if err != nil {
+		return body, err
+	}
+
+	rr := &moduleResolver{
+		source: mod.Partials,
+		local:  partials,
+	}
+
+	root := moduleScope{
+		pkg:      mod.Package,
+		filename: mod.Filename,
+		imports:  imports,
+		isLocal:  true,
+	}
+
+	statements, err := rr.resolveStatements(root, "", remaining, nil)
+	if err != nil {
+		return body, err
+	}
+	statements, err = applyNulls(root, statements)
+	if err != nil {
+		return body, err
+	}
+
+	return parser.Body{
+		IsRoot:     body.IsRoot,
+		Statements: statements,
+	}, nil
+}
+
+func isDirective(stmt parser.Statement, name string) (*parser.Block, bool) {
+	block, ok := stmt.(*parser.Block)
+	if !ok || !block.Directive {
+		return nil, false
+	}
+	return block, block.Type.String() == name
+}
+
+func directiveErr(block *parser.Block, format string, args ...interface{}) error {
+	return errpos.AddPosition(fmt.Errorf(format, args...), block.Position())
+}
+
+func readImports(body parser.Body) (map[string]string, error) {
+	imports := map[string]string{}
+	for _, stmt := range body.Statements {
+		block, ok := isDirective(stmt, directiveImport)
+		if !ok {
+			continue
+		}
+
+		// .import foo.bar
+		// .import foo.bar as baz
+		tags := block.Tags
+		if len(tags) != 1 && len(tags) != 3 || len(block.Body.Statements) > 0 || len(block.Qualifiers) > 0 {
+			return nil, directiveErr(block, "expected .import <package> [as <alias>]")
+		}
+
+		if tags[0].Reference == nil {
+			return nil, directiveErr(block, "import package must be a reference")
+		}
+		pkg := tags[0].Reference.String()
+		alias := tags[0].Reference.Idents[len(tags[0].Reference.Idents)-1].Value
+
+		if len(tags) == 3 {
+			as, _ := tags[1].AsString()
+			if as != "as" || tags[2].Reference == nil || len(tags[2].Reference.Idents) != 1 {
+				return nil, directiveErr(block, "expected .import <package> [as <alias>]")
+			}
+			alias = tags[2].Reference.String()
+		}
+
+		if existing, ok := imports[alias]; ok {
+			return nil, directiveErr(block, "import alias %q already used for %q", alias, existing)
+		}
+		imports[alias] = pkg
+	}
+	return imports, nil
+}
+
+// readModuleRoot splits the root of a file into partials, imports and the
+// remaining statements.
+func readModuleRoot(mod Module, body parser.Body) ([]*Partial, map[string]string, []parser.Statement, error) {
+	imports, err := readImports(body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	partials := make([]*Partial, 0)
+	remaining := make([]parser.Statement, 0, len(body.Statements))
+	names := map[string]*Partial{}
+
+	for _, stmt := range body.Statements {
+		if _, ok := isDirective(stmt, directiveImport); ok {
+			continue
+		}
+
+		block, ok := isDirective(stmt, directivePartial)
+		if !ok {
+			remaining = append(remaining, stmt)
+			continue
+		}
+
+		partial, err := newPartial(mod, block)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		partial.imports = imports
+
+		if existing, ok := names[partial.Name]; ok {
+			return nil, nil, nil, directiveErr(block, "partial %q already declared at %s", partial.Name, existing.Position)
+		}
+		names[partial.Name] = partial
+		partials = append(partials, partial)
+	}
+
+	return partials, imports, remaining, nil
+}
+
+func newPartial(mod Module, block *parser.Block) (*Partial, error) {
+	// .partial <type> <name> { ... }
+	if len(block.Tags) != 2 || len(block.Qualifiers) > 0 {
+		return nil, directiveErr(block, "expected .partial <type> <name>")
+	}
+
+	typeTag, nameTag := block.Tags[0], block.Tags[1]
+	if typeTag.Reference == nil || nameTag.Reference == nil || len(nameTag.Reference.Idents) != 1 {
+		return nil, directiveErr(block, "expected .partial <type> <name>")
+	}
+
+	partial := &Partial{
+		Package:  mod.Package,
+		Filename: mod.Filename,
+		Type:     typeTag.Reference.String(),
+		Name:     nameTag.Reference.String(),
+		Position: block.Position(),
+	}
+
+	if block.Description != nil {
+		return nil, directiveErr(block, "partials cannot have a description")
+	}
+
+	statements := make([]parser.Statement, 0, len(block.Body.Statements))
+	for _, stmt := range block.Body.Statements {
+		if export, ok := isDirective(stmt, directiveExport); ok {
+			if len(export.Tags) > 0 || len(export.Body.Statements) > 0 {
+				return nil, directiveErr(export, "unexpected arguments for .export")
+			}
+			partial.Exported = true
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+	partial.Body = parser.Body{
+		Statements: statements,
+	}
+
+	return partial, nil
+}
+
+type moduleScope struct {
+	pkg      string
+	filename string
+	imports  map[string]string
+	isLocal  bool // The scope is the file being resolved
+}
+
+func (ms moduleScope) position(pos errpos.Position) errpos.Position {
+	if ms.filename != "" {
+		pos.Filename = &ms.filename
+	}
+	return pos
+}
+
+type moduleResolver struct {
+	source PartialSource
+	local  []*Partial
+}
+
+func (rr *moduleResolver) packagePartials(pkg string) ([]*Partial, error) {
+	if rr.source == nil {
+		return nil, nil
+	}
+	return rr.source.PackagePartials(pkg)
+}
+
+func findPartial(partials []*Partial, name string) *Partial {
+	for _, partial := range partials {
+		if partial.Name == name {
+			return partial
+		}
+	}
+	return nil
+}
+
+func (rr *moduleResolver) findPartial(scope moduleScope, ref *parser.Reference) (*Partial, error) {
+	parts := ref.Strings()
+	switch len(parts) {
+	case 1:
+		name := parts[0]
+		if scope.isLocal {
+			if partial := findPartial(rr.local, name); partial != nil {
+				return partial, nil
+			}
+		}
+
+		pkgPartials, err := rr.packagePartials(scope.pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		if partial := findPartial(pkgPartials, name); partial != nil {
+			return partial, nil
+		}
+		return nil, fmt.Errorf("partial %q not found", name)
+
+	case 2:
+		alias, name := parts[0], parts[1]
+		pkg, ok := scope.imports[alias]
+		if !ok {
+			return nil, fmt.Errorf("no import for %q, partial %q", alias, ref.String())
+		}
+
+		pkgPartials, err := rr.packagePartials(pkg)
+		if err != nil {
+			return nil, err
+		}
+
+		partial := findPartial(pkgPartials, name)
+		if partial == nil {
+			return nil, fmt.Errorf("partial %q not found in package %q", name, pkg)
+		}
+		if !partial.Exported && pkg != scope.pkg {
+			return nil, fmt.Errorf("partial %q in package %q is not exported", name, pkg)
+		}
+		return partial, nil
+
+	default:
+		return nil, fmt.Errorf("invalid partial reference %q, expected <name> or <import>.<name>", ref.String())
+	}
+}
+
+func partialKey(partial *Partial) string {
+	return partial.Package + "." + partial.Name
+}
+
+func (rr *moduleResolver) include(scope moduleScope, blockType string, block *parser.Block, stack []string) (*Included, error) {
+	// .include <name>
+	// .include <import>.<name>
+	if len(block.Tags) != 1 || block.Tags[0].Reference == nil || len(block.Body.Statements) > 0 {
+		return nil, directiveErr(block, "expected .include <partial>")
+	}
+	ref := block.Tags[0].Reference
+
+	if blockType == "" {
+		return nil, directiveErr(block, "partials can only be included in a block")
+	}
+
+	partial, err := rr.findPartial(scope, ref)
+	if err != nil {
+		return nil, errpos.AddPosition(err, ref.Position())
+	}
+
+	if partial.Type != blockType {
+		return nil, errpos.AddPosition(fmt.Errorf("partial %q is for %q blocks, cannot include in %q", ref.String(), partial.Type, blockType), ref.Position())
+	}
+
+	key := partialKey(partial)
+	for _, visited := range stack {
+		if visited == key {
+			return nil, errpos.AddPosition(fmt.Errorf("partial %q includes itself: %s", ref.String(), strings.Join(append(stack, key), " -> ")), ref.Position())
+		}
+	}
+
+	partialScope := moduleScope{
+		pkg:      partial.Package,
+		filename: partial.Filename,
+		imports:  partial.imports,
+		isLocal:  scope.isLocal && partial.Filename == scope.filename,
+	}
+
+	statements, err := rr.resolveStatements(partialScope, blockType, partial.Body.Statements, append(stack, key))
+	if err != nil {
+		return nil, errpos.AddVia(err, scope.position(block.Position()), partial.Filename)
+	}
+
+	return &Included{
+		Site:       scope.position(block.Position()),
+		Partial:    partial,
+		Statements: statements,
+	}, nil
+}
+
+func (rr *moduleResolver) resolveStatements(scope moduleScope, blockType string, statements []parser.Statement, stack []string) ([]parser.Statement, error) {
+	out := make([]parser.Statement, 0, len(statements))
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *parser.Block:
+			if stmt.Directive {
+				switch stmt.Type.String() {
+				case directiveInclude:
+					included, err := rr.include(scope, blockType, stmt, stack)
+					if err != nil {
+						return nil, err
+					}
+					out = append(out, included)
+
+				case directiveImport, directivePartial:
+					return nil, directiveErr(stmt, ".%s is only valid at the root of a file", stmt.Type.String())
+
+				case directiveExport:
+					return nil, directiveErr(stmt, ".export is only valid within a .partial")
+
+				default:
+					return nil, directiveErr(stmt, "unknown directive .%s", stmt.Type.String())
+				}
+				continue
+			}
+
+			body, err := rr.resolveStatements(scope, stmt.Type.String(), stmt.Body.Statements, stack)
+			if err != nil {
+				return nil, err
+			}
+			body, err = applyNulls(scope, body)
+			if err != nil {
+				return nil, err
+			}
+
+			// Partials are shared between includes, copy rather than modify.
+			resolved := *stmt
+			resolved.Body = parser.Body{
+				IsRoot:     stmt.Body.IsRoot,
+				Statements: body,
+			}
+			out = append(out, &resolved)
+
+		default:
+			out = append(out, stmt)
+		}
+	}
+	return out, nil
+}
+
+// nullAssignment is a `key = null` in a block, directly or from an included
+// partial.
+type nullAssignment struct {
+	assign *parser.Assignment
+	index  int             // of the statement in the block, or the include
+	site   errpos.Position // to report the null
+	used   bool
+}
+
+func collectNulls(statements []parser.Statement, index int, site errpos.Position, out []*nullAssignment) []*nullAssignment {
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			if stmt.Value.IsNull() {
+				out = append(out, &nullAssignment{assign: stmt, index: index, site: site})
+			}
+		case *Included:
+			out = collectNulls(stmt.Statements, index, site, out)
+		}
+	}
+	return out
+}
+
+// applyNulls removes the null assignments of a block, once all includes are
+// merged, along with the values they un-set. A null un-sets the key in every
+// partial included in the block, and in the assignments before it in the
+// block itself, so a value can still be set again after the null.
+func applyNulls(scope moduleScope, statements []parser.Statement) ([]parser.Statement, error) {
+	nulls := make([]*nullAssignment, 0)
+	for idx, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			if stmt.Value.IsNull() {
+				nulls = append(nulls, &nullAssignment{
+					assign: stmt,
+					index:  idx,
+					site:   scope.position(stmt.Position()),
+				})
+			}
+		case *Included:
+			nulls = collectNulls(stmt.Statements, idx, stmt.Site, nulls)
+		}
+	}
+	if len(nulls) == 0 {
+		return statements, nil
+	}
+
+	out := make([]parser.Statement, 0, len(statements))
+	for idx, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			if stmt.Value.IsNull() {
+				continue
+			}
+			unset := false
+			for _, null := range nulls {
+				if null.index > idx && null.assign.Key.String() == stmt.Key.String() {
+					null.used = true
+					unset = true
+				}
+			}
+			if unset {
+				continue
+			}
+		case *Included:
+			out = append(out, removeNulled(stmt, nulls))
+			continue
+		}
+		out = append(out, stmt)
+	}
+
+	for _, null := range nulls {
+		if !null.used {
+			return nil, errpos.AddPosition(fmt.Errorf("%s = null does not un-set a value", null.assign.Key.String()), null.site)
+		}
+	}
+	return out, nil
+}
+
+// removeNulled copies the included statements without the null assignments
+// and the values they un-set.
+func removeNulled(included *Included, nulls []*nullAssignment) *Included {
+	out := make([]parser.Statement, 0, len(included.Statements))
+	for _, stmt := range included.Statements {
+		switch stmt := stmt.(type) {
+		case *parser.Assignment:
+			if stmt.Value.IsNull() {
+				continue
+			}
+			unset := false
+			for _, null := range nulls {
+				if null.assign.Key.String() == stmt.Key.String() {
+					null.used = true
+					unset = true
+				}
+			}
+			if unset {
+				continue
+			}
+		case *Included:
+			out = append(out, removeNulled(stmt, nulls))
+			continue
+		}
+		out = append(out, stmt)
+	}
+	return &Included{
+		Site:       included.Site,
+		Partial:    included.Partial,
+		Statements: out,
+	}
+}
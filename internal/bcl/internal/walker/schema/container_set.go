@@ -5,6 +5,7 @@ import (
 	"sort"
 
 	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
 	"golang.org/x/exp/maps"
 )
 
@@ -34,7 +35,7 @@ func (bs containerSet) allChildFields() map[string]*schema_j5pb.Field {
 			return nil
 		})
 		for name, path := range blockSchema.spec.Aliases {
-			schema, err := blockSchema.container.ContainerSchema().WalkToProperty(path...)
+			schema, err := walkSchemaPath(blockSchema.container.ContainerSchema(), path)
 			if err != nil {
 				continue
 			}
@@ -46,6 +47,30 @@ func (bs containerSet) allChildFields() map[string]*schema_j5pb.Field {
 	return children
 }
 
+// walkSchemaPath walks to the field schema at path, stepping into the items of
+// arrays along the way as the walker does when setting values.
+func walkSchemaPath(container j5schema.Container, path []string) (j5schema.FieldSchema, error) {
+	var field j5schema.FieldSchema
+	for idx, name := range path {
+		if idx > 0 {
+			if arrayField, ok := field.(*j5schema.ArrayField); ok {
+				field = arrayField.Schema
+			}
+			next, ok := field.AsContainer()
+			if !ok {
+				return nil, fmt.Errorf("%s is not a container", path[idx-1])
+			}
+			container = next
+		}
+		next, err := container.WalkToProperty(name)
+		if err != nil {
+			return nil, err
+		}
+		field = next
+	}
+	return field, nil
+}
+
 func (bs containerSet) listChildren() []string {
 	fields := bs.allChildFields()
 	fieldNames := maps.Keys(fields)
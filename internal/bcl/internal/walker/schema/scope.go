@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/pentops/j5/lib/j5reflect"
+	"github.com/pentops/j5/lib/j5schema"
 	"github.com/pentops/j5build/internal/bcl/errpos"
 	"github.com/pentops/j5build/internal/bcl/gen/j5/bcl/v1/bcl_j5pb"
 )
@@ -95,6 +96,49 @@ func (sw *Scope) ListBlocks() []string {
 	return sw.blockSet.listBlocks()
 }
 
+// EnumOptions returns the options of the enum, or array of enums, attribute
+// with the given name. The bool is false when the attribute is not an enum.
+func (sw *Scope) EnumOptions(name string) ([]string, bool) {
+	root, spec, ok := sw.findBlock(name)
+	if !ok {
+		return nil, false
+	}
+
+	fieldSchema, err := walkSchemaPath(root.container.ContainerSchema(), spec.Path)
+	if err != nil {
+		return nil, false
+	}
+
+	if arrayField, ok := fieldSchema.(*j5schema.ArrayField); ok {
+		fieldSchema = arrayField.Schema
+	}
+
+	enumField, ok := fieldSchema.(*j5schema.EnumField)
+	if !ok {
+		return nil, false
+	}
+	return enumField.Schema().OptionsList(), true
+}
+
+// TypeSelectOptions lists the types which the type-select tag of the current
+// block can select, nil if the block has no type-select tag.
+func (sw *Scope) TypeSelectOptions() []string {
+	typeSelect := sw.leafBlock.spec.TypeSelect
+	if typeSelect == nil {
+		return nil
+	}
+
+	if typeSelect.FieldName == "" || typeSelect.FieldName == "." {
+		return sw.TailScope().ListBlocks()
+	}
+
+	typeScope, err := sw.ChildBlock(typeSelect.FieldName, SourceLocation{})
+	if err != nil {
+		return nil
+	}
+	return typeScope.ListBlocks()
+}
+
 func (sw *Scope) ChildBlock(name string, source SourceLocation) (*Scope, *WalkPathError) {
 	root, spec, ok := sw.findBlock(name)
 	if !ok {
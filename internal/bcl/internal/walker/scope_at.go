@@ -0,0 +1,61 @@
+package walker
+
+import (
+	"fmt"
+
+	"github.com/pentops/j5build/internal/bcl/internal/parser"
+	"github.com/pentops/j5build/internal/bcl/internal/walker/schema"
+)
+
+const probeStatement parser.StatementType = "probe"
+
+// scopeProbe records the scope of the body it is placed in.
+type scopeProbe struct {
+	scope *schema.Scope
+}
+
+var _ parser.Statement = &scopeProbe{}
+
+func (sp *scopeProbe) StatementType() parser.StatementType {
+	return probeStatement
+}
+
+func (sp *scopeProbe) Source() parser.SourceNode {
+	return parser.SourceNode{}
+}
+
+// ScopeAt returns the scope of the body of the last block in path. The first
+// block is in the root body, and each following block is a direct child of the
+// block before it. Only the headers of the blocks are walked, the rest of their
+// bodies are ignored.
+func ScopeAt(scope *schema.Scope, path []*parser.Block) (*schema.Scope, error) {
+	probe := &scopeProbe{}
+	body := parser.Body{
+		IsRoot:     len(path) == 0,
+		Statements: []parser.Statement{probe},
+	}
+	for idx := len(path) - 1; idx >= 0; idx-- {
+		block := *path[idx]
+		block.Body = body
+		body = parser.Body{
+			IsRoot:     idx == 0,
+			Statements: []parser.Statement{&block},
+		}
+	}
+
+	rootContext := &walkContext{
+		scope: scope,
+		path:  []string{""},
+	}
+
+	err := rootContext.run(func(sc Context) error {
+		return doBody(sc, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if probe.scope == nil {
+		return nil, fmt.Errorf("scope not reached")
+	}
+	return probe.scope, nil
+}
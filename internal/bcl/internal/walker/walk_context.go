@@ -41,6 +41,7 @@ type Context interface {
 	AppendAttribute(path schema.PathSpec, ref []parser.Ident, value parser.ASTValue) error
 
 	setContainerFromScalar(bs schema.BlockSpec, vals parser.ASTValue) error
+	currentScope() *schema.Scope
 
 	Logf(format string, args ...interface{})
 	WrapErr(err error, pos HasPosition) error
@@ -91,6 +92,10 @@ func combinePath(path schema.PathSpec, ref []parser.Ident) []pathElement {
 	return pathToBlock
 }
 
+func (sc *walkContext) currentScope() *schema.Scope {
+	return sc.scope
+}
+
 func (sc *walkContext) SetLocation(loc schema.SourceLocation) {
 	sc.blockLocation = loc
 }
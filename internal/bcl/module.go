@@ -0,0 +1,68 @@
+package bcl
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/bcl/internal/parser"
+	"github.com/pentops/j5build/internal/bcl/internal/walker"
+)
+
+// PackageName returns the package of a file, which is the directory of the
+// file with '/' replaced by '.'.
+func PackageName(filename string) string {
+	dirName := path.Dir(filename)
+	if dirName == "." || dirName == "/" {
+		return ""
+	}
+	dirName = strings.Trim(dirName, "/")
+	return strings.ReplaceAll(dirName, "/", ".")
+}
+
+// ReadPartials parses the file and returns the partials declared in it. No
+// schema is applied, the partials are only checked when included.
+func ReadPartials(filename string, data string) ([]*Partial, error) {
+	tree, err := parser.ParseFile(data, true)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+
+	partials, err := walker.ReadPartials(walker.Module{
+		Package:  PackageName(filename),
+		Filename: filename,
+	}, tree.Body)
+	if err != nil {
+		return nil, errpos.AddSourceFile(err, filename, data)
+	}
+	return partials, nil
+}
+
+// PartialSet is a PartialSource built from files in memory, keyed by package.
+type PartialSet map[string][]*Partial
+
+var _ PartialSource = PartialSet{}
+
+// AddFile reads the partials declared in the file into the set.
+func (ps PartialSet) AddFile(filename string, data string) error {
+	partials, err := ReadPartials(filename, data)
+	if err != nil {
+		return err
+	}
+
+	for _, partial := range partials {
+		for _, existing := range ps[partial.Package] {
+			if existing.Name == partial.Name {
+				err := fmt.Errorf("partial %q already declared in %s", partial.Name, existing.Filename)
+				return errpos.AddSourceFile(errpos.AddPosition(err, partial.Position), filename, data)
+			}
+		}
+		ps[partial.Package] = append(ps[partial.Package], partial)
+	}
+	return nil
+}
+
+func (ps PartialSet) PackagePartials(pkg string) ([]*Partial, error) {
+	return ps[pkg], nil
+}
@@ -23,8 +23,20 @@ type Parser struct {
 	FailFast bool
 	validate protovalidate.Validator
 	schema   *schema.SchemaSet
+
+	// Partials supplies partials declared outside of the file being parsed,
+	// for .include directives. When nil, only partials in the file are
+	// available.
+	Partials PartialSource
 }
 
+// Partial is a block declared with .partial, which can be merged into other
+// blocks of the same type with .include.
+type Partial = walker.Partial
+
+// PartialSource supplies partials by package, see PartialSet.
+type PartialSource = walker.PartialSource
+
 func NewParser(schemaSpec *bcl_j5pb.Schema) (*Parser, error) {
 	pv, err := protovalidate.New()
 	if err != nil {
@@ -60,7 +72,13 @@ func (p *Parser) ParseFile(filename string, data string, msg protoreflect.Messag
 		return nil, fmt.Errorf("parse file not HadErrors - : %w", err)
 	}
 
-	loc, err := p.ParseAST(tree, msg)
+	mod := walker.Module{
+		Package:  PackageName(filename),
+		Filename: filename,
+		Partials: p.Partials,
+	}
+
+	loc, err := p.parseAST(mod, tree, msg)
 	if err != nil {
 		err = errpos.AddSourceFile(err, filename, data)
 		return loc, err
@@ -69,18 +87,29 @@ func (p *Parser) ParseFile(filename string, data string, msg protoreflect.Messag
 }
 
 func (p *Parser) ParseAST(tree *parser.File, msg protoreflect.Message) (*bcl_j5pb.SourceLocation, error) {
+	return p.parseAST(walker.Module{
+		Partials: p.Partials,
+	}, tree, msg)
+}
+
+func (p *Parser) parseAST(mod walker.Module, tree *parser.File, msg protoreflect.Message) (*bcl_j5pb.SourceLocation, error) {
 	obj, err := p.refl.NewObject(msg)
 	if err != nil {
 		return nil, err
 	}
 
+	body, err := walker.ResolveModule(mod, tree.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	source := &bcl_j5pb.SourceLocation{}
 	scope, err := schema.NewRootSchemaWalker(p.schema, obj, source)
 	if err != nil {
 		return nil, err
 	}
 
-	err = walker.WalkSchema(scope, tree.Body, p.Verbose)
+	err = walker.WalkSchema(scope, body, p.Verbose)
 	if err != nil {
 		return source, fmt.Errorf("walkSchema: %w", err)
 	}
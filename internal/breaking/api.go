@@ -0,0 +1,497 @@
+package breaking
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/internal/j5client"
+	"github.com/pentops/j5build/internal/structure"
+)
+
+type api struct {
+	source *source_j5pb.API
+	client *client_j5pb.API
+}
+
+func buildAPI(img *source_j5pb.SourceImage, rules []structure.ServiceRule) (*api, error) {
+	sourceAPI, err := structure.APIFromImage(img, rules...)
+	if err != nil {
+		return nil, fmt.Errorf("source API from image: %w", err)
+	}
+
+	clientAPI, err := j5client.APIFromSource(sourceAPI)
+	if err != nil {
+		return nil, fmt.Errorf("client API from source: %w", err)
+	}
+
+	return &api{
+		source: sourceAPI.Source,
+		client: clientAPI,
+	}, nil
+}
+
+// compareAPI checks the JSON representation of the schemas, the REST methods
+// and the topics.
+func compareAPI(cc *changeCollector, baseline, current *api) {
+	currentPackages := map[string]*client_j5pb.Package{}
+	for _, pkg := range current.client.Packages {
+		currentPackages[pkg.Name] = pkg
+	}
+
+	for _, basePkg := range baseline.client.Packages {
+		curPkg, ok := currentPackages[basePkg.Name]
+		if !ok {
+			cc.add(RulePackageRemoved, basePkg.Name, basePkg.Name, "package removed")
+			continue
+		}
+		pc := &packageCompare{cc: cc, pkg: basePkg.Name}
+		pc.compareSchemas(basePkg.Schemas, curPkg.Schemas)
+		pc.compareMethods(packageMethods(basePkg), packageMethods(curPkg))
+	}
+
+	currentTopics := packageTopics(current.source)
+	for key, baseTopic := range packageTopics(baseline.source) {
+		pkg := strings.SplitN(key, "/", 2)[0]
+		curTopic, ok := currentTopics[key]
+		if !ok {
+			cc.add(RuleTopicRemoved, pkg, key, "topic removed")
+			continue
+		}
+		messages := map[string]struct{}{}
+		for _, msg := range curTopic.Messages {
+			messages[msg.Name] = struct{}{}
+		}
+		for _, msg := range baseTopic.Messages {
+			if _, ok := messages[msg.Name]; !ok {
+				cc.add(RuleTopicMessageRemoved, pkg, key+"/"+msg.Name, "topic message removed")
+			}
+		}
+	}
+}
+
+// packageMethods indexes the methods of a package by service and method name.
+func packageMethods(pkg *client_j5pb.Package) map[string]*client_j5pb.Method {
+	services := slices.Clone(pkg.Services)
+	for _, entity := range pkg.StateEntities {
+		if entity.QueryService != nil {
+			services = append(services, entity.QueryService)
+		}
+		services = append(services, entity.CommandServices...)
+	}
+
+	methods := map[string]*client_j5pb.Method{}
+	for _, service := range services {
+		for _, method := range service.Methods {
+			methods[service.Name+"/"+method.Name] = method
+		}
+	}
+	return methods
+}
+
+// packageTopics indexes topics by package, sub-package and name.
+func packageTopics(sourceAPI *source_j5pb.API) map[string]*source_j5pb.Topic {
+	topics := map[string]*source_j5pb.Topic{}
+	for _, pkg := range sourceAPI.Packages {
+		for _, subPkg := range pkg.SubPackages {
+			for _, topic := range subPkg.Topics {
+				topics[pkg.Name+"/"+subPkg.Name+"/"+topic.Name] = topic
+			}
+		}
+	}
+	return topics
+}
+
+type packageCompare struct {
+	cc  *changeCollector
+	pkg string
+}
+
+func (pc *packageCompare) add(rule string, subject string, format string, args ...interface{}) {
+	pc.cc.add(rule, pc.pkg, subject, format, args...)
+}
+
+func (pc *packageCompare) compareSchemas(base, cur map[string]*schema_j5pb.RootSchema) {
+	for name, baseSchema := range base {
+		subject := pc.pkg + "." + name
+		curSchema, ok := cur[name]
+		if !ok {
+			pc.add(RuleSchemaRemoved, subject, "schema removed")
+			continue
+		}
+
+		switch baseType := baseSchema.Type.(type) {
+		case *schema_j5pb.RootSchema_Object:
+			curType, ok := curSchema.Type.(*schema_j5pb.RootSchema_Object)
+			if !ok {
+				pc.add(RuleSchemaTypeChanged, subject, "changed from object to %s", rootSchemaKind(curSchema))
+				continue
+			}
+			// Shared schemas may be used in requests, so are checked as
+			// inputs.
+			pc.compareProperties(subject, baseType.Object.Properties, curType.Object.Properties, true)
+
+		case *schema_j5pb.RootSchema_Oneof:
+			curType, ok := curSchema.Type.(*schema_j5pb.RootSchema_Oneof)
+			if !ok {
+				pc.add(RuleSchemaTypeChanged, subject, "changed from oneof to %s", rootSchemaKind(curSchema))
+				continue
+			}
+			pc.compareProperties(subject, baseType.Oneof.Properties, curType.Oneof.Properties, true)
+
+		case *schema_j5pb.RootSchema_Enum:
+			curType, ok := curSchema.Type.(*schema_j5pb.RootSchema_Enum)
+			if !ok {
+				pc.add(RuleSchemaTypeChanged, subject, "changed from enum to %s", rootSchemaKind(curSchema))
+				continue
+			}
+			pc.compareEnumOptions(subject, baseType.Enum, curType.Enum)
+		}
+	}
+}
+
+func rootSchemaKind(schema *schema_j5pb.RootSchema) string {
+	switch schema.Type.(type) {
+	case *schema_j5pb.RootSchema_Object:
+		return "object"
+	case *schema_j5pb.RootSchema_Oneof:
+		return "oneof"
+	case *schema_j5pb.RootSchema_Enum:
+		return "enum"
+	default:
+		return "unknown"
+	}
+}
+
+func (pc *packageCompare) compareMethods(base, cur map[string]*client_j5pb.Method) {
+	for name, baseMethod := range base {
+		subject := pc.pkg + "." + name
+		curMethod, ok := cur[name]
+		if !ok {
+			pc.add(RuleMethodRemoved, subject, "method removed")
+			continue
+		}
+
+		baseRoute := fmt.Sprintf("%s %s", httpMethodName(baseMethod.HttpMethod), baseMethod.HttpPath)
+		curRoute := fmt.Sprintf("%s %s", httpMethodName(curMethod.HttpMethod), curMethod.HttpPath)
+		if baseRoute != curRoute {
+			pc.add(RuleMethodRouteChanged, subject, "route changed from %s to %s", baseRoute, curRoute)
+		}
+
+		baseRequest := baseMethod.Request
+		curRequest := curMethod.Request
+		if baseRequest != nil && curRequest != nil {
+			pc.compareProperties(subject+" path", baseRequest.PathParameters, curRequest.PathParameters, true)
+			pc.compareProperties(subject+" query", baseRequest.QueryParameters, curRequest.QueryParameters, true)
+			if baseRequest.Body != nil && curRequest.Body != nil {
+				pc.compareProperties(subject+" request", baseRequest.Body.Properties, curRequest.Body.Properties, true)
+			}
+		}
+
+		if baseMethod.ResponseBody != nil && curMethod.ResponseBody != nil {
+			pc.compareProperties(subject+" response", baseMethod.ResponseBody.Properties, curMethod.ResponseBody.Properties, false)
+		}
+	}
+}
+
+func httpMethodName(method client_j5pb.HTTPMethod) string {
+	return strings.TrimPrefix(method.String(), "HTTP_METHOD_")
+}
+
+// compareProperties checks the properties of an object or oneof. Requiring a
+// property, or tightening its validation, only breaks clients sending the
+// object, so is only checked for inputs.
+func (pc *packageCompare) compareProperties(subject string, base, cur []*schema_j5pb.ObjectProperty, input bool) {
+	curProps := map[string]*schema_j5pb.ObjectProperty{}
+	for _, prop := range cur {
+		curProps[prop.Name] = prop
+	}
+
+	baseProps := map[string]struct{}{}
+	for _, baseProp := range base {
+		baseProps[baseProp.Name] = struct{}{}
+		propSubject := subject + "." + baseProp.Name
+		curProp, ok := curProps[baseProp.Name]
+		if !ok {
+			pc.add(RulePropertyRemoved, propSubject, "property removed")
+			continue
+		}
+		if input && curProp.Required && !baseProp.Required {
+			pc.add(RulePropertyRequired, propSubject, "property is now required")
+		}
+		pc.compareField(propSubject, baseProp.Schema, curProp.Schema, input)
+	}
+
+	if !input {
+		return
+	}
+	for _, curProp := range cur {
+		if _, ok := baseProps[curProp.Name]; ok {
+			continue
+		}
+		if curProp.Required {
+			pc.add(RulePropertyRequired, subject+"."+curProp.Name, "new required property")
+		}
+	}
+}
+
+func (pc *packageCompare) compareField(subject string, base, cur *schema_j5pb.Field, input bool) {
+	if base == nil || cur == nil {
+		return
+	}
+
+	baseSig := typeSignature(base)
+	curSig := typeSignature(cur)
+	if baseSig != curSig {
+		pc.add(RulePropertyTypeChanged, subject, "type changed from %s to %s", baseSig, curSig)
+		return
+	}
+
+	switch baseType := base.Type.(type) {
+	case *schema_j5pb.Field_Object:
+		curType := cur.Type.(*schema_j5pb.Field_Object)
+		baseInline := baseType.Object.GetObject()
+		curInline := curType.Object.GetObject()
+		if baseInline != nil && curInline != nil {
+			pc.compareProperties(subject, baseInline.Properties, curInline.Properties, input)
+		}
+
+	case *schema_j5pb.Field_Oneof:
+		curType := cur.Type.(*schema_j5pb.Field_Oneof)
+		baseInline := baseType.Oneof.GetOneof()
+		curInline := curType.Oneof.GetOneof()
+		if baseInline != nil && curInline != nil {
+			pc.compareProperties(subject, baseInline.Properties, curInline.Properties, input)
+		}
+
+	case *schema_j5pb.Field_Enum:
+		curType := cur.Type.(*schema_j5pb.Field_Enum)
+		baseInline := baseType.Enum.GetEnum()
+		curInline := curType.Enum.GetEnum()
+		if baseInline != nil && curInline != nil {
+			pc.compareEnumOptions(subject, baseInline, curInline)
+		}
+
+	case *schema_j5pb.Field_Array:
+		curType := cur.Type.(*schema_j5pb.Field_Array)
+		pc.compareField(subject+"[]", baseType.Array.Items, curType.Array.Items, input)
+
+	case *schema_j5pb.Field_Map:
+		curType := cur.Type.(*schema_j5pb.Field_Map)
+		pc.compareField(subject+"{}", baseType.Map.ItemSchema, curType.Map.ItemSchema, input)
+	}
+
+	if !input {
+		return
+	}
+	for _, tightened := range tightenedRules(base, cur) {
+		pc.add(RuleValidationTightened, subject, "%s", tightened)
+	}
+}
+
+func (pc *packageCompare) compareEnumOptions(subject string, base, cur *schema_j5pb.Enum) {
+	options := map[string]struct{}{}
+	for _, option := range cur.Options {
+		options[option.Name] = struct{}{}
+	}
+	for _, option := range base.Options {
+		if _, ok := options[option.Name]; !ok {
+			pc.add(RuleEnumOptionRemoved, subject+"."+option.Name, "enum option removed")
+		}
+	}
+}
+
+// typeSignature describes the JSON shape of a field, fields with the same
+// signature are encoded the same way.
+func typeSignature(field *schema_j5pb.Field) string {
+	switch ft := field.Type.(type) {
+	case *schema_j5pb.Field_Any:
+		return "any"
+	case *schema_j5pb.Field_Object:
+		return refSignature("object", ft.Object.GetRef())
+	case *schema_j5pb.Field_Oneof:
+		return refSignature("oneof", ft.Oneof.GetRef())
+	case *schema_j5pb.Field_Enum:
+		return refSignature("enum", ft.Enum.GetRef())
+	case *schema_j5pb.Field_Array:
+		return "array<" + typeSignature(ft.Array.Items) + ">"
+	case *schema_j5pb.Field_Map:
+		return "map<" + typeSignature(ft.Map.ItemSchema) + ">"
+	case *schema_j5pb.Field_String_:
+		return "string"
+	case *schema_j5pb.Field_Integer:
+		return "integer:" + strings.ToLower(strings.TrimPrefix(ft.Integer.Format.String(), "FORMAT_"))
+	case *schema_j5pb.Field_Float:
+		return "float:" + strings.ToLower(strings.TrimPrefix(ft.Float.Format.String(), "FORMAT_"))
+	case *schema_j5pb.Field_Bool:
+		return "bool"
+	case *schema_j5pb.Field_Bytes:
+		return "bytes"
+	case *schema_j5pb.Field_Decimal:
+		return "decimal"
+	case *schema_j5pb.Field_Date:
+		return "date"
+	case *schema_j5pb.Field_Timestamp:
+		return "timestamp"
+	case *schema_j5pb.Field_Key:
+		return "key"
+	default:
+		return fmt.Sprintf("%T", ft)
+	}
+}
+
+func refSignature(kind string, ref *schema_j5pb.Ref) string {
+	if ref == nil {
+		return kind
+	}
+	return fmt.Sprintf("%s:%s.%s", kind, ref.Package, ref.Schema)
+}
+
+// tightenedRules describes the validation rules of the field which reject
+// values the baseline accepted. The fields must have the same signature.
+func tightenedRules(base, cur *schema_j5pb.Field) []string {
+	out := []string{}
+	add := func(msg string) {
+		if msg != "" {
+			out = append(out, msg)
+		}
+	}
+
+	switch baseType := base.Type.(type) {
+	case *schema_j5pb.Field_String_:
+		baseField, curField := baseType.String_, cur.GetString_()
+		if curField.Format != nil && curField.GetFormat() != baseField.GetFormat() {
+			add(fmt.Sprintf("format changed to %q", curField.GetFormat()))
+		}
+		baseRules := cmp.Or(baseField.GetRules(), &schema_j5pb.StringField_Rules{})
+		curRules := cmp.Or(curField.GetRules(), &schema_j5pb.StringField_Rules{})
+		if curRules.Pattern != nil && curRules.GetPattern() != baseRules.GetPattern() {
+			add(fmt.Sprintf("pattern changed to %q", curRules.GetPattern()))
+		}
+		add(raisedMin("min_length", baseRules.MinLength, curRules.MinLength))
+		add(loweredMax("max_length", baseRules.MaxLength, curRules.MaxLength))
+
+	case *schema_j5pb.Field_Bytes:
+		baseRules := cmp.Or(baseType.Bytes.GetRules(), &schema_j5pb.BytesField_Rules{})
+		curRules := cmp.Or(cur.GetBytes().GetRules(), &schema_j5pb.BytesField_Rules{})
+		add(raisedMin("min_length", baseRules.MinLength, curRules.MinLength))
+		add(loweredMax("max_length", baseRules.MaxLength, curRules.MaxLength))
+
+	case *schema_j5pb.Field_Integer:
+		baseRules := cmp.Or(baseType.Integer.GetRules(), &schema_j5pb.IntegerField_Rules{})
+		curRules := cmp.Or(cur.GetInteger().GetRules(), &schema_j5pb.IntegerField_Rules{})
+		add(raisedMin("minimum", baseRules.Minimum, curRules.Minimum))
+		add(loweredMax("maximum", baseRules.Maximum, curRules.Maximum))
+		add(flagSet("exclusive_minimum", baseRules.GetExclusiveMinimum(), curRules.GetExclusiveMinimum()))
+		add(flagSet("exclusive_maximum", baseRules.GetExclusiveMaximum(), curRules.GetExclusiveMaximum()))
+		if curRules.MultipleOf != nil && curRules.GetMultipleOf() != baseRules.GetMultipleOf() {
+			add(fmt.Sprintf("multiple_of changed to %d", curRules.GetMultipleOf()))
+		}
+
+	case *schema_j5pb.Field_Float:
+		baseRules := cmp.Or(baseType.Float.GetRules(), &schema_j5pb.FloatField_Rules{})
+		curRules := cmp.Or(cur.GetFloat().GetRules(), &schema_j5pb.FloatField_Rules{})
+		add(raisedMin("minimum", baseRules.Minimum, curRules.Minimum))
+		add(loweredMax("maximum", baseRules.Maximum, curRules.Maximum))
+		add(flagSet("exclusive_minimum", baseRules.GetExclusiveMinimum(), curRules.GetExclusiveMinimum()))
+		add(flagSet("exclusive_maximum", baseRules.GetExclusiveMaximum(), curRules.GetExclusiveMaximum()))
+		if curRules.MultipleOf != nil && curRules.GetMultipleOf() != baseRules.GetMultipleOf() {
+			add(fmt.Sprintf("multiple_of changed to %v", curRules.GetMultipleOf()))
+		}
+
+	case *schema_j5pb.Field_Array:
+		baseRules := cmp.Or(baseType.Array.GetRules(), &schema_j5pb.ArrayField_Rules{})
+		curRules := cmp.Or(cur.GetArray().GetRules(), &schema_j5pb.ArrayField_Rules{})
+		add(raisedMin("min_items", baseRules.MinItems, curRules.MinItems))
+		add(loweredMax("max_items", baseRules.MaxItems, curRules.MaxItems))
+		add(flagSet("unique_items", baseRules.GetUniqueItems(), curRules.GetUniqueItems()))
+
+	case *schema_j5pb.Field_Map:
+		baseRules := cmp.Or(baseType.Map.GetRules(), &schema_j5pb.MapField_Rules{})
+		curRules := cmp.Or(cur.GetMap().GetRules(), &schema_j5pb.MapField_Rules{})
+		add(raisedMin("min_pairs", baseRules.MinPairs, curRules.MinPairs))
+		add(loweredMax("max_pairs", baseRules.MaxPairs, curRules.MaxPairs))
+
+	case *schema_j5pb.Field_Enum:
+		baseRules, curRules := baseType.Enum.GetRules(), cur.GetEnum().GetRules()
+		if len(curRules.GetIn()) > 0 {
+			for _, allowed := range baseRules.GetIn() {
+				if !slices.Contains(curRules.GetIn(), allowed) {
+					add(fmt.Sprintf("%s no longer allowed", allowed))
+				}
+			}
+			if len(baseRules.GetIn()) == 0 {
+				add("allowed values restricted")
+			}
+		}
+		for _, denied := range curRules.GetNotIn() {
+			if !slices.Contains(baseRules.GetNotIn(), denied) {
+				add(fmt.Sprintf("%s no longer allowed", denied))
+			}
+		}
+
+	case *schema_j5pb.Field_Key:
+		baseFormat, curFormat := keyFormat(baseType.Key.GetFormat()), keyFormat(cur.GetKey().GetFormat())
+		if curFormat != "" && curFormat != baseFormat {
+			add(fmt.Sprintf("key format changed to %s", curFormat))
+		}
+	}
+
+	return out
+}
+
+func keyFormat(format *schema_j5pb.KeyFormat) string {
+	switch ft := format.GetType().(type) {
+	case nil:
+		return ""
+	case *schema_j5pb.KeyFormat_Informal_:
+		return "informal"
+	case *schema_j5pb.KeyFormat_Uuid:
+		return "uuid"
+	case *schema_j5pb.KeyFormat_Id62:
+		return "id62"
+	case *schema_j5pb.KeyFormat_Custom_:
+		return "custom:" + ft.Custom.Pattern
+	default:
+		return fmt.Sprintf("%T", ft)
+	}
+}
+
+// raisedMin reports a minimum which was added or raised.
+func raisedMin[T cmp.Ordered](name string, base, cur *T) string {
+	if cur == nil {
+		return ""
+	}
+	if base == nil {
+		return fmt.Sprintf("%s %v added", name, *cur)
+	}
+	if *cur > *base {
+		return fmt.Sprintf("%s raised from %v to %v", name, *base, *cur)
+	}
+	return ""
+}
+
+// loweredMax reports a maximum which was added or lowered.
+func loweredMax[T cmp.Ordered](name string, base, cur *T) string {
+	if cur == nil {
+		return ""
+	}
+	if base == nil {
+		return fmt.Sprintf("%s %v added", name, *cur)
+	}
+	if *cur < *base {
+		return fmt.Sprintf("%s lowered from %v to %v", name, *base, *cur)
+	}
+	return ""
+}
+
+func flagSet(name string, base, cur bool) string {
+	if cur && !base {
+		return fmt.Sprintf("%s set", name)
+	}
+	return ""
+}
@@ -0,0 +1,207 @@
+package breaking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/structure"
+)
+
+// RuleSet groups rules by the kind of client they break.
+type RuleSet string
+
+const (
+	// WireRules break clients using the protobuf binary encoding or gRPC.
+	WireRules RuleSet = "WIRE"
+
+	// JSONRules break clients using the JSON encoding, the REST API or the
+	// published topics.
+	JSONRules RuleSet = "JSON"
+)
+
+const (
+	RuleFieldRemoved            = "FIELD_REMOVED"
+	RuleFieldRenumbered         = "FIELD_RENUMBERED"
+	RuleFieldTypeChanged        = "FIELD_TYPE_CHANGED"
+	RuleFieldCardinalityChanged = "FIELD_CARDINALITY_CHANGED"
+	RuleFieldOneofChanged       = "FIELD_ONEOF_CHANGED"
+	RuleEnumValueRemoved        = "ENUM_VALUE_REMOVED"
+	RuleRPCRemoved              = "RPC_REMOVED"
+	RuleRPCTypeChanged          = "RPC_TYPE_CHANGED"
+
+	RulePackageRemoved      = "PACKAGE_REMOVED"
+	RuleSchemaRemoved       = "SCHEMA_REMOVED"
+	RuleSchemaTypeChanged   = "SCHEMA_TYPE_CHANGED"
+	RulePropertyRemoved     = "PROPERTY_REMOVED"
+	RulePropertyTypeChanged = "PROPERTY_TYPE_CHANGED"
+	RulePropertyRequired    = "PROPERTY_REQUIRED"
+	RuleValidationTightened = "VALIDATION_TIGHTENED"
+	RuleEnumOptionRemoved   = "ENUM_OPTION_REMOVED"
+	RuleMethodRemoved       = "METHOD_REMOVED"
+	RuleMethodRouteChanged  = "METHOD_ROUTE_CHANGED"
+	RuleTopicRemoved        = "TOPIC_REMOVED"
+	RuleTopicMessageRemoved = "TOPIC_MESSAGE_REMOVED"
+)
+
+var ruleSets = map[string]RuleSet{
+	RuleFieldRemoved:            WireRules,
+	RuleFieldRenumbered:         WireRules,
+	RuleFieldTypeChanged:        WireRules,
+	RuleFieldCardinalityChanged: WireRules,
+	RuleFieldOneofChanged:       WireRules,
+	RuleEnumValueRemoved:        WireRules,
+	RuleRPCRemoved:              WireRules,
+	RuleRPCTypeChanged:          WireRules,
+
+	RulePackageRemoved:      JSONRules,
+	RuleSchemaRemoved:       JSONRules,
+	RuleSchemaTypeChanged:   JSONRules,
+	RulePropertyRemoved:     JSONRules,
+	RulePropertyTypeChanged: JSONRules,
+	RulePropertyRequired:    JSONRules,
+	RuleValidationTightened: JSONRules,
+	RuleEnumOptionRemoved:   JSONRules,
+	RuleMethodRemoved:       JSONRules,
+	RuleMethodRouteChanged:  JSONRules,
+	RuleTopicRemoved:        JSONRules,
+	RuleTopicMessageRemoved: JSONRules,
+}
+
+// Change is a breaking change from the baseline.
+type Change struct {
+	Rule    string
+	Set     RuleSet
+	Package string
+
+	// Subject is the element which changed, e.g. foo.v1.Foo.bar
+	Subject string
+	Message string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s [%s]", c.Subject, c.Message, c.Rule)
+}
+
+// Config selects the rules to report.
+type Config struct {
+	Use            []RuleSet
+	Except         []string
+	IgnorePackages []string
+
+	// Services classifies services when building the API, from the bundle's
+	// structure config.
+	Services []structure.ServiceRule
+}
+
+// ConfigFromFile validates the config from j5.yaml, a nil config uses all
+// rules.
+func ConfigFromFile(cfg *config_j5pb.BreakingConfig) (*Config, error) {
+	out := &Config{
+		Use: []RuleSet{WireRules, JSONRules},
+	}
+	if cfg == nil {
+		return out, nil
+	}
+
+	if len(cfg.Use) > 0 {
+		out.Use = make([]RuleSet, 0, len(cfg.Use))
+		for _, use := range cfg.Use {
+			set := RuleSet(strings.ToUpper(use))
+			if set != WireRules && set != JSONRules {
+				return nil, fmt.Errorf("unknown breaking rule set %q, expecting %s or %s", use, WireRules, JSONRules)
+			}
+			out.Use = append(out.Use, set)
+		}
+	}
+
+	for _, except := range cfg.Except {
+		if _, ok := ruleSets[except]; !ok {
+			return nil, fmt.Errorf("unknown breaking rule %q", except)
+		}
+		out.Except = append(out.Except, except)
+	}
+
+	out.IgnorePackages = cfg.IgnorePackages
+	return out, nil
+}
+
+func (cfg *Config) uses(set RuleSet) bool {
+	for _, use := range cfg.Use {
+		if use == set {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *Config) reports(change *Change) bool {
+	for _, except := range cfg.Except {
+		if change.Rule == except {
+			return false
+		}
+	}
+	for _, ignore := range cfg.IgnorePackages {
+		if change.Package == ignore || strings.HasPrefix(change.Package, ignore+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare returns the breaking changes from the baseline image to the current
+// image, sorted by rule set, package and subject.
+func Compare(baseline, current *source_j5pb.SourceImage, cfg *Config) ([]*Change, error) {
+	cc := &changeCollector{}
+
+	if cfg.uses(WireRules) {
+		compareWire(cc, baseline, current)
+	}
+
+	if cfg.uses(JSONRules) {
+		baseAPI, err := buildAPI(baseline, cfg.Services)
+		if err != nil {
+			return nil, fmt.Errorf("baseline: %w", err)
+		}
+		currentAPI, err := buildAPI(current, cfg.Services)
+		if err != nil {
+			return nil, fmt.Errorf("current: %w", err)
+		}
+		compareAPI(cc, baseAPI, currentAPI)
+	}
+
+	changes := make([]*Change, 0, len(cc.changes))
+	for _, change := range cc.changes {
+		if cfg.reports(change) {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Set != b.Set {
+			return a.Set == WireRules
+		}
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		return a.Subject < b.Subject
+	})
+	return changes, nil
+}
+
+type changeCollector struct {
+	changes []*Change
+}
+
+func (cc *changeCollector) add(rule string, pkg string, subject string, format string, args ...interface{}) {
+	cc.changes = append(cc.changes, &Change{
+		Rule:    rule,
+		Set:     ruleSets[rule],
+		Package: pkg,
+		Subject: subject,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
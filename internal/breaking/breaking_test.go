@@ -0,0 +1,230 @@
+package breaking
+
+import (
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/pentops/flowtest/prototest"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func testImage() *source_j5pb.SourceImage {
+	requiredPath := &descriptorpb.FieldDescriptorProto{
+		Name:    proto.String("path_field"),
+		Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Number:  proto.Int32(1),
+		Options: &descriptorpb.FieldOptions{},
+	}
+	proto.SetExtension(requiredPath.Options, validate.E_Field, &validate.FieldConstraints{
+		Required: proto.Bool(true),
+	})
+
+	return &source_j5pb.SourceImage{
+		Packages: []*source_j5pb.PackageInfo{{
+			Label: "Test",
+			Name:  "test.v1",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{{
+			Syntax:     proto.String("proto3"),
+			Name:       proto.String("test/v1/service/service.proto"),
+			Package:    proto.String("test.v1.service"),
+			Dependency: []string{"test/v1/test.proto"},
+			Service: []*descriptorpb.ServiceDescriptorProto{{
+				Name: proto.String("TestService"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					prototest.BuildHTTPMethod("Test", &annotations.HttpRule{
+						Pattern: &annotations.HttpRule_Get{
+							Get: "/test/{path_field}",
+						},
+					}),
+				},
+			}},
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("TestRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{requiredPath, {
+					Name:   proto.String("query_field"),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Number: proto.Int32(2),
+				}},
+			}, {
+				Name: proto.String("TestResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("msg"),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+					Number:   proto.Int32(1),
+					TypeName: proto.String(".test.v1.Referenced"),
+				}},
+			}},
+		}, {
+			Syntax:  proto.String("proto3"),
+			Name:    proto.String("test/v1/test.proto"),
+			Package: proto.String("test.v1"),
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Referenced"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:   proto.String("field_1"),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					Number: proto.Int32(1),
+				}, {
+					Name:     proto.String("enum"),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+					Number:   proto.Int32(2),
+					TypeName: proto.String(".test.v1.TestEnum"),
+				}},
+			}},
+			EnumType: []*descriptorpb.EnumDescriptorProto{{
+				Name: proto.String("TestEnum"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{{
+					Name:   proto.String("TEST_ENUM_UNSPECIFIED"),
+					Number: proto.Int32(0),
+				}, {
+					Name:   proto.String("TEST_ENUM_FOO"),
+					Number: proto.Int32(1),
+				}},
+			}},
+		}},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config *config_j5pb.BreakingConfig
+		modify func(*source_j5pb.SourceImage)
+		want   []string
+	}{{
+		name:   "unchanged",
+		modify: func(img *source_j5pb.SourceImage) {},
+		want:   []string{},
+	}, {
+		name: "field removed",
+		modify: func(img *source_j5pb.SourceImage) {
+			req := img.File[0].MessageType[0]
+			req.Field = req.Field[:1]
+		},
+		want: []string{
+			"FIELD_REMOVED test.v1.service.TestRequest.query_field",
+			"PROPERTY_REMOVED test.v1.TestService/Test query.queryField",
+		},
+	}, {
+		name: "field removed and reserved",
+		modify: func(img *source_j5pb.SourceImage) {
+			req := img.File[0].MessageType[0]
+			req.Field = req.Field[:1]
+			req.ReservedRange = []*descriptorpb.DescriptorProto_ReservedRange{{
+				Start: proto.Int32(2),
+				End:   proto.Int32(3),
+			}}
+		},
+		want: []string{
+			"PROPERTY_REMOVED test.v1.TestService/Test query.queryField",
+		},
+	}, {
+		name: "renumbered",
+		modify: func(img *source_j5pb.SourceImage) {
+			img.File[1].MessageType[0].Field[0].Number = proto.Int32(5)
+		},
+		want: []string{
+			"FIELD_RENUMBERED test.v1.Referenced.field_1",
+		},
+	}, {
+		name: "type changed",
+		modify: func(img *source_j5pb.SourceImage) {
+			img.File[1].MessageType[0].Field[0].Type = descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum()
+		},
+		want: []string{
+			"FIELD_TYPE_CHANGED test.v1.Referenced.field_1",
+			"PROPERTY_TYPE_CHANGED test.v1.Referenced.field1",
+		},
+	}, {
+		name: "enum value removed",
+		modify: func(img *source_j5pb.SourceImage) {
+			enum := img.File[1].EnumType[0]
+			enum.Value = enum.Value[:1]
+		},
+		want: []string{
+			"ENUM_VALUE_REMOVED test.v1.TestEnum.TEST_ENUM_FOO",
+			"ENUM_OPTION_REMOVED test.v1.TestEnum.FOO",
+		},
+	}, {
+		name: "route changed",
+		modify: func(img *source_j5pb.SourceImage) {
+			img.File[0].Service[0].Method[0] = prototest.BuildHTTPMethod("Test", &annotations.HttpRule{
+				Pattern: &annotations.HttpRule_Get{
+					Get: "/tests/{path_field}",
+				},
+			})
+		},
+		want: []string{
+			"METHOD_ROUTE_CHANGED test.v1.TestService/Test",
+		},
+	}, {
+		name: "wire only",
+		config: &config_j5pb.BreakingConfig{
+			Use: []string{"wire"},
+		},
+		modify: func(img *source_j5pb.SourceImage) {
+			enum := img.File[1].EnumType[0]
+			enum.Value = enum.Value[:1]
+		},
+		want: []string{
+			"ENUM_VALUE_REMOVED test.v1.TestEnum.TEST_ENUM_FOO",
+		},
+	}, {
+		name: "except and ignore",
+		config: &config_j5pb.BreakingConfig{
+			Except:         []string{RuleEnumOptionRemoved},
+			IgnorePackages: []string{"test.v1.service"},
+		},
+		modify: func(img *source_j5pb.SourceImage) {
+			enum := img.File[1].EnumType[0]
+			enum.Value = enum.Value[:1]
+			req := img.File[0].MessageType[0]
+			req.Field = req.Field[:1]
+		},
+		want: []string{
+			"ENUM_VALUE_REMOVED test.v1.TestEnum.TEST_ENUM_FOO",
+			"PROPERTY_REMOVED test.v1.TestService/Test query.queryField",
+		},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := ConfigFromFile(tc.config)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			baseline := testImage()
+			current := testImage()
+			tc.modify(current)
+
+			changes, err := Compare(baseline, current, cfg)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+
+			got := make([]string, 0, len(changes))
+			for _, change := range changes {
+				t.Log(change.String())
+				got = append(got, change.Rule+" "+change.Subject)
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestConfigFromFile(t *testing.T) {
+	_, err := ConfigFromFile(&config_j5pb.BreakingConfig{
+		Use: []string{"REST"},
+	})
+	assert.Error(t, err)
+
+	_, err = ConfigFromFile(&config_j5pb.BreakingConfig{
+		Except: []string{"NOT_A_RULE"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,261 @@
+package breaking
+
+import (
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// wireIndex holds the descriptors of the packages built by an image, by full
+// name without the leading dot.
+type wireIndex struct {
+	messages map[string]*wireElement[*descriptorpb.DescriptorProto]
+	enums    map[string]*wireElement[*descriptorpb.EnumDescriptorProto]
+	services map[string]*wireElement[*descriptorpb.ServiceDescriptorProto]
+}
+
+type wireElement[T any] struct {
+	pkg  string
+	desc T
+}
+
+func inImagePackages(img *source_j5pb.SourceImage, pkgName string) bool {
+	for _, pkg := range img.Packages {
+		if pkgName == pkg.Name || strings.HasPrefix(pkgName, pkg.Name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func buildWireIndex(img *source_j5pb.SourceImage) *wireIndex {
+	idx := &wireIndex{
+		messages: map[string]*wireElement[*descriptorpb.DescriptorProto]{},
+		enums:    map[string]*wireElement[*descriptorpb.EnumDescriptorProto]{},
+		services: map[string]*wireElement[*descriptorpb.ServiceDescriptorProto]{},
+	}
+
+	for _, file := range img.File {
+		pkg := file.GetPackage()
+		if !inImagePackages(img, pkg) {
+			continue
+		}
+		idx.addMessages(pkg, pkg, file.MessageType)
+		idx.addEnums(pkg, pkg, file.EnumType)
+		for _, service := range file.Service {
+			idx.services[pkg+"."+service.GetName()] = &wireElement[*descriptorpb.ServiceDescriptorProto]{pkg: pkg, desc: service}
+		}
+	}
+	return idx
+}
+
+func (idx *wireIndex) addMessages(pkg string, prefix string, messages []*descriptorpb.DescriptorProto) {
+	for _, msg := range messages {
+		fullName := prefix + "." + msg.GetName()
+		idx.messages[fullName] = &wireElement[*descriptorpb.DescriptorProto]{pkg: pkg, desc: msg}
+		idx.addMessages(pkg, fullName, msg.NestedType)
+		idx.addEnums(pkg, fullName, msg.EnumType)
+	}
+}
+
+func (idx *wireIndex) addEnums(pkg string, prefix string, enums []*descriptorpb.EnumDescriptorProto) {
+	for _, enum := range enums {
+		idx.enums[prefix+"."+enum.GetName()] = &wireElement[*descriptorpb.EnumDescriptorProto]{pkg: pkg, desc: enum}
+	}
+}
+
+// compareWire checks the protobuf encoding and gRPC services. Removing a whole
+// message or enum does not change the encoding of the others, it is reported
+// by the JSON rules when the schema was part of the API.
+func compareWire(cc *changeCollector, baseline, current *source_j5pb.SourceImage) {
+	baseIdx := buildWireIndex(baseline)
+	currentIdx := buildWireIndex(current)
+
+	for name, base := range baseIdx.messages {
+		cur, ok := currentIdx.messages[name]
+		if !ok {
+			continue
+		}
+		compareMessage(cc, base.pkg, name, base.desc, cur.desc)
+	}
+
+	for name, base := range baseIdx.enums {
+		cur, ok := currentIdx.enums[name]
+		if !ok {
+			continue
+		}
+		compareEnum(cc, base.pkg, name, base.desc, cur.desc)
+	}
+
+	for name, base := range baseIdx.services {
+		cur, ok := currentIdx.services[name]
+		if !ok {
+			cc.add(RuleRPCRemoved, base.pkg, name, "service removed")
+			continue
+		}
+		compareService(cc, base.pkg, name, base.desc, cur.desc)
+	}
+}
+
+func compareMessage(cc *changeCollector, pkg string, name string, base, cur *descriptorpb.DescriptorProto) {
+	byNumber := map[int32]*descriptorpb.FieldDescriptorProto{}
+	byName := map[string]*descriptorpb.FieldDescriptorProto{}
+	for _, field := range cur.Field {
+		byNumber[field.GetNumber()] = field
+		byName[field.GetName()] = field
+	}
+
+	for _, baseField := range base.Field {
+		subject := name + "." + baseField.GetName()
+		curField, ok := byNumber[baseField.GetNumber()]
+		if !ok {
+			if renamed, ok := byName[baseField.GetName()]; ok {
+				cc.add(RuleFieldRenumbered, pkg, subject, "field number changed from %d to %d", baseField.GetNumber(), renamed.GetNumber())
+			} else if !numberReserved(cur.ReservedRange, baseField.GetNumber()) {
+				cc.add(RuleFieldRemoved, pkg, subject, "field %d removed without reserving the number", baseField.GetNumber())
+			}
+			continue
+		}
+
+		if isRepeated(baseField) != isRepeated(curField) {
+			cc.add(RuleFieldCardinalityChanged, pkg, subject, "field %d changed from %s to %s", baseField.GetNumber(), cardinality(baseField), cardinality(curField))
+			continue
+		}
+
+		if !wireCompatible(baseField, curField) {
+			cc.add(RuleFieldTypeChanged, pkg, subject, "field %d type changed from %s to %s", baseField.GetNumber(), fieldTypeName(baseField), fieldTypeName(curField))
+			continue
+		}
+
+		baseOneof := oneofName(base, baseField)
+		curOneof := oneofName(cur, curField)
+		if baseOneof != curOneof {
+			cc.add(RuleFieldOneofChanged, pkg, subject, "field %d moved from oneof %q to %q", baseField.GetNumber(), baseOneof, curOneof)
+		}
+	}
+}
+
+func compareEnum(cc *changeCollector, pkg string, name string, base, cur *descriptorpb.EnumDescriptorProto) {
+	numbers := map[int32]struct{}{}
+	for _, value := range cur.Value {
+		numbers[value.GetNumber()] = struct{}{}
+	}
+
+	for _, value := range base.Value {
+		if _, ok := numbers[value.GetNumber()]; ok {
+			continue
+		}
+		if enumNumberReserved(cur.ReservedRange, value.GetNumber()) {
+			continue
+		}
+		cc.add(RuleEnumValueRemoved, pkg, name+"."+value.GetName(), "enum value %d removed without reserving the number", value.GetNumber())
+	}
+}
+
+func compareService(cc *changeCollector, pkg string, name string, base, cur *descriptorpb.ServiceDescriptorProto) {
+	methods := map[string]*descriptorpb.MethodDescriptorProto{}
+	for _, method := range cur.Method {
+		methods[method.GetName()] = method
+	}
+
+	for _, baseMethod := range base.Method {
+		subject := name + "/" + baseMethod.GetName()
+		curMethod, ok := methods[baseMethod.GetName()]
+		if !ok {
+			cc.add(RuleRPCRemoved, pkg, subject, "method removed")
+			continue
+		}
+		if baseMethod.GetInputType() != curMethod.GetInputType() {
+			cc.add(RuleRPCTypeChanged, pkg, subject, "request type changed from %s to %s", trimDot(baseMethod.GetInputType()), trimDot(curMethod.GetInputType()))
+		}
+		if baseMethod.GetOutputType() != curMethod.GetOutputType() {
+			cc.add(RuleRPCTypeChanged, pkg, subject, "response type changed from %s to %s", trimDot(baseMethod.GetOutputType()), trimDot(curMethod.GetOutputType()))
+		}
+		if baseMethod.GetClientStreaming() != curMethod.GetClientStreaming() || baseMethod.GetServerStreaming() != curMethod.GetServerStreaming() {
+			cc.add(RuleRPCTypeChanged, pkg, subject, "streaming changed")
+		}
+	}
+}
+
+func numberReserved(ranges []*descriptorpb.DescriptorProto_ReservedRange, number int32) bool {
+	for _, rr := range ranges {
+		// End is exclusive for messages.
+		if number >= rr.GetStart() && number < rr.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func enumNumberReserved(ranges []*descriptorpb.EnumDescriptorProto_EnumReservedRange, number int32) bool {
+	for _, rr := range ranges {
+		// End is inclusive for enums.
+		if number >= rr.GetStart() && number <= rr.GetEnd() {
+			return true
+		}
+	}
+	return false
+}
+
+func isRepeated(field *descriptorpb.FieldDescriptorProto) bool {
+	return field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+}
+
+func cardinality(field *descriptorpb.FieldDescriptorProto) string {
+	if isRepeated(field) {
+		return "repeated"
+	}
+	return "singular"
+}
+
+func oneofName(msg *descriptorpb.DescriptorProto, field *descriptorpb.FieldDescriptorProto) string {
+	if field.OneofIndex == nil || field.GetProto3Optional() {
+		return ""
+	}
+	idx := int(field.GetOneofIndex())
+	if idx >= len(msg.OneofDecl) {
+		return ""
+	}
+	return msg.OneofDecl[idx].GetName()
+}
+
+// wireGroups are scalar types which share an encoding, changing between them
+// does not break the wire format.
+var wireGroups = map[descriptorpb.FieldDescriptorProto_Type]string{
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:    "varint",
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:    "varint",
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   "varint",
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   "varint",
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL:     "varint",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32:   "zigzag",
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64:   "zigzag",
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  "fixed32",
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: "fixed32",
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  "fixed64",
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: "fixed64",
+	descriptorpb.FieldDescriptorProto_TYPE_STRING:   "bytes",
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES:    "bytes",
+}
+
+func wireCompatible(base, cur *descriptorpb.FieldDescriptorProto) bool {
+	if base.GetType() == cur.GetType() {
+		return base.GetTypeName() == cur.GetTypeName()
+	}
+	baseGroup, ok := wireGroups[base.GetType()]
+	if !ok {
+		return false
+	}
+	return baseGroup == wireGroups[cur.GetType()]
+}
+
+func fieldTypeName(field *descriptorpb.FieldDescriptorProto) string {
+	if field.GetTypeName() != "" {
+		return trimDot(field.GetTypeName())
+	}
+	return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+}
+
+func trimDot(name string) string {
+	return strings.TrimPrefix(name, ".")
+}
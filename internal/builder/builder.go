@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,6 +33,10 @@ type PluginContext struct {
 	Variables map[string]string
 	ErrOut    io.Writer
 	Dest      Dest
+
+	// Services classify the services of the input when building the client
+	// API for J5_CLIENT plugins, see structure.RulesFromConfig.
+	Services []structure.ServiceRule
 }
 
 type PipeRunner interface {
@@ -39,6 +44,13 @@ type PipeRunner interface {
 }
 type Builder struct {
 	runner PipeRunner
+	cache  *Cache
+}
+
+// SetCache enables caching plugin responses, plugins with an unchanged
+// request and spec are not run again.
+func (b *Builder) SetCache(cache *Cache) {
+	b.cache = cache
 }
 
 type Dest interface {
@@ -50,7 +62,15 @@ func (b *Builder) RunGenerateBuild(ctx context.Context, pc PluginContext, input
 }
 
 func (b *Builder) RunPublishBuild(ctx context.Context, pc PluginContext, input *source_j5pb.SourceImage, build *config_j5pb.PublishConfig) error {
-	err := b.runPlugins(ctx, pc, input, build.Plugins)
+	pluginContext := pc
+	var packed *memDest
+	if npm, ok := build.OutputFormat.GetType().(*config_j5pb.OutputType_Npm_); ok && npm.Npm.Pack {
+		// collect the output to pack rather than writing it
+		packed = &memDest{files: map[string][]byte{}}
+		pluginContext.Dest = packed
+	}
+
+	err := b.runPlugins(ctx, pluginContext, input, build.Plugins)
 	if err != nil {
 		return err
 	}
@@ -70,6 +90,25 @@ func (b *Builder) RunPublishBuild(ctx context.Context, pc PluginContext, input *
 			}
 			return nil
 
+		case *config_j5pb.OutputType_Npm_:
+
+			packageFile, err := buildNpmPackageFile(pkg.Npm, input.Version)
+			if err != nil {
+				return err
+			}
+
+			if packed == nil {
+				return pc.Dest.PutFile(ctx, "package.json", bytes.NewReader(packageFile))
+			}
+
+			packed.files["package.json"] = packageFile
+			tarball, err := buildNpmTarball(packed.files)
+			if err != nil {
+				return err
+			}
+			filename := npmTarballName(pkg.Npm.Name, npmVersion(input.Version))
+			return pc.Dest.PutFile(ctx, filename, bytes.NewReader(tarball))
+
 		}
 		// Fallthrough default, is OK to not specify
 	}
@@ -131,7 +170,7 @@ func (b *Builder) runPlugins(ctx context.Context, pc PluginContext, input *sourc
 
 		case config_j5pb.Plugin_J5_CLIENT:
 
-			sourceAPI, err := structure.APIFromImage(input)
+			sourceAPI, err := structure.APIFromImage(input, pc.Services...)
 			if err != nil {
 				return err
 			}
@@ -181,29 +220,22 @@ func (b *Builder) runProtocPlugin(ctx context.Context, pc PluginContext, plugin
 	for k, v := range plugin.Opts {
 		parameters = append(parameters, fmt.Sprintf("%s=%s", k, v))
 	}
+	sort.Strings(parameters)
 	parameter := strings.Join(parameters, ",")
 	sourceProto.Parameter = &parameter
 
-	reqBytes, err := proto.Marshal(sourceProto)
+	reqBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(sourceProto)
 	if err != nil {
 		return err
 	}
 
-	outBuffer := &bytes.Buffer{}
-	inBuffer := bytes.NewReader(reqBytes)
-
-	if err := b.runner.Run(ctx, RunContext{
-		Vars:    pc.Variables,
-		StdIn:   inBuffer,
-		StdOut:  outBuffer,
-		StdErr:  pc.ErrOut,
-		Command: plugin,
-	}); err != nil {
+	outBytes, err := b.runPlugin(ctx, pc, plugin, reqBytes)
+	if err != nil {
 		return err
 	}
 
 	resp := pluginpb.CodeGeneratorResponse{}
-	if err := proto.Unmarshal(outBuffer.Bytes(), &resp); err != nil {
+	if err := proto.Unmarshal(outBytes, &resp); err != nil {
 		return err
 	}
 
@@ -228,6 +260,36 @@ func (b *Builder) runProtocPlugin(ctx context.Context, pc PluginContext, plugin
 	return nil
 }
 
+// runPlugin runs the plugin with the request on stdin, returning stdout. The
+// response is cached when the plugin runs successfully.
+func (b *Builder) runPlugin(ctx context.Context, pc PluginContext, plugin *config_j5pb.BuildPlugin, reqBytes []byte) ([]byte, error) {
+	key, useCache := b.cacheKey(ctx, pc, plugin, reqBytes)
+	if useCache {
+		if cached, ok := b.cache.get(ctx, key); ok {
+			log.Info(ctx, "Using cached plugin response")
+			return cached, nil
+		}
+	}
+
+	outBuffer := &bytes.Buffer{}
+	inBuffer := bytes.NewReader(reqBytes)
+
+	if err := b.runner.Run(ctx, RunContext{
+		Vars:    pc.Variables,
+		StdIn:   inBuffer,
+		StdOut:  outBuffer,
+		StdErr:  pc.ErrOut,
+		Command: plugin,
+	}); err != nil {
+		return nil, err
+	}
+
+	if useCache {
+		b.cache.put(ctx, key, outBuffer.Bytes())
+	}
+	return outBuffer.Bytes(), nil
+}
+
 func (b *Builder) runJ5ClientPlugin(ctx context.Context, pc PluginContext, plugin *config_j5pb.BuildPlugin, descriptorAPI *client_j5pb.API) error {
 
 	start := time.Now()
@@ -240,26 +302,18 @@ func (b *Builder) runJ5ClientPlugin(ctx context.Context, pc PluginContext, plugi
 		buildRequest.Options[key] = opt
 	}
 
-	reqBytes, err := proto.Marshal(buildRequest)
+	reqBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(buildRequest)
 	if err != nil {
 		return err
 	}
 
-	outBuffer := &bytes.Buffer{}
-	inBuffer := bytes.NewReader(reqBytes)
-
-	if err := b.runner.Run(ctx, RunContext{
-		Vars:    pc.Variables,
-		StdIn:   inBuffer,
-		StdOut:  outBuffer,
-		StdErr:  pc.ErrOut,
-		Command: plugin,
-	}); err != nil {
+	outBytes, err := b.runPlugin(ctx, pc, plugin, reqBytes)
+	if err != nil {
 		return err
 	}
 
 	resp := &plugin_j5pb.CodeGenerationResponse{}
-	if err := proto.Unmarshal(outBuffer.Bytes(), resp); err != nil {
+	if err := proto.Unmarshal(outBytes, resp); err != nil {
 		return err
 	}
 
@@ -0,0 +1,150 @@
+package builder
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/pentops/j5/gen/j5/plugin/v1/plugin_j5pb"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/structure"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type clientRunner struct{}
+
+// Run writes the package names of the j5 client request.
+func (clientRunner) Run(ctx context.Context, rc RunContext) error {
+	reqBytes, err := io.ReadAll(rc.StdIn)
+	if err != nil {
+		return err
+	}
+	req := &plugin_j5pb.CodeGenerationRequest{}
+	if err := proto.Unmarshal(reqBytes, req); err != nil {
+		return err
+	}
+
+	lines := []string{}
+	for _, pkg := range req.Packages {
+		lines = append(lines, pkg.Name)
+	}
+
+	resp := &plugin_j5pb.CodeGenerationResponse{
+		Files: []*plugin_j5pb.File{{
+			Name:    "out.txt",
+			Content: strings.Join(lines, "\n"),
+		}},
+	}
+	respBytes, err := proto.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = rc.StdOut.Write(respBytes)
+	return err
+}
+
+// testThirdPartyImage has services which do not follow the naming
+// conventions, as in the structure tests. FooAPI is a topic, VendorAdmin is
+// marked with the test.v1.internal option.
+func testThirdPartyImage() *source_j5pb.SourceImage {
+	internalOpts := &descriptorpb.ServiceOptions{}
+	internalOpts.ProtoReflect().SetUnknown(protowire.AppendVarint(protowire.AppendTag(nil, 50001, protowire.VarintType), 1))
+
+	return &source_j5pb.SourceImage{
+		Packages: []*source_j5pb.PackageInfo{{
+			Name: "test.v1",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto),
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto), {
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("test/v1/options.proto"),
+				Package:    proto.String("test.v1"),
+				Dependency: []string{"google/protobuf/descriptor.proto"},
+				Extension: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("internal"),
+					Number:   proto.Int32(50001),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum(),
+					Extendee: proto.String(".google.protobuf.ServiceOptions"),
+				}},
+			}, {
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("test/v1/topic/foo.proto"),
+				Package:    proto.String("test.v1.topic"),
+				Dependency: []string{"google/protobuf/empty.proto"},
+				MessageType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("FooMessage"),
+				}},
+				Service: []*descriptorpb.ServiceDescriptorProto{{
+					Name: proto.String("FooAPI"),
+					Method: []*descriptorpb.MethodDescriptorProto{{
+						Name:       proto.String("Foo"),
+						InputType:  proto.String(".test.v1.topic.FooMessage"),
+						OutputType: proto.String(".google.protobuf.Empty"),
+					}},
+				}, {
+					Name:    proto.String("VendorAdmin"),
+					Options: internalOpts,
+					Method: []*descriptorpb.MethodDescriptorProto{{
+						Name:       proto.String("Reset"),
+						InputType:  proto.String(".google.protobuf.Empty"),
+						OutputType: proto.String(".google.protobuf.Empty"),
+					}},
+				}},
+			}},
+	}
+}
+
+func TestJ5ClientServiceRules(t *testing.T) {
+	ctx := context.Background()
+	bb := NewBuilder(clientRunner{})
+
+	generate := func(rules []structure.ServiceRule) (mapDest, error) {
+		dest := mapDest{}
+		err := bb.RunGenerateBuild(ctx, PluginContext{
+			Dest:     dest,
+			ErrOut:   io.Discard,
+			Services: rules,
+		}, testThirdPartyImage(), &config_j5pb.GenerateConfig{
+			Plugins: []*config_j5pb.BuildPlugin{{
+				Name: "test",
+				Type: config_j5pb.Plugin_J5_CLIENT,
+				Local: &config_j5pb.CommandSpec{
+					Cmd: "test",
+				},
+			}},
+		})
+		return dest, err
+	}
+
+	_, err := generate(nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported service name") {
+		t.Fatalf("expected unsupported service name without rules, got %v", err)
+	}
+
+	rules, err := structure.RulesFromConfig(&config_j5pb.StructureConfig{
+		Services: []*config_j5pb.ServiceRule{{
+			Option: "test.v1.internal",
+			Kind:   config_j5pb.ServiceKind_IGNORE,
+		}, {
+			Suffix: "API",
+			Kind:   config_j5pb.ServiceKind_TOPIC,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	out, err := generate(rules)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "test.v1", out["out.txt"])
+}
@@ -0,0 +1,134 @@
+package builder
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/log.go/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// Cache stores plugin responses on disk, keyed by the request and everything
+// about the plugin which could change the response.
+type Cache struct {
+	dir string
+}
+
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{
+		dir: dir,
+	}, nil
+}
+
+// NewEnvCache uses the generate directory within $J5_CACHE_DIR, the same
+// root as the source image cache.
+func NewEnvCache() (*Cache, error) {
+	cacheDir := os.Getenv("J5_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = filepath.Join(os.Getenv("HOME"), ".cache", "j5")
+	}
+	return NewCache(filepath.Join(cacheDir, "generate"))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+func (c *Cache) get(ctx context.Context, key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(ctx, err).Error("failed to read generate cache")
+		}
+		return nil, false
+	}
+	return data, true
+}
+
+func (c *Cache) put(ctx context.Context, key string, data []byte) {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.WithError(ctx, err).Error("failed to write generate cache")
+		return
+	}
+	// write then rename so concurrent plugins never read a partial response
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		log.WithError(ctx, err).Error("failed to write generate cache")
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		log.WithError(ctx, err).Error("failed to write generate cache")
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		log.WithError(ctx, err).Error("failed to write generate cache")
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		log.WithError(ctx, err).Error("failed to write generate cache")
+	}
+}
+
+// ErrNotCacheable is returned by PluginKey for plugins which should always
+// run.
+var ErrNotCacheable = errors.New("plugin is not cacheable")
+
+// PluginKeyer is implemented by runners which can identify the exact plugin
+// which will run, e.g. the docker image digest or a hash of the local binary.
+// Plugins are only cached when the runner implements it.
+type PluginKeyer interface {
+	PluginKey(ctx context.Context, plugin *config_j5pb.BuildPlugin, vars map[string]string) (string, error)
+}
+
+func (b *Builder) cacheKey(ctx context.Context, pc PluginContext, plugin *config_j5pb.BuildPlugin, reqBytes []byte) (string, bool) {
+	if b.cache == nil {
+		return "", false
+	}
+	keyer, ok := b.runner.(PluginKeyer)
+	if !ok {
+		return "", false
+	}
+
+	pluginKey, err := keyer.PluginKey(ctx, plugin, pc.Variables)
+	if errors.Is(err, ErrNotCacheable) {
+		return "", false
+	} else if err != nil {
+		log.WithError(ctx, err).Warn("not caching plugin")
+		return "", false
+	}
+
+	specBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(plugin)
+	if err != nil {
+		log.WithError(ctx, err).Warn("not caching plugin")
+		return "", false
+	}
+
+	vars := make([]string, 0, len(pc.Variables))
+	for key, val := range pc.Variables {
+		vars = append(vars, key+"="+val)
+	}
+	sort.Strings(vars)
+
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(pluginKey), specBytes, reqBytes} {
+		fmt.Fprintf(hash, "%d\x00", len(part))
+		hash.Write(part)
+	}
+	for _, v := range vars {
+		fmt.Fprintf(hash, "%s\x00", v)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
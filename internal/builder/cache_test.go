@@ -0,0 +1,164 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+type countingRunner struct {
+	lock sync.Mutex
+	runs int
+	key  string
+}
+
+func (cr *countingRunner) Run(ctx context.Context, rc RunContext) error {
+	cr.lock.Lock()
+	cr.runs++
+	cr.lock.Unlock()
+
+	reqBytes, err := io.ReadAll(rc.StdIn)
+	if err != nil {
+		return err
+	}
+	req := &pluginpb.CodeGeneratorRequest{}
+	if err := proto.Unmarshal(reqBytes, req); err != nil {
+		return err
+	}
+
+	resp := &pluginpb.CodeGeneratorResponse{
+		File: []*pluginpb.CodeGeneratorResponse_File{{
+			Name:    proto.String("out.txt"),
+			Content: proto.String(req.GetParameter()),
+		}},
+	}
+	respBytes, err := proto.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = rc.StdOut.Write(respBytes)
+	return err
+}
+
+func (cr *countingRunner) PluginKey(ctx context.Context, plugin *config_j5pb.BuildPlugin, vars map[string]string) (string, error) {
+	return cr.key, nil
+}
+
+type mapDest map[string]string
+
+func (md mapDest) PutFile(ctx context.Context, path string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	md[path] = string(data)
+	return nil
+}
+
+func TestGenerateCache(t *testing.T) {
+	ctx := context.Background()
+
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	runner := &countingRunner{key: "v1"}
+	bb := NewBuilder(runner)
+	bb.SetCache(cache)
+
+	img := &source_j5pb.SourceImage{
+		SourceFilenames: []string{"test/v1/test.proto"},
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("test/v1/test.proto"),
+			Package: proto.String("test.v1"),
+			Syntax:  proto.String("proto3"),
+		}},
+	}
+
+	generate := func(opts map[string]string) mapDest {
+		t.Helper()
+		dest := mapDest{}
+		err := bb.RunGenerateBuild(ctx, PluginContext{
+			Dest:   dest,
+			ErrOut: io.Discard,
+		}, img, &config_j5pb.GenerateConfig{
+			Plugins: []*config_j5pb.BuildPlugin{{
+				Name: "test",
+				Type: config_j5pb.Plugin_PLUGIN_PROTO,
+				Local: &config_j5pb.CommandSpec{
+					Cmd: "test",
+				},
+				Opts: opts,
+			}},
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return dest
+	}
+
+	opts := map[string]string{"a": "1", "b": "2", "c": "3"}
+
+	out := generate(opts)
+	assert.Equal(t, 1, runner.runs)
+	assert.Equal(t, "a=1,b=2,c=3", out["out.txt"])
+
+	out = generate(opts)
+	assert.Equal(t, 1, runner.runs, "unchanged inputs should use the cache")
+	assert.Equal(t, "a=1,b=2,c=3", out["out.txt"], "cached outputs are still written")
+
+	out = generate(map[string]string{"a": "2"})
+	assert.Equal(t, 2, runner.runs, "changed opts should run the plugin")
+	assert.Equal(t, "a=2", out["out.txt"])
+
+	runner.key = "v2"
+	generate(opts)
+	assert.Equal(t, 3, runner.runs, "a changed plugin should run again")
+}
+
+func TestLocalPluginKey(t *testing.T) {
+	ctx := context.Background()
+	rr := &Runner{}
+
+	plugin := &config_j5pb.BuildPlugin{
+		Name: "test",
+		Local: &config_j5pb.CommandSpec{
+			Cmd: "sh",
+			Env: []string{"TEST_PLUGIN_ENV", "OUT=${dir}"},
+		},
+	}
+
+	_, err := rr.PluginKey(ctx, plugin, nil)
+	if !errors.Is(err, ErrNotCacheable) {
+		t.Fatalf("expected ErrNotCacheable without cache set, got %v", err)
+	}
+
+	plugin.Local.Cache = true
+	key := func(vars map[string]string) string {
+		t.Helper()
+		key, err := rr.PluginKey(ctx, plugin, vars)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return key
+	}
+
+	t.Setenv("TEST_PLUGIN_ENV", "a")
+	base := key(map[string]string{"dir": "a"})
+	assert.Equal(t, base, key(map[string]string{"dir": "a"}))
+
+	assert.NotEqual(t, base, key(map[string]string{"dir": "b"}), "expanded vars are in the key")
+
+	t.Setenv("TEST_PLUGIN_ENV", "b")
+	assert.NotEqual(t, base, key(map[string]string{"dir": "a"}), "passed through env is in the key")
+}
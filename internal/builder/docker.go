@@ -36,10 +36,15 @@ var DefaultRegistryAuths = []*config_j5pb.DockerRegistryAuth{{
 
 func (dw *Runner) runDocker(ctx context.Context, rc RunContext) error {
 
-	ctx = log.WithField(ctx, "image", rc.Command.Docker.Image)
+	img, err := dw.pinnedImage(rc.Command.Docker.Image)
+	if err != nil {
+		return err
+	}
+
+	ctx = log.WithField(ctx, "image", img)
 	t0 := time.Now()
 	log.WithField(ctx, "t0", time.Since(t0).String()).Debug("Pull If Needed")
-	if err := dw.pullIfNeeded(ctx, rc.Command.Docker.Image); err != nil {
+	if err := dw.pullIfNeeded(ctx, img); err != nil {
 		log.WithError(ctx, err).Error("failed to pull image")
 		return err
 	}
@@ -54,7 +59,7 @@ func (dw *Runner) runDocker(ctx context.Context, rc RunContext) error {
 		Tty: false,
 
 		Env:        rc.Command.Docker.Env,
-		Image:      rc.Command.Docker.Image,
+		Image:      img,
 		Entrypoint: rc.Command.Docker.Entrypoint,
 		Cmd:        rc.Command.Docker.Cmd,
 	}, nil, nil, nil, "")
@@ -164,96 +169,8 @@ func (dw *Runner) pullIfNeeded(ctx context.Context, img string) error {
 		return nil
 	}
 
-	type basicAuth struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
-
-	pullOptions := image.PullOptions{}
-
-	var registryAuth *config_j5pb.DockerRegistryAuth
-	for _, auth := range dw.auth {
-		// If auth's registry pattern with * wildcards matches the spec's image, use it.
-		if glob.Glob(auth.Registry, img) {
-			registryAuth = auth
-			log.WithField(ctx, "registry", auth.Registry).Debug("using auth")
-			break
-		}
-	}
-	if registryAuth == nil {
-		log.WithField(ctx, "image", img).Debug("no registry auth matched")
-	}
-
-	if registryAuth != nil {
-		pullOptions.PrivilegeFunc = func(ctx context.Context) (string, error) {
-			var authConfig *basicAuth
-
-			switch authType := registryAuth.Auth.(type) {
-			case *config_j5pb.DockerRegistryAuth_Basic_:
-				val := os.Getenv(authType.Basic.PasswordEnvVar)
-				if val == "" {
-					return "", fmt.Errorf("basic auth password (%s) not set", authType.Basic.PasswordEnvVar)
-				}
-
-				authConfig = &basicAuth{
-					Username: authType.Basic.Username,
-					Password: val,
-				}
-
-			case *config_j5pb.DockerRegistryAuth_Github_:
-				envVar := authType.Github.TokenEnvVar
-				if envVar == "" {
-					envVar = "GITHUB_TOKEN"
-				}
-				val := os.Getenv(envVar)
-				if val == "" {
-					return "", fmt.Errorf("github token (%s) not set", envVar)
-				}
-
-				authConfig = &basicAuth{
-					Username: "GITHUB",
-					Password: val,
-				}
-
-			case *config_j5pb.DockerRegistryAuth_AwsEcs:
-
-				// TODO: This is a little too magic.
-				awsConfig, err := config.LoadDefaultConfig(ctx)
-				if err != nil {
-					return "", fmt.Errorf("failed to load configuration: %w", err)
-				}
-
-				ecrClient := ecr.NewFromConfig(awsConfig)
-				resp, err := ecrClient.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
-				if err != nil {
-					return "", fmt.Errorf("failed to get authorization token: %w", err)
-				}
-
-				if len(resp.AuthorizationData) == 0 {
-					return "", fmt.Errorf("no authorization data returned")
-				}
-
-				authData, err := base64.StdEncoding.DecodeString(*resp.AuthorizationData[0].AuthorizationToken)
-				if err != nil {
-					return "", fmt.Errorf("failed to decode authorization token: %w", err)
-				}
-
-				parts := strings.SplitN(string(authData), ":", 2)
-				if len(parts) != 2 {
-					return "", fmt.Errorf("invalid authorization token")
-				}
-
-				authConfig = &basicAuth{
-					Username: parts[0],
-					Password: parts[1],
-				}
-
-			default:
-				return "", fmt.Errorf("unknown auth type: %T", authType)
-			}
-			cred, _ := json.Marshal(authConfig)
-			return base64.StdEncoding.EncodeToString(cred), nil
-		}
+	pullOptions := image.PullOptions{
+		PrivilegeFunc: dw.privilegeFunc(ctx, img),
 	}
 
 	reader, err := dw.client.ImagePull(ctx, img, pullOptions)
@@ -288,3 +205,96 @@ func (dw *Runner) pullIfNeeded(ctx context.Context, img string) error {
 	}
 	return nil
 }
+
+// privilegeFunc returns the auth for the first registry pattern matching the
+// image, nil when none match.
+func (dw *Runner) privilegeFunc(ctx context.Context, img string) func(context.Context) (string, error) {
+	type basicAuth struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+
+	var registryAuth *config_j5pb.DockerRegistryAuth
+	for _, auth := range dw.auth {
+		// If auth's registry pattern with * wildcards matches the spec's image, use it.
+		if glob.Glob(auth.Registry, img) {
+			registryAuth = auth
+			log.WithField(ctx, "registry", auth.Registry).Debug("using auth")
+			break
+		}
+	}
+	if registryAuth == nil {
+		log.WithField(ctx, "image", img).Debug("no registry auth matched")
+		return nil
+	}
+
+	return func(ctx context.Context) (string, error) {
+		var authConfig *basicAuth
+
+		switch authType := registryAuth.Auth.(type) {
+		case *config_j5pb.DockerRegistryAuth_Basic_:
+			val := os.Getenv(authType.Basic.PasswordEnvVar)
+			if val == "" {
+				return "", fmt.Errorf("basic auth password (%s) not set", authType.Basic.PasswordEnvVar)
+			}
+
+			authConfig = &basicAuth{
+				Username: authType.Basic.Username,
+				Password: val,
+			}
+
+		case *config_j5pb.DockerRegistryAuth_Github_:
+			envVar := authType.Github.TokenEnvVar
+			if envVar == "" {
+				envVar = "GITHUB_TOKEN"
+			}
+			val := os.Getenv(envVar)
+			if val == "" {
+				return "", fmt.Errorf("github token (%s) not set", envVar)
+			}
+
+			authConfig = &basicAuth{
+				Username: "GITHUB",
+				Password: val,
+			}
+
+		case *config_j5pb.DockerRegistryAuth_AwsEcs:
+
+			// TODO: This is a little too magic.
+			awsConfig, err := config.LoadDefaultConfig(ctx)
+			if err != nil {
+				return "", fmt.Errorf("failed to load configuration: %w", err)
+			}
+
+			ecrClient := ecr.NewFromConfig(awsConfig)
+			resp, err := ecrClient.GetAuthorizationToken(ctx, &ecr.GetAuthorizationTokenInput{})
+			if err != nil {
+				return "", fmt.Errorf("failed to get authorization token: %w", err)
+			}
+
+			if len(resp.AuthorizationData) == 0 {
+				return "", fmt.Errorf("no authorization data returned")
+			}
+
+			authData, err := base64.StdEncoding.DecodeString(*resp.AuthorizationData[0].AuthorizationToken)
+			if err != nil {
+				return "", fmt.Errorf("failed to decode authorization token: %w", err)
+			}
+
+			parts := strings.SplitN(string(authData), ":", 2)
+			if len(parts) != 2 {
+				return "", fmt.Errorf("invalid authorization token")
+			}
+
+			authConfig = &basicAuth{
+				Username: parts[0],
+				Password: parts[1],
+			}
+
+		default:
+			return "", fmt.Errorf("unknown auth type: %T", authType)
+		}
+		cred, _ := json.Marshal(authConfig)
+		return base64.StdEncoding.EncodeToString(cred), nil
+	}
+}
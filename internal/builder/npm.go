@@ -0,0 +1,207 @@
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"golang.org/x/mod/semver"
+)
+
+type npmPackageFile struct {
+	Name         string               `json:"name"`
+	Version      string               `json:"version"`
+	Main         string               `json:"main"`
+	Types        string               `json:"types"`
+	Exports      map[string]npmExport `json:"exports"`
+	Dependencies map[string]string    `json:"dependencies,omitempty"`
+}
+
+type npmExport struct {
+	// types must come before default for TypeScript to resolve it.
+	Types   string `json:"types,omitempty"`
+	Default string `json:"default,omitempty"`
+}
+
+func buildNpmPackageFile(pkg *config_j5pb.OutputType_Npm, version *string) ([]byte, error) {
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("npm output requires a name")
+	}
+
+	main, err := npmRelPath(pkg.Main, "index.js")
+	if err != nil {
+		return nil, fmt.Errorf("npm main: %w", err)
+	}
+	types, err := npmRelPath(pkg.Types, "index.d.ts")
+	if err != nil {
+		return nil, fmt.Errorf("npm types: %w", err)
+	}
+
+	file := npmPackageFile{
+		Name:    pkg.Name,
+		Version: npmVersion(version),
+		Main:    main,
+		Types:   types,
+		Exports: map[string]npmExport{
+			".": {
+				Types:   types,
+				Default: main,
+			},
+		},
+	}
+
+	for _, export := range pkg.Exports {
+		exportPath, err := npmRelPath(export.Path, "")
+		if err != nil {
+			return nil, fmt.Errorf("npm export path: %w", err)
+		}
+		if exportPath == "" || exportPath == "." {
+			return nil, fmt.Errorf("npm export requires a path other than '.'")
+		}
+		if _, ok := file.Exports[exportPath]; ok {
+			return nil, fmt.Errorf("duplicate npm export %q", exportPath)
+		}
+		exportTypes, err := npmRelPath(export.Types, "")
+		if err != nil {
+			return nil, fmt.Errorf("npm export %q types: %w", exportPath, err)
+		}
+		exportMain, err := npmRelPath(export.Main, "")
+		if err != nil {
+			return nil, fmt.Errorf("npm export %q main: %w", exportPath, err)
+		}
+		file.Exports[exportPath] = npmExport{
+			Types:   exportTypes,
+			Default: exportMain,
+		}
+	}
+
+	if len(pkg.Deps) > 0 {
+		file.Dependencies = map[string]string{}
+		for _, dep := range pkg.Deps {
+			file.Dependencies[dep.Name] = dep.Version
+		}
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// npmRelPath formats a path in the package as ./path, as node requires for
+// exports. Paths outside of the package are rejected.
+func npmRelPath(val, fallback string) (string, error) {
+	if val == "" {
+		val = fallback
+	}
+	if val == "" {
+		return "", nil
+	}
+	clean := path.Clean(val)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("path %q is not within the package", val)
+	}
+	if clean == "." {
+		return ".", nil
+	}
+	return "./" + clean, nil
+}
+
+var nonPrereleaseChars = regexp.MustCompile(`[^0-9A-Za-z-.]+`)
+
+// npmVersion uses the image version when it is a full semver, e.g. a tag of
+// v1.2.3, otherwise a prerelease of 0.0.0, e.g. a commit hash.
+func npmVersion(version *string) string {
+	if version == nil || *version == "" {
+		return "0.0.0"
+	}
+
+	v := *version
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	// semver accepts shorthand like v1.2, which npm does not
+	if semver.IsValid(v) && semver.Canonical(v) == strings.SplitN(v, "+", 2)[0] {
+		return strings.TrimPrefix(v, "v")
+	}
+
+	prerelease := nonPrereleaseChars.ReplaceAllString(*version, "-")
+	prerelease = strings.Trim(strings.ReplaceAll(prerelease, "..", "."), ".")
+	return "0.0.0-" + prerelease
+}
+
+// npmTarballName matches npm pack, e.g. pentops-client-1.0.0.tgz for
+// @pentops/client
+func npmTarballName(name, version string) string {
+	name = strings.TrimPrefix(name, "@")
+	name = strings.ReplaceAll(name, "/", "-")
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+// npmPackTime is the fixed modification time npm pack uses, so the tarball
+// only changes with the content.
+var npmPackTime = time.Date(1985, time.October, 26, 8, 15, 0, 0, time.UTC)
+
+// memDest collects the files written by plugins, which run concurrently.
+type memDest struct {
+	lock  sync.Mutex
+	files map[string][]byte
+}
+
+func (md *memDest) PutFile(ctx context.Context, filename string, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	md.lock.Lock()
+	defer md.lock.Unlock()
+	md.files[filename] = data
+	return nil
+}
+
+// buildNpmTarball writes the files under package/, as npm pack does.
+func buildNpmTarball(files map[string][]byte) ([]byte, error) {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, filename := range filenames {
+		data := files[filename]
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     path.Join("package", filename),
+			Mode:     0644,
+			Size:     int64(len(data)),
+			ModTime:  npmPackTime,
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
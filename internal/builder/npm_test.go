@@ -0,0 +1,202 @@
+package builder
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestNpmVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version *string
+		want    string
+	}{
+		{version: nil, want: "0.0.0"},
+		{version: gl.Ptr("v1.2.3"), want: "1.2.3"},
+		{version: gl.Ptr("1.2.3-rc.1"), want: "1.2.3-rc.1"},
+		{version: gl.Ptr("v1.2"), want: "0.0.0-v1.2"},
+		{version: gl.Ptr("b7c60d2460bb"), want: "0.0.0-b7c60d2460bb"},
+		{version: gl.Ptr("feature/foo"), want: "0.0.0-feature-foo"},
+	} {
+		assert.Equal(t, tc.want, npmVersion(tc.version))
+	}
+}
+
+func TestNpmPackageFile(t *testing.T) {
+	data, err := buildNpmPackageFile(&config_j5pb.OutputType_Npm{
+		Name:  "@pentops/test-client",
+		Types: "dist/index.d.ts",
+		Exports: []*config_j5pb.OutputType_Npm_Export{{
+			Path:  "foo",
+			Main:  "dist/foo.js",
+			Types: "dist/foo.d.ts",
+		}},
+		Deps: []*config_j5pb.OutputType_Npm_Dep{{
+			Name:    "@pentops/j5-client",
+			Version: "^1.0.0",
+		}},
+	}, gl.Ptr("v1.0.0"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.JSONEq(t, `{
+		"name": "@pentops/test-client",
+		"version": "1.0.0",
+		"main": "./index.js",
+		"types": "./dist/index.d.ts",
+		"exports": {
+			".": {"types": "./dist/index.d.ts", "default": "./index.js"},
+			"./foo": {"types": "./dist/foo.d.ts", "default": "./dist/foo.js"}
+		},
+		"dependencies": {
+			"@pentops/j5-client": "^1.0.0"
+		}
+	}`, string(data))
+
+	_, err = buildNpmPackageFile(&config_j5pb.OutputType_Npm{}, nil)
+	assert.Error(t, err, "name is required")
+}
+
+func TestNpmRelPath(t *testing.T) {
+	for _, tc := range []struct {
+		val  string
+		want string
+		err  bool
+	}{
+		{val: "", want: ""},
+		{val: "index.js", want: "./index.js"},
+		{val: "./dist/index.js", want: "./dist/index.js"},
+		{val: "dist/../index.js", want: "./index.js"},
+		{val: ".", want: "."},
+		{val: "./", want: "."},
+		{val: "../x", err: true},
+		{val: "dist/../../x", err: true},
+		{val: "..", err: true},
+		{val: "/abs", err: true},
+		{val: "//abs", err: true},
+	} {
+		got, err := npmRelPath(tc.val, "")
+		if tc.err {
+			assert.Error(t, err, tc.val)
+			continue
+		}
+		if assert.NoError(t, err, tc.val) {
+			assert.Equal(t, tc.want, got, tc.val)
+		}
+	}
+
+	_, err := buildNpmPackageFile(&config_j5pb.OutputType_Npm{
+		Name: "@pentops/test-client",
+		Exports: []*config_j5pb.OutputType_Npm_Export{{
+			Path: "foo",
+			Main: "../foo.js",
+		}},
+	}, nil)
+	assert.ErrorContains(t, err, "not within the package")
+
+	_, err = buildNpmPackageFile(&config_j5pb.OutputType_Npm{
+		Name: "@pentops/test-client",
+		Exports: []*config_j5pb.OutputType_Npm_Export{{
+			Path: "./",
+		}},
+	}, nil)
+	assert.ErrorContains(t, err, "other than '.'")
+}
+
+func TestNpmPublishPack(t *testing.T) {
+	ctx := context.Background()
+
+	bb := NewBuilder(&countingRunner{})
+	img := &source_j5pb.SourceImage{
+		Version:         gl.Ptr("v2.0.0"),
+		SourceFilenames: []string{"test/v1/test.proto"},
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("test/v1/test.proto"),
+			Package: proto.String("test.v1"),
+			Syntax:  proto.String("proto3"),
+		}},
+	}
+
+	publish := func(pack bool) mapDest {
+		t.Helper()
+		dest := mapDest{}
+		err := bb.RunPublishBuild(ctx, PluginContext{
+			Dest:   dest,
+			ErrOut: io.Discard,
+		}, img, &config_j5pb.PublishConfig{
+			Plugins: []*config_j5pb.BuildPlugin{{
+				Name: "test",
+				Type: config_j5pb.Plugin_PLUGIN_PROTO,
+				Local: &config_j5pb.CommandSpec{
+					Cmd: "test",
+				},
+				Opts: map[string]string{"a": "1"},
+			}},
+			OutputFormat: &config_j5pb.OutputType{
+				Type: &config_j5pb.OutputType_Npm_{
+					Npm: &config_j5pb.OutputType_Npm{
+						Name: "@pentops/test-client",
+						Pack: pack,
+					},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		return dest
+	}
+
+	files := publish(false)
+	assert.Equal(t, "a=1", files["out.txt"])
+	packageFile := npmPackageFile{}
+	if err := json.Unmarshal([]byte(files["package.json"]), &packageFile); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "2.0.0", packageFile.Version)
+
+	packed := publish(true)
+	tarball, ok := packed["pentops-test-client-2.0.0.tgz"]
+	if !ok || len(packed) != 1 {
+		t.Fatalf("expected only the tarball, got %v", packed)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader([]byte(tarball)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tr := tar.NewReader(gz)
+	contents := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		contents[hdr.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		"package/out.txt":      "a=1",
+		"package/package.json": files["package.json"],
+	}, contents)
+
+	assert.Equal(t, tarball, publish(true)["pentops-test-client-2.0.0.tgz"], "packing is reproducible")
+}
@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/log.go/log"
+)
+
+// SetPluginLocks pins docker plugin images to the digests in the lock file.
+// Once set, running an image which is not locked is an error, as the lock is
+// stale.
+func (dw *Runner) SetPluginLocks(locks []*config_j5pb.PluginLock) {
+	if len(locks) == 0 {
+		dw.pluginLocks = nil
+		return
+	}
+	dw.pluginLocks = make(map[string]string, len(locks))
+	for _, lock := range locks {
+		dw.pluginLocks[lock.Name] = lock.Version
+	}
+}
+
+// LockPlugins resolves the tag of each image to the digest in its registry.
+func (dw *Runner) LockPlugins(ctx context.Context, images []string) ([]*config_j5pb.PluginLock, error) {
+	images = append([]string(nil), images...)
+	sort.Strings(images)
+
+	locks := make([]*config_j5pb.PluginLock, 0, len(images))
+	seen := map[string]struct{}{}
+	for _, img := range images {
+		if _, ok := seen[img]; ok {
+			continue
+		}
+		seen[img] = struct{}{}
+
+		digest, err := dw.resolveDigest(ctx, img)
+		if err != nil {
+			return nil, fmt.Errorf("resolving plugin image %s: %w", img, err)
+		}
+
+		log.WithFields(ctx, map[string]interface{}{
+			"image":  img,
+			"digest": digest,
+		}).Info("Runner: adding plugin lock")
+
+		locks = append(locks, &config_j5pb.PluginLock{
+			Name:    img,
+			Version: digest,
+		})
+	}
+	return locks, nil
+}
+
+func (dw *Runner) resolveDigest(ctx context.Context, img string) (string, error) {
+	if _, digest, ok := strings.Cut(img, "@"); ok {
+		return digest, nil
+	}
+
+	encodedAuth := ""
+	if privilegeFunc := dw.privilegeFunc(ctx, img); privilegeFunc != nil {
+		token, err := privilegeFunc(ctx)
+		if err != nil {
+			return "", err
+		}
+		encodedAuth = token
+	}
+
+	inspect, err := dw.client.DistributionInspect(ctx, img, encodedAuth)
+	if err != nil {
+		return "", err
+	}
+	return inspect.Descriptor.Digest.String(), nil
+}
+
+// pinnedImage returns the reference to run for the configured image, by
+// digest when plugins are locked.
+func (dw *Runner) pinnedImage(img string) (string, error) {
+	if dw.pluginLocks == nil || strings.Contains(img, "@") {
+		return img, nil
+	}
+
+	digest, ok := dw.pluginLocks[img]
+	if !ok {
+		return "", fmt.Errorf("plugin image %s is not in j5-lock.yaml, the lock is stale, run latest-deps", img)
+	}
+	return pinImage(img, digest)
+}
+
+// pinImage replaces the tag of the image with the digest.
+func pinImage(img, digest string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(img)
+	if err != nil {
+		return "", fmt.Errorf("parsing image %s: %w", img, err)
+	}
+	return reference.FamiliarName(named) + "@" + digest, nil
+}
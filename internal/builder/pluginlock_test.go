@@ -0,0 +1,41 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinnedImage(t *testing.T) {
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	rr := &Runner{}
+
+	img, err := rr.pinnedImage("ghcr.io/pentops/protoc-gen-go:v1.34.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "ghcr.io/pentops/protoc-gen-go:v1.34.2", img, "unlocked images run by tag")
+
+	rr.SetPluginLocks([]*config_j5pb.PluginLock{{
+		Name:    "ghcr.io/pentops/protoc-gen-go:v1.34.2",
+		Version: digest,
+	}, {
+		Name:    "golang:1.23",
+		Version: digest,
+	}})
+
+	img, err = rr.pinnedImage("ghcr.io/pentops/protoc-gen-go:v1.34.2")
+	assert.NoError(t, err)
+	assert.Equal(t, "ghcr.io/pentops/protoc-gen-go@"+digest, img)
+
+	img, err = rr.pinnedImage("golang:1.23")
+	assert.NoError(t, err)
+	assert.Equal(t, "golang@"+digest, img)
+
+	img, err = rr.pinnedImage("ghcr.io/pentops/other@" + digest)
+	assert.NoError(t, err)
+	assert.Equal(t, "ghcr.io/pentops/other@"+digest, img, "images with a digest are already pinned")
+
+	_, err = rr.pinnedImage("ghcr.io/pentops/protoc-gen-go:v1.35.0")
+	assert.ErrorContains(t, err, "stale")
+}
@@ -2,6 +2,8 @@ package builder
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -22,6 +24,10 @@ type Runner struct {
 	auth   []*config_j5pb.DockerRegistryAuth
 
 	DockerOverride map[string]string // map[cmd]localCommand
+
+	// pluginLocks maps docker images, as configured, to the locked digest.
+	// Nil when the lock file has no plugins, images then run by tag.
+	pluginLocks map[string]string
 }
 
 func NewRunner(registryAuth []*config_j5pb.DockerRegistryAuth) (*Runner, error) {
@@ -107,3 +113,53 @@ func mapEnvVars(spec []string, vars map[string]string) ([]string, error) {
 	}
 	return env, nil
 }
+
+// PluginKey identifies the exact plugin which will run: the image ID for
+// docker, pulling it if needed, or for local commands which set cache, a hash
+// of the binary and the environment passed to it. Other local commands may run
+// anything, e.g. go run or a wrapper script, so are not cacheable.
+func (rr *Runner) PluginKey(ctx context.Context, plugin *config_j5pb.BuildPlugin, vars map[string]string) (string, error) {
+	if plugin.Local != nil {
+		if !plugin.Local.Cache {
+			return "", ErrNotCacheable
+		}
+		path, err := exec.LookPath(plugin.Local.Cmd)
+		if err != nil {
+			return "", err
+		}
+		envVars, err := mapEnvVars(plugin.Local.Env, vars)
+		if err != nil {
+			return "", err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		hash := sha256.New()
+		if _, err := io.Copy(hash, f); err != nil {
+			return "", err
+		}
+		for _, env := range envVars {
+			fmt.Fprintf(hash, "%s\x00", env)
+		}
+		return "local:" + hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	if plugin.Docker != nil {
+		img, err := rr.pinnedImage(plugin.Docker.Image)
+		if err != nil {
+			return "", err
+		}
+		if err := rr.pullIfNeeded(ctx, img); err != nil {
+			return "", err
+		}
+		inspect, err := rr.client.ImageInspect(ctx, img)
+		if err != nil {
+			return "", err
+		}
+		return "docker:" + inspect.ID, nil
+	}
+
+	return "", fmt.Errorf("no command specified")
+}
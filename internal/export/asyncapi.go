@@ -0,0 +1,444 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/messaging/v1/messaging_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/structure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	asyncAPIVersion   = "3.0.0"
+	asyncSchemaPrefix = "#/components/schemas/"
+)
+
+// AsyncAPIDocument describes the topics of an API as an AsyncAPI 3.0
+// document. Each topic is a channel, each topic method is a message and an
+// operation.
+type AsyncAPIDocument struct {
+	AsyncAPI           string                     `json:"asyncapi"`
+	Info               DocumentInfo               `json:"info"`
+	DefaultContentType string                     `json:"defaultContentType"`
+	Channels           map[string]*AsyncChannel   `json:"channels"`
+	Operations         map[string]*AsyncOperation `json:"operations"`
+	Components         AsyncComponents            `json:"components"`
+}
+
+type AsyncChannel struct {
+	Address     string               `json:"address"`
+	Description string               `json:"description,omitempty"`
+	Messages    map[string]*AsyncRef `json:"messages"`
+
+	Role       string `json:"x-j5-role"`
+	EntityName string `json:"x-j5-entity,omitempty"`
+}
+
+type AsyncRef struct {
+	Ref string `json:"$ref"`
+}
+
+type AsyncOperation struct {
+	Action      string      `json:"action"`
+	Channel     *AsyncRef   `json:"channel"`
+	Description string      `json:"description,omitempty"`
+	Messages    []*AsyncRef `json:"messages"`
+}
+
+type AsyncComponents struct {
+	Messages map[string]*AsyncMessage `json:"messages"`
+	Schemas  map[string]*Schema       `json:"schemas"`
+}
+
+type AsyncMessage struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	ContentType string        `json:"contentType"`
+	Headers     *AsyncHeaders `json:"headers"`
+	Payload     *Schema       `json:"payload"`
+}
+
+// AsyncHeaders is the schema of the o5 message envelope fields which are sent
+// alongside the payload.
+type AsyncHeaders struct {
+	Type       string                  `json:"type"`
+	Required   []string                `json:"required"`
+	Properties map[string]*AsyncHeader `json:"properties"`
+}
+
+type AsyncHeader struct {
+	Type        string `json:"type"`
+	Format      string `json:"format,omitempty"`
+	Const       string `json:"const,omitempty"`
+	Description string `json:"description"`
+}
+
+// topicActions is the action of the application which declares a topic,
+// requests are received and replied to, all other roles are sent.
+var topicActions = map[string]string{
+	"publish": "send",
+	"event":   "send",
+	"upsert":  "send",
+	"request": "receive",
+	"reply":   "send",
+}
+
+// BuildAsyncAPI converts the topics in the packages of the image, including
+// the topics generated for state entities, to an AsyncAPI document. Services
+// with topic options are only included where the rules, or the default name
+// suffixes, classify them as topics.
+func BuildAsyncAPI(image *source_j5pb.SourceImage, rules ...structure.ServiceRule) (*AsyncAPIDocument, error) {
+	descFiles, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{
+		File: image.File,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("new files: %w", err)
+	}
+
+	classifier, err := structure.NewClassifier(descFiles, rules...)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &AsyncAPIDocument{
+		AsyncAPI:           asyncAPIVersion,
+		Info:               asyncInfo(image),
+		DefaultContentType: "application/json",
+		Channels:           map[string]*AsyncChannel{},
+		Operations:         map[string]*AsyncOperation{},
+		Components: AsyncComponents{
+			Messages: map[string]*AsyncMessage{},
+			Schemas:  map[string]*Schema{},
+		},
+	}
+
+	schemas := &schemaCollector{
+		cache:   j5schema.NewSchemaCache(),
+		schemas: doc.Components.Schemas,
+	}
+
+	for _, fileProto := range image.File {
+		file, err := descFiles.FindFileByPath(fileProto.GetName())
+		if err != nil {
+			return nil, err
+		}
+		if !inImagePackages(image, string(file.Package())) {
+			continue
+		}
+		services := file.Services()
+		for ii := 0; ii < services.Len(); ii++ {
+			service := services.Get(ii)
+			config, ok := proto.GetExtension(service.Options(), messaging_j5pb.E_Service).(*messaging_j5pb.ServiceConfig)
+			if !ok || config == nil {
+				continue
+			}
+			kind, err := classifier.Kind(service)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", service.FullName(), err)
+			}
+			if kind != config_j5pb.ServiceKind_TOPIC {
+				continue
+			}
+			if err := doc.addTopic(schemas, service, config); err != nil {
+				return nil, fmt.Errorf("topic %s: %w", service.FullName(), err)
+			}
+		}
+	}
+
+	return doc, nil
+}
+
+func asyncInfo(image *source_j5pb.SourceImage) DocumentInfo {
+	info := DocumentInfo{
+		Title:   image.SourceName,
+		Version: "0.0.0",
+	}
+	if image.Version != nil {
+		info.Version = *image.Version
+	}
+	if info.Title == "" {
+		names := make([]string, 0, len(image.Packages))
+		for _, pkg := range image.Packages {
+			names = append(names, pkg.Name)
+		}
+		info.Title = strings.Join(names, ", ")
+	}
+	return info
+}
+
+func inImagePackages(image *source_j5pb.SourceImage, name string) bool {
+	for _, pkg := range image.Packages {
+		if name == pkg.Name || strings.HasPrefix(name, pkg.Name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (dd *AsyncAPIDocument) addTopic(schemas *schemaCollector, service protoreflect.ServiceDescriptor, config *messaging_j5pb.ServiceConfig) error {
+	channelID := string(service.FullName())
+	channel := &AsyncChannel{
+		Address:     config.GetTopicName(),
+		Description: commentString(service),
+		Messages:    map[string]*AsyncRef{},
+	}
+	if channel.Address == "" {
+		return fmt.Errorf("missing topic_name")
+	}
+
+	switch role := config.Role.(type) {
+	case *messaging_j5pb.ServiceConfig_Publish_:
+		channel.Role = "publish"
+	case *messaging_j5pb.ServiceConfig_Event_:
+		channel.Role = "event"
+		channel.EntityName = role.Event.EntityName
+	case *messaging_j5pb.ServiceConfig_Upsert_:
+		channel.Role = "upsert"
+		channel.EntityName = role.Upsert.EntityName
+	case *messaging_j5pb.ServiceConfig_Request_:
+		channel.Role = "request"
+	case *messaging_j5pb.ServiceConfig_Reply_:
+		channel.Role = "reply"
+	default:
+		return fmt.Errorf("unsupported topic role %T", role)
+	}
+
+	methods := service.Methods()
+	for ii := 0; ii < methods.Len(); ii++ {
+		method := methods.Get(ii)
+		messageID := string(method.FullName())
+
+		payload, err := schemas.rootRef(method.Input())
+		if err != nil {
+			return fmt.Errorf("method %s: %w", method.Name(), err)
+		}
+
+		dd.Components.Messages[messageID] = &AsyncMessage{
+			Name:        string(method.Name()),
+			Description: commentString(method),
+			ContentType: "application/json",
+			Headers:     messageHeaders(service, method),
+			Payload:     payload,
+		}
+
+		name := string(method.Name())
+		channel.Messages[name] = &AsyncRef{
+			Ref: "#/components/messages/" + messageID,
+		}
+
+		dd.Operations[messageID] = &AsyncOperation{
+			Action:      topicActions[channel.Role],
+			Description: commentString(method),
+			Channel: &AsyncRef{
+				Ref: "#/channels/" + channelID,
+			},
+			Messages: []*AsyncRef{{
+				Ref: "#/channels/" + channelID + "/messages/" + name,
+			}},
+		}
+	}
+
+	dd.Channels[channelID] = channel
+	return nil
+}
+
+// messageHeaders describes the o5 message envelope, which identifies the
+// message by the topic service and method names.
+func messageHeaders(service protoreflect.ServiceDescriptor, method protoreflect.MethodDescriptor) *AsyncHeaders {
+	return &AsyncHeaders{
+		Type:     "object",
+		Required: []string{"messageId", "grpcService", "grpcMethod"},
+		Properties: map[string]*AsyncHeader{
+			"messageId": {
+				Type:        "string",
+				Description: "Unique ID of the message, used for deduplication",
+			},
+			"grpcService": {
+				Type:        "string",
+				Const:       string(service.FullName()),
+				Description: "Full name of the topic service",
+			},
+			"grpcMethod": {
+				Type:        "string",
+				Const:       string(method.Name()),
+				Description: "Name of the topic method",
+			},
+			"sourceApp": {
+				Type:        "string",
+				Description: "Name of the application which sent the message",
+			},
+			"sourceEnv": {
+				Type:        "string",
+				Description: "Full name of the environment which sent the message",
+			},
+			"timestamp": {
+				Type:        "string",
+				Format:      "date-time",
+				Description: "Time the message was produced or replayed",
+			},
+		},
+	}
+}
+
+func commentString(desc protoreflect.Descriptor) string {
+	loc := desc.ParentFile().SourceLocations().ByDescriptor(desc)
+	return strings.TrimSpace(loc.LeadingComments)
+}
+
+// schemaCollector converts j5 schemas for the document components, adding
+// every schema reachable from the converted roots.
+type schemaCollector struct {
+	cache   *j5schema.SchemaCache
+	schemas map[string]*Schema
+}
+
+// rootRef adds the schema for the message and returns a reference to it.
+func (sc *schemaCollector) rootRef(msg protoreflect.MessageDescriptor) (*Schema, error) {
+	root, err := sc.cache.Schema(msg)
+	if err != nil {
+		return nil, err
+	}
+	if err := sc.addRoot(root); err != nil {
+		return nil, err
+	}
+	ref := asyncSchemaPrefix + root.FullName()
+	return &Schema{
+		Ref: &ref,
+	}, nil
+}
+
+func (sc *schemaCollector) addRoot(root j5schema.RootSchema) error {
+	name := root.FullName()
+	if _, ok := sc.schemas[name]; ok {
+		return nil
+	}
+
+	clientRoot := proto.Clone(root.ToJ5ClientRoot()).(*schema_j5pb.RootSchema)
+	stringScalars(clientRoot.ProtoReflect())
+	converted, err := ConvertRootSchema(clientRoot)
+	if err != nil {
+		return fmt.Errorf("schema %s: %w", name, err)
+	}
+	converted.rewriteRefs(swaggerSchemaPrefix, asyncSchemaPrefix)
+	sc.schemas[name] = converted
+
+	var props []*j5schema.ObjectProperty
+	switch st := root.(type) {
+	case *j5schema.ObjectSchema:
+		props = st.ClientProperties()
+	case *j5schema.OneofSchema:
+		props = st.Properties
+	}
+	for _, prop := range props {
+		if err := sc.addField(prop.Schema); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (sc *schemaCollector) addField(field j5schema.FieldSchema) error {
+	var ref *j5schema.RefSchema
+	switch st := field.(type) {
+	case *j5schema.ObjectField:
+		ref = st.Ref
+	case *j5schema.OneofField:
+		ref = st.Ref
+	case *j5schema.EnumField:
+		ref = st.Ref
+	case *j5schema.ArrayField:
+		return sc.addField(st.Schema)
+	case *j5schema.MapField:
+		return sc.addField(st.Schema)
+	}
+	if ref == nil {
+		return nil
+	}
+	if ref.To == nil {
+		return fmt.Errorf("unlinked ref %s", ref.FullName())
+	}
+	return sc.addRoot(ref.To)
+}
+
+// id62Pattern matches the base62 encoding of a 128 bit ID.
+const id62Pattern = "^[0-9A-Za-z]{22}$"
+
+// stringScalars replaces the fields of the schema which are encoded as JSON
+// strings, but which the Swagger converter does not accept, with string
+// fields of the matching format.
+func stringScalars(msg protoreflect.Message) {
+	if field, ok := msg.Interface().(*schema_j5pb.Field); ok {
+		if str := scalarString(field); str != nil {
+			field.Type = &schema_j5pb.Field_String_{
+				String_: str,
+			}
+			return
+		}
+	}
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, val protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				return true
+			}
+			val.Map().Range(func(_ protoreflect.MapKey, item protoreflect.Value) bool {
+				stringScalars(item.Message())
+				return true
+			})
+		case fd.Message() == nil:
+		case fd.IsList():
+			list := val.List()
+			for idx := range list.Len() {
+				stringScalars(list.Get(idx).Message())
+			}
+		default:
+			stringScalars(val.Message())
+		}
+		return true
+	})
+}
+
+func scalarString(field *schema_j5pb.Field) *schema_j5pb.StringField {
+	switch ft := field.Type.(type) {
+	case *schema_j5pb.Field_Key:
+		str := &schema_j5pb.StringField{}
+		switch kf := ft.Key.Format.GetType().(type) {
+		case *schema_j5pb.KeyFormat_Uuid:
+			str.Format = proto.String("uuid")
+		case *schema_j5pb.KeyFormat_Id62:
+			str.Format = proto.String("id62")
+			str.Rules = &schema_j5pb.StringField_Rules{
+				Pattern: proto.String(id62Pattern),
+			}
+		case *schema_j5pb.KeyFormat_Custom_:
+			str.Rules = &schema_j5pb.StringField_Rules{
+				Pattern: proto.String(kf.Custom.Pattern),
+			}
+		}
+		return str
+
+	case *schema_j5pb.Field_Bytes:
+		return &schema_j5pb.StringField{Format: proto.String("byte")}
+
+	case *schema_j5pb.Field_Decimal:
+		return &schema_j5pb.StringField{Format: proto.String("decimal")}
+
+	case *schema_j5pb.Field_Date:
+		return &schema_j5pb.StringField{Format: proto.String("date")}
+
+	case *schema_j5pb.Field_Timestamp:
+		return &schema_j5pb.StringField{Format: proto.String("date-time")}
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,232 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/pentops/flowtest/jsontest"
+	"github.com/pentops/j5/gen/j5/messaging/v1/messaging_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/structure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func topicService(name string, config *messaging_j5pb.ServiceConfig, methods ...string) *descriptorpb.ServiceDescriptorProto {
+	service := &descriptorpb.ServiceDescriptorProto{
+		Name:    proto.String(name),
+		Options: &descriptorpb.ServiceOptions{},
+	}
+	proto.SetExtension(service.Options, messaging_j5pb.E_Service, config)
+	for _, method := range methods {
+		service.Method = append(service.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(method),
+			InputType:  proto.String(".test.v1.topic." + method + "Message"),
+			OutputType: proto.String(".google.protobuf.Empty"),
+		})
+	}
+	return service
+}
+
+func testTopicImage() *source_j5pb.SourceImage {
+	return &source_j5pb.SourceImage{
+		SourceName: "test",
+		Packages: []*source_j5pb.PackageInfo{{
+			Name: "test.v1",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto),
+			{
+				Name:    proto.String("test/v1/foo.proto"),
+				Package: proto.String("test.v1"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("Foo"),
+					Field: []*descriptorpb.FieldDescriptorProto{{
+						Name:     proto.String("id"),
+						JsonName: proto.String("id"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					}},
+				}},
+			},
+			{
+				Name:       proto.String("test/v1/topic/foo.proto"),
+				Package:    proto.String("test.v1.topic"),
+				Syntax:     proto.String("proto3"),
+				Dependency: []string{"google/protobuf/empty.proto", "test/v1/foo.proto"},
+				MessageType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("FooMessage"),
+					Field: []*descriptorpb.FieldDescriptorProto{{
+						Name:     proto.String("foo"),
+						JsonName: proto.String("foo"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".test.v1.Foo"),
+					}},
+				}, {
+					Name: proto.String("WorkRequestMessage"),
+				}},
+				Service: []*descriptorpb.ServiceDescriptorProto{
+					topicService("FooTopic", &messaging_j5pb.ServiceConfig{
+						TopicName: proto.String("foo"),
+						Role: &messaging_j5pb.ServiceConfig_Publish_{
+							Publish: &messaging_j5pb.ServiceConfig_Publish{},
+						},
+					}, "Foo"),
+					topicService("WorkRequestTopic", &messaging_j5pb.ServiceConfig{
+						TopicName: proto.String("work"),
+						Role: &messaging_j5pb.ServiceConfig_Request_{
+							Request: &messaging_j5pb.ServiceConfig_Request{},
+						},
+					}, "WorkRequest"),
+				},
+			},
+		},
+	}
+}
+
+func TestBuildAsyncAPI(t *testing.T) {
+	doc, err := BuildAsyncAPI(testTopicImage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := jsontest.NewAsserter(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Print(t)
+
+	out.AssertEqual(t, "asyncapi", "3.0.0")
+	out.AssertEqual(t, "info.title", "test")
+
+	out.AssertEqual(t, "channels.test\\.v1\\.topic\\.FooTopic.address", "foo")
+	out.AssertEqual(t, "channels.test\\.v1\\.topic\\.FooTopic.x-j5-role", "publish")
+	out.AssertEqual(t, "channels.test\\.v1\\.topic\\.FooTopic.messages.Foo.$ref", "#/components/messages/test.v1.topic.FooTopic.Foo")
+
+	out.AssertEqual(t, "operations.test\\.v1\\.topic\\.FooTopic\\.Foo.action", "send")
+	out.AssertEqual(t, "operations.test\\.v1\\.topic\\.FooTopic\\.Foo.channel.$ref", "#/channels/test.v1.topic.FooTopic")
+	out.AssertEqual(t, "operations.test\\.v1\\.topic\\.WorkRequestTopic\\.WorkRequest.action", "receive")
+
+	msg := "components.messages.test\\.v1\\.topic\\.FooTopic\\.Foo"
+	out.AssertEqual(t, msg+".name", "Foo")
+	out.AssertEqual(t, msg+".payload.$ref", "#/components/schemas/test.v1.topic.FooMessage")
+	out.AssertEqual(t, msg+".headers.properties.grpcService.const", "test.v1.topic.FooTopic")
+	out.AssertEqual(t, msg+".headers.properties.grpcMethod.const", "Foo")
+
+	// Schemas referenced from the payload are included, and referenced from
+	// the components rather than the swagger definitions.
+	out.AssertEqual(t, "components.schemas.test\\.v1\\.topic\\.FooMessage.properties.foo.$ref", "#/components/schemas/test.v1.Foo")
+	out.AssertEqual(t, "components.schemas.test\\.v1\\.Foo.properties.id.type", "string")
+}
+
+func TestAsyncAPIServiceRules(t *testing.T) {
+	image := testTopicImage()
+	services := &image.File[2].Service
+	*services = append(*services, topicService("WorkAPI", &messaging_j5pb.ServiceConfig{
+		TopicName: proto.String("work-api"),
+		Role: &messaging_j5pb.ServiceConfig_Publish_{
+			Publish: &messaging_j5pb.ServiceConfig_Publish{},
+		},
+	}, "WorkRequest"))
+
+	_, err := BuildAsyncAPI(image)
+	if err == nil {
+		t.Fatal("expected an error for WorkAPI without a rule")
+	}
+
+	doc, err := BuildAsyncAPI(image, structure.ServiceRule{
+		Name: "test.v1.topic.FooTopic",
+		Kind: config_j5pb.ServiceKind_IGNORE,
+	}, structure.ServiceRule{
+		Suffix: "API",
+		Kind:   config_j5pb.ServiceKind_TOPIC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := doc.Channels["test.v1.topic.FooTopic"]; ok {
+		t.Error("ignored FooTopic should not be a channel")
+	}
+	for _, name := range []string{"test.v1.topic.WorkRequestTopic", "test.v1.topic.WorkAPI"} {
+		if _, ok := doc.Channels[name]; !ok {
+			t.Errorf("missing channel %s", name)
+		}
+	}
+}
+
+func TestAsyncStringScalars(t *testing.T) {
+	field := func(name string, ft *schema_j5pb.Field) *schema_j5pb.ObjectProperty {
+		return &schema_j5pb.ObjectProperty{
+			Name:   name,
+			Schema: ft,
+		}
+	}
+	root := &schema_j5pb.RootSchema{
+		Type: &schema_j5pb.RootSchema_Object{
+			Object: &schema_j5pb.Object{
+				Name: "Foo",
+				Properties: []*schema_j5pb.ObjectProperty{
+					field("key", &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Key{
+							Key: &schema_j5pb.KeyField{
+								Format: &schema_j5pb.KeyFormat{
+									Type: &schema_j5pb.KeyFormat_Id62{
+										Id62: &schema_j5pb.KeyFormat_ID62{},
+									},
+								},
+							},
+						},
+					}),
+					field("times", &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Array{
+							Array: &schema_j5pb.ArrayField{
+								Items: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_Timestamp{
+										Timestamp: &schema_j5pb.TimestampField{},
+									},
+								},
+							},
+						},
+					}),
+					field("dates", &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Map{
+							Map: &schema_j5pb.MapField{
+								ItemSchema: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_Date{
+										Date: &schema_j5pb.DateField{},
+									},
+								},
+							},
+						},
+					}),
+				},
+			},
+		},
+	}
+
+	stringScalars(root.ProtoReflect())
+	converted, err := ConvertRootSchema(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := jsontest.NewAsserter(converted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Print(t)
+
+	out.AssertEqual(t, "properties.key.type", "string")
+	out.AssertEqual(t, "properties.key.format", "id62")
+	out.AssertEqual(t, "properties.key.pattern", id62Pattern)
+	out.AssertEqual(t, "properties.times.items.format", "date-time")
+	out.AssertEqual(t, "properties.dates.additionalProperties.format", "date")
+}
@@ -7,8 +7,12 @@ import (
 	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
 )
 
+// swaggerSchemaPrefix is the path of the schemas referenced from converted
+// schemas.
+const swaggerSchemaPrefix = "#/definitions/"
+
 // BuildSwagger converts the J5 Document to a Swagger Document
-func BuildSwagger(b *client_j5pb.API) (*Document, error) {
+func BuildSwagger(b *client_j5pb.API, events ...*EventService) (*Document, error) {
 	doc := &Document{
 		OpenAPI: "3.0.0",
 		Components: Components{
@@ -25,6 +29,10 @@ func BuildSwagger(b *client_j5pb.API) (*Document, error) {
 		}
 	}
 
+	for _, service := range events {
+		doc.addEvents(service)
+	}
+
 	schemas := make(map[string]*Schema)
 	for _, pkg := range b.Packages {
 		for key, schema := range pkg.Schemas {
@@ -92,7 +100,7 @@ func convertSchema(schema *schema_j5pb.Field) (*Schema, error) {
 			out.SchemaItem.Type = convertEnumItem(t.Enum).Type
 
 		case *schema_j5pb.EnumField_Ref:
-			refStr := fmt.Sprintf("#/definitions/%s.%s", t.Ref.Package, t.Ref.Schema)
+			refStr := fmt.Sprintf("%s%s.%s", swaggerSchemaPrefix, t.Ref.Package, t.Ref.Schema)
 			out.Ref = &refStr
 
 		default:
@@ -110,7 +118,7 @@ func convertSchema(schema *schema_j5pb.Field) (*Schema, error) {
 			out.SchemaItem.Type = item.Type
 
 		case *schema_j5pb.ObjectField_Ref:
-			refStr := fmt.Sprintf("#/definitions/%s.%s", t.Ref.Package, t.Ref.Schema)
+			refStr := fmt.Sprintf("%s%s.%s", swaggerSchemaPrefix, t.Ref.Package, t.Ref.Schema)
 			out.Ref = &refStr
 
 		default:
@@ -127,7 +135,7 @@ func convertSchema(schema *schema_j5pb.Field) (*Schema, error) {
 
 			out.SchemaItem.Type = item.Type
 		case *schema_j5pb.OneofField_Ref:
-			refStr := fmt.Sprintf("#/definitions/%s.%s", t.Ref.Package, t.Ref.Schema)
+			refStr := fmt.Sprintf("%s%s.%s", swaggerSchemaPrefix, t.Ref.Package, t.Ref.Schema)
 			out.Ref = &refStr
 
 		default:
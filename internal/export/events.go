@@ -0,0 +1,59 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5build/internal/structure"
+)
+
+// EventService is a message only service which receives the events of a
+// state entity. client_j5pb has no type for these, so they are passed to the
+// jdef and Swagger builders alongside the client API.
+type EventService struct {
+	Package string // e.g. "foo.v1"
+	Entity  string // full name, e.g. "foo.v1/foo"
+	Name    string
+	Methods []*EventMethod
+}
+
+type EventMethod struct {
+	Name         string
+	FullGrpcName string
+	Schema       string // the entity event, e.g. "foo.v1.FooEvent"
+}
+
+// EventsFromStructure converts the event services of the API structure. The
+// services are not checked against their state entities here, that happens
+// when building the client API.
+func EventsFromStructure(services []*structure.EventService) []*EventService {
+	out := make([]*EventService, 0, len(services))
+	for _, service := range services {
+		entity := service.EntityName
+		if !strings.Contains(entity, "/") {
+			entity = fmt.Sprintf("%s/%s", service.Package, entity)
+		}
+		events := &EventService{
+			Package: service.Package,
+			Entity:  entity,
+			Name:    service.Name,
+			Methods: make([]*EventMethod, 0, len(service.Messages)),
+		}
+		for _, msg := range service.Messages {
+			events.Methods = append(events.Methods, &EventMethod{
+				Name:         msg.Name,
+				FullGrpcName: msg.FullGrpcName,
+				Schema:       msg.Schema,
+			})
+		}
+		out = append(out, events)
+	}
+	return out
+}
+
+func (mm *EventMethod) schemaRef() *Schema {
+	refStr := fmt.Sprintf("%s%s", swaggerSchemaPrefix, mm.Schema)
+	return &Schema{
+		Ref: &refStr,
+	}
+}
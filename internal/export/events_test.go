@@ -0,0 +1,113 @@
+package export
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/internal/structure"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func testEventServices() []*EventService {
+	return []*EventService{{
+		Package: "test.v1",
+		Entity:  "test.v1/foo",
+		Name:    "FooEvents",
+		Methods: []*EventMethod{{
+			Name:         "Event",
+			FullGrpcName: "test.v1.topic.FooEvents.Event",
+			Schema:       "test.v1.FooEvent",
+		}},
+	}}
+}
+
+func TestEventsFromStructure(t *testing.T) {
+	events := EventsFromStructure([]*structure.EventService{{
+		Package:    "test.v1",
+		SubPackage: "topic",
+		Name:       "FooEvents",
+		EntityName: "foo",
+		Messages: []*source_j5pb.TopicMessage{{
+			Name:         "Event",
+			Schema:       "test.v1.FooEvent",
+			FullGrpcName: "test.v1.topic.FooEvents.Event",
+		}},
+	}})
+	assert.Equal(t, testEventServices(), events)
+}
+
+func TestEventsOutput(t *testing.T) {
+	api := &client_j5pb.API{
+		Packages: []*client_j5pb.Package{{
+			Name: "test.v1",
+		}, {
+			Name: "other.v1",
+		}},
+	}
+
+	t.Run("Swagger", func(t *testing.T) {
+		doc, err := BuildSwagger(api, testEventServices()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jsonVal, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		events := gjson.GetBytes(jsonVal, "x-events").Array()
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+		event := events[0]
+		for path, want := range map[string]string{
+			"operationId":    "test.v1.topic.FooEvents.Event",
+			"x-grpc-service": "FooEvents",
+			"x-grpc-method":  "Event",
+			"x-entity":       "test.v1/foo",
+			"schema.$ref":    "#/definitions/test.v1.FooEvent",
+		} {
+			if got := event.Get(path).String(); got != want {
+				t.Errorf("%s: expected %q, got %q", path, want, got)
+			}
+		}
+	})
+
+	t.Run("Swagger Without Events", func(t *testing.T) {
+		doc, err := BuildSwagger(api)
+		if err != nil {
+			t.Fatal(err)
+		}
+		jsonVal, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gjson.GetBytes(jsonVal, "x-events").Exists() {
+			t.Errorf("unexpected x-events")
+		}
+	})
+
+	t.Run("JDef", func(t *testing.T) {
+		jdef, err := FromProto(api, testEventServices()...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(jdef.Packages[1].Events) != 0 {
+			t.Errorf("unexpected events in other package")
+		}
+		events := jdef.Packages[0].Events
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event, got %d", len(events))
+		}
+		event := events[0]
+		if event.Name != "Event" || event.GrpcServiceName != "FooEvents" || event.FullGrpcName != "test.v1.topic.FooEvents.Event" || event.Entity != "test.v1/foo" {
+			t.Errorf("unexpected event %+v", event)
+		}
+		if event.Schema.Ref == nil || *event.Schema.Ref != "#/definitions/test.v1.FooEvent" {
+			t.Errorf("unexpected schema %v", event.Schema.Ref)
+		}
+	})
+}
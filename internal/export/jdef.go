@@ -16,8 +16,9 @@ type Package struct {
 	Label string `json:"label"`
 	Name  string `json:"name"`
 
-	Introduction string    `json:"introduction,omitempty"`
-	Methods      []*Method `json:"methods"`
+	Introduction string       `json:"introduction,omitempty"`
+	Methods      []*Method    `json:"methods"`
+	Events       []*EventSpec `json:"events,omitempty"`
 }
 
 type Method struct {
@@ -34,8 +35,11 @@ type Method struct {
 }
 
 type EventSpec struct {
-	Name   string  `json:"name"`
-	Schema *Schema `json:"schema,omitempty"`
+	Name            string  `json:"name"`
+	GrpcServiceName string  `json:"grpcServiceName"`
+	FullGrpcName    string  `json:"fullGrpcName"`
+	Entity          string  `json:"entity"`
+	Schema          *Schema `json:"schema,omitempty"`
 }
 
 type JdefParameter struct {
@@ -45,7 +49,7 @@ type JdefParameter struct {
 	Schema      Schema `json:"schema"`
 }
 
-func FromProto(protoSchema *client_j5pb.API) (*API, error) {
+func FromProto(protoSchema *client_j5pb.API, events ...*EventService) (*API, error) {
 	out := &API{
 		Packages: make([]*Package, len(protoSchema.Packages)),
 		Schemas:  make(map[string]*Schema),
@@ -58,6 +62,21 @@ func FromProto(protoSchema *client_j5pb.API) (*API, error) {
 		}
 		out.Packages[idx] = pkg
 
+		for _, service := range events {
+			if service.Package != pkg.Name {
+				continue
+			}
+			for _, method := range service.Methods {
+				pkg.Events = append(pkg.Events, &EventSpec{
+					Name:            method.Name,
+					GrpcServiceName: service.Name,
+					FullGrpcName:    method.FullGrpcName,
+					Entity:          service.Entity,
+					Schema:          method.schemaRef(),
+				})
+			}
+		}
+
 		for key, protoSchema := range protoPackage.Schemas {
 			schema, err := ConvertRootSchema(protoSchema)
 			if err != nil {
@@ -0,0 +1,552 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+)
+
+const (
+	jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+	jsonSchemaPrefix  = "#/$defs/"
+)
+
+// JSONSchema is a JSON Schema draft 2020-12 schema, describing values as they
+// are encoded by the j5 codec.
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Defs        map[string]*JSONSchema `json:"$defs,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+
+	Type            string   `json:"type,omitempty"`
+	Format          string   `json:"format,omitempty"`
+	ContentEncoding string   `json:"contentEncoding,omitempty"`
+	Pattern         string   `json:"pattern,omitempty"`
+	MinLength       *uint64  `json:"minLength,omitempty"`
+	MaxLength       *uint64  `json:"maxLength,omitempty"`
+	Enum            []string `json:"enum,omitempty"`
+	Const           any      `json:"const,omitempty"`
+	Examples        []any    `json:"examples,omitempty"`
+
+	// Numbers are float64 or int64, 2020-12 exclusive bounds are numbers
+	// rather than flags.
+	Minimum          any `json:"minimum,omitempty"`
+	Maximum          any `json:"maximum,omitempty"`
+	ExclusiveMinimum any `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum any `json:"exclusiveMaximum,omitempty"`
+	MultipleOf       any `json:"multipleOf,omitempty"`
+
+	Items       *JSONSchema `json:"items,omitempty"`
+	MinItems    *uint64     `json:"minItems,omitempty"`
+	MaxItems    *uint64     `json:"maxItems,omitempty"`
+	UniqueItems bool        `json:"uniqueItems,omitempty"`
+
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	PropertyNames        *JSONSchema            `json:"propertyNames,omitempty"`
+	MinProperties        *uint64                `json:"minProperties,omitempty"`
+	MaxProperties        *uint64                `json:"maxProperties,omitempty"`
+
+	OneOf []*JSONSchema `json:"oneOf,omitempty"`
+	Not   *JSONSchema   `json:"not,omitempty"`
+}
+
+// BuildJSONSchema converts every schema in the API packages to a single JSON
+// Schema document, with each schema in $defs keyed by its full name.
+// References between schemas, including across packages, point into the same
+// $defs.
+// When packages are listed, only the schemas of those packages and the
+// schemas they reference are included.
+func BuildJSONSchema(api *client_j5pb.API, packages ...string) (*JSONSchema, error) {
+	defs := map[string]*JSONSchema{}
+	for _, pkg := range api.Packages {
+		for key, schema := range pkg.Schemas {
+			fullKey := fmt.Sprintf("%s.%s", pkg.Name, key)
+			converted, err := jsonRootSchema(schema)
+			if err != nil {
+				return nil, fmt.Errorf("schema %s: %w", fullKey, err)
+			}
+			defs[fullKey] = converted
+		}
+	}
+
+	doc := &JSONSchema{
+		Schema: jsonSchemaDialect,
+		Defs:   defs,
+	}
+	if len(packages) == 0 {
+		return doc, nil
+	}
+
+	doc.Defs = map[string]*JSONSchema{}
+	for _, name := range packages {
+		pkg := findPackage(api, name)
+		if pkg == nil {
+			return nil, fmt.Errorf("package %q not found", name)
+		}
+		for key := range pkg.Schemas {
+			if err := doc.addDef(defs, fmt.Sprintf("%s.%s", pkg.Name, key)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+func findPackage(api *client_j5pb.API, name string) *client_j5pb.Package {
+	for _, pkg := range api.Packages {
+		if pkg.Name == name {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// addDef copies the named schema from all, along with every schema it
+// references.
+func (s *JSONSchema) addDef(all map[string]*JSONSchema, name string) error {
+	if _, ok := s.Defs[name]; ok {
+		return nil
+	}
+	def, ok := all[name]
+	if !ok {
+		return fmt.Errorf("schema %s not found", name)
+	}
+	s.Defs[name] = def
+	for _, ref := range def.refs() {
+		if err := s.addDef(all, strings.TrimPrefix(ref, jsonSchemaPrefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refs lists the $refs in the schema and its children.
+func (s *JSONSchema) refs() []string {
+	if s == nil {
+		return nil
+	}
+	var out []string
+	if s.Ref != "" {
+		out = append(out, s.Ref)
+	}
+	children := []*JSONSchema{s.Items, s.AdditionalProperties, s.PropertyNames, s.Not}
+	children = append(children, s.OneOf...)
+	for _, prop := range s.Properties {
+		children = append(children, prop)
+	}
+	for _, child := range children {
+		out = append(out, child.refs()...)
+	}
+	return out
+}
+
+func jsonRootSchema(schema *schema_j5pb.RootSchema) (*JSONSchema, error) {
+	switch t := schema.Type.(type) {
+	case *schema_j5pb.RootSchema_Object:
+		return jsonObjectSchema(t.Object)
+	case *schema_j5pb.RootSchema_Oneof:
+		return jsonOneofSchema(t.Oneof)
+	case *schema_j5pb.RootSchema_Enum:
+		return jsonEnumSchema(t.Enum), nil
+	default:
+		return nil, fmt.Errorf("expected root schema, got %T", schema.Type)
+	}
+}
+
+func jsonRef(ref *schema_j5pb.Ref) *JSONSchema {
+	return &JSONSchema{
+		Ref: fmt.Sprintf("%s%s.%s", jsonSchemaPrefix, ref.Package, ref.Schema),
+	}
+}
+
+func jsonFieldSchema(schema *schema_j5pb.Field) (*JSONSchema, error) {
+	switch t := schema.Type.(type) {
+
+	case *schema_j5pb.Field_Any:
+		return jsonAnySchema(t.Any), nil
+
+	case *schema_j5pb.Field_String_:
+		return jsonStringSchema(t.String_), nil
+
+	case *schema_j5pb.Field_Key:
+		return jsonKeySchema(t.Key), nil
+
+	case *schema_j5pb.Field_Bytes:
+		return &JSONSchema{
+			Type:            "string",
+			ContentEncoding: "base64",
+		}, nil
+
+	case *schema_j5pb.Field_Decimal:
+		return &JSONSchema{
+			Type:     "string",
+			Format:   "decimal",
+			Pattern:  decimalPattern,
+			Examples: []any{"12.34"},
+		}, nil
+
+	case *schema_j5pb.Field_Date:
+		return &JSONSchema{
+			Type:     "string",
+			Format:   "date",
+			Examples: []any{"2021-01-01"},
+		}, nil
+
+	case *schema_j5pb.Field_Timestamp:
+		return &JSONSchema{
+			Type:   "string",
+			Format: "date-time",
+		}, nil
+
+	case *schema_j5pb.Field_Integer:
+		return jsonIntegerSchema(t.Integer), nil
+
+	case *schema_j5pb.Field_Float:
+		return jsonFloatSchema(t.Float), nil
+
+	case *schema_j5pb.Field_Bool:
+		out := &JSONSchema{
+			Type: "boolean",
+		}
+		if rules := t.Bool.GetRules(); rules != nil && rules.Const != nil {
+			out.Const = *rules.Const
+		}
+		return out, nil
+
+	case *schema_j5pb.Field_Array:
+		return jsonArraySchema(t.Array)
+
+	case *schema_j5pb.Field_Map:
+		return jsonMapSchema(t.Map)
+
+	case *schema_j5pb.Field_Enum:
+		var out *JSONSchema
+		switch st := t.Enum.Schema.(type) {
+		case *schema_j5pb.EnumField_Enum:
+			out = jsonEnumSchema(st.Enum)
+		case *schema_j5pb.EnumField_Ref:
+			out = jsonRef(st.Ref)
+		default:
+			return nil, fmt.Errorf("unknown enum schema type for json schema %T", st)
+		}
+		if rules := t.Enum.GetRules(); rules != nil {
+			if len(rules.In) > 0 {
+				out.Enum = rules.In
+			}
+			if len(rules.NotIn) > 0 {
+				out.Not = &JSONSchema{
+					Enum: rules.NotIn,
+				}
+			}
+		}
+		return out, nil
+
+	case *schema_j5pb.Field_Object:
+		var out *JSONSchema
+		switch st := t.Object.Schema.(type) {
+		case *schema_j5pb.ObjectField_Object:
+			var err error
+			out, err = jsonObjectSchema(st.Object)
+			if err != nil {
+				return nil, err
+			}
+		case *schema_j5pb.ObjectField_Ref:
+			out = jsonRef(st.Ref)
+		default:
+			return nil, fmt.Errorf("unknown object schema type for json schema %T", st)
+		}
+		if rules := t.Object.GetRules(); rules != nil {
+			out.MinProperties = rules.MinProperties
+			out.MaxProperties = rules.MaxProperties
+		}
+		return out, nil
+
+	case *schema_j5pb.Field_Oneof:
+		switch st := t.Oneof.Schema.(type) {
+		case *schema_j5pb.OneofField_Oneof:
+			return jsonOneofSchema(st.Oneof)
+		case *schema_j5pb.OneofField_Ref:
+			return jsonRef(st.Ref), nil
+		default:
+			return nil, fmt.Errorf("unknown oneof schema type for json schema %T", st)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown schema type for json schema %T", t)
+	}
+}
+
+// decimalPattern matches the string encoding of decimal values.
+const decimalPattern = "^-?[0-9]+(\\.[0-9]+)?$"
+
+func jsonStringSchema(item *schema_j5pb.StringField) *JSONSchema {
+	out := &JSONSchema{
+		Type: "string",
+	}
+	if format := item.GetFormat(); format != "" {
+		out.Format = format
+		if example := stringExample(&format); example != nil {
+			out.Examples = []any{*example}
+		}
+	}
+	if rules := item.GetRules(); rules != nil {
+		out.Pattern = rules.GetPattern()
+		out.MinLength = rules.MinLength
+		out.MaxLength = rules.MaxLength
+	}
+	return out
+}
+
+func jsonKeySchema(item *schema_j5pb.KeyField) *JSONSchema {
+	out := &JSONSchema{
+		Type: "string",
+	}
+
+	switch ft := item.GetFormat().GetType().(type) {
+	case *schema_j5pb.KeyFormat_Uuid:
+		out.Format = "uuid"
+		out.Examples = []any{quickUUID()}
+	case *schema_j5pb.KeyFormat_Id62:
+		out.Format = "id62"
+		out.Pattern = id62Pattern
+	case *schema_j5pb.KeyFormat_Custom_:
+		out.Pattern = ft.Custom.Pattern
+	}
+
+	return out
+}
+
+// jsonIntegerPatterns are the patterns of 64 bit integers, which the codec
+// encodes as strings. The numeric rules can't be expressed on the string, so
+// are only enforced when decoding.
+var jsonIntegerPatterns = map[schema_j5pb.IntegerField_Format]string{
+	schema_j5pb.IntegerField_FORMAT_INT64:  "^-?[0-9]+$",
+	schema_j5pb.IntegerField_FORMAT_UINT64: "^[0-9]+$",
+}
+
+func jsonIntegerSchema(item *schema_j5pb.IntegerField) *JSONSchema {
+	if pattern, ok := jsonIntegerPatterns[item.GetFormat()]; ok {
+		return &JSONSchema{
+			Type:    "string",
+			Format:  integerFormats[item.Format],
+			Pattern: pattern,
+		}
+	}
+
+	out := &JSONSchema{
+		Type:   "integer",
+		Format: integerFormats[item.GetFormat()],
+	}
+	if item.GetFormat() == schema_j5pb.IntegerField_FORMAT_UINT32 {
+		out.Minimum = int64(0)
+	}
+
+	if rules := item.GetRules(); rules != nil {
+		if rules.Minimum != nil {
+			if rules.GetExclusiveMinimum() {
+				out.ExclusiveMinimum = *rules.Minimum
+			} else {
+				out.Minimum = *rules.Minimum
+			}
+		}
+		if rules.Maximum != nil {
+			if rules.GetExclusiveMaximum() {
+				out.ExclusiveMaximum = *rules.Maximum
+			} else {
+				out.Maximum = *rules.Maximum
+			}
+		}
+		if rules.MultipleOf != nil {
+			out.MultipleOf = *rules.MultipleOf
+		}
+	}
+
+	return out
+}
+
+func jsonFloatSchema(item *schema_j5pb.FloatField) *JSONSchema {
+	out := &JSONSchema{
+		Type:   "number",
+		Format: floatFormats[item.GetFormat()],
+	}
+
+	if rules := item.GetRules(); rules != nil {
+		if rules.Minimum != nil {
+			if rules.GetExclusiveMinimum() {
+				out.ExclusiveMinimum = *rules.Minimum
+			} else {
+				out.Minimum = *rules.Minimum
+			}
+		}
+		if rules.Maximum != nil {
+			if rules.GetExclusiveMaximum() {
+				out.ExclusiveMaximum = *rules.Maximum
+			} else {
+				out.Maximum = *rules.Maximum
+			}
+		}
+		if rules.MultipleOf != nil {
+			out.MultipleOf = *rules.MultipleOf
+		}
+	}
+
+	return out
+}
+
+func jsonEnumSchema(item *schema_j5pb.Enum) *JSONSchema {
+	out := &JSONSchema{
+		Title:       item.Name,
+		Description: item.Description,
+		Type:        "string",
+	}
+	for _, val := range item.Options {
+		out.Enum = append(out.Enum, val.Name)
+	}
+	return out
+}
+
+// jsonAnySchema describes the any wrapper, the type name alongside the
+// encoded value.
+func jsonAnySchema(item *schema_j5pb.AnyField) *JSONSchema {
+	typeName := &JSONSchema{
+		Type: "string",
+	}
+	if item.GetOnlyDefined() {
+		typeName.Enum = item.GetTypes()
+	}
+	return &JSONSchema{
+		Type: "object",
+		Properties: map[string]*JSONSchema{
+			"!type": typeName,
+			"value": {},
+		},
+		Required: []string{"!type", "value"},
+	}
+}
+
+func jsonArraySchema(item *schema_j5pb.ArrayField) (*JSONSchema, error) {
+	items, err := jsonFieldSchema(item.GetItems())
+	if err != nil {
+		return nil, err
+	}
+
+	out := &JSONSchema{
+		Type:  "array",
+		Items: items,
+	}
+	if rules := item.GetRules(); rules != nil {
+		out.MinItems = rules.MinItems
+		out.MaxItems = rules.MaxItems
+		out.UniqueItems = rules.GetUniqueItems()
+	}
+	return out, nil
+}
+
+func jsonMapSchema(item *schema_j5pb.MapField) (*JSONSchema, error) {
+	values, err := jsonFieldSchema(item.GetItemSchema())
+	if err != nil {
+		return nil, fmt.Errorf("map value: %w", err)
+	}
+
+	out := &JSONSchema{
+		Type:                 "object",
+		AdditionalProperties: values,
+	}
+
+	if item.GetKeySchema() != nil {
+		keys, err := jsonFieldSchema(item.KeySchema)
+		if err != nil {
+			return nil, fmt.Errorf("map key: %w", err)
+		}
+		// Keys are always strings, the type is implied.
+		keys.Type = ""
+		if keys.Pattern != "" || keys.Format != "" || keys.MinLength != nil || keys.MaxLength != nil {
+			out.PropertyNames = keys
+		}
+	}
+
+	if rules := item.GetRules(); rules != nil {
+		out.MinProperties = rules.MinPairs
+		out.MaxProperties = rules.MaxPairs
+	}
+	return out, nil
+}
+
+func jsonProperties(props []*schema_j5pb.ObjectProperty) (map[string]*JSONSchema, error) {
+	out := map[string]*JSONSchema{}
+	for _, prop := range props {
+		schema, err := jsonFieldSchema(prop.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("property '%s': %w", prop.Name, err)
+		}
+		if prop.Description != "" && schema.Description == "" {
+			schema.Description = prop.Description
+		}
+		out[prop.Name] = schema
+	}
+	return out, nil
+}
+
+func jsonObjectSchema(item *schema_j5pb.Object) (*JSONSchema, error) {
+	props, err := jsonProperties(item.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", item.Name, err)
+	}
+
+	out := &JSONSchema{
+		Title:       item.Name,
+		Description: item.Description,
+		Type:        "object",
+		Properties:  props,
+	}
+	for _, prop := range item.Properties {
+		if prop.Required {
+			out.Required = append(out.Required, prop.Name)
+		}
+	}
+	return out, nil
+}
+
+// jsonOneofSchema describes the oneof wrapper, an object with the name of the
+// set property in '!type' and the value under the property name, or an empty
+// object when no property is set.
+func jsonOneofSchema(item *schema_j5pb.Oneof) (*JSONSchema, error) {
+	props, err := jsonProperties(item.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("oneof %s: %w", item.Name, err)
+	}
+
+	typeName := &JSONSchema{
+		Type: "string",
+	}
+	props["!type"] = typeName
+
+	out := &JSONSchema{
+		Title:         item.Name,
+		Description:   item.Description,
+		Type:          "object",
+		Properties:    props,
+		MaxProperties: Ptr(uint64(2)),
+		OneOf: []*JSONSchema{{
+			MaxProperties: Ptr(uint64(0)),
+		}},
+	}
+
+	for _, prop := range item.Properties {
+		typeName.Enum = append(typeName.Enum, prop.Name)
+		out.OneOf = append(out.OneOf, &JSONSchema{
+			Properties: map[string]*JSONSchema{
+				"!type": {Const: prop.Name},
+			},
+			Required: []string{"!type", prop.Name},
+		})
+	}
+
+	return out, nil
+}
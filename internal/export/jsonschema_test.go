@@ -0,0 +1,256 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/pentops/flowtest/jsontest"
+	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+)
+
+func objectRoot(name string, props ...*schema_j5pb.ObjectProperty) *schema_j5pb.RootSchema {
+	return &schema_j5pb.RootSchema{
+		Type: &schema_j5pb.RootSchema_Object{
+			Object: &schema_j5pb.Object{
+				Name:       name,
+				Properties: props,
+			},
+		},
+	}
+}
+
+func testJSONSchemaAPI() *client_j5pb.API {
+	return &client_j5pb.API{
+		Packages: []*client_j5pb.Package{{
+			Name: "test.v1",
+			Schemas: map[string]*schema_j5pb.RootSchema{
+				"Foo": objectRoot("Foo", &schema_j5pb.ObjectProperty{
+					Name:     "id",
+					Required: true,
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Key{
+							Key: &schema_j5pb.KeyField{
+								Format: &schema_j5pb.KeyFormat{
+									Type: &schema_j5pb.KeyFormat_Id62{
+										Id62: &schema_j5pb.KeyFormat_ID62{},
+									},
+								},
+							},
+						},
+					},
+				}, &schema_j5pb.ObjectProperty{
+					Name: "count",
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Integer{
+							Integer: &schema_j5pb.IntegerField{
+								Format: schema_j5pb.IntegerField_FORMAT_INT32,
+								Rules: &schema_j5pb.IntegerField_Rules{
+									Minimum:          Ptr(int64(0)),
+									ExclusiveMinimum: Ptr(true),
+									Maximum:          Ptr(int64(10)),
+								},
+							},
+						},
+					},
+				}, &schema_j5pb.ObjectProperty{
+					Name: "total",
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Integer{
+							Integer: &schema_j5pb.IntegerField{
+								Format: schema_j5pb.IntegerField_FORMAT_INT64,
+							},
+						},
+					},
+				}, &schema_j5pb.ObjectProperty{
+					Name: "amount",
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Decimal{
+							Decimal: &schema_j5pb.DecimalField{},
+						},
+					},
+				}, &schema_j5pb.ObjectProperty{
+					Name: "labels",
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Map{
+							Map: &schema_j5pb.MapField{
+								ItemSchema: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_String_{
+										String_: &schema_j5pb.StringField{},
+									},
+								},
+								KeySchema: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_String_{
+										String_: &schema_j5pb.StringField{
+											Rules: &schema_j5pb.StringField_Rules{
+												Pattern: Ptr("^[a-z]+$"),
+											},
+										},
+									},
+								},
+								Rules: &schema_j5pb.MapField_Rules{
+									MaxPairs: Ptr(uint64(5)),
+								},
+							},
+						},
+					},
+				}, &schema_j5pb.ObjectProperty{
+					Name: "status",
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Enum{
+							Enum: &schema_j5pb.EnumField{
+								Schema: &schema_j5pb.EnumField_Ref{
+									Ref: &schema_j5pb.Ref{
+										Package: "other.v1",
+										Schema:  "Status",
+									},
+								},
+							},
+						},
+					},
+				}, &schema_j5pb.ObjectProperty{
+					Name: "tags",
+					Schema: &schema_j5pb.Field{
+						Type: &schema_j5pb.Field_Array{
+							Array: &schema_j5pb.ArrayField{
+								Items: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_Bytes{
+										Bytes: &schema_j5pb.BytesField{},
+									},
+								},
+								Rules: &schema_j5pb.ArrayField_Rules{
+									MinItems: Ptr(uint64(1)),
+								},
+							},
+						},
+					},
+				}),
+				"Choice": {
+					Type: &schema_j5pb.RootSchema_Oneof{
+						Oneof: &schema_j5pb.Oneof{
+							Name: "Choice",
+							Properties: []*schema_j5pb.ObjectProperty{{
+								Name: "foo",
+								Schema: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_Object{
+										Object: &schema_j5pb.ObjectField{
+											Schema: &schema_j5pb.ObjectField_Ref{
+												Ref: &schema_j5pb.Ref{
+													Package: "test.v1",
+													Schema:  "Foo",
+												},
+											},
+										},
+									},
+								},
+							}, {
+								Name: "when",
+								Schema: &schema_j5pb.Field{
+									Type: &schema_j5pb.Field_Timestamp{
+										Timestamp: &schema_j5pb.TimestampField{},
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+		}, {
+			Name: "other.v1",
+			Schemas: map[string]*schema_j5pb.RootSchema{
+				"Status": {
+					Type: &schema_j5pb.RootSchema_Enum{
+						Enum: &schema_j5pb.Enum{
+							Name:   "Status",
+							Prefix: "STATUS_",
+							Options: []*schema_j5pb.Enum_Option{{
+								Name: "UNSPECIFIED",
+							}, {
+								Name: "ACTIVE",
+							}},
+						},
+					},
+				},
+			},
+		}},
+	}
+}
+
+func TestBuildJSONSchema(t *testing.T) {
+	doc, err := BuildJSONSchema(testJSONSchemaAPI())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := jsontest.NewAsserter(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Print(t)
+
+	out.AssertEqual(t, "$schema", "https://json-schema.org/draft/2020-12/schema")
+
+	foo := "$defs.test\\.v1\\.Foo"
+	out.AssertEqual(t, foo+".type", "object")
+	out.AssertEqual(t, foo+".required", jsontest.Array[string]{"id"})
+
+	out.AssertEqual(t, foo+".properties.id.format", "id62")
+	out.AssertEqual(t, foo+".properties.id.pattern", id62Pattern)
+
+	out.AssertEqual(t, foo+".properties.count.type", "integer")
+	out.AssertEqual(t, foo+".properties.count.exclusiveMinimum", 0)
+	out.AssertEqual(t, foo+".properties.count.maximum", 10)
+	out.AssertNotSet(t, foo+".properties.count.minimum")
+
+	// 64 bit integers and decimals are encoded as strings
+	out.AssertEqual(t, foo+".properties.total.type", "string")
+	out.AssertEqual(t, foo+".properties.total.format", "int64")
+	out.AssertEqual(t, foo+".properties.amount.type", "string")
+	out.AssertEqual(t, foo+".properties.amount.format", "decimal")
+
+	out.AssertEqual(t, foo+".properties.labels.type", "object")
+	out.AssertEqual(t, foo+".properties.labels.additionalProperties.type", "string")
+	out.AssertEqual(t, foo+".properties.labels.propertyNames.pattern", "^[a-z]+$")
+	out.AssertEqual(t, foo+".properties.labels.maxProperties", 5)
+
+	out.AssertEqual(t, foo+".properties.status.$ref", "#/$defs/other.v1.Status")
+
+	out.AssertEqual(t, foo+".properties.tags.minItems", 1)
+	out.AssertEqual(t, foo+".properties.tags.items.contentEncoding", "base64")
+
+	choice := "$defs.test\\.v1\\.Choice"
+	out.AssertEqual(t, choice+".properties.foo.$ref", "#/$defs/test.v1.Foo")
+	out.AssertEqual(t, choice+".properties.when.format", "date-time")
+	out.AssertEqual(t, choice+".properties.!type.enum", jsontest.Array[string]{"foo", "when"})
+	out.AssertEqual(t, choice+".oneOf.0.maxProperties", 0)
+	out.AssertEqual(t, choice+".oneOf.1.properties.!type.const", "foo")
+	out.AssertEqual(t, choice+".oneOf.1.required", jsontest.Array[string]{"!type", "foo"})
+
+	out.AssertEqual(t, "$defs.other\\.v1\\.Status.enum", jsontest.Array[string]{"UNSPECIFIED", "ACTIVE"})
+}
+
+func TestBuildJSONSchemaPackages(t *testing.T) {
+	api := testJSONSchemaAPI()
+
+	doc, err := BuildJSONSchema(api, "other.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Defs) != 1 || doc.Defs["other.v1.Status"] == nil {
+		t.Errorf("expected only other.v1.Status, got %v", doc.Defs)
+	}
+
+	// Referenced schemas from other packages are included
+	doc, err = BuildJSONSchema(api, "test.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"test.v1.Foo", "test.v1.Choice", "other.v1.Status"} {
+		if doc.Defs[name] == nil {
+			t.Errorf("missing %s", name)
+		}
+	}
+
+	if _, err := BuildJSONSchema(api, "missing.v1"); err == nil {
+		t.Error("expected error for missing package")
+	}
+}
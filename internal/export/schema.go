@@ -3,6 +3,7 @@ package export
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // Schema is a JSON Schema wrapper for any of the high level types.
@@ -33,6 +34,40 @@ func (s *Schema) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s.SchemaItem)
 }
 
+// rewriteRefs replaces the path prefix of the refs in the schema and all of
+// its children, for documents which keep schemas elsewhere.
+func (s *Schema) rewriteRefs(from, to string) {
+	if s == nil {
+		return
+	}
+	if s.Ref != nil {
+		if rest, ok := strings.CutPrefix(*s.Ref, from); ok {
+			ref := to + rest
+			s.Ref = &ref
+		}
+	}
+	for _, child := range s.OneOf {
+		child.rewriteRefs(from, to)
+	}
+	for _, child := range s.AnyOf {
+		child.rewriteRefs(from, to)
+	}
+	if s.SchemaItem == nil {
+		return
+	}
+	switch st := s.SchemaItem.Type.(type) {
+	case *ObjectItem:
+		for _, prop := range st.Properties {
+			prop.Schema.rewriteRefs(from, to)
+		}
+	case *ArrayItem:
+		st.Items.rewriteRefs(from, to)
+	case *MapSchemaItem:
+		st.ValueProperty.rewriteRefs(from, to)
+		st.KeyProperty.rewriteRefs(from, to)
+	}
+}
+
 type SchemaItem struct {
 	Type SchemaType
 }
@@ -14,6 +14,17 @@ type Document struct {
 	Info       DocumentInfo `json:"info"`
 	Paths      PathSet      `json:"paths"`
 	Components Components   `json:"components"`
+
+	// Events are received by event services, which have no HTTP path.
+	Events []*EventOperation `json:"x-events,omitempty"`
+}
+
+type EventOperation struct {
+	OperationID     string  `json:"operationId"`
+	GrpcServiceName string  `json:"x-grpc-service"`
+	GrpcMethodName  string  `json:"x-grpc-method"`
+	Entity          string  `json:"x-entity"`
+	Schema          *Schema `json:"schema"`
 }
 
 type DocumentInfo struct {
@@ -99,6 +110,18 @@ func (dd *Document) addService(service *client_j5pb.Service) error {
 	return nil
 }
 
+func (dd *Document) addEvents(service *EventService) {
+	for _, method := range service.Methods {
+		dd.Events = append(dd.Events, &EventOperation{
+			OperationID:     method.FullGrpcName,
+			GrpcServiceName: service.Name,
+			GrpcMethodName:  method.Name,
+			Entity:          service.Entity,
+			Schema:          method.schemaRef(),
+		})
+	}
+}
+
 func (dd *Document) addMethod(service *client_j5pb.Service, method *client_j5pb.Method) error {
 
 	operation := &Operation{
@@ -0,0 +1,197 @@
+package j5client
+
+import (
+	"testing"
+
+	"github.com/pentops/j5/gen/j5/ext/v1/ext_j5pb"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
+	"github.com/pentops/j5build/internal/structure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func psmMessage(name string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	msg := &descriptorpb.DescriptorProto{
+		Name:    proto.String(name),
+		Options: &descriptorpb.MessageOptions{},
+		Field:   fields,
+	}
+	proto.SetExtension(msg.Options, ext_j5pb.E_Psm, &ext_j5pb.PSMOptions{
+		EntityName: "foo",
+	})
+	return msg
+}
+
+func eventsSourceAPI(t *testing.T) *structure.API {
+	t.Helper()
+	keysField := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("keys"),
+		JsonName: proto.String("keys"),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		Number:   proto.Int32(1),
+		TypeName: proto.String(".test.v1.FooKeys"),
+	}
+
+	img := &source_j5pb.SourceImage{
+		Packages: []*source_j5pb.PackageInfo{{
+			Name: "test.v1",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto), {
+				Syntax:  proto.String("proto3"),
+				Name:    proto.String("test/v1/test.proto"),
+				Package: proto.String("test.v1"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					psmMessage("FooKeys", &descriptorpb.FieldDescriptorProto{
+						Name:     proto.String("foo_id"),
+						JsonName: proto.String("fooId"),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Number:   proto.Int32(1),
+					}),
+					psmMessage("FooState", keysField),
+					psmMessage("FooEvent", keysField, &descriptorpb.FieldDescriptorProto{
+						Name:     proto.String("event"),
+						JsonName: proto.String("event"),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						Number:   proto.Int32(2),
+						TypeName: proto.String(".test.v1.FooEventType"),
+					}), {
+						Name: proto.String("FooEventType"),
+						Field: []*descriptorpb.FieldDescriptorProto{{
+							Name:       proto.String("created"),
+							JsonName:   proto.String("created"),
+							Type:       descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+							Number:     proto.Int32(1),
+							TypeName:   proto.String(".test.v1.FooEventType.Created"),
+							OneofIndex: proto.Int32(0),
+						}},
+						OneofDecl: []*descriptorpb.OneofDescriptorProto{{
+							Name: proto.String("type"),
+						}},
+						NestedType: []*descriptorpb.DescriptorProto{{
+							Name: proto.String("Created"),
+						}},
+					},
+				},
+			}, {
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("test/v1/topic/events.proto"),
+				Package:    proto.String("test.v1.topic"),
+				Dependency: []string{"google/protobuf/empty.proto", "test/v1/test.proto"},
+				Service: []*descriptorpb.ServiceDescriptorProto{{
+					Name: proto.String("FooEvents"),
+					Method: []*descriptorpb.MethodDescriptorProto{{
+						Name:       proto.String("Event"),
+						InputType:  proto.String(".test.v1.FooEvent"),
+						OutputType: proto.String(".google.protobuf.Empty"),
+					}},
+				}},
+			}},
+	}
+
+	sourceAPI, err := structure.APIFromImage(img)
+	if err != nil {
+		t.Fatalf("APIFromImage: %v", err)
+	}
+	return sourceAPI
+}
+
+func apiBase(t *testing.T, sourceAPI *structure.API) (*API, error) {
+	t.Helper()
+	schemaSet, err := j5schema.PackageSetFromSourceAPI(sourceAPI.Source.Packages)
+	if err != nil {
+		t.Fatalf("PackageSetFromSourceAPI: %v", err)
+	}
+
+	sb := &sourceBuilder{
+		schemas: schemaSet,
+	}
+	return sb.apiBaseFromSource(sourceAPI)
+}
+
+func TestEventServices(t *testing.T) {
+	api, err := apiBase(t, eventsSourceAPI(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entities := api.Packages[0].StateEntities
+	if len(entities) != 1 {
+		t.Fatalf("expected 1 entity, got %d", len(entities))
+	}
+	foo := entities[0]
+
+	if len(foo.EventServices) != 1 {
+		t.Fatalf("expected 1 event service, got %d", len(foo.EventServices))
+	}
+	service := foo.EventServices[0]
+	if service.Name != "FooEvents" || service.Entity != foo {
+		t.Errorf("unexpected service %q", service.Name)
+	}
+	if len(service.Methods) != 1 {
+		t.Fatalf("expected 1 method, got %d", len(service.Methods))
+	}
+	if service.Methods[0].Schema != foo.EventSchema {
+		t.Errorf("method schema %s is not the entity event", service.Methods[0].Schema.FullName())
+	}
+
+	t.Run("Not Event", func(t *testing.T) {
+		sourceAPI := eventsSourceAPI(t)
+		sourceAPI.Events[0].Messages[0].Schema = "test.v1.FooState"
+
+		_, err := apiBase(t, sourceAPI)
+		if err == nil {
+			t.Fatal("expected error for a method which is not the entity event")
+		}
+		t.Log(err.Error())
+	})
+
+	t.Run("Topic Named Events", func(t *testing.T) {
+		// Only services classified as events are linked to the entity, a
+		// topic is not, whatever the name.
+		sourceAPI := eventsSourceAPI(t)
+		subPkg := sourceAPI.Source.Packages[0].SubPackages[0]
+		subPkg.Topics = append(subPkg.Topics, &source_j5pb.Topic{
+			Name: "BarEvents",
+			Messages: []*source_j5pb.TopicMessage{{
+				Name:         "Bar",
+				Schema:       "BarMessage",
+				FullGrpcName: "test.v1.topic.BarEvents.Bar",
+			}},
+		})
+
+		api, err := apiBase(t, sourceAPI)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(api.Packages[0].StateEntities[0].EventServices); got != 1 {
+			t.Errorf("expected 1 event service, got %d", got)
+		}
+	})
+}
+
+func TestAPIFromSourceEvents(t *testing.T) {
+	clientAPI, err := APIFromSource(eventsSourceAPI(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The event is referenced by the service, so is in the client schemas.
+	if _, ok := clientAPI.Packages[0].Schemas["FooEvent"]; !ok {
+		t.Errorf("FooEvent missing from client schemas")
+	}
+
+	t.Run("Unknown Entity", func(t *testing.T) {
+		sourceAPI := eventsSourceAPI(t)
+		sourceAPI.Events[0].EntityName = "bar"
+
+		_, err := APIFromSource(sourceAPI)
+		if err == nil {
+			t.Fatal("expected error for an unknown entity")
+		}
+		t.Log(err.Error())
+	})
+}
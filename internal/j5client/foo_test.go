@@ -35,7 +35,7 @@ func TestFooSchema(t *testing.T) {
 		t.Fatalf("APIFromImage: %v", err)
 	}
 
-	for _, pkg := range sourceAPI.Packages {
+	for _, pkg := range sourceAPI.Source.Packages {
 		t.Logf("Package: %s", pkg.Name)
 		for name := range pkg.Schemas {
 			t.Logf("Schema: %s", name)
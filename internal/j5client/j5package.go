@@ -212,10 +212,11 @@ func (mm *Method) ToJ5Proto() (*client_j5pb.Method, error) {
 }
 
 type StateEntity struct {
-	Package  *Package // parent
-	Name     string
-	Commands []*Service
-	Query    *Service
+	Package       *Package // parent
+	Name          string
+	Commands      []*Service
+	Query         *Service
+	EventServices []*EventService
 
 	KeysSchema  *j5schema.ObjectSchema
 	StateSchema *j5schema.ObjectSchema
@@ -304,6 +305,20 @@ func (entity *StateEntity) ToJ5Proto() (*client_j5pb.StateEntity, error) {
 
 }
 
+// EventService is a message only service which receives the events of a
+// state entity.
+type EventService struct {
+	Entity  *StateEntity // parent
+	Name    string
+	Methods []*EventMethod
+}
+
+type EventMethod struct {
+	Name         string
+	FullGrpcName string
+	Schema       *j5schema.ObjectSchema
+}
+
 type StateEvent struct {
 	StateEntity *StateEntity // parent
 	Name        string
@@ -483,6 +498,14 @@ func collectPackageRefs(api *API) (map[string]*schemaRef, error) {
 					}
 				}
 			}
+
+			for _, eventService := range entity.EventServices {
+				for _, method := range eventService.Methods {
+					if err := includeRootObject(method.Schema); err != nil {
+						return nil, fmt.Errorf("event method %q: %w", method.FullGrpcName, err)
+					}
+				}
+			}
 		}
 
 		for _, exported := range pkg.Exported {
@@ -9,10 +9,11 @@ import (
 	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
 	"github.com/pentops/j5/lib/j5schema"
 	"github.com/pentops/j5/lib/patherr"
+	"github.com/pentops/j5build/internal/structure"
 )
 
-func APIFromSource(api *source_j5pb.API) (*client_j5pb.API, error) {
-	schemaSet, err := j5schema.PackageSetFromSourceAPI(api.Packages)
+func APIFromSource(api *structure.API) (*client_j5pb.API, error) {
+	schemaSet, err := j5schema.PackageSetFromSourceAPI(api.Source.Packages)
 	if err != nil {
 		return nil, fmt.Errorf("package set from source api: %w", err)
 	}
@@ -33,13 +34,13 @@ type sourceBuilder struct {
 	schemas *j5schema.SchemaSet
 }
 
-func (sb *sourceBuilder) apiBaseFromSource(api *source_j5pb.API) (*API, error) {
+func (sb *sourceBuilder) apiBaseFromSource(api *structure.API) (*API, error) {
 	apiPkg := &API{
 		Packages: []*Package{},
 		Metadata: &client_j5pb.Metadata{},
 	}
 
-	for _, pkgSource := range api.Packages {
+	for _, pkgSource := range api.Source.Packages {
 		pkg := &Package{
 			Name:          pkgSource.Name,
 			Label:         pkgSource.Label,
@@ -93,11 +94,30 @@ func (sb *sourceBuilder) apiBaseFromSource(api *source_j5pb.API) (*API, error) {
 
 				pkg.Services = append(pkg.Services, service)
 			}
-
 		}
 
 	}
 
+	for _, eventsSrc := range api.Events {
+		var pkg *Package
+		for _, search := range apiPkg.Packages {
+			if search.Name == eventsSrc.Package {
+				pkg = search
+				break
+			}
+		}
+		if pkg == nil {
+			return nil, fmt.Errorf("events service %q: unknown package %q", eventsSrc.Name, eventsSrc.Package)
+		}
+		sub := &subPackage{
+			Package: pkg,
+			Name:    eventsSrc.SubPackage,
+		}
+		if err := sb.eventsFromSource(sub, eventsSrc); err != nil {
+			return nil, patherr.Wrap(err, pkg.Name, eventsSrc.Name)
+		}
+	}
+
 	return apiPkg, nil
 }
 
@@ -228,6 +248,45 @@ func (sb *sourceBuilder) serviceFromSource(pkg *subPackage, src *source_j5pb.Ser
 	return service, nil
 }
 
+// eventsFromSource links an events service to the state entity of its event
+// messages.
+func (sb *sourceBuilder) eventsFromSource(pkg *subPackage, src *structure.EventService) error {
+	entity, err := getEntity(pkg, src.EntityName)
+	if err != nil {
+		return err
+	}
+
+	service := &EventService{
+		Entity:  entity,
+		Name:    src.Name,
+		Methods: make([]*EventMethod, 0, len(src.Messages)),
+	}
+
+	for _, msg := range src.Messages {
+		idx := strings.LastIndex(msg.Schema, ".")
+		if idx < 0 {
+			return fmt.Errorf("event schema %q is not a full name", msg.Schema)
+		}
+		schema, err := sb.schemas.SchemaByName(msg.Schema[:idx], msg.Schema[idx+1:])
+		if err != nil {
+			return patherr.Wrap(err, msg.Name)
+		}
+		obj, ok := schema.(*j5schema.ObjectSchema)
+		if !ok || entity.EventSchema != obj {
+			return fmt.Errorf("method %q: schema %q is not the event of entity %q", msg.Name, msg.Schema, entity.Name)
+		}
+
+		service.Methods = append(service.Methods, &EventMethod{
+			Name:         msg.Name,
+			FullGrpcName: msg.FullGrpcName,
+			Schema:       obj,
+		})
+	}
+
+	entity.EventServices = append(entity.EventServices, service)
+	return nil
+}
+
 func (sb *sourceBuilder) methodFromSource(pkg *subPackage, service *Service, src *source_j5pb.Method) (*Method, error) {
 
 	requestSchema, err := sb.schemas.SchemaByName(pkg.FullName(), src.RequestSchema)
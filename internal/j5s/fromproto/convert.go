@@ -0,0 +1,621 @@
+package fromproto
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pentops/j5/gen/j5/ext/v1/ext_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"github.com/pentops/j5build/internal/j5s/j5convert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// File is a j5s file converted from proto files.
+type File struct {
+	// Filename is the path of the j5s file, in the package directory.
+	Filename string
+	Source   *sourcedef_j5pb.SourceFile
+	// Content is the formatted j5s, set once the file is verified.
+	Content string
+	// Replaces lists the proto files the j5s file was converted from.
+	Replaces []string
+}
+
+// fileGroup is the set of proto files which become one j5s file, j5s puts
+// services and topics into sub-packages, so foo/v1/foo.proto,
+// foo/v1/service/foo.proto and foo/v1/topic/foo.proto all become
+// foo/v1/foo.j5s.
+type fileGroup struct {
+	base    string
+	root    protoreflect.FileDescriptor
+	service protoreflect.FileDescriptor
+	topic   protoreflect.FileDescriptor
+}
+
+func (fg *fileGroup) files() []protoreflect.FileDescriptor {
+	files := make([]protoreflect.FileDescriptor, 0, 3)
+	for _, file := range []protoreflect.FileDescriptor{fg.root, fg.service, fg.topic} {
+		if file != nil {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+type converter struct {
+	pkgName string
+	schemas *j5schema.SchemaCache
+
+	// inlined marks the nested messages and enums which were written inline
+	// in the field which uses them, j5s can't reference nested types so all
+	// others are an error.
+	inlined map[protoreflect.FullName]bool
+
+	// consumed marks the messages which were converted as part of a service,
+	// topic or entity rather than as objects.
+	consumed map[protoreflect.FullName]bool
+
+	entities []*entityParts
+}
+
+// ConvertPackage converts the proto files of a package into j5s files. Files
+// generated from j5s (.j5s.proto) are skipped, the remaining files must be
+// hand-written protos in the package directory, or the service and topic
+// sub-packages.
+func ConvertPackage(pkgName string, files []protoreflect.FileDescriptor) ([]*File, error) {
+	cc := &converter{
+		pkgName:  pkgName,
+		schemas:  j5schema.NewSchemaCache(),
+		inlined:  map[protoreflect.FullName]bool{},
+		consumed: map[protoreflect.FullName]bool{},
+	}
+
+	groups, err := cc.groupFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	cc.entities = findEntities(pkgName, groups)
+
+	out := make([]*File, 0, len(groups))
+	for _, group := range groups {
+		file, err := cc.convertGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, file)
+	}
+
+	for _, group := range groups {
+		for _, file := range group.files() {
+			if err := cc.checkNestedInlined(file.Messages(), file.Enums()); err != nil {
+				return nil, fmt.Errorf("in %s: %w", file.Path(), err)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+func (cc *converter) groupFiles(files []protoreflect.FileDescriptor) ([]*fileGroup, error) {
+	groups := map[string]*fileGroup{}
+	for _, file := range files {
+		filename := file.Path()
+		if strings.HasSuffix(filename, ".j5s.proto") || !strings.HasSuffix(filename, ".proto") {
+			continue
+		}
+		pkg, sub, err := j5convert.SplitPackageFromFilename(filename)
+		if err != nil {
+			return nil, err
+		}
+		if pkg != cc.pkgName {
+			continue
+		}
+
+		if dirPackage := j5convert.PackageFromFilename(filename); string(file.Package()) != dirPackage {
+			return nil, fmt.Errorf("file %s has package %s, j5s requires package %s to match the directory", filename, file.Package(), dirPackage)
+		}
+
+		base := strings.TrimSuffix(path.Base(filename), ".proto")
+		group, ok := groups[base]
+		if !ok {
+			group = &fileGroup{base: base}
+			groups[base] = group
+		}
+
+		switch sub {
+		case "":
+			group.root = file
+		case "service":
+			group.service = file
+		case "topic":
+			group.topic = file
+		default:
+			return nil, fmt.Errorf("file %s is in sub-package %q, j5s only generates service and topic sub-packages", filename, sub)
+		}
+	}
+
+	out := make([]*fileGroup, 0, len(groups))
+	for _, group := range groups {
+		out = append(out, group)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].base < out[j].base
+	})
+	return out, nil
+}
+
+func (cc *converter) convertGroup(group *fileGroup) (*File, error) {
+	pkgDir := strings.ReplaceAll(cc.pkgName, ".", "/")
+	filename := path.Join(pkgDir, group.base+".j5s")
+
+	fc := &fileConverter{
+		converter: cc,
+		imports:   map[string]struct{}{},
+	}
+
+	out := &File{
+		Filename: filename,
+		Source: &sourcedef_j5pb.SourceFile{
+			Path: filename,
+			Package: &sourcedef_j5pb.Package{
+				Name: cc.pkgName,
+			},
+		},
+	}
+
+	for _, file := range group.files() {
+		out.Replaces = append(out.Replaces, file.Path())
+	}
+
+	var elements []*sourcedef_j5pb.RootElement
+	var err error
+	if group.root != nil {
+		elements, err = fc.rootElements(group.root)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %w", group.root.Path(), err)
+		}
+		out.Source.Elements = append(out.Source.Elements, elements...)
+	}
+
+	if group.service != nil {
+		elements, err = fc.serviceElements(group.service)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %w", group.service.Path(), err)
+		}
+		out.Source.Elements = append(out.Source.Elements, elements...)
+	}
+
+	if group.topic != nil {
+		elements, err = fc.topicElements(group.topic)
+		if err != nil {
+			return nil, fmt.Errorf("in %s: %w", group.topic.Path(), err)
+		}
+		out.Source.Elements = append(out.Source.Elements, elements...)
+	}
+
+	imports := make([]string, 0, len(fc.imports))
+	for imp := range fc.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		out.Source.Imports = append(out.Source.Imports, &sourcedef_j5pb.Import{
+			Path: imp,
+		})
+	}
+
+	return out, nil
+}
+
+// checkNestedInlined returns an error for nested types which were not written
+// inline in the field using them.
+func (cc *converter) checkNestedInlined(messages protoreflect.MessageDescriptors, enums protoreflect.EnumDescriptors) error {
+	for ii := 0; ii < messages.Len(); ii++ {
+		msg := messages.Get(ii)
+		if msg.IsMapEntry() {
+			continue
+		}
+		if _, isNested := msg.Parent().(protoreflect.MessageDescriptor); isNested && !cc.inlined[msg.FullName()] {
+			return fmt.Errorf("nested message %s is not used by a field of its parent, j5s can only declare nested types inline", msg.FullName())
+		}
+		if err := cc.checkNestedInlined(msg.Messages(), msg.Enums()); err != nil {
+			return err
+		}
+	}
+	for ii := 0; ii < enums.Len(); ii++ {
+		enum := enums.Get(ii)
+		if _, isNested := enum.Parent().(protoreflect.MessageDescriptor); isNested && !cc.inlined[enum.FullName()] {
+			return fmt.Errorf("nested enum %s is not used by a field of its parent, j5s can only declare nested types inline", enum.FullName())
+		}
+	}
+	return nil
+}
+
+type fileConverter struct {
+	*converter
+	imports map[string]struct{}
+}
+
+// rootElements converts the messages and enums of the file, in the order
+// they are declared.
+func (fc *fileConverter) rootElements(file protoreflect.FileDescriptor) ([]*sourcedef_j5pb.RootElement, error) {
+	descriptors := make([]protoreflect.Descriptor, 0, file.Messages().Len()+file.Enums().Len())
+	for ii := 0; ii < file.Messages().Len(); ii++ {
+		descriptors = append(descriptors, file.Messages().Get(ii))
+	}
+	for ii := 0; ii < file.Enums().Len(); ii++ {
+		descriptors = append(descriptors, file.Enums().Get(ii))
+	}
+	sortBySource(descriptors)
+
+	elements := make([]*sourcedef_j5pb.RootElement, 0, len(descriptors))
+	for _, desc := range descriptors {
+		if entity := fc.entityFor(desc); entity != nil {
+			if entity.converted {
+				continue
+			}
+			element, err := fc.entityElement(entity)
+			if err != nil {
+				return nil, fmt.Errorf("entity %s: %w", entity.name, err)
+			}
+			elements = append(elements, element)
+			continue
+		}
+
+		switch desc := desc.(type) {
+		case protoreflect.MessageDescriptor:
+			element, err := fc.messageElement(desc)
+			if err != nil {
+				return nil, fmt.Errorf("message %s: %w", desc.Name(), err)
+			}
+			elements = append(elements, element)
+
+		case protoreflect.EnumDescriptor:
+			enum, err := fc.enumSchema(desc)
+			if err != nil {
+				return nil, fmt.Errorf("enum %s: %w", desc.Name(), err)
+			}
+			elements = append(elements, &sourcedef_j5pb.RootElement{
+				Type: &sourcedef_j5pb.RootElement_Enum{
+					Enum: enum,
+				},
+			})
+		}
+	}
+	return elements, nil
+}
+
+func sortBySource(descriptors []protoreflect.Descriptor) {
+	sort.SliceStable(descriptors, func(i, j int) bool {
+		li := descriptors[i].ParentFile().SourceLocations().ByDescriptor(descriptors[i])
+		lj := descriptors[j].ParentFile().SourceLocations().ByDescriptor(descriptors[j])
+		return li.StartLine < lj.StartLine
+	})
+}
+
+func (fc *fileConverter) messageElement(msg protoreflect.MessageDescriptor) (*sourcedef_j5pb.RootElement, error) {
+	root, err := fc.schemas.Schema(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch st := root.ToJ5Root().Type.(type) {
+	case *schema_j5pb.RootSchema_Object:
+		obj, err := fc.object(msg, st.Object)
+		if err != nil {
+			return nil, err
+		}
+		if obj.Entity != nil && obj.Entity.Entity != strcase.ToCamel(obj.Entity.Entity) {
+			return nil, fmt.Errorf("psm entity %q is not part of an entity j5s can generate, and j5s objects require CamelCase entity names", obj.Entity.Entity)
+		}
+		return &sourcedef_j5pb.RootElement{
+			Type: &sourcedef_j5pb.RootElement_Object{
+				Object: &sourcedef_j5pb.Object{
+					Def: obj,
+				},
+			},
+		}, nil
+
+	case *schema_j5pb.RootSchema_Oneof:
+		oneof, err := fc.oneof(msg, st.Oneof)
+		if err != nil {
+			return nil, err
+		}
+		return &sourcedef_j5pb.RootElement{
+			Type: &sourcedef_j5pb.RootElement_Oneof{
+				Oneof: &sourcedef_j5pb.Oneof{
+					Def: oneof,
+				},
+			},
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported schema type %T", st)
+	}
+}
+
+// objectProperties returns the properties of the message, as they would be
+// written in j5s.
+func (fc *fileConverter) objectProperties(msg protoreflect.MessageDescriptor) ([]*schema_j5pb.ObjectProperty, error) {
+	root, err := fc.schemas.Schema(msg)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := root.ToJ5Root().Type.(*schema_j5pb.RootSchema_Object)
+	if !ok {
+		return nil, fmt.Errorf("message %s is not an object", msg.FullName())
+	}
+	return fc.properties(msg, obj.Object.Properties)
+}
+
+func (fc *fileConverter) object(msg protoreflect.MessageDescriptor, obj *schema_j5pb.Object) (*schema_j5pb.Object, error) {
+	props, err := fc.properties(msg, obj.Properties)
+	if err != nil {
+		return nil, err
+	}
+	obj.Properties = props
+	return obj, nil
+}
+
+func (fc *fileConverter) oneof(msg protoreflect.MessageDescriptor, oneof *schema_j5pb.Oneof) (*schema_j5pb.Oneof, error) {
+	props, err := fc.properties(msg, oneof.Properties)
+	if err != nil {
+		return nil, err
+	}
+	oneof.Properties = props
+	return oneof, nil
+}
+
+func (fc *fileConverter) properties(msg protoreflect.MessageDescriptor, props []*schema_j5pb.ObjectProperty) ([]*schema_j5pb.ObjectProperty, error) {
+	for _, prop := range props {
+		if len(prop.ProtoField) != 1 {
+			return nil, fmt.Errorf("property %s is nested in a proto oneof, which j5s can't declare", prop.Name)
+		}
+		prop.ProtoField = nil
+		if err := fc.field(msg, prop.Name, prop.Schema); err != nil {
+			return nil, fmt.Errorf("property %s: %w", prop.Name, err)
+		}
+	}
+	return props, nil
+}
+
+// field converts references in the field, from the j5schema form to the
+// j5s form: local types drop the package, other packages are imported, and
+// nested types are declared inline.
+func (fc *fileConverter) field(msg protoreflect.MessageDescriptor, propName string, field *schema_j5pb.Field) error {
+	switch ft := field.Type.(type) {
+	case *schema_j5pb.Field_Array:
+		// j5s always sets the array extension, an empty one says nothing.
+		if ft.Array.Ext != nil && proto.Size(ft.Array.Ext) == 0 {
+			ft.Array.Ext = nil
+		}
+		return fc.field(msg, propName, ft.Array.Items)
+
+	case *schema_j5pb.Field_Map:
+		return fc.field(msg, propName, ft.Map.ItemSchema)
+
+	case *schema_j5pb.Field_Object:
+		ref := ft.Object.GetRef()
+		if ref == nil {
+			return nil
+		}
+		nested, err := fc.ref(msg, ref)
+		if err != nil || nested == nil {
+			return err
+		}
+		nestedMsg, ok := nested.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("%s is not a message", nested.FullName())
+		}
+		root, err := fc.schemas.Schema(nestedMsg)
+		if err != nil {
+			return err
+		}
+		obj, ok := root.ToJ5Root().Type.(*schema_j5pb.RootSchema_Object)
+		if !ok {
+			return fmt.Errorf("%s is not an object", nested.FullName())
+		}
+		inline, err := fc.object(nestedMsg, obj.Object)
+		if err != nil {
+			return err
+		}
+		inline.Name = inlineName(propName, string(nested.Name()))
+		ft.Object.Schema = &schema_j5pb.ObjectField_Object{
+			Object: inline,
+		}
+
+	case *schema_j5pb.Field_Oneof:
+		ref := ft.Oneof.GetRef()
+		if ref == nil {
+			return nil
+		}
+		nested, err := fc.ref(msg, ref)
+		if err != nil || nested == nil {
+			return err
+		}
+		nestedMsg, ok := nested.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("%s is not a message", nested.FullName())
+		}
+		root, err := fc.schemas.Schema(nestedMsg)
+		if err != nil {
+			return err
+		}
+		oneof, ok := root.ToJ5Root().Type.(*schema_j5pb.RootSchema_Oneof)
+		if !ok {
+			return fmt.Errorf("%s is not a oneof", nested.FullName())
+		}
+		inline, err := fc.oneof(nestedMsg, oneof.Oneof)
+		if err != nil {
+			return err
+		}
+		inline.Name = inlineName(propName, string(nested.Name()))
+		ft.Oneof.Schema = &schema_j5pb.OneofField_Oneof{
+			Oneof: inline,
+		}
+
+	case *schema_j5pb.Field_Enum:
+		ref := ft.Enum.GetRef()
+		if ref == nil {
+			return nil
+		}
+		nested, err := fc.ref(msg, ref)
+		if err != nil || nested == nil {
+			return err
+		}
+		nestedEnum, ok := nested.(protoreflect.EnumDescriptor)
+		if !ok {
+			return fmt.Errorf("%s is not an enum", nested.FullName())
+		}
+		inline, err := fc.enumSchema(nestedEnum)
+		if err != nil {
+			return err
+		}
+		inline.Name = inlineName(propName, string(nested.Name()))
+		ft.Enum.Schema = &schema_j5pb.EnumField_Enum{
+			Enum: inline,
+		}
+	}
+	return nil
+}
+
+// inlineName returns the name to set on an inline type, which is blank when it
+// matches the default derived from the property name.
+func inlineName(propName string, name string) string {
+	if name == strcase.ToCamel(propName) {
+		return ""
+	}
+	return name
+}
+
+// ref converts the j5schema reference to the j5s form, returning the
+// descriptor when it is a nested type which must be declared inline.
+func (fc *fileConverter) ref(msg protoreflect.MessageDescriptor, ref *schema_j5pb.Ref) (protoreflect.Descriptor, error) {
+	// j5schema names nested types Parent_Child
+	isNested := strings.Contains(ref.Schema, "_")
+	msgPackage, msgName := splitJ5Name(msg)
+
+	if ref.Package == fc.pkgName && !isNested {
+		ref.Package = ""
+		return nil, nil
+	}
+
+	if ref.Package != msgPackage {
+		if ref.Package == fc.pkgName || strings.HasPrefix(ref.Package, fc.pkgName+".") {
+			return nil, fmt.Errorf("%s.%s can't be referenced from j5s, j5s can only use nested types from their parent", ref.Package, ref.Schema)
+		}
+		if !j5convert.IsImplicitImport(ref.Package, ref.Schema) {
+			fc.imports[ref.Package] = struct{}{}
+		}
+		return nil, nil
+	}
+
+	parts := strings.Split(ref.Schema, "_")
+	parentName := strings.Join(parts[:len(parts)-1], "_")
+	if !isNested || parentName != msgName {
+		return nil, fmt.Errorf("%s.%s can't be referenced from j5s, j5s can only use nested types from their parent", ref.Package, ref.Schema)
+	}
+
+	childName := protoreflect.Name(parts[len(parts)-1])
+	var nested protoreflect.Descriptor
+	if nestedMsg := msg.Messages().ByName(childName); nestedMsg != nil {
+		nested = nestedMsg
+	} else if nestedEnum := msg.Enums().ByName(childName); nestedEnum != nil {
+		nested = nestedEnum
+	} else {
+		return nil, fmt.Errorf("nested type %s not found in %s", childName, msg.FullName())
+	}
+
+	if fc.inlined[nested.FullName()] {
+		return nil, fmt.Errorf("nested type %s is used by more than one field, j5s can only declare nested types inline", nested.FullName())
+	}
+	fc.inlined[nested.FullName()] = true
+	return nested, nil
+}
+
+// splitJ5Name returns the package and name of the descriptor, as named by
+// j5schema.
+func splitJ5Name(desc protoreflect.Descriptor) (string, string) {
+	path := []string{}
+	for {
+		path = append([]string{string(desc.Name())}, path...)
+		if file, ok := desc.Parent().(protoreflect.FileDescriptor); ok {
+			return string(file.Package()), strings.Join(path, "_")
+		}
+		desc = desc.Parent()
+	}
+}
+
+// enumSchema converts the enum to the j5s form, where the UNSPECIFIED value is
+// implied, and the prefix is only set when it differs from the default.
+func (fc *fileConverter) enumSchema(enum protoreflect.EnumDescriptor) (*schema_j5pb.Enum, error) {
+	values := enum.Values()
+	unspecified := values.Get(0)
+	prefix, ok := strings.CutSuffix(string(unspecified.Name()), "UNSPECIFIED")
+	if !ok || unspecified.Number() != 0 {
+		return nil, fmt.Errorf("the first value must be *_UNSPECIFIED = 0")
+	}
+
+	out := &schema_j5pb.Enum{
+		Name:        string(enum.Name()),
+		Description: commentDescription(enum),
+	}
+	if prefix != strcase.ToScreamingSnake(out.Name)+"_" {
+		out.Prefix = prefix
+	}
+
+	for ii := 1; ii < values.Len(); ii++ {
+		value := values.Get(ii)
+		name, ok := strings.CutPrefix(string(value.Name()), prefix)
+		if !ok {
+			return nil, fmt.Errorf("value %s does not have the prefix %s", value.Name(), prefix)
+		}
+		option := &schema_j5pb.Enum_Option{
+			Name:        name,
+			Number:      int32(value.Number()),
+			Description: commentDescription(value),
+		}
+		valueExt := proto.GetExtension(value.Options(), ext_j5pb.E_EnumValue).(*ext_j5pb.EnumValueOptions)
+		if valueExt != nil {
+			option.Info = valueExt.Info
+		}
+		out.Options = append(out.Options, option)
+	}
+
+	enumExt := proto.GetExtension(enum.Options(), ext_j5pb.E_Enum).(*ext_j5pb.EnumOptions)
+	if enumExt != nil {
+		for _, info := range enumExt.InfoFields {
+			out.Info = append(out.Info, &schema_j5pb.Enum_OptionInfoField{
+				Name:        info.Name,
+				Label:       info.Label,
+				Description: info.Description,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+// commentDescription reads the leading comment of the descriptor, the same
+// way j5schema reads descriptions.
+func commentDescription(desc protoreflect.Descriptor) string {
+	loc := desc.ParentFile().SourceLocations().ByDescriptor(desc)
+	lines := make([]string, 0)
+	for _, comment := range []string{loc.LeadingComments, loc.TrailingComments} {
+		for _, line := range strings.Split(comment, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
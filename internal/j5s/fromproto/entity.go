@@ -0,0 +1,405 @@
+package fromproto
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pentops/j5/gen/j5/ext/v1/ext_j5pb"
+	"github.com/pentops/j5/gen/j5/messaging/v1/messaging_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// entityParts is the set of proto elements which j5s generates for an entity
+// block, found by the naming convention of the entity.
+type entityParts struct {
+	name    string // the psm entity name, foo_bar
+	camel   string // the j5s entity name, FooBar
+	pkgName string
+
+	keys      protoreflect.MessageDescriptor
+	data      protoreflect.MessageDescriptor
+	state     protoreflect.MessageDescriptor
+	event     protoreflect.MessageDescriptor
+	eventType protoreflect.MessageDescriptor
+	status    protoreflect.EnumDescriptor
+
+	query     protoreflect.ServiceDescriptor
+	commands  []protoreflect.ServiceDescriptor
+	publish   protoreflect.ServiceDescriptor
+	summaries []protoreflect.ServiceDescriptor
+
+	converted bool
+}
+
+func (ep *entityParts) rootParts() []protoreflect.Descriptor {
+	return []protoreflect.Descriptor{ep.keys, ep.data, ep.state, ep.event, ep.eventType, ep.status}
+}
+
+func (ep *entityParts) services() []protoreflect.ServiceDescriptor {
+	services := []protoreflect.ServiceDescriptor{ep.query, ep.publish}
+	services = append(services, ep.commands...)
+	return append(services, ep.summaries...)
+}
+
+// findEntities finds the entities in the package which can be written as an
+// entity block. Entities missing any of the generated parts are left to be
+// converted as plain objects.
+func findEntities(pkgName string, groups []*fileGroup) []*entityParts {
+	entities := []*entityParts{}
+	for _, group := range groups {
+		if group.root == nil || group.service == nil || group.topic == nil {
+			continue
+		}
+
+		names := []string{}
+		seen := map[string]bool{}
+		messages := group.root.Messages()
+		for ii := 0; ii < messages.Len(); ii++ {
+			psm := proto.GetExtension(messages.Get(ii).Options(), ext_j5pb.E_Psm).(*ext_j5pb.PSMOptions)
+			if psm == nil || seen[psm.EntityName] {
+				continue
+			}
+			seen[psm.EntityName] = true
+			names = append(names, psm.EntityName)
+		}
+
+		for _, name := range names {
+			if entity := findEntity(pkgName, name, group); entity != nil {
+				entities = append(entities, entity)
+			}
+		}
+	}
+	return entities
+}
+
+func findEntity(pkgName string, name string, group *fileGroup) *entityParts {
+	camel := strcase.ToCamel(name)
+	if strcase.ToSnake(camel) != name {
+		return nil
+	}
+
+	ep := &entityParts{
+		name:    name,
+		camel:   camel,
+		pkgName: pkgName,
+	}
+
+	entityMessage := func(suffix string, part schema_j5pb.EntityPart) protoreflect.MessageDescriptor {
+		msg := group.root.Messages().ByName(protoreflect.Name(camel + suffix))
+		if msg == nil {
+			return nil
+		}
+		psm := proto.GetExtension(msg.Options(), ext_j5pb.E_Psm).(*ext_j5pb.PSMOptions)
+		if psm == nil || psm.EntityName != name || psm.EntityPart == nil || *psm.EntityPart != part {
+			return nil
+		}
+		return msg
+	}
+
+	ep.keys = entityMessage("Keys", schema_j5pb.EntityPart_KEYS)
+	ep.data = entityMessage("Data", schema_j5pb.EntityPart_DATA)
+	ep.state = entityMessage("State", schema_j5pb.EntityPart_STATE)
+	ep.event = entityMessage("Event", schema_j5pb.EntityPart_EVENT)
+	ep.eventType = group.root.Messages().ByName(protoreflect.Name(camel + "EventType"))
+	ep.status = group.root.Enums().ByName(protoreflect.Name(camel + "Status"))
+	if ep.keys == nil || ep.data == nil || ep.state == nil || ep.event == nil || ep.eventType == nil || ep.status == nil {
+		return nil
+	}
+
+	services := group.service.Services()
+	for ii := 0; ii < services.Len(); ii++ {
+		service := services.Get(ii)
+		serviceExt := proto.GetExtension(service.Options(), ext_j5pb.E_Service).(*ext_j5pb.ServiceOptions)
+		switch st := serviceExt.GetType().(type) {
+		case *ext_j5pb.ServiceOptions_StateQuery_:
+			if st.StateQuery.Entity == name && service.Name() == protoreflect.Name(camel+"QueryService") {
+				ep.query = service
+			}
+		case *ext_j5pb.ServiceOptions_StateCommand_:
+			if st.StateCommand.Entity == name {
+				ep.commands = append(ep.commands, service)
+			}
+		}
+	}
+
+	fullName := pkgName + "." + camel
+	topics := group.topic.Services()
+	for ii := 0; ii < topics.Len(); ii++ {
+		topic := topics.Get(ii)
+		config := proto.GetExtension(topic.Options(), messaging_j5pb.E_Service).(*messaging_j5pb.ServiceConfig)
+		switch role := config.GetRole().(type) {
+		case *messaging_j5pb.ServiceConfig_Event_:
+			if role.Event.EntityName == fullName && topic.Name() == protoreflect.Name(camel+"PublishTopic") {
+				ep.publish = topic
+			}
+		case *messaging_j5pb.ServiceConfig_Upsert_:
+			if role.Upsert.EntityName == fullName {
+				ep.summaries = append(ep.summaries, topic)
+			}
+		}
+	}
+
+	if ep.query == nil || ep.publish == nil {
+		return nil
+	}
+	return ep
+}
+
+// entityFor returns the entity which the root element is part of.
+func (cc *converter) entityFor(desc protoreflect.Descriptor) *entityParts {
+	for _, entity := range cc.entities {
+		for _, part := range entity.rootParts() {
+			if part.FullName() == desc.FullName() {
+				return entity
+			}
+		}
+	}
+	return nil
+}
+
+// isEntityService returns true for services and topics which are generated
+// by an entity block.
+func (cc *converter) isEntityService(service protoreflect.ServiceDescriptor) bool {
+	for _, entity := range cc.entities {
+		for _, part := range entity.services() {
+			if part.FullName() == service.FullName() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (fc *fileConverter) entityElement(ep *entityParts) (*sourcedef_j5pb.RootElement, error) {
+	ep.converted = true
+
+	entity := &sourcedef_j5pb.Entity{
+		Name: ep.camel,
+	}
+
+	baseURLPath, err := entityBaseURLPath(ep)
+	if err != nil {
+		return nil, err
+	}
+	defaultPath := strings.Join(append(strings.Split(ep.pkgName, "."), ep.name), "/")
+	if baseURLPath != defaultPath {
+		entity.BaseUrlPath = baseURLPath
+	}
+
+	shardKeys, err := entityShardKeys(ep)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := fc.objectProperties(ep.keys)
+	if err != nil {
+		return nil, fmt.Errorf("keys: %w", err)
+	}
+	for _, key := range keys {
+		entity.Keys = append(entity.Keys, &sourcedef_j5pb.EntityKey{
+			Def:      key,
+			ShardKey: shardKeys[key.Name],
+		})
+	}
+
+	entity.Data, err = fc.objectProperties(ep.data)
+	if err != nil {
+		return nil, fmt.Errorf("data: %w", err)
+	}
+
+	status, err := fc.enumSchema(ep.status)
+	if err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+	if status.Prefix != "" || len(status.Info) > 0 {
+		return nil, fmt.Errorf("status enum %s must use the default prefix and no info fields", ep.status.Name())
+	}
+	entity.Status = status.Options
+
+	entity.Events, err = fc.entityEvents(ep)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, command := range ep.commands {
+		service, err := fc.entityCommand(ep, baseURLPath, command)
+		if err != nil {
+			return nil, fmt.Errorf("command %s: %w", command.Name(), err)
+		}
+		entity.Commands = append(entity.Commands, service)
+	}
+
+	for _, topic := range ep.summaries {
+		summary, err := fc.entitySummary(ep, topic)
+		if err != nil {
+			return nil, fmt.Errorf("summary %s: %w", topic.Name(), err)
+		}
+		entity.Summaries = append(entity.Summaries, summary)
+	}
+
+	for _, service := range []protoreflect.ServiceDescriptor{ep.query, ep.publish} {
+		methods := service.Methods()
+		for ii := 0; ii < methods.Len(); ii++ {
+			method := methods.Get(ii)
+			fc.consumed[method.Input().FullName()] = true
+			fc.consumed[method.Output().FullName()] = true
+		}
+	}
+
+	return &sourcedef_j5pb.RootElement{
+		Type: &sourcedef_j5pb.RootElement_Entity{
+			Entity: entity,
+		},
+	}, nil
+}
+
+// entityBaseURLPath reads the base path from the query service, which j5s
+// puts at /{baseUrlPath}/q
+func entityBaseURLPath(ep *entityParts) (string, error) {
+	methods := ep.query.Methods()
+	if methods.Len() == 0 {
+		return "", fmt.Errorf("query service %s has no methods", ep.query.Name())
+	}
+	rule, err := httpRule(methods.Get(0))
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(strings.TrimPrefix(rule.path, "/"), "/")
+	for ii := len(parts) - 1; ii > 0; ii-- {
+		if parts[ii] == "q" {
+			return strings.Join(parts[:ii], "/"), nil
+		}
+	}
+	return "", fmt.Errorf("query path %s does not match the j5s entity convention", rule.path)
+}
+
+// entityShardKeys returns the shard keys, which j5s adds to the request of
+// the list method.
+func entityShardKeys(ep *entityParts) (map[string]bool, error) {
+	list := ep.query.Methods().ByName(protoreflect.Name(ep.camel + "List"))
+	if list == nil {
+		return nil, fmt.Errorf("query service %s has no %sList method", ep.query.Name(), ep.camel)
+	}
+	shardKeys := map[string]bool{}
+	fields := list.Input().Fields()
+	for ii := 0; ii < fields.Len(); ii++ {
+		name := fields.Get(ii).JSONName()
+		if name == "page" || name == "query" {
+			continue
+		}
+		shardKeys[name] = true
+	}
+	return shardKeys, nil
+}
+
+// entityEvents converts the nested messages of the event type oneof, which are
+// declared as events in the entity.
+func (fc *fileConverter) entityEvents(ep *entityParts) ([]*sourcedef_j5pb.Object, error) {
+	events := []*sourcedef_j5pb.Object{}
+	fields := ep.eventType.Fields()
+	for ii := 0; ii < fields.Len(); ii++ {
+		field := fields.Get(ii)
+		eventMsg := field.Message()
+		if eventMsg == nil || eventMsg.Parent().FullName() != ep.eventType.FullName() {
+			return nil, fmt.Errorf("event %s must be a message nested in %s", field.Name(), ep.eventType.Name())
+		}
+		if field.JSONName() != strcase.ToLowerCamel(string(eventMsg.Name())) {
+			return nil, fmt.Errorf("event field %s must be named for the event message %s", field.Name(), eventMsg.Name())
+		}
+		fc.inlined[eventMsg.FullName()] = true
+
+		root, err := fc.schemas.Schema(eventMsg)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := root.ToJ5Root().Type.(*schema_j5pb.RootSchema_Object)
+		if !ok {
+			return nil, fmt.Errorf("event %s is not an object", eventMsg.Name())
+		}
+		event, err := fc.object(eventMsg, obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", eventMsg.Name(), err)
+		}
+		event.Name = string(eventMsg.Name())
+		events = append(events, &sourcedef_j5pb.Object{
+			Def: event,
+		})
+	}
+	return events, nil
+}
+
+func (fc *fileConverter) entityCommand(ep *entityParts, baseURLPath string, command protoreflect.ServiceDescriptor) (*sourcedef_j5pb.Service, error) {
+	serviceExt := proto.GetExtension(command.Options(), ext_j5pb.E_Service).(*ext_j5pb.ServiceOptions)
+	expectOptions := &ext_j5pb.ServiceOptions{
+		Type: &ext_j5pb.ServiceOptions_StateCommand_{
+			StateCommand: &ext_j5pb.ServiceOptions_StateCommand{
+				Entity: ep.name,
+			},
+		},
+	}
+	if !proto.Equal(serviceExt, expectOptions) {
+		return nil, fmt.Errorf("entity commands can't set service options other than the state command")
+	}
+
+	// The base path is relative to the entity, and defaults to /c
+	if command.Methods().Len() == 0 {
+		return nil, fmt.Errorf("command has no methods")
+	}
+	rule, err := httpRule(command.Methods().Get(0))
+	if err != nil {
+		return nil, err
+	}
+	relative, ok := strings.CutPrefix(rule.path, "/"+baseURLPath+"/")
+	if !ok {
+		return nil, fmt.Errorf("command paths must start with /%s/", baseURLPath)
+	}
+	basePath, _, _ := strings.Cut(relative, "/")
+
+	service, err := fc.service(command, "/"+baseURLPath+"/"+basePath)
+	if err != nil {
+		return nil, err
+	}
+	service.Options = nil
+
+	if *service.Name == ep.camel+"Command" {
+		service.Name = nil
+	}
+
+	if basePath == "c" {
+		service.BasePath = nil
+	} else {
+		service.BasePath = &basePath
+	}
+
+	return service, nil
+}
+
+func (fc *fileConverter) entitySummary(ep *entityParts, topic protoreflect.ServiceDescriptor) (*sourcedef_j5pb.EntitySummary, error) {
+	name, ok := strings.CutSuffix(string(topic.Name()), "Topic")
+	if !ok {
+		return nil, fmt.Errorf("topic name must end with Topic")
+	}
+
+	summary := &sourcedef_j5pb.EntitySummary{}
+	if name != ep.camel+"Summary" {
+		suffix, ok := strings.CutPrefix(name, ep.camel)
+		if !ok || suffix == "" || strcase.ToCamel(suffix) != suffix {
+			return nil, fmt.Errorf("summary topic names must start with the entity name %s", ep.camel)
+		}
+		summary.Name = suffix
+	}
+
+	methods, err := fc.topicMethods(topic, name, upsertPrepend)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) != 1 || methods[0].Name != nil {
+		return nil, fmt.Errorf("summary topics must have a single method named %s", name)
+	}
+	summary.Fields = methods[0].Fields
+	return summary, nil
+}
@@ -0,0 +1,362 @@
+package fromproto
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+type testFiles struct {
+	files    map[string][]byte
+	packages []string
+}
+
+func (tf *testFiles) GetLocalFile(_ context.Context, filename string) ([]byte, error) {
+	if data, ok := tf.files[filename]; ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("file not found: %s", filename)
+}
+
+func (tf *testFiles) ListPackages() []string {
+	return tf.packages
+}
+
+func (tf *testFiles) ListSourceFiles(_ context.Context, root string) ([]string, error) {
+	files := []string{}
+	for filename := range tf.files {
+		if strings.HasPrefix(filename, root) {
+			files = append(files, filename)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+type noDeps struct{}
+
+func (noDeps) ListDependencyFiles(string) []string { return nil }
+func (noDeps) GetDependencyFile(filename string) (*descriptorpb.FileDescriptorProto, error) {
+	return nil, fmt.Errorf("no dependency %s", filename)
+}
+
+func testPackage(files map[string]string) *testFiles {
+	tf := &testFiles{
+		files:    map[string][]byte{},
+		packages: []string{"test.v1"},
+	}
+	for filename, content := range files {
+		tf.files[filename] = []byte(content)
+	}
+	return tf
+}
+
+func assertContains(t *testing.T, content string, want ...string) {
+	t.Helper()
+	for _, part := range want {
+		if !strings.Contains(content, part) {
+			t.Errorf("missing %q in:\n%s", part, content)
+		}
+	}
+}
+
+const testBaseProto = `syntax = "proto3";
+package test.v1;
+
+import "buf/validate/validate.proto";
+import "google/protobuf/timestamp.proto";
+import "j5/ext/v1/annotations.proto";
+
+// Foo is a thing
+message Foo {
+  option (j5.ext.v1.message).object = {};
+
+  // The ID
+  string id = 1 [
+    (buf.validate.field).required = true,
+    (buf.validate.field).string.uuid = true,
+    (j5.ext.v1.field).key = {}
+  ];
+  Bar bar = 2 [(j5.ext.v1.field).object = {}];
+  repeated string tags = 3 [(j5.ext.v1.field).array = {}];
+  map<string, string> labels = 4 [(j5.ext.v1.field).map = {}];
+  Color color = 5 [
+    (buf.validate.field).enum.defined_only = true,
+    (j5.ext.v1.field).enum = {}
+  ];
+  google.protobuf.Timestamp created = 6 [(j5.ext.v1.field).timestamp = {}];
+  int64 count = 7 [(j5.ext.v1.field).integer = {}];
+  Thing thing = 8 [(j5.ext.v1.field).oneof = {}];
+  optional string maybe = 9 [(j5.ext.v1.field).string = {}];
+
+  message Bar {
+    option (j5.ext.v1.message).object = {};
+    string name = 1 [(j5.ext.v1.field).string = {}];
+  }
+}
+
+enum Color {
+  option (j5.ext.v1.enum).info_fields = {name: "hex", label: "Hex"};
+  COLOR_UNSPECIFIED = 0;
+  // Red things
+  COLOR_RED = 1 [(j5.ext.v1.enum_value).info = {key: "hex", value: "f00"}];
+  COLOR_BLUE = 2;
+}
+
+message Thing {
+  option (j5.ext.v1.message).oneof = {};
+  oneof type {
+    Foo foo = 1 [(j5.ext.v1.field).object = {}];
+    string name = 2 [(j5.ext.v1.field).string = {}];
+  }
+}
+`
+
+const testServiceProto = `syntax = "proto3";
+package test.v1.service;
+
+import "google/api/annotations.proto";
+import "google/api/httpbody.proto";
+import "j5/ext/v1/annotations.proto";
+import "test/v1/foo.proto";
+
+service FooService {
+  // Gets a foo
+  rpc GetFoo(GetFooRequest) returns (GetFooResponse) {
+    option (google.api.http) = {get: "/test/v1/foo/{foo_id}"};
+  }
+  rpc Download(DownloadRequest) returns (google.api.HttpBody) {
+    option (google.api.http) = {post: "/test/v1/download", body: "*"};
+  }
+}
+
+message GetFooRequest {
+  option (j5.ext.v1.message).object = {};
+  string foo_id = 1 [(j5.ext.v1.field).string = {}];
+}
+
+message GetFooResponse {
+  option (j5.ext.v1.message).object = {};
+  test.v1.Foo foo = 1 [(j5.ext.v1.field).object = {}];
+}
+
+message DownloadRequest {
+  option (j5.ext.v1.message).object = {};
+}
+`
+
+const testTopicProto = `syntax = "proto3";
+package test.v1.topic;
+
+import "google/protobuf/empty.proto";
+import "buf/validate/validate.proto";
+import "j5/ext/v1/annotations.proto";
+import "j5/messaging/v1/annotations.proto";
+import "j5/messaging/v1/reqres.proto";
+import "j5/messaging/v1/upsert.proto";
+import "test/v1/foo.proto";
+
+service FooTopic {
+  option (j5.messaging.v1.service) = {
+    topic_name: "foo"
+    publish: {}
+  };
+  rpc Foo(FooMessage) returns (google.protobuf.Empty) {}
+}
+
+service ThingTopic {
+  option (j5.messaging.v1.service) = {
+    topic_name: "thing"
+    upsert: {entity_name: "test.v1.Thing"}
+  };
+  rpc Thing(ThingMessage) returns (google.protobuf.Empty) {}
+}
+
+service WorkRequestTopic {
+  option (j5.messaging.v1.service) = {
+    topic_name: "work"
+    request: {}
+  };
+  rpc WorkRequest(WorkRequestMessage) returns (google.protobuf.Empty) {}
+}
+
+service WorkReplyTopic {
+  option (j5.messaging.v1.service) = {
+    topic_name: "work"
+    reply: {}
+  };
+  rpc WorkReply(WorkReplyMessage) returns (google.protobuf.Empty) {}
+}
+
+message FooMessage {
+  option (j5.ext.v1.message).object = {};
+  test.v1.Foo foo = 1 [(j5.ext.v1.field).object = {}];
+}
+
+message ThingMessage {
+  option (j5.ext.v1.message).object = {};
+  j5.messaging.v1.UpsertMetadata upsert = 1 [(buf.validate.field).required = true, (j5.ext.v1.field).object = {}];
+  string name = 2 [(j5.ext.v1.field).string = {}];
+}
+
+message WorkRequestMessage {
+  option (j5.ext.v1.message).object = {};
+  j5.messaging.v1.RequestMetadata request = 1 [(buf.validate.field).required = true, (j5.ext.v1.field).object = {}];
+  string job = 2 [(j5.ext.v1.field).string = {}];
+}
+
+message WorkReplyMessage {
+  option (j5.ext.v1.message).object = {};
+  j5.messaging.v1.RequestMetadata request = 1 [(buf.validate.field).required = true, (j5.ext.v1.field).object = {}];
+  bool ok = 2 [(j5.ext.v1.field).bool = {}];
+}
+`
+
+func TestConvertLocalPackage(t *testing.T) {
+	tf := testPackage(map[string]string{
+		"test/v1/foo.proto":         testBaseProto,
+		"test/v1/service/foo.proto": testServiceProto,
+		"test/v1/topic/foo.proto":   testTopicProto,
+	})
+
+	files, err := ConvertLocalPackage(context.Background(), noDeps{}, tf, "test.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	file := files[0]
+	t.Log(file.Content)
+
+	if file.Filename != "test/v1/foo.j5s" {
+		t.Errorf("filename: got %s", file.Filename)
+	}
+	wantReplaces := []string{"test/v1/foo.proto", "test/v1/service/foo.proto", "test/v1/topic/foo.proto"}
+	if strings.Join(file.Replaces, ",") != strings.Join(wantReplaces, ",") {
+		t.Errorf("replaces: got %v, want %v", file.Replaces, wantReplaces)
+	}
+
+	assertContains(t, file.Content,
+		"package test.v1\n",
+		"object Foo {\n\t| Foo is a thing\n",
+		"\tfield id key:uuid {\n\t\t| The ID\n\t\trequired = true\n\t}\n",
+		"\tfield bar object {\n\t\tfield name string\n\t}\n",
+		"\tfield tags array:string\n",
+		"\tfield color enum:Color\n",
+		"\tfield count integer:INT64\n",
+		"\tfield maybe string {\n\t\toptional = true\n\t}\n",
+		"enum Color {\n\tinfo hex {\n\t\tlabel = \"Hex\"\n\t}\n",
+		"\toption RED {\n\t\t| Red things\n\t\tinfo.hex = \"f00\"\n\t}\n",
+		"oneof Thing {\n\toption foo object:Foo\n\toption name string\n}\n",
+		"service Foo {\n\tbasePath = \"/test/v1\"\n",
+		"\t\thttpPath = \"/foo/:fooId\"\n",
+		"topic Foo publish {\n",
+		"topic Thing upsert {\n\tentityName = \"test.v1.Thing\"\n\tmessage {\n\t\tfield name string\n\t}\n}\n",
+		"topic Work reqres {\n\trequest {\n\t\tfield job string\n\t}\n\treply {\n\t\tfield ok bool\n\t}\n}\n",
+	)
+}
+
+func TestConvertErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{{
+		name: "message in service file",
+		files: map[string]string{
+			"test/v1/foo.proto": testBaseProto,
+			"test/v1/service/foo.proto": testServiceProto + `
+message Stray {
+  option (j5.ext.v1.message).object = {};
+}
+`,
+		},
+		wantErr: "message Stray can't be declared in test.v1.service",
+	}, {
+		name: "unused nested message",
+		files: map[string]string{
+			"test/v1/foo.proto": `syntax = "proto3";
+package test.v1;
+
+import "j5/ext/v1/annotations.proto";
+
+message Foo {
+  option (j5.ext.v1.message).object = {};
+  string id = 1 [(j5.ext.v1.field).string = {}];
+
+  message Unused {
+    option (j5.ext.v1.message).object = {};
+  }
+}
+`,
+		},
+		wantErr: "Unused",
+	}, {
+		name: "existing j5s file",
+		files: map[string]string{
+			"test/v1/foo.proto": testBaseProto,
+			"test/v1/foo.j5s":   "package test.v1\n",
+		},
+		wantErr: "test/v1/foo.j5s",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			tf := testPackage(tc.files)
+			_, err := ConvertLocalPackage(context.Background(), noDeps{}, tf, "test.v1")
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error %q does not contain %q", err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+// TestFixture converts the protos generated for j5stest back to j5s, which
+// collapses the entity parts into a single entity block.
+func TestFixture(t *testing.T) {
+	tf := &testFiles{
+		files:    map[string][]byte{},
+		packages: []string{"j5st.v1"},
+	}
+	root := "../../../j5stest/proto"
+	for _, name := range []string{"j5st/v1/foo.j5s.proto", "j5st/v1/service/foo.p.j5s.proto", "j5st/v1/topic/foo.p.j5s.proto"} {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Present the generated files as hand-written protos.
+		filename := strings.TrimSuffix(strings.TrimSuffix(name, ".j5s.proto"), ".p") + ".proto"
+		content := strings.ReplaceAll(string(data), "j5st/v1/foo.j5s.proto", "j5st/v1/foo.proto")
+		tf.files[filename] = []byte(content)
+	}
+
+	files, err := ConvertLocalPackage(context.Background(), noDeps{}, tf, "j5st.v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	t.Log(files[0].Content)
+
+	assertContains(t, files[0].Content,
+		"entity Foo {\n",
+		"\tkey fooId key:id62 {\n\t\tprimary = true\n\t}\n",
+		"\tkey accountId key:id62 {\n\t\tprimary = false\n\t}\n",
+		"\tdata name string\n",
+		"\tstatus ACTIVE\n\tstatus INACTIVE\n",
+		"\tevent Create {\n\t\tfield name string\n\t}\n",
+		"\tsummary {\n\t\tfield name string\n\t}\n",
+	)
+	if strings.Contains(files[0].Content, "object Foo") {
+		t.Error("entity parts were not collapsed")
+	}
+}
@@ -0,0 +1,751 @@
+package fromproto
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"github.com/pentops/j5build/internal/bcl"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PrintFile prints the source file as formatted j5s.
+func PrintFile(file *sourcedef_j5pb.SourceFile) (string, error) {
+	p := &printer{}
+	if err := p.file(file); err != nil {
+		return "", fmt.Errorf("printing %s: %w", file.Path, err)
+	}
+	out, err := bcl.Fmt(p.String())
+	if err != nil {
+		return "", fmt.Errorf("formatting %s: %w", file.Path, err)
+	}
+	return out, nil
+}
+
+type printer struct {
+	lines  []string
+	indent int
+}
+
+func (p *printer) String() string {
+	return strings.Join(p.lines, "\n") + "\n"
+}
+
+func (p *printer) p(parts ...string) {
+	p.lines = append(p.lines, strings.Repeat("\t", p.indent)+strings.Join(parts, ""))
+}
+
+func (p *printer) gap() {
+	if len(p.lines) > 0 && p.lines[len(p.lines)-1] != "" {
+		p.lines = append(p.lines, "")
+	}
+}
+
+// block prints the header, followed by the body in braces when the body is
+// not empty. Force prints the braces for an empty body, for blocks where the
+// presence of the block matters.
+func (p *printer) block(header string, force bool, body func(*printer) error) error {
+	child := &printer{indent: p.indent + 1}
+	if err := body(child); err != nil {
+		return err
+	}
+	if len(child.lines) == 0 {
+		if force {
+			p.p(header, " {")
+			p.p("}")
+		} else {
+			p.p(header)
+		}
+		return nil
+	}
+	p.p(header, " {")
+	p.lines = append(p.lines, child.lines...)
+	p.p("}")
+	return nil
+}
+
+func (p *printer) description(desc string) {
+	if desc == "" {
+		return
+	}
+	for _, line := range strings.Split(desc, "\n") {
+		p.p("| ", line)
+	}
+}
+
+func (p *printer) file(file *sourcedef_j5pb.SourceFile) error {
+	p.p("package ", file.Package.Name)
+
+	if len(file.Imports) > 0 {
+		p.gap()
+		for _, imp := range file.Imports {
+			if imp.Alias != "" {
+				p.p("import ", imp.Path, ":", imp.Alias)
+			} else {
+				p.p("import ", imp.Path)
+			}
+		}
+	}
+
+	for _, element := range file.Elements {
+		p.gap()
+		var err error
+		switch et := element.Type.(type) {
+		case *sourcedef_j5pb.RootElement_Object:
+			err = p.object("object", et.Object)
+		case *sourcedef_j5pb.RootElement_Oneof:
+			err = p.oneof("oneof", et.Oneof)
+		case *sourcedef_j5pb.RootElement_Enum:
+			err = p.enum("enum", et.Enum)
+		case *sourcedef_j5pb.RootElement_Service:
+			err = p.service("service", et.Service)
+		case *sourcedef_j5pb.RootElement_Topic:
+			err = p.topic(et.Topic)
+		case *sourcedef_j5pb.RootElement_Entity:
+			err = p.entity(et.Entity)
+		default:
+			err = fmt.Errorf("unsupported element %T", et)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *printer) object(keyword string, obj *sourcedef_j5pb.Object) error {
+	if len(obj.Schemas) > 0 {
+		return fmt.Errorf("object %s: nested schemas are not supported", obj.Def.Name)
+	}
+	return p.block(keyword+" "+obj.Def.Name, true, func(p *printer) error {
+		p.description(obj.Def.Description)
+		if err := p.assignments("", obj.Def.ProtoReflect(), "name", "description", "properties"); err != nil {
+			return err
+		}
+		return p.properties("field", obj.Def.Properties)
+	})
+}
+
+func (p *printer) oneof(keyword string, oneof *sourcedef_j5pb.Oneof) error {
+	if len(oneof.Schemas) > 0 {
+		return fmt.Errorf("oneof %s: nested schemas are not supported", oneof.Def.Name)
+	}
+	return p.block(keyword+" "+oneof.Def.Name, true, func(p *printer) error {
+		p.description(oneof.Def.Description)
+		return p.properties("option", oneof.Def.Properties)
+	})
+}
+
+func (p *printer) enum(keyword string, enum *schema_j5pb.Enum) error {
+	return p.block(keyword+" "+enum.Name, true, func(p *printer) error {
+		p.description(enum.Description)
+		if enum.Prefix != "" {
+			p.p("prefix = ", quote(enum.Prefix))
+		}
+		for _, info := range enum.Info {
+			if err := p.block("info "+info.Name, true, func(p *printer) error {
+				return p.assignments("", info.ProtoReflect(), "name")
+			}); err != nil {
+				return err
+			}
+		}
+		return p.enumOptions(enum.Options)
+	})
+}
+
+func (p *printer) enumOptions(options []*schema_j5pb.Enum_Option) error {
+	for _, option := range options {
+		if err := p.block("option "+option.Name, false, func(p *printer) error {
+			p.description(option.Description)
+			return p.assignments("", option.ProtoReflect(), "name", "number", "description")
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *printer) service(keyword string, service *sourcedef_j5pb.Service) error {
+	header := keyword
+	if service.Name != nil {
+		header += " " + *service.Name
+	}
+	return p.block(header, true, func(p *printer) error {
+		p.description(service.Description)
+		if service.BasePath != nil {
+			p.p("basePath = ", quote(*service.BasePath))
+		}
+		if service.Options != nil {
+			if err := p.messageValue("options", service.Options.ProtoReflect()); err != nil {
+				return err
+			}
+		}
+		for _, method := range service.Methods {
+			p.gap()
+			if err := p.method(method); err != nil {
+				return fmt.Errorf("method %s: %w", method.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (p *printer) method(method *sourcedef_j5pb.APIMethod) error {
+	return p.block("method "+method.Name, true, func(p *printer) error {
+		p.description(method.Description)
+		if err := p.assignments("", method.ProtoReflect(), "name", "description", "request", "response"); err != nil {
+			return err
+		}
+		if err := p.anonymousObject("request", method.Request); err != nil {
+			return err
+		}
+		return p.anonymousObject("response", method.Response)
+	})
+}
+
+func (p *printer) anonymousObject(keyword string, obj *sourcedef_j5pb.AnonymousObject) error {
+	if obj == nil {
+		return nil
+	}
+	return p.block(keyword, true, func(p *printer) error {
+		return p.properties("field", obj.Properties)
+	})
+}
+
+func (p *printer) topic(topic *sourcedef_j5pb.Topic) error {
+	var role string
+	var body func(*printer) error
+
+	switch tt := topic.Type.Type.(type) {
+	case *sourcedef_j5pb.TopicType_Publish_:
+		role = "publish"
+		body = func(p *printer) error {
+			return p.topicMethods("message", tt.Publish.Messages)
+		}
+	case *sourcedef_j5pb.TopicType_Reqres:
+		role = "reqres"
+		body = func(p *printer) error {
+			if err := p.topicMethods("request", tt.Reqres.Request); err != nil {
+				return err
+			}
+			return p.topicMethods("reply", tt.Reqres.Reply)
+		}
+	case *sourcedef_j5pb.TopicType_Upsert_:
+		role = "upsert"
+		body = func(p *printer) error {
+			if tt.Upsert.EntityName != "" {
+				p.p("entityName = ", quote(tt.Upsert.EntityName))
+			}
+			return p.topicMethods("message", []*sourcedef_j5pb.TopicMethod{tt.Upsert.Message})
+		}
+	case *sourcedef_j5pb.TopicType_Event_:
+		role = "event"
+		body = func(p *printer) error {
+			if tt.Event.EntityName != "" {
+				p.p("entityName = ", quote(tt.Event.EntityName))
+			}
+			return p.topicMethods("message", []*sourcedef_j5pb.TopicMethod{tt.Event.Message})
+		}
+	default:
+		return fmt.Errorf("topic %s: unsupported type %T", topic.Name, tt)
+	}
+
+	return p.block("topic "+topic.Name+" "+role, true, func(p *printer) error {
+		p.description(topic.Description)
+		return body(p)
+	})
+}
+
+func (p *printer) topicMethods(keyword string, methods []*sourcedef_j5pb.TopicMethod) error {
+	for _, method := range methods {
+		header := keyword
+		if method.Name != nil {
+			header += " " + *method.Name
+		}
+		if err := p.block(header, true, func(p *printer) error {
+			p.description(method.Description)
+			return p.properties("field", method.Fields)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *printer) entity(entity *sourcedef_j5pb.Entity) error {
+	if len(entity.Schemas) > 0 {
+		return fmt.Errorf("entity %s: nested schemas are not supported", entity.Name)
+	}
+	return p.block("entity "+entity.Name, true, func(p *printer) error {
+		p.description(entity.Description)
+		if entity.BaseUrlPath != "" {
+			p.p("baseUrlPath = ", quote(entity.BaseUrlPath))
+		}
+
+		p.gap()
+		for _, key := range entity.Keys {
+			def := proto.Clone(key.Def).(*schema_j5pb.ObjectProperty)
+			primary := ""
+			if keyField := def.Schema.GetKey(); keyField != nil && keyField.Entity != nil {
+				// The primary alias sets the key entity, primary keys are
+				// always required.
+				switch {
+				case keyField.Entity.Type == nil:
+					primary = "false"
+				case keyField.Entity.GetPrimaryKey():
+					primary = "true"
+					def.Required = false
+				}
+				if primary != "" {
+					keyField.Entity = nil
+				}
+			}
+			if err := p.property("key", def, func(p *printer) {
+				if primary != "" {
+					p.p("primary = ", primary)
+				}
+				if key.ShardKey {
+					p.p("shardKey = true")
+				}
+			}); err != nil {
+				return err
+			}
+		}
+
+		p.gap()
+		if err := p.properties("data", entity.Data); err != nil {
+			return err
+		}
+
+		p.gap()
+		for _, status := range entity.Status {
+			if err := p.block("status "+status.Name, false, func(p *printer) error {
+				p.description(status.Description)
+				return p.assignments("", status.ProtoReflect(), "name", "number", "description")
+			}); err != nil {
+				return err
+			}
+		}
+
+		for _, event := range entity.Events {
+			p.gap()
+			if err := p.object("event", event); err != nil {
+				return err
+			}
+		}
+
+		for _, command := range entity.Commands {
+			p.gap()
+			if err := p.service("command", command); err != nil {
+				return err
+			}
+		}
+
+		for _, summary := range entity.Summaries {
+			p.gap()
+			header := "summary"
+			if summary.Name != "" {
+				header += " " + summary.Name
+			}
+			if err := p.block(header, true, func(p *printer) error {
+				p.description(summary.Description)
+				return p.properties("field", summary.Fields)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *printer) properties(keyword string, props []*schema_j5pb.ObjectProperty) error {
+	for _, prop := range props {
+		if err := p.property(keyword, prop, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *printer) property(keyword string, prop *schema_j5pb.ObjectProperty, extra func(*printer)) error {
+	header, body, err := fieldType(prop.Schema)
+	if err != nil {
+		return fmt.Errorf("property %s: %w", prop.Name, err)
+	}
+	return p.block(keyword+" "+prop.Name+" "+header, false, func(p *printer) error {
+		p.description(prop.Description)
+		if prop.Required {
+			p.p("required = true")
+		}
+		if prop.ExplicitlyOptional {
+			p.p("optional = true")
+		}
+		if extra != nil {
+			extra(p)
+		}
+		return body(p)
+	})
+}
+
+// fieldType returns the type for the header of a property, e.g.
+// `object:foo.v1.Bar`, and a function to print the remainder of the field
+// into the property body.
+func fieldType(field *schema_j5pb.Field) (string, func(*printer) error, error) {
+	if field == nil || field.Type == nil {
+		return "", nil, fmt.Errorf("missing field type")
+	}
+
+	switch ft := field.Type.(type) {
+	case *schema_j5pb.Field_Object:
+		st := ft.Object
+		switch schema := st.Schema.(type) {
+		case *schema_j5pb.ObjectField_Ref:
+			return "object:" + refString(schema.Ref), func(p *printer) error {
+				return p.assignments("", st.ProtoReflect(), "ref")
+			}, nil
+		case *schema_j5pb.ObjectField_Object:
+			return "object", func(p *printer) error {
+				if err := p.assignments("object.", schema.Object.ProtoReflect(), "properties"); err != nil {
+					return err
+				}
+				if err := p.assignments("", st.ProtoReflect(), "object"); err != nil {
+					return err
+				}
+				return p.properties("field", schema.Object.Properties)
+			}, nil
+		}
+		return "", nil, fmt.Errorf("object field has no schema")
+
+	case *schema_j5pb.Field_Oneof:
+		st := ft.Oneof
+		switch schema := st.Schema.(type) {
+		case *schema_j5pb.OneofField_Ref:
+			return "oneof:" + refString(schema.Ref), func(p *printer) error {
+				return p.assignments("", st.ProtoReflect(), "ref")
+			}, nil
+		case *schema_j5pb.OneofField_Oneof:
+			return "oneof", func(p *printer) error {
+				if err := p.assignments("oneof.", schema.Oneof.ProtoReflect(), "properties"); err != nil {
+					return err
+				}
+				if err := p.assignments("", st.ProtoReflect(), "oneof"); err != nil {
+					return err
+				}
+				return p.properties("option", schema.Oneof.Properties)
+			}, nil
+		}
+		return "", nil, fmt.Errorf("oneof field has no schema")
+
+	case *schema_j5pb.Field_Enum:
+		st := ft.Enum
+		switch schema := st.Schema.(type) {
+		case *schema_j5pb.EnumField_Ref:
+			return "enum:" + refString(schema.Ref), func(p *printer) error {
+				return p.assignments("", st.ProtoReflect(), "ref")
+			}, nil
+		case *schema_j5pb.EnumField_Enum:
+			if len(schema.Enum.Info) > 0 {
+				return "", nil, fmt.Errorf("inline enum %s has info fields, which must be declared at the top level", schema.Enum.Name)
+			}
+			return "enum", func(p *printer) error {
+				if err := p.assignments("enum.", schema.Enum.ProtoReflect(), "options", "info"); err != nil {
+					return err
+				}
+				if err := p.assignments("", st.ProtoReflect(), "enum"); err != nil {
+					return err
+				}
+				return p.enumOptions(schema.Enum.Options)
+			}, nil
+		}
+		return "", nil, fmt.Errorf("enum field has no schema")
+
+	case *schema_j5pb.Field_Array:
+		st := ft.Array
+		return collectionType("array", "items", st.ProtoReflect(), st.Items)
+
+	case *schema_j5pb.Field_Map:
+		st := ft.Map
+		if key := st.KeySchema; key != nil {
+			_, isString := key.Type.(*schema_j5pb.Field_String_)
+			if !isString || (key.GetString_() != nil && !isEmpty(key.GetString_())) {
+				return "", nil, fmt.Errorf("map keys must be plain strings")
+			}
+		}
+		return collectionType("map", "item_schema", st.ProtoReflect(), st.ItemSchema)
+
+	case *schema_j5pb.Field_Integer:
+		st := ft.Integer
+		header := "integer"
+		if st.Format != schema_j5pb.IntegerField_FORMAT_UNSPECIFIED {
+			header += ":" + strings.TrimPrefix(st.Format.String(), "FORMAT_")
+		}
+		return header, func(p *printer) error {
+			return p.assignments("", st.ProtoReflect(), "format")
+		}, nil
+
+	case *schema_j5pb.Field_Float:
+		st := ft.Float
+		header := "float"
+		if st.Format != schema_j5pb.FloatField_FORMAT_UNSPECIFIED {
+			header += ":" + strings.TrimPrefix(st.Format.String(), "FORMAT_")
+		}
+		return header, func(p *printer) error {
+			return p.assignments("", st.ProtoReflect(), "format")
+		}, nil
+
+	case *schema_j5pb.Field_Key:
+		st := ft.Key
+		format := st.Format.ProtoReflect()
+		var formatName string
+		if which := format.WhichOneof(format.Descriptor().Oneofs().ByName("type")); which != nil && isEmpty(format.Get(which).Message().Interface()) {
+			formatName = string(which.Name())
+		}
+		if formatName == "" {
+			return "key", func(p *printer) error {
+				return p.assignments("", st.ProtoReflect())
+			}, nil
+		}
+		return "key:" + formatName, func(p *printer) error {
+			return p.assignments("", st.ProtoReflect(), "format")
+		}, nil
+	}
+
+	// Scalars with no qualifier, the type name is the name of the field in
+	// the type oneof
+	msg := field.ProtoReflect()
+	which := msg.WhichOneof(msg.Descriptor().Oneofs().ByName("type"))
+	if which == nil {
+		return "", nil, fmt.Errorf("missing field type")
+	}
+	value := msg.Get(which).Message()
+	return which.JSONName(), func(p *printer) error {
+		return p.assignments("", value)
+	}, nil
+}
+
+// collectionType handles arrays and maps, which print the item type in the
+// header, the inline definition of the item in the body, and the remaining
+// item fields prefixed by the path to the item.
+func collectionType(name string, itemField protoreflect.Name, collection protoreflect.Message, item *schema_j5pb.Field) (string, func(*printer) error, error) {
+	itemHeader, _, err := fieldType(item)
+	if err != nil {
+		return "", nil, err
+	}
+
+	itemMsg := item.ProtoReflect()
+	which := itemMsg.WhichOneof(itemMsg.Descriptor().Oneofs().ByName("type"))
+	itemValue := itemMsg.Get(which).Message()
+	itemPrefix := collection.Descriptor().Fields().ByName(itemField).JSONName() + "." + which.JSONName() + "."
+
+	return name + ":" + itemHeader, func(p *printer) error {
+		if err := p.assignments("", collection, itemField, "key_schema"); err != nil {
+			return err
+		}
+
+		switch it := item.Type.(type) {
+		case *schema_j5pb.Field_Object:
+			if inline := it.Object.GetObject(); inline != nil {
+				if err := p.assignments("object.", inline.ProtoReflect(), "properties"); err != nil {
+					return err
+				}
+				if err := p.properties("field", inline.Properties); err != nil {
+					return err
+				}
+			}
+			return p.assignments(itemPrefix, itemValue, "ref", "object")
+		case *schema_j5pb.Field_Oneof:
+			if inline := it.Oneof.GetOneof(); inline != nil {
+				if err := p.assignments("oneof.", inline.ProtoReflect(), "properties"); err != nil {
+					return err
+				}
+				if err := p.properties("option", inline.Properties); err != nil {
+					return err
+				}
+			}
+			return p.assignments(itemPrefix, itemValue, "ref", "oneof")
+		case *schema_j5pb.Field_Enum:
+			if inline := it.Enum.GetEnum(); inline != nil {
+				if len(inline.Info) > 0 {
+					return fmt.Errorf("inline enum %s has info fields, which must be declared at the top level", inline.Name)
+				}
+				if err := p.assignments("enum.", inline.ProtoReflect(), "options", "info"); err != nil {
+					return err
+				}
+				if err := p.enumOptions(inline.Options); err != nil {
+					return err
+				}
+			}
+			return p.assignments(itemPrefix, itemValue, "ref", "enum")
+		case *schema_j5pb.Field_Integer, *schema_j5pb.Field_Float:
+			return p.assignments(itemPrefix, itemValue, "format")
+		case *schema_j5pb.Field_Key:
+			if strings.Contains(itemHeader, ":") {
+				return p.assignments(itemPrefix, itemValue, "format")
+			}
+		}
+		return p.assignments(itemPrefix, itemValue)
+	}, nil
+}
+
+func refString(ref *schema_j5pb.Ref) string {
+	if ref.Package == "" {
+		return ref.Schema
+	}
+	return ref.Package + "." + ref.Schema
+}
+
+func isEmpty(msg proto.Message) bool {
+	empty := true
+	msg.ProtoReflect().Range(func(protoreflect.FieldDescriptor, protoreflect.Value) bool {
+		empty = false
+		return false
+	})
+	return empty
+}
+
+// messageValue prints the set fields of the message prefixed by the name, or
+// an empty block to set an empty message.
+func (p *printer) messageValue(name string, msg protoreflect.Message) error {
+	if isEmpty(msg.Interface()) {
+		p.p(name, " {")
+		p.p("}")
+		return nil
+	}
+	return p.assignments(name+".", msg)
+}
+
+// optionalEmpty fields are set to empty messages by j5schema, but an empty
+// value means the same as no value, so they are left out of the j5s.
+var optionalEmpty = map[protoreflect.Name]bool{
+	"rules": true,
+}
+
+var reMapKey = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// assignments prints each set field of the message, in field number order,
+// as `path.to.field = value`, skipping the named fields.
+func (p *printer) assignments(prefix string, msg protoreflect.Message, skip ...protoreflect.Name) error {
+	fields := msg.Descriptor().Fields()
+	for idx := 0; idx < fields.Len(); idx++ {
+		field := fields.Get(idx)
+		if !msg.Has(field) || isSkipped(field.Name(), skip) {
+			continue
+		}
+		value := msg.Get(field)
+		if optionalEmpty[field.Name()] && field.Kind() == protoreflect.MessageKind && !field.IsList() && !field.IsMap() && isEmpty(value.Message().Interface()) {
+			continue
+		}
+		name := prefix + field.JSONName()
+
+		switch {
+		case field.IsMap():
+			keys := make([]string, 0, value.Map().Len())
+			value.Map().Range(func(key protoreflect.MapKey, _ protoreflect.Value) bool {
+				keys = append(keys, key.String())
+				return true
+			})
+			sort.Strings(keys)
+			for _, key := range keys {
+				if !reMapKey.MatchString(key) {
+					return fmt.Errorf("%s: map key %q can't be written in j5s", name, key)
+				}
+				val := value.Map().Get(protoreflect.ValueOfString(key).MapKey())
+				if err := p.assignValue(name+"."+key, field.MapValue(), val); err != nil {
+					return err
+				}
+			}
+
+		case field.IsList():
+			if field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind {
+				return fmt.Errorf("%s: repeated messages can't be written as j5s attributes", name)
+			}
+			list := value.List()
+			vals := make([]string, 0, list.Len())
+			for ii := 0; ii < list.Len(); ii++ {
+				val, err := scalarString(field, list.Get(ii))
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				vals = append(vals, val)
+			}
+			p.p(name, " = [", strings.Join(vals, ", "), "]")
+
+		default:
+			if err := p.assignValue(name, field, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *printer) assignValue(name string, field protoreflect.FieldDescriptor, value protoreflect.Value) error {
+	if field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind {
+		return p.messageValue(name, value.Message())
+	}
+	val, err := scalarString(field, value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	p.p(name, " = ", val)
+	return nil
+}
+
+func isSkipped(name protoreflect.Name, skip []protoreflect.Name) bool {
+	for _, s := range skip {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+func scalarString(field protoreflect.FieldDescriptor, value protoreflect.Value) (string, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return quote(value.String()), nil
+	case protoreflect.BoolKind:
+		return strconv.FormatBool(value.Bool()), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64), nil
+	case protoreflect.EnumKind:
+		return enumValueName(field.Enum(), value.Enum())
+	default:
+		return "", fmt.Errorf("unsupported value kind %s", field.Kind())
+	}
+}
+
+// enumValueName returns the short name of the enum value, without the prefix
+// of the UNSPECIFIED value, which is how j5s refers to enum values.
+func enumValueName(enum protoreflect.EnumDescriptor, number protoreflect.EnumNumber) (string, error) {
+	value := enum.Values().ByNumber(number)
+	if value == nil {
+		return "", fmt.Errorf("no value %d in enum %s", number, enum.FullName())
+	}
+	name := string(value.Name())
+	unspecified := string(enum.Values().Get(0).Name())
+	if prefix, ok := strings.CutSuffix(unspecified, "UNSPECIFIED"); ok {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return name, nil
+}
+
+// quote returns the string as a j5s string literal, where the only escapes
+// are the quote and backslash, and newlines are escaped literally.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\n", "\\\n")
+	return `"` + s + `"`
+}
@@ -0,0 +1,248 @@
+package fromproto
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
+	"github.com/pentops/j5/gen/j5/ext/v1/ext_j5pb"
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const googleAPIHttpBody = "google.api.HttpBody"
+
+// serviceElements converts the services of a service sub-package file. The
+// file may only contain services and their request and response messages.
+func (fc *fileConverter) serviceElements(file protoreflect.FileDescriptor) ([]*sourcedef_j5pb.RootElement, error) {
+	elements := []*sourcedef_j5pb.RootElement{}
+	services := file.Services()
+	for ii := 0; ii < services.Len(); ii++ {
+		desc := services.Get(ii)
+		if fc.isEntityService(desc) {
+			continue
+		}
+		service, err := fc.service(desc, "")
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", desc.Name(), err)
+		}
+		elements = append(elements, &sourcedef_j5pb.RootElement{
+			Type: &sourcedef_j5pb.RootElement_Service{
+				Service: service,
+			},
+		})
+	}
+
+	if err := fc.checkConsumed(file, "request and response messages of its services"); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
+
+// checkConsumed returns an error for messages and enums in a sub-package file
+// which were not converted as part of a service or topic, j5s has no way to
+// declare them.
+func (fc *fileConverter) checkConsumed(file protoreflect.FileDescriptor, allowed string) error {
+	if file.Enums().Len() > 0 {
+		return fmt.Errorf("enum %s can't be declared in %s, which may only contain the %s", file.Enums().Get(0).Name(), file.Package(), allowed)
+	}
+	messages := file.Messages()
+	for ii := 0; ii < messages.Len(); ii++ {
+		msg := messages.Get(ii)
+		if !fc.consumed[msg.FullName()] {
+			return fmt.Errorf("message %s can't be declared in %s, which may only contain the %s", msg.Name(), file.Package(), allowed)
+		}
+	}
+	return nil
+}
+
+// service converts a proto service. The base path is the common prefix of the
+// method paths unless one is given.
+func (fc *fileConverter) service(desc protoreflect.ServiceDescriptor, basePath string) (*sourcedef_j5pb.Service, error) {
+	name, ok := strings.CutSuffix(string(desc.Name()), "Service")
+	if !ok {
+		return nil, fmt.Errorf("service names must end with Service")
+	}
+
+	service := &sourcedef_j5pb.Service{
+		Name:        &name,
+		Description: commentDescription(desc),
+	}
+
+	serviceExt := proto.GetExtension(desc.Options(), ext_j5pb.E_Service).(*ext_j5pb.ServiceOptions)
+	if serviceExt != nil {
+		service.Options = serviceExt
+	}
+
+	methods := desc.Methods()
+	rules := make([]*httpMethod, 0, methods.Len())
+	for ii := 0; ii < methods.Len(); ii++ {
+		rule, err := httpRule(methods.Get(ii))
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", methods.Get(ii).Name(), err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if basePath == "" {
+		basePath = commonBasePath(rules)
+	}
+	if basePath != "" {
+		service.BasePath = &basePath
+	}
+
+	for ii := 0; ii < methods.Len(); ii++ {
+		method, err := fc.method(methods.Get(ii), rules[ii], basePath)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", methods.Get(ii).Name(), err)
+		}
+		service.Methods = append(service.Methods, method)
+	}
+
+	return service, nil
+}
+
+func (fc *fileConverter) method(desc protoreflect.MethodDescriptor, rule *httpMethod, basePath string) (*sourcedef_j5pb.APIMethod, error) {
+	if desc.IsStreamingClient() || desc.IsStreamingServer() {
+		return nil, fmt.Errorf("streaming methods can't be declared in j5s")
+	}
+
+	httpPath := rule.path
+	if basePath != "" {
+		relative, ok := strings.CutPrefix(rule.path, basePath)
+		if !ok || (relative != "" && !strings.HasPrefix(relative, "/")) {
+			return nil, fmt.Errorf("path %s is not under the service base path %s", rule.path, basePath)
+		}
+		httpPath = relative
+	}
+
+	method := &sourcedef_j5pb.APIMethod{
+		Name:        string(desc.Name()),
+		Description: commentDescription(desc),
+		HttpMethod:  rule.method,
+		HttpPath:    reHTTPPathParam.ReplaceAllStringFunc(httpPath, pathParamToJ5s),
+	}
+
+	methodExt := proto.GetExtension(desc.Options(), ext_j5pb.E_Method).(*ext_j5pb.MethodOptions)
+	if methodExt != nil {
+		method.Options = methodExt
+	}
+
+	input := desc.Input()
+	if input.Name() != protoreflect.Name(method.Name+"Request") || input.ParentFile().Path() != desc.ParentFile().Path() {
+		return nil, fmt.Errorf("request must be %sRequest, declared in the same file", method.Name)
+	}
+	props, err := fc.objectProperties(input)
+	if err != nil {
+		return nil, fmt.Errorf("request: %w", err)
+	}
+	method.Request = &sourcedef_j5pb.AnonymousObject{
+		Properties: props,
+	}
+	fc.consumed[input.FullName()] = true
+
+	output := desc.Output()
+	if output.FullName() == googleAPIHttpBody {
+		return method, nil
+	}
+	if output.Name() != protoreflect.Name(method.Name+"Response") || output.ParentFile().Path() != desc.ParentFile().Path() {
+		return nil, fmt.Errorf("response must be %sResponse declared in the same file, or %s", method.Name, googleAPIHttpBody)
+	}
+	props, err = fc.objectProperties(output)
+	if err != nil {
+		return nil, fmt.Errorf("response: %w", err)
+	}
+	method.Response = &sourcedef_j5pb.AnonymousObject{
+		Properties: props,
+	}
+	fc.consumed[output.FullName()] = true
+
+	return method, nil
+}
+
+type httpMethod struct {
+	method client_j5pb.HTTPMethod
+	path   string
+}
+
+// httpRule reads the google.api.http option, in the form j5s generates: GET
+// without a body, and all other methods with the whole request as the body.
+func httpRule(desc protoreflect.MethodDescriptor) (*httpMethod, error) {
+	rule, ok := proto.GetExtension(desc.Options(), annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil || rule.Pattern == nil {
+		return nil, fmt.Errorf("missing google.api.http option")
+	}
+	if len(rule.AdditionalBindings) > 0 || rule.ResponseBody != "" {
+		return nil, fmt.Errorf("additional_bindings and response_body can't be declared in j5s")
+	}
+
+	out := &httpMethod{}
+	expectBody := "*"
+	switch pattern := rule.Pattern.(type) {
+	case *annotations.HttpRule_Get:
+		out.method = client_j5pb.HTTPMethod_GET
+		out.path = pattern.Get
+		expectBody = ""
+	case *annotations.HttpRule_Post:
+		out.method = client_j5pb.HTTPMethod_POST
+		out.path = pattern.Post
+	case *annotations.HttpRule_Delete:
+		out.method = client_j5pb.HTTPMethod_DELETE
+		out.path = pattern.Delete
+	case *annotations.HttpRule_Patch:
+		out.method = client_j5pb.HTTPMethod_PATCH
+		out.path = pattern.Patch
+	case *annotations.HttpRule_Put:
+		out.method = client_j5pb.HTTPMethod_PUT
+		out.path = pattern.Put
+	default:
+		return nil, fmt.Errorf("unsupported http pattern %T", pattern)
+	}
+
+	if rule.Body != expectBody {
+		return nil, fmt.Errorf("%s methods must have body %q, j5s doesn't support other bodies", out.method, expectBody)
+	}
+	return out, nil
+}
+
+var reHTTPPathParam = regexp.MustCompile(`\{[a-z0-9_]+\}`)
+
+// pathParamToJ5s converts a path parameter from {foo_id} to the j5s form,
+// :fooId, which names the request property.
+func pathParamToJ5s(param string) string {
+	return ":" + strcase.ToLowerCamel(strings.Trim(param, "{}"))
+}
+
+// commonBasePath returns the longest path prefix shared by all methods which
+// still leaves at least one segment of each method path.
+func commonBasePath(rules []*httpMethod) string {
+	if len(rules) == 0 {
+		return ""
+	}
+	var common []string
+	for idx, rule := range rules {
+		parts := strings.Split(rule.path, "/")
+		parts = parts[:len(parts)-1]
+		if idx == 0 {
+			common = parts
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	// parameters stay in the method paths
+	for idx, part := range common {
+		if strings.HasPrefix(part, "{") {
+			common = common[:idx]
+			break
+		}
+	}
+	return strings.Join(common, "/")
+}
@@ -0,0 +1,223 @@
+package fromproto
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pentops/j5/gen/j5/messaging/v1/messaging_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const googleProtobufEmpty = "google.protobuf.Empty"
+
+// prependField is a field which j5s adds to the start of each topic message
+// for the topic type, which is removed from the j5s fields.
+type prependField struct {
+	name   string
+	pkg    string
+	schema string
+}
+
+var upsertPrepend = &prependField{
+	name:   "upsert",
+	pkg:    "j5.messaging.v1",
+	schema: "UpsertMetadata",
+}
+
+var requestPrepend = &prependField{
+	name:   "request",
+	pkg:    "j5.messaging.v1",
+	schema: "RequestMetadata",
+}
+
+// topicElements converts the topics of a topic sub-package file. The file may
+// only contain topics and their messages.
+func (fc *fileConverter) topicElements(file protoreflect.FileDescriptor) ([]*sourcedef_j5pb.RootElement, error) {
+	elements := []*sourcedef_j5pb.RootElement{}
+	reqres := map[string]*sourcedef_j5pb.TopicType_ReqRes{}
+	hasReply := map[string]bool{}
+
+	services := file.Services()
+	for ii := 0; ii < services.Len(); ii++ {
+		desc := services.Get(ii)
+		if fc.isEntityService(desc) {
+			continue
+		}
+
+		config := proto.GetExtension(desc.Options(), messaging_j5pb.E_Service).(*messaging_j5pb.ServiceConfig)
+		if config == nil || config.Role == nil {
+			return nil, fmt.Errorf("topic %s: missing j5.messaging.v1.service option", desc.Name())
+		}
+		name, ok := strings.CutSuffix(string(desc.Name()), "Topic")
+		if !ok || strcase.ToCamel(name) != name {
+			return nil, fmt.Errorf("topic %s: topic names must be CamelCase and end with Topic", desc.Name())
+		}
+
+		topic := &sourcedef_j5pb.Topic{
+			Name:        name,
+			Description: commentDescription(desc),
+			Type:        &sourcedef_j5pb.TopicType{},
+		}
+
+		var err error
+		switch role := config.Role.(type) {
+		case *messaging_j5pb.ServiceConfig_Publish_:
+			var methods []*sourcedef_j5pb.TopicMethod
+			methods, err = fc.topicMethods(desc, name, nil)
+			topic.Type.Type = &sourcedef_j5pb.TopicType_Publish_{
+				Publish: &sourcedef_j5pb.TopicType_Publish{
+					Messages: methods,
+				},
+			}
+
+		case *messaging_j5pb.ServiceConfig_Event_:
+			var method *sourcedef_j5pb.TopicMethod
+			method, err = fc.singleTopicMethod(desc, name, nil)
+			topic.Type.Type = &sourcedef_j5pb.TopicType_Event_{
+				Event: &sourcedef_j5pb.TopicType_Event{
+					EntityName: role.Event.EntityName,
+					Message:    method,
+				},
+			}
+
+		case *messaging_j5pb.ServiceConfig_Upsert_:
+			var method *sourcedef_j5pb.TopicMethod
+			method, err = fc.singleTopicMethod(desc, name, upsertPrepend)
+			topic.Type.Type = &sourcedef_j5pb.TopicType_Upsert_{
+				Upsert: &sourcedef_j5pb.TopicType_Upsert{
+					EntityName: role.Upsert.EntityName,
+					Message:    method,
+				},
+			}
+
+		case *messaging_j5pb.ServiceConfig_Request_, *messaging_j5pb.ServiceConfig_Reply_:
+			suffix := "Request"
+			if _, isReply := role.(*messaging_j5pb.ServiceConfig_Reply_); isReply {
+				suffix = "Reply"
+			}
+			reqresName, ok := strings.CutSuffix(name, suffix)
+			if !ok {
+				return nil, fmt.Errorf("topic %s: %s topic names must end with %sTopic", desc.Name(), strings.ToLower(suffix), suffix)
+			}
+			if config.GetTopicName() != strcase.ToSnake(reqresName) {
+				return nil, fmt.Errorf("topic %s: topic_name must be %q", desc.Name(), strcase.ToSnake(reqresName))
+			}
+
+			methods, err := fc.topicMethods(desc, name, requestPrepend)
+			if err != nil {
+				return nil, fmt.Errorf("topic %s: %w", desc.Name(), err)
+			}
+
+			pair, ok := reqres[reqresName]
+			if !ok {
+				pair = &sourcedef_j5pb.TopicType_ReqRes{}
+				reqres[reqresName] = pair
+				topic.Name = reqresName
+				topic.Type.Type = &sourcedef_j5pb.TopicType_Reqres{
+					Reqres: pair,
+				}
+				elements = append(elements, &sourcedef_j5pb.RootElement{
+					Type: &sourcedef_j5pb.RootElement_Topic{
+						Topic: topic,
+					},
+				})
+			}
+			if suffix == "Reply" {
+				pair.Reply = methods
+				hasReply[reqresName] = true
+			} else {
+				pair.Request = methods
+			}
+			continue
+
+		default:
+			return nil, fmt.Errorf("topic %s: unsupported role %T", desc.Name(), role)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("topic %s: %w", desc.Name(), err)
+		}
+
+		if config.GetTopicName() != strcase.ToSnake(name) {
+			return nil, fmt.Errorf("topic %s: topic_name must be %q", desc.Name(), strcase.ToSnake(name))
+		}
+
+		elements = append(elements, &sourcedef_j5pb.RootElement{
+			Type: &sourcedef_j5pb.RootElement_Topic{
+				Topic: topic,
+			},
+		})
+	}
+
+	for name, pair := range reqres {
+		if len(pair.Request) == 0 || !hasReply[name] {
+			return nil, fmt.Errorf("reqres topic %s must have both %sRequestTopic and %sReplyTopic", name, name, name)
+		}
+	}
+
+	if err := fc.checkConsumed(file, "messages of its topics"); err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
+
+func (fc *fileConverter) singleTopicMethod(desc protoreflect.ServiceDescriptor, name string, prepend *prependField) (*sourcedef_j5pb.TopicMethod, error) {
+	methods, err := fc.topicMethods(desc, name, prepend)
+	if err != nil {
+		return nil, err
+	}
+	if len(methods) != 1 {
+		return nil, fmt.Errorf("topic must have exactly one method")
+	}
+	return methods[0], nil
+}
+
+// topicMethods converts the methods of a topic, where each method takes
+// {Method}Message and returns google.protobuf.Empty. A single method with the
+// default name is left unnamed.
+func (fc *fileConverter) topicMethods(desc protoreflect.ServiceDescriptor, defaultName string, prepend *prependField) ([]*sourcedef_j5pb.TopicMethod, error) {
+	methods := desc.Methods()
+	out := make([]*sourcedef_j5pb.TopicMethod, 0, methods.Len())
+	for ii := 0; ii < methods.Len(); ii++ {
+		method := methods.Get(ii)
+		name := string(method.Name())
+
+		input := method.Input()
+		if input.Name() != protoreflect.Name(name+"Message") || input.ParentFile().Path() != desc.ParentFile().Path() {
+			return nil, fmt.Errorf("method %s: input must be %sMessage, declared in the same file", name, name)
+		}
+		if method.Output().FullName() != googleProtobufEmpty {
+			return nil, fmt.Errorf("method %s: output must be %s", name, googleProtobufEmpty)
+		}
+
+		props, err := fc.objectProperties(input)
+		if err != nil {
+			return nil, fmt.Errorf("method %s: %w", name, err)
+		}
+		if prepend != nil {
+			if len(props) == 0 || !prepend.matches(props[0]) {
+				return nil, fmt.Errorf("method %s: the first field must be the required %s %s.%s", name, prepend.name, prepend.pkg, prepend.schema)
+			}
+			props = props[1:]
+		}
+		fc.consumed[input.FullName()] = true
+
+		topicMethod := &sourcedef_j5pb.TopicMethod{
+			Description: commentDescription(method),
+			Fields:      props,
+		}
+		if methods.Len() != 1 || name != defaultName {
+			topicMethod.Name = &name
+		}
+		out = append(out, topicMethod)
+	}
+	return out, nil
+}
+
+func (pf *prependField) matches(prop *schema_j5pb.ObjectProperty) bool {
+	ref := prop.Schema.GetObject().GetRef()
+	return prop.Name == pf.name && prop.Required && ref != nil && ref.Package == pf.pkg && ref.Schema == pf.schema
+}
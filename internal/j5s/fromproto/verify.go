@@ -0,0 +1,480 @@
+package fromproto
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/ext/v1/ext_j5pb"
+	"github.com/pentops/j5/gen/j5/messaging/v1/messaging_j5pb"
+	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
+	"github.com/pentops/j5/lib/j5schema"
+	"github.com/pentops/j5build/internal/j5s/protobuild"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// ConvertLocalPackage converts the hand-written proto files of a local
+// package to j5s, then compiles the package again with the j5s files in place
+// of the proto files, returning an error if the result is not equivalent.
+func ConvertLocalPackage(ctx context.Context, deps protobuild.DependencySet, localFiles protobuild.LocalFileSource, pkgName string) ([]*File, error) {
+	original, err := compilePackage(ctx, deps, localFiles, pkgName)
+	if err != nil {
+		return nil, fmt.Errorf("compiling %s: %w", pkgName, err)
+	}
+
+	files, err := ConvertPackage(pkgName, original)
+	if err != nil {
+		return nil, fmt.Errorf("converting %s: %w", pkgName, err)
+	}
+
+	overlay := &overlayFiles{
+		LocalFileSource: localFiles,
+		added:           map[string][]byte{},
+		removed:         map[string]bool{},
+	}
+	for _, file := range files {
+		if _, err := localFiles.GetLocalFile(ctx, file.Filename); err == nil {
+			return nil, fmt.Errorf("converting %s: %s already exists", pkgName, file.Filename)
+		}
+		file.Content, err = PrintFile(file.Source)
+		if err != nil {
+			return nil, err
+		}
+		overlay.added[file.Filename] = []byte(file.Content)
+		for _, replaced := range file.Replaces {
+			overlay.removed[replaced] = true
+		}
+	}
+
+	converted, err := compilePackage(ctx, deps, overlay, pkgName)
+	if err != nil {
+		return nil, fmt.Errorf("compiling converted %s: %w", pkgName, err)
+	}
+
+	if err := comparePackages(original, converted); err != nil {
+		return nil, fmt.Errorf("converted %s does not match the proto files: %w", pkgName, err)
+	}
+
+	return files, nil
+}
+
+func compilePackage(ctx context.Context, deps protobuild.DependencySet, localFiles protobuild.LocalFileSource, pkgName string) ([]protoreflect.FileDescriptor, error) {
+	ps, err := protobuild.NewPackageSet(deps, localFiles)
+	if err != nil {
+		return nil, err
+	}
+	// legacy packages put services and topics in sub-packages by hand
+	ps.SubPackageProtos = true
+	// comments are converted from the proto source info
+	ps.ProtoSourceInfo = true
+	linked, err := ps.CompilePackage(ctx, pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The options of linked files are dynamicpb messages, rebuilding the
+	// files from marshalled descriptors resolves them to the Go types, which
+	// proto.GetExtension requires.
+	fileSet := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	var addFile func(protoreflect.FileDescriptor) error
+	addFile = func(file protoreflect.FileDescriptor) error {
+		if seen[file.Path()] {
+			return nil
+		}
+		seen[file.Path()] = true
+		imports := file.Imports()
+		for ii := 0; ii < imports.Len(); ii++ {
+			if err := addFile(imports.Get(ii).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		data, err := proto.Marshal(protodesc.ToFileDescriptorProto(file))
+		if err != nil {
+			return err
+		}
+		desc := &descriptorpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(data, desc); err != nil {
+			return err
+		}
+		fileSet.File = append(fileSet.File, desc)
+		return nil
+	}
+	for _, file := range linked {
+		if err := addFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	resolved, err := protodesc.NewFiles(fileSet)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]protoreflect.FileDescriptor, 0, len(linked))
+	for _, file := range linked {
+		desc, err := resolved.FindFileByPath(file.Path())
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, desc)
+	}
+	return files, nil
+}
+
+// overlayFiles replaces the proto files with the converted j5s files.
+type overlayFiles struct {
+	protobuild.LocalFileSource
+	added   map[string][]byte
+	removed map[string]bool
+}
+
+func (of *overlayFiles) GetLocalFile(ctx context.Context, filename string) ([]byte, error) {
+	if data, ok := of.added[filename]; ok {
+		return data, nil
+	}
+	if of.removed[filename] {
+		return nil, fmt.Errorf("file %s was converted to j5s", filename)
+	}
+	return of.LocalFileSource.GetLocalFile(ctx, filename)
+}
+
+func (of *overlayFiles) ListSourceFiles(ctx context.Context, root string) ([]string, error) {
+	files, err := of.LocalFileSource.ListSourceFiles(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(files)+len(of.added))
+	for _, filename := range files {
+		if !of.removed[filename] {
+			out = append(out, filename)
+		}
+	}
+	for filename := range of.added {
+		if strings.HasPrefix(filename, root) {
+			out = append(out, filename)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// comparePackages compares every message, enum and service in the two
+// packages by full name. Comments, json names and source locations are
+// ignored, the proto skeleton, j5 schema and the options j5s can write must
+// be equal.
+func comparePackages(original, converted []protoreflect.FileDescriptor) error {
+	want := indexDescriptors(original)
+	got := indexDescriptors(converted)
+
+	names := map[protoreflect.FullName]struct{}{}
+	for name := range want {
+		names[name] = struct{}{}
+	}
+	for name := range got {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, string(name))
+	}
+	sort.Strings(sorted)
+
+	// the caches are keyed by name, so each side needs its own
+	schemas := [2]*j5schema.SchemaCache{j5schema.NewSchemaCache(), j5schema.NewSchemaCache()}
+	diffs := []string{}
+	for _, name := range sorted {
+		wantDesc, ok := want[protoreflect.FullName(name)]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: added", name))
+			continue
+		}
+		gotDesc, ok := got[protoreflect.FullName(name)]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: missing", name))
+			continue
+		}
+		if err := compareDescriptor(schemas, wantDesc, gotDesc); err != nil {
+			diffs = append(diffs, fmt.Sprintf("%s: %s", name, err))
+		}
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("\n  %s", strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
+
+func indexDescriptors(files []protoreflect.FileDescriptor) map[protoreflect.FullName]protoreflect.Descriptor {
+	index := map[protoreflect.FullName]protoreflect.Descriptor{}
+	var addMessages func(protoreflect.MessageDescriptors)
+	addEnums := func(enums protoreflect.EnumDescriptors) {
+		for ii := 0; ii < enums.Len(); ii++ {
+			index[enums.Get(ii).FullName()] = enums.Get(ii)
+		}
+	}
+	addMessages = func(messages protoreflect.MessageDescriptors) {
+		for ii := 0; ii < messages.Len(); ii++ {
+			msg := messages.Get(ii)
+			if msg.IsMapEntry() {
+				continue
+			}
+			index[msg.FullName()] = msg
+			addMessages(msg.Messages())
+			addEnums(msg.Enums())
+		}
+	}
+	for _, file := range files {
+		addMessages(file.Messages())
+		addEnums(file.Enums())
+		for ii := 0; ii < file.Services().Len(); ii++ {
+			index[file.Services().Get(ii).FullName()] = file.Services().Get(ii)
+		}
+	}
+	return index
+}
+
+func compareDescriptor(schemas [2]*j5schema.SchemaCache, want, got protoreflect.Descriptor) error {
+	switch want := want.(type) {
+	case protoreflect.MessageDescriptor:
+		got, ok := got.(protoreflect.MessageDescriptor)
+		if !ok {
+			return fmt.Errorf("not a message")
+		}
+		return compareMessage(schemas, want, got)
+
+	case protoreflect.EnumDescriptor:
+		got, ok := got.(protoreflect.EnumDescriptor)
+		if !ok {
+			return fmt.Errorf("not an enum")
+		}
+		return compareEnum(want, got)
+
+	case protoreflect.ServiceDescriptor:
+		got, ok := got.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return fmt.Errorf("not a service")
+		}
+		return compareService(want, got)
+
+	default:
+		return fmt.Errorf("unsupported descriptor %T", want)
+	}
+}
+
+func compareMessage(schemas [2]*j5schema.SchemaCache, want, got protoreflect.MessageDescriptor) error {
+	wantSkeleton := messageSkeleton(want)
+	gotSkeleton := messageSkeleton(got)
+	if !proto.Equal(wantSkeleton, gotSkeleton) {
+		return skeletonDiff(wantSkeleton, gotSkeleton)
+	}
+	if err := compareExtension(want.Options(), got.Options(), ext_j5pb.E_Psm); err != nil {
+		return err
+	}
+
+	wantSchema, err := schemas[0].Schema(want)
+	if err != nil {
+		return err
+	}
+	gotSchema, err := schemas[1].Schema(got)
+	if err != nil {
+		return err
+	}
+	wantRoot := wantSchema.ToJ5Root()
+	gotRoot := gotSchema.ToJ5Root()
+	clearDescriptions(wantRoot.ProtoReflect())
+	clearDescriptions(gotRoot.ProtoReflect())
+	if !proto.Equal(wantRoot, gotRoot) {
+		return schemaDiff(wantRoot, gotRoot)
+	}
+	return nil
+}
+
+// messageSkeleton returns the message descriptor without options, names
+// which j5s derives, and nested types which are compared on their own.
+func messageSkeleton(msg protoreflect.MessageDescriptor) *descriptorpb.DescriptorProto {
+	desc := protodesc.ToDescriptorProto(msg)
+	desc.Options = nil
+	desc.EnumType = nil
+	nested := make([]*descriptorpb.DescriptorProto, 0)
+	for _, nestedMsg := range desc.NestedType {
+		if nestedMsg.GetOptions().GetMapEntry() {
+			for _, field := range nestedMsg.Field {
+				field.Options = nil
+				field.JsonName = nil
+			}
+			nested = append(nested, nestedMsg)
+		}
+	}
+	desc.NestedType = nested
+	// j5s doesn't declare the synthetic oneofs of proto3 optional fields,
+	// which are always last
+	realOneofs := len(desc.OneofDecl)
+	for _, field := range desc.Field {
+		field.Options = nil
+		field.JsonName = nil
+		if field.GetProto3Optional() {
+			if field.OneofIndex != nil && int(*field.OneofIndex) < realOneofs {
+				realOneofs = int(*field.OneofIndex)
+			}
+			field.OneofIndex = nil
+		}
+	}
+	desc.OneofDecl = desc.OneofDecl[:realOneofs]
+	for _, oneof := range desc.OneofDecl {
+		oneof.Options = nil
+	}
+	return desc
+}
+
+// skeletonDiff describes the first difference in the message skeletons.
+func skeletonDiff(want, got *descriptorpb.DescriptorProto) error {
+	gotFields := map[int32]*descriptorpb.FieldDescriptorProto{}
+	for _, field := range got.Field {
+		gotFields[field.GetNumber()] = field
+	}
+	for _, field := range want.Field {
+		gotField, ok := gotFields[field.GetNumber()]
+		if !ok {
+			return fmt.Errorf("field %d %s is missing", field.GetNumber(), field.GetName())
+		}
+		if !proto.Equal(field, gotField) {
+			return fmt.Errorf("field %d %s differs: got %s, want %s", field.GetNumber(), field.GetName(), prototextCompact(gotField), prototextCompact(field))
+		}
+	}
+	if len(want.Field) != len(got.Field) {
+		return fmt.Errorf("has %d fields, want %d", len(got.Field), len(want.Field))
+	}
+	return fmt.Errorf("message structure differs")
+}
+
+// schemaDiff describes the first property which differs in the schemas.
+func schemaDiff(want, got *schema_j5pb.RootSchema) error {
+	wantProps := rootProperties(want)
+	gotProps := map[string]*schema_j5pb.ObjectProperty{}
+	for _, prop := range rootProperties(got) {
+		gotProps[prop.Name] = prop
+	}
+	for _, prop := range wantProps {
+		gotProp, ok := gotProps[prop.Name]
+		if !ok {
+			return fmt.Errorf("property %s is missing", prop.Name)
+		}
+		if !proto.Equal(prop, gotProp) {
+			return fmt.Errorf("property %s differs: got %s, want %s", prop.Name, prototextCompact(gotProp), prototextCompact(prop))
+		}
+	}
+	return fmt.Errorf("j5 schema differs: got %s, want %s", prototextCompact(got), prototextCompact(want))
+}
+
+func rootProperties(root *schema_j5pb.RootSchema) []*schema_j5pb.ObjectProperty {
+	switch rt := root.Type.(type) {
+	case *schema_j5pb.RootSchema_Object:
+		return rt.Object.Properties
+	case *schema_j5pb.RootSchema_Oneof:
+		return rt.Oneof.Properties
+	}
+	return nil
+}
+
+func prototextCompact(msg proto.Message) string {
+	return prototext.MarshalOptions{}.Format(msg)
+}
+
+func compareEnum(want, got protoreflect.EnumDescriptor) error {
+	wantDesc := protodesc.ToEnumDescriptorProto(want)
+	gotDesc := protodesc.ToEnumDescriptorProto(got)
+	wantDesc.Options = nil
+	gotDesc.Options = nil
+	for _, value := range append(wantDesc.Value, gotDesc.Value...) {
+		value.Options = nil
+	}
+	if !proto.Equal(wantDesc, gotDesc) {
+		return fmt.Errorf("enum values differ")
+	}
+
+	if err := compareExtension(want.Options(), got.Options(), ext_j5pb.E_Enum); err != nil {
+		return err
+	}
+	for ii := 0; ii < want.Values().Len(); ii++ {
+		wantValue := want.Values().Get(ii)
+		gotValue := got.Values().Get(ii)
+		if err := compareExtension(wantValue.Options(), gotValue.Options(), ext_j5pb.E_EnumValue); err != nil {
+			return fmt.Errorf("value %s: %w", wantValue.Name(), err)
+		}
+	}
+	return nil
+}
+
+func compareService(want, got protoreflect.ServiceDescriptor) error {
+	if err := compareExtension(want.Options(), got.Options(), ext_j5pb.E_Service); err != nil {
+		return err
+	}
+	if err := compareExtension(want.Options(), got.Options(), messaging_j5pb.E_Service); err != nil {
+		return err
+	}
+
+	if want.Methods().Len() != got.Methods().Len() {
+		return fmt.Errorf("has %d methods, want %d", got.Methods().Len(), want.Methods().Len())
+	}
+	for ii := 0; ii < want.Methods().Len(); ii++ {
+		wantMethod := want.Methods().Get(ii)
+		gotMethod := got.Methods().Get(ii)
+		if wantMethod.Name() != gotMethod.Name() {
+			return fmt.Errorf("method %d is %s, want %s", ii, gotMethod.Name(), wantMethod.Name())
+		}
+		if wantMethod.Input().FullName() != gotMethod.Input().FullName() || wantMethod.Output().FullName() != gotMethod.Output().FullName() {
+			return fmt.Errorf("method %s: request or response type differs", wantMethod.Name())
+		}
+		if err := compareExtension(wantMethod.Options(), gotMethod.Options(), annotations.E_Http); err != nil {
+			return fmt.Errorf("method %s: %w", wantMethod.Name(), err)
+		}
+		if err := compareExtension(wantMethod.Options(), gotMethod.Options(), ext_j5pb.E_Method); err != nil {
+			return fmt.Errorf("method %s: %w", wantMethod.Name(), err)
+		}
+	}
+	return nil
+}
+
+func compareExtension(want, got proto.Message, ext protoreflect.ExtensionType) error {
+	wantVal := proto.GetExtension(want, ext)
+	gotVal := proto.GetExtension(got, ext)
+	wantMsg, _ := wantVal.(proto.Message)
+	gotMsg, _ := gotVal.(proto.Message)
+	if !proto.Equal(wantMsg, gotMsg) {
+		return fmt.Errorf("option %s differs", ext.TypeDescriptor().FullName())
+	}
+	return nil
+}
+
+// clearDescriptions removes descriptions from the schema, j5s writes them as
+// comments which aren't compared.
+func clearDescriptions(msg protoreflect.Message) {
+	msg.Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case field.Name() == "description" && field.Kind() == protoreflect.StringKind:
+			msg.Clear(field)
+		case field.IsMap():
+			if field.MapValue().Kind() == protoreflect.MessageKind {
+				value.Map().Range(func(_ protoreflect.MapKey, val protoreflect.Value) bool {
+					clearDescriptions(val.Message())
+					return true
+				})
+			}
+		case field.IsList():
+			if field.Kind() == protoreflect.MessageKind {
+				for ii := 0; ii < value.List().Len(); ii++ {
+					clearDescriptions(value.List().Get(ii).Message())
+				}
+			}
+		case field.Kind() == protoreflect.MessageKind:
+			clearDescriptions(value.Message())
+		}
+		return true
+	})
+}
@@ -85,6 +85,11 @@ func buildProperty(ww *conversionVisitor, node *sourcewalk.PropertyNode) (*descr
 			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
 			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
 			TypeName: &entryName,
+			Options:  &descriptorpb.FieldOptions{},
+		}
+
+		if st.Map.Ext != nil {
+			ww.setJ5Ext(node.Source, fieldDesc.Options, "map", st.Map.Ext)
 		}
 
 	case *schema_j5pb.Field_Array:
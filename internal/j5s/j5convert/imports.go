@@ -92,6 +92,17 @@ var implicitImports = map[string]*PackageSummary{
 	},
 }
 
+// IsImplicitImport returns true for types which j5s files can reference by
+// full name without importing the package.
+func IsImplicitImport(pkg string, name string) bool {
+	implicit, ok := implicitImports[pkg]
+	if !ok {
+		return false
+	}
+	_, ok = implicit.Exports[name]
+	return ok
+}
+
 type TypeNotFoundError struct {
 	Package string
 	Name    string
@@ -184,6 +195,29 @@ func j5Imports(file *sourcedef_j5pb.SourceFile) (*importMap, error) {
 	}, nil
 }
 
+// ImportAliases maps the shortest namespace for each package imported by the
+// file, as used in type references, to the full package name.
+func ImportAliases(file *sourcedef_j5pb.SourceFile) (map[string]string, error) {
+	imports, err := j5Imports(file)
+	if err != nil {
+		return nil, err
+	}
+
+	shortest := map[string]string{}
+	for alias, def := range imports.vals {
+		existing, ok := shortest[def.fullPath]
+		if !ok || len(alias) < len(existing) {
+			shortest[def.fullPath] = alias
+		}
+	}
+
+	aliases := make(map[string]string, len(shortest))
+	for pkg, alias := range shortest {
+		aliases[alias] = pkg
+	}
+	return aliases, nil
+}
+
 type expandedRef struct {
 	ref      *schema_j5pb.Ref
 	implicit *TypeRef
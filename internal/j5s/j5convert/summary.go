@@ -21,9 +21,19 @@ type FileSummary struct {
 	FileDependencies []string
 	TypeDependencies []*schema_j5pb.Ref
 
+	// References are the positions of explicit type references in the
+	// source, with the package expanded through the file's imports.
+	References []*TypeReference
+
 	ProducesFiles []string
 }
 
+// TypeReference is a reference to a type at a position in a source file
+type TypeReference struct {
+	Ref      *schema_j5pb.Ref
+	Position *errpos.Position
+}
+
 // MessageRef is the summary of a message definition (Object or Oneof)
 type MessageRef struct {
 	Oneof bool
@@ -57,6 +67,11 @@ type TypeRef struct {
 	File     string
 	Position *errpos.Position
 
+	// SourceFilename and Description are set for types declared in j5s
+	// sources.
+	SourceFilename string
+	Description    string
+
 	// Oneof
 	*EnumRef
 	*MessageRef
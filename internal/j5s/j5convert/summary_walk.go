@@ -2,6 +2,7 @@ package j5convert
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
@@ -10,9 +11,12 @@ import (
 )
 
 type ErrCollector interface {
-	WarnPos(pos *errpos.Position, err error)
+	WarnPos(pos *errpos.Position, rule string, err error)
 }
 
+// RuleUnusedImport is the warning for an import with no references.
+const RuleUnusedImport = "UNUSED_IMPORT"
+
 // SourceSummary collects the exports and imports for a j5 source file
 func SourceSummary(sourceFile *sourcedef_j5pb.SourceFile, ec ErrCollector) (*FileSummary, error) {
 
@@ -45,6 +49,9 @@ func SourceSummary(sourceFile *sourcedef_j5pb.SourceFile, ec ErrCollector) (*Fil
 		expanded := importMap.expand(refSrc.Ref)
 		if expanded == nil {
 			err := fmt.Errorf("package %q not imported (for schema %s)", refSrc.Ref.Package, refSrc.Ref.Schema)
+			if fix := addImportFix(sourceFile, refSrc.Ref.Package); fix != nil {
+				err = errpos.AddFix(err, fix)
+			}
 			err = errpos.AddContext(err, strings.Join(refSrc.Source.Path, "."))
 			loc := refSrc.Source.GetPos()
 			if loc != nil {
@@ -54,11 +61,18 @@ func SourceSummary(sourceFile *sourcedef_j5pb.SourceFile, ec ErrCollector) (*Fil
 		}
 
 		fs.TypeDependencies = append(fs.TypeDependencies, expanded.ref)
+		if !refSrc.Inline {
+			fs.References = append(fs.References, &TypeReference{
+				Ref:      expanded.ref,
+				Position: refSrc.Source.GetPos(),
+			})
+		}
 	}
 
 	for _, export := range cc.exports {
 		export.Package = sourceFile.Package.Name
 		export.File = importPath
+		export.SourceFilename = sourceFile.Path
 		fs.Exports[export.Name] = export
 		//fmt.Printf("export from %s: %s\n", export.Package, export.Name)
 	}
@@ -71,6 +85,7 @@ func SourceSummary(sourceFile *sourcedef_j5pb.SourceFile, ec ErrCollector) (*Fil
 		var pos *errpos.Position
 		if ref.source != nil {
 			pos = &errpos.Position{
+				Filename: &sourceFile.Path,
 				Start: errpos.Point{
 					Line:   int(ref.source.StartLine),
 					Column: int(ref.source.StartColumn),
@@ -80,14 +95,54 @@ func SourceSummary(sourceFile *sourcedef_j5pb.SourceFile, ec ErrCollector) (*Fil
 					Column: int(ref.source.EndColumn),
 				},
 			}
+			// imports are a statement per line
+			err = errpos.AddFix(err, &errpos.Fix{
+				Title: fmt.Sprintf("Remove import %s", ref.fullPath),
+				Edits: []errpos.Edit{{
+					Start: errpos.Point{Line: pos.Start.Line},
+					End:   errpos.Point{Line: pos.Start.Line + 1},
+				}},
+			})
 		}
-		ec.WarnPos(pos, err)
+		ec.WarnPos(pos, RuleUnusedImport, err)
 	}
 
 	return fs, nil
 
 }
 
+// addImportFix inserts an import after the existing imports, or after the
+// package. Only full package names can be imported, a short name like foo
+// does not give the version.
+func addImportFix(sourceFile *sourcedef_j5pb.SourceFile, pkg string) *errpos.Fix {
+	if strings.Count(pkg, ".") < 1 {
+		return nil
+	}
+
+	root := sourcewalk.NewRoot(sourceFile).Source
+	var after sourcewalk.SourceNode
+	newText := fmt.Sprintf("import %s\n", pkg)
+	if len(sourceFile.Imports) > 0 {
+		after = root.Child("imports", strconv.Itoa(len(sourceFile.Imports)-1))
+	} else {
+		after = root.Child("package")
+		newText = "\n" + newText
+	}
+	if after.IsVirtual() {
+		return nil
+	}
+
+	insertAt := errpos.Point{Line: int(after.Source.EndLine) + 1}
+	return &errpos.Fix{
+		Title: fmt.Sprintf("Import %s", pkg),
+		Edits: []errpos.Edit{{
+			Start:   insertAt,
+			End:     insertAt,
+			NewText: newText,
+		}},
+	}
+}
+
 type summaryWalker struct {
 	exports         []*TypeRef
 	refs            []*sourcewalk.RefNode
@@ -114,8 +169,30 @@ func (c *summaryWalker) addRef(ref *sourcewalk.RefNode) {
 func (cc *summaryWalker) collectFileRefs(sourceFile *sourcedef_j5pb.SourceFile) error {
 	file := sourcewalk.NewRoot(sourceFile)
 
+	if err := checkDuplicateSchemas(file); err != nil {
+		return err
+	}
+
+	// properties of inline objects are visited within the parent, so the
+	// names for each object are a stack.
+	propertyNames := []map[string]bool{}
+	pushProperties := func() {
+		propertyNames = append(propertyNames, map[string]bool{})
+	}
+	popProperties := func() {
+		propertyNames = propertyNames[:len(propertyNames)-1]
+	}
+
 	visitor := &sourcewalk.DefaultVisitor{
 		Property: func(node *sourcewalk.PropertyNode) error {
+			if len(propertyNames) > 0 {
+				names := propertyNames[len(propertyNames)-1]
+				if names[node.Schema.Name] && !node.Source.IsVirtual() {
+					return duplicateError("field", node.Schema.Name, node.Source.Child("name"), names)
+				}
+				names[node.Schema.Name] = true
+			}
+
 			if node.Field.Ref != nil {
 				cc.addRef(node.Field.Ref)
 			} else if node.Field.Items != nil && node.Field.Items.Ref != nil {
@@ -125,10 +202,20 @@ func (cc *summaryWalker) collectFileRefs(sourceFile *sourcedef_j5pb.SourceFile)
 		},
 		Object: func(node *sourcewalk.ObjectNode) error {
 			cc.addExport(objectTypeRef(node))
+			pushProperties()
+			return nil
+		},
+		ObjectExit: func(node *sourcewalk.ObjectNode) error {
+			popProperties()
 			return nil
 		},
 		Oneof: func(node *sourcewalk.OneofNode) error {
 			cc.addExport(oneofTypeRef(node))
+			pushProperties()
+			return nil
+		},
+		OneofExit: func(node *sourcewalk.OneofNode) error {
+			popProperties()
 			return nil
 		},
 		Enum: func(node *sourcewalk.EnumNode) error {
@@ -153,18 +240,66 @@ func (cc *summaryWalker) collectFileRefs(sourceFile *sourcedef_j5pb.SourceFile)
 
 }
 
+// checkDuplicateSchemas finds root schemas declared twice in the file, which
+// would otherwise fail when linking with no source position.
+func checkDuplicateSchemas(file *sourcewalk.FileNode) error {
+	names := map[string]bool{}
+	for idx, element := range file.Elements {
+		source := file.Source.Child("elements", strconv.Itoa(idx))
+		var kind, name string
+		switch element := element.Type.(type) {
+		case *sourcedef_j5pb.RootElement_Object:
+			kind, name = "object", element.Object.Def.Name
+			source = source.Child("object", "def", "name")
+		case *sourcedef_j5pb.RootElement_Oneof:
+			kind, name = "oneof", element.Oneof.Def.Name
+			source = source.Child("oneof", "def", "name")
+		case *sourcedef_j5pb.RootElement_Enum:
+			kind, name = "enum", element.Enum.Name
+			source = source.Child("enum", "name")
+		default:
+			continue
+		}
+		if names[name] {
+			return duplicateError(kind, name, source, names)
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+// duplicateError reports the name at the source, with a fix renaming it to
+// the first free name with a number suffix.
+func duplicateError(kind string, name string, source sourcewalk.SourceNode, taken map[string]bool) error {
+	err := fmt.Errorf("%s %q is declared more than once", kind, name)
+	if source.IsVirtual() {
+		return errpos.AddPosition(err, *source.GetPos())
+	}
+
+	newName := name
+	for idx := 2; taken[newName]; idx++ {
+		newName = fmt.Sprintf("%s%d", name, idx)
+	}
+
+	pos := *source.GetPos()
+	err = errpos.AddPosition(err, pos)
+	return errpos.AddFix(err, errpos.ReplaceFix(fmt.Sprintf("Rename to %s", newName), pos, newName))
+}
+
 func objectTypeRef(node *sourcewalk.ObjectNode) *TypeRef {
 	return &TypeRef{
-		Name:       node.NameInPackage(),
-		Position:   node.Source.GetPos(),
-		MessageRef: &MessageRef{},
+		Name:        node.NameInPackage(),
+		Position:    node.Source.GetPos(),
+		Description: node.Description,
+		MessageRef:  &MessageRef{},
 	}
 }
 
 func oneofTypeRef(node *sourcewalk.OneofNode) *TypeRef {
 	return &TypeRef{
-		Name:     node.NameInPackage(),
-		Position: node.Source.GetPos(),
+		Name:        node.NameInPackage(),
+		Position:    node.Source.GetPos(),
+		Description: node.Schema.Description,
 		MessageRef: &MessageRef{
 			Oneof: true,
 		},
@@ -177,8 +312,9 @@ func enumTypeRef(node *sourcewalk.EnumNode) *TypeRef {
 		valMap[node.Schema.Prefix+value.Name] = value.Number
 	}
 	return &TypeRef{
-		Name:     node.NameInPackage(),
-		Position: node.Source.GetPos(),
+		Name:        node.NameInPackage(),
+		Position:    node.Source.GetPos(),
+		Description: node.Schema.Description,
 
 		EnumRef: &EnumRef{
 			Prefix: node.Schema.Prefix,
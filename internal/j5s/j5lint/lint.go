@@ -0,0 +1,171 @@
+// Package j5lint checks j5s source files against style rules. Findings are
+// warnings, on top of the compile errors reported by protobuild.
+package j5lint
+
+import (
+	"fmt"
+
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/j5s/sourcewalk"
+)
+
+// File is a parsed j5s file to lint. Data is the raw text, which holds the
+// suppression comments.
+type File struct {
+	Filename string
+	Data     string
+	Source   *sourcedef_j5pb.SourceFile
+}
+
+// Rule is a style check. Checks is called once per Lint call, so the returned
+// callbacks can keep state across files, e.g. to find duplicates.
+type Rule struct {
+	ID          string
+	Description string
+
+	// Optional rules only run when enabled in the bundle config.
+	Optional bool
+
+	Checks func(rp *Reporter) *Checks
+}
+
+// Checks are the callbacks for a rule, any may be nil.
+type Checks struct {
+	File     func(*sourcewalk.FileNode)
+	Object   func(*sourcewalk.ObjectNode)
+	Oneof    func(*sourcewalk.OneofNode)
+	Enum     func(*sourcewalk.EnumNode)
+	Property func(*sourcewalk.PropertyNode)
+	Service  func(*sourcewalk.ServiceNode)
+	Topic    func(*sourcewalk.TopicNode)
+
+	// Nested is called for schemas declared in a schemas block, before the
+	// Object, Oneof or Enum callback. Inline schemas of fields are not
+	// included.
+	Nested func(nameInPackage string, source sourcewalk.SourceNode)
+
+	// Done is called after all files.
+	Done func()
+}
+
+// Reporter adds findings for a single rule.
+type Reporter struct {
+	rule   string
+	linter *linter
+}
+
+// Report adds a finding at the source node, in the file being walked.
+func (rp *Reporter) Report(source sourcewalk.SourceNode, format string, args ...any) {
+	rp.ReportPos(rp.Position(source), format, args...)
+}
+
+// Position is the position of the node in the file being walked, for
+// findings which are reported later, e.g. in Done.
+func (rp *Reporter) Position(source sourcewalk.SourceNode) *errpos.Position {
+	pos := source.GetPos()
+	filename := rp.linter.filename
+	pos.Filename = &filename
+	return pos
+}
+
+// ReportPos adds a finding at a position from Position.
+func (rp *Reporter) ReportPos(pos *errpos.Position, format string, args ...any) {
+	rp.linter.add(rp.rule, pos, nil, fmt.Errorf(format, args...))
+}
+
+// ReportFix adds a finding at the source node with a suggested fix, the fix
+// may be nil.
+func (rp *Reporter) ReportFix(source sourcewalk.SourceNode, fix *errpos.Fix, format string, args ...any) {
+	rp.linter.add(rp.rule, rp.Position(source), fix, fmt.Errorf(format, args...))
+}
+
+// RenameFix replaces the name of the node, from the 'name' child. Returns nil
+// when the name is not in the source, e.g. for generated nodes.
+func RenameFix(source sourcewalk.SourceNode, newName string) *errpos.Fix {
+	name := source.Child("name")
+	if name.IsVirtual() {
+		return nil
+	}
+	return errpos.ReplaceFix(fmt.Sprintf("Rename to %s", newName), *name.GetPos(), newName)
+}
+
+// APIRenameFix is a RenameFix for a name which is part of the API, so the fix
+// is marked as changing it.
+func APIRenameFix(source sourcewalk.SourceNode, newName string) *errpos.Fix {
+	fix := RenameFix(source, newName)
+	if fix == nil {
+		return nil
+	}
+	fix.Title = fmt.Sprintf("Rename to %s (changes the API)", newName)
+	fix.ChangesAPI = true
+	return fix
+}
+
+type linter struct {
+	filename string
+	suppress map[string]*suppressions
+
+	// seen stops duplicates, from virtual nodes at the same position as the
+	// source node which produced them.
+	seen     map[string]bool
+	findings errpos.Errors
+}
+
+func (ll *linter) add(rule string, pos *errpos.Position, fix *errpos.Fix, err error) {
+	key := fmt.Sprintf("%s %s %s", rule, pos.String(), err.Error())
+	if ll.seen[key] {
+		return
+	}
+	ll.seen[key] = true
+
+	if pos.Filename != nil {
+		if sup, ok := ll.suppress[*pos.Filename]; ok && sup.ignores(rule, pos.Start.Line) {
+			return
+		}
+	}
+
+	ll.findings = append(ll.findings, &errpos.Err{
+		Pos:      pos,
+		Err:      err,
+		Severity: errpos.SeverityWarning,
+		Rule:     rule,
+		Fix:      fix,
+	})
+}
+
+// Lint runs the rules over the files, which may be from any package in a
+// bundle, returning warnings for the findings which are not suppressed.
+func Lint(files []*File, rules []Rule) (errpos.Errors, error) {
+	ll := &linter{
+		suppress: map[string]*suppressions{},
+		seen:     map[string]bool{},
+		findings: errpos.Errors{},
+	}
+
+	checks := make([]*Checks, 0, len(rules))
+	for _, rule := range rules {
+		checks = append(checks, rule.Checks(&Reporter{
+			rule:   rule.ID,
+			linter: ll,
+		}))
+	}
+
+	for _, file := range files {
+		ll.filename = file.Filename
+		ll.suppress[file.Filename] = parseSuppressions(file.Data)
+
+		ww := &walker{checks: checks}
+		if err := ww.VisitFile(sourcewalk.NewRoot(file.Source)); err != nil {
+			return nil, fmt.Errorf("linting %s: %w", file.Filename, err)
+		}
+	}
+
+	for _, check := range checks {
+		if check.Done != nil {
+			check.Done()
+		}
+	}
+
+	return ll.findings, nil
+}
@@ -0,0 +1,296 @@
+package j5lint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/j5s/j5parse"
+)
+
+func testLint(t *testing.T, rules []Rule, files map[string][]string) []string {
+	t.Helper()
+	parser, err := j5parse.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lintFiles := make([]*File, 0, len(files))
+	for filename, lines := range files {
+		data := strings.Join(lines, "\n")
+		sourceFile, err := parser.ParseFile(filename, data)
+		if err != nil {
+			t.Fatalf("parsing %s: %s", filename, err)
+		}
+		lintFiles = append(lintFiles, &File{
+			Filename: filename,
+			Data:     data,
+			Source:   sourceFile,
+		})
+	}
+
+	findings, err := Lint(lintFiles, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		str := finding.Rule + " " + finding.Pos.String()
+		t.Logf("%s %s", str, finding.Err)
+		out = append(out, str)
+	}
+	return out
+}
+
+func assertFindings(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d findings %q, want %q", len(got), got, want)
+	}
+	for idx := range want {
+		if got[idx] != want[idx] {
+			t.Errorf("finding %d: got %q, want %q", idx, got[idx], want[idx])
+		}
+	}
+}
+
+func ruleByID(t *testing.T, id string) []Rule {
+	t.Helper()
+	for _, rule := range BuiltinRules {
+		if rule.ID == id {
+			return []Rule{rule}
+		}
+	}
+	t.Fatalf("no rule %s", id)
+	return nil
+}
+
+func TestNaming(t *testing.T) {
+	file := []string{
+		"package foo.v1",
+		"",
+		"object foo_bar {",
+		"  field bar_id string",
+		"  field barName string",
+		"}",
+		"",
+		"enum Status {",
+		"  option ACTIVE",
+		"  option STATUS_INACTIVE",
+		"  option Deleted",
+		"}",
+	}
+
+	t.Run("Object", func(t *testing.T) {
+		got := testLint(t, ruleByID(t, RuleObjectNameCase), map[string][]string{"foo/v1/foo.j5s": file})
+		assertFindings(t, got, "OBJECT_NAME_CASE foo/v1/foo.j5s:3:8")
+	})
+
+	t.Run("Field", func(t *testing.T) {
+		got := testLint(t, ruleByID(t, RuleFieldNameCase), map[string][]string{"foo/v1/foo.j5s": file})
+		assertFindings(t, got, "FIELD_NAME_CASE foo/v1/foo.j5s:5:3")
+	})
+
+	t.Run("Enum Prefix", func(t *testing.T) {
+		got := testLint(t, ruleByID(t, RuleEnumValuePrefix), map[string][]string{"foo/v1/foo.j5s": file})
+		assertFindings(t, got, "ENUM_VALUE_PREFIX foo/v1/foo.j5s:10:3")
+	})
+
+	t.Run("Enum Case", func(t *testing.T) {
+		got := testLint(t, ruleByID(t, RuleEnumValueCase), map[string][]string{"foo/v1/foo.j5s": file})
+		assertFindings(t, got, "ENUM_VALUE_CASE foo/v1/foo.j5s:11:3")
+	})
+}
+
+func TestUnusedNested(t *testing.T) {
+	got := testLint(t, ruleByID(t, RuleUnusedNested), map[string][]string{
+		"foo/v1/foo.j5s": {
+			"package foo.v1",
+			"",
+			"object Foo {",
+			"  field bar object:Bar",
+			"",
+			"  object Bar {",
+			"    field id string",
+			"  }",
+			"",
+			"  object Baz {",
+			"    field id string",
+			"  }",
+			"}",
+		},
+	})
+	assertFindings(t, got, "UNUSED_NESTED_SCHEMA foo/v1/foo.j5s:10:3")
+}
+
+func TestDuplicateHTTPPath(t *testing.T) {
+	got := testLint(t, ruleByID(t, RuleDuplicateHTTPPath), map[string][]string{
+		"foo/v1/foo.j5s": {
+			"package foo.v1",
+			"",
+			"service Foo {",
+			"  basePath = \"/foo/v1\"",
+			"  method GetFoo {",
+			"    httpMethod = \"GET\"",
+			"    httpPath = \"/foo/:id\"",
+			"    request {",
+			"      field id string",
+			"    }",
+			"    response {",
+			"      field name string",
+			"    }",
+			"  }",
+			"  method GetFooByName {",
+			"    httpMethod = \"GET\"",
+			"    httpPath = \"/foo/:name\"",
+			"    request {",
+			"      field name string",
+			"    }",
+			"    response {",
+			"      field name string",
+			"    }",
+			"  }",
+			"}",
+		},
+	})
+	assertFindings(t, got, "DUPLICATE_HTTP_PATH foo/v1/foo.j5s:15:3")
+}
+
+func TestSuppression(t *testing.T) {
+	rules := append(ruleByID(t, RuleObjectNameCase), ruleByID(t, RuleFieldNameCase)...)
+	got := testLint(t, rules, map[string][]string{
+		"foo/v1/foo.j5s": {
+			"package foo.v1",
+			"// j5lint:ignore-file FIELD_NAME_CASE",
+			"",
+			"// j5lint:ignore OBJECT_NAME_CASE",
+			"object foo_bar {",
+			"  field bar_id string",
+			"  field barName string",
+			"}",
+			"",
+			"object baz_qux {",
+			"}",
+		},
+	})
+	assertFindings(t, got, "OBJECT_NAME_CASE foo/v1/foo.j5s:10:8")
+}
+
+func TestRulesFromConfig(t *testing.T) {
+	ids := func(rules []Rule) map[string]bool {
+		out := map[string]bool{}
+		for _, rule := range rules {
+			out[rule.ID] = true
+		}
+		return out
+	}
+
+	defaults, err := RulesFromConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(defaults); got[RuleMissingDescription] || !got[RuleObjectNameCase] {
+		t.Errorf("unexpected default rules %v", got)
+	}
+
+	configured, err := RulesFromConfig(&config_j5pb.LintConfig{
+		Enable:  []string{RuleMissingDescription},
+		Disable: []string{RuleObjectNameCase},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(configured); !got[RuleMissingDescription] || got[RuleObjectNameCase] {
+		t.Errorf("unexpected configured rules %v", got)
+	}
+
+	_, err = RulesFromConfig(&config_j5pb.LintConfig{
+		Disable: []string{"NOT_A_RULE"},
+	})
+	if err == nil {
+		t.Error("expected error for unknown rule")
+	}
+}
+
+func TestFixes(t *testing.T) {
+	parser, err := j5parse.NewParser()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := strings.Join([]string{
+		"package foo.v1",
+		"",
+		"object Foo {",
+		"  field bar_id string",
+		"  field barName string",
+		"}",
+		"",
+		"enum Status {",
+		"  option ACTIVE",
+		"  option STATUS_INACTIVE",
+		"  option Deleted",
+		"}",
+	}, "\n")
+	sourceFile, err := parser.ParseFile("foo/v1/foo.j5s", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := Lint([]*File{{
+		Filename: "foo/v1/foo.j5s",
+		Data:     data,
+		Source:   sourceFile,
+	}}, BuiltinRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixes := []*errpos.Fix{}
+	changesAPI := []string{}
+	for _, finding := range findings {
+		if finding.Fix != nil {
+			fixes = append(fixes, finding.Fix)
+			if finding.Fix.ChangesAPI {
+				changesAPI = append(changesAPI, finding.Fix.Title)
+			}
+		}
+	}
+
+	// Removing the prefix keeps the same enum values, the renames do not.
+	wantChangesAPI := []string{
+		"Rename to bar_name (changes the API)",
+		"Rename to DELETED (changes the API)",
+	}
+	if strings.Join(changesAPI, ", ") != strings.Join(wantChangesAPI, ", ") {
+		t.Errorf("fixes changing the API: %v, want %v", changesAPI, wantChangesAPI)
+	}
+
+	fixed, applied, err := errpos.ApplyFixes(data, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 3 {
+		t.Errorf("applied %d fixes, want 3", applied)
+	}
+
+	want := strings.Join([]string{
+		"package foo.v1",
+		"",
+		"object Foo {",
+		"  field bar_id string",
+		"  field bar_name string",
+		"}",
+		"",
+		"enum Status {",
+		"  option ACTIVE",
+		"  option INACTIVE",
+		"  option DELETED",
+		"}",
+	}, "\n")
+	if fixed != want {
+		t.Errorf("got:\n%s\nwant:\n%s", fixed, want)
+	}
+}
@@ -0,0 +1,352 @@
+package j5lint
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/j5s/sourcewalk"
+)
+
+const (
+	RuleObjectNameCase     = "OBJECT_NAME_CASE"
+	RuleEnumNameCase       = "ENUM_NAME_CASE"
+	RuleEnumValuePrefix    = "ENUM_VALUE_PREFIX"
+	RuleEnumValueCase      = "ENUM_VALUE_CASE"
+	RuleFieldNameCase      = "FIELD_NAME_CASE"
+	RuleMissingDescription = "MISSING_DESCRIPTION"
+	RuleUnusedNested       = "UNUSED_NESTED_SCHEMA"
+	RuleEntityNoCommands   = "ENTITY_NO_COMMANDS"
+	RuleDuplicateHTTPPath  = "DUPLICATE_HTTP_PATH"
+)
+
+// BuiltinRules are the rules available to the bundle config, in the order
+// they run.
+var BuiltinRules = []Rule{{
+	ID:          RuleObjectNameCase,
+	Description: "Object and oneof names are UpperCamelCase",
+	Checks:      objectNameCase,
+}, {
+	ID:          RuleEnumNameCase,
+	Description: "Enum names are UpperCamelCase",
+	Checks:      enumNameCase,
+}, {
+	ID:          RuleEnumValuePrefix,
+	Description: "Enum options do not repeat the prefix, which is added when converting",
+	Checks:      enumValuePrefix,
+}, {
+	ID:          RuleEnumValueCase,
+	Description: "Enum options are SCREAMING_SNAKE_CASE",
+	Checks:      enumValueCase,
+}, {
+	ID:          RuleFieldNameCase,
+	Description: "Field names are all snake_case or all lowerCamelCase within a file",
+	Checks:      fieldNameCase,
+}, {
+	ID:          RuleMissingDescription,
+	Description: "Root objects, oneofs, enums, entities and services have a description",
+	Optional:    true,
+	Checks:      missingDescription,
+}, {
+	ID:          RuleUnusedNested,
+	Description: "Schemas in a schemas block are referenced by a field",
+	Checks:      unusedNested,
+}, {
+	ID:          RuleEntityNoCommands,
+	Description: "Entities have at least one command",
+	Checks:      entityNoCommands,
+}, {
+	ID:          RuleDuplicateHTTPPath,
+	Description: "HTTP method and path pairs are unique across services",
+	Checks:      duplicateHTTPPath,
+}}
+
+// RulesFromConfig returns the rules to run for the bundle: the builtin rules
+// which are not optional, plus enabled, minus disabled.
+func RulesFromConfig(cfg *config_j5pb.LintConfig) ([]Rule, error) {
+	known := map[string]bool{}
+	for _, rule := range BuiltinRules {
+		known[rule.ID] = true
+	}
+
+	enabled := map[string]bool{}
+	for _, id := range cfg.GetEnable() {
+		if !known[id] {
+			return nil, fmt.Errorf("lint enable: unknown rule %q", id)
+		}
+		enabled[id] = true
+	}
+
+	disabled := map[string]bool{}
+	for _, id := range cfg.GetDisable() {
+		if !known[id] {
+			return nil, fmt.Errorf("lint disable: unknown rule %q", id)
+		}
+		if enabled[id] {
+			return nil, fmt.Errorf("lint rule %q is both enabled and disabled", id)
+		}
+		disabled[id] = true
+	}
+
+	rules := make([]Rule, 0, len(BuiltinRules))
+	for _, rule := range BuiltinRules {
+		if disabled[rule.ID] || (rule.Optional && !enabled[rule.ID]) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+var (
+	upperCamelCase     = regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`)
+	lowerCamelCase     = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+	snakeCase          = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+	screamingSnakeCase = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+)
+
+func objectNameCase(rp *Reporter) *Checks {
+	return &Checks{
+		Object: func(node *sourcewalk.ObjectNode) {
+			if !upperCamelCase.MatchString(node.Name) {
+				rp.Report(node.Source, "object name %q should be UpperCamelCase", node.Name)
+			}
+		},
+		Oneof: func(node *sourcewalk.OneofNode) {
+			if !upperCamelCase.MatchString(node.Schema.Name) {
+				rp.Report(node.Source, "oneof name %q should be UpperCamelCase", node.Schema.Name)
+			}
+		},
+	}
+}
+
+func enumNameCase(rp *Reporter) *Checks {
+	return &Checks{
+		Enum: func(node *sourcewalk.EnumNode) {
+			if !upperCamelCase.MatchString(node.Schema.Name) {
+				rp.Report(node.Source, "enum name %q should be UpperCamelCase", node.Schema.Name)
+			}
+		},
+	}
+}
+
+// enumPrefix matches the prefix used by j5convert.
+func enumPrefix(node *sourcewalk.EnumNode) string {
+	if node.Schema.Prefix != "" {
+		return node.Schema.Prefix
+	}
+	return strcase.ToScreamingSnake(node.Schema.Name) + "_"
+}
+
+func enumValuePrefix(rp *Reporter) *Checks {
+	return &Checks{
+		Enum: func(node *sourcewalk.EnumNode) {
+			prefix := enumPrefix(node)
+			for idx, option := range node.Schema.Options {
+				trimmed, ok := strings.CutPrefix(option.Name, prefix)
+				if !ok {
+					continue
+				}
+				source := node.Source.Child("options", strconv.Itoa(idx))
+				// j5convert only adds the prefix when it is missing, so
+				// the proto and JSON names do not change.
+				var fix *errpos.Fix
+				if trimmed != "" {
+					fix = RenameFix(source, trimmed)
+				}
+				rp.ReportFix(source, fix,
+					"enum option %q repeats the prefix %q, use %q", option.Name, prefix, trimmed)
+			}
+		},
+	}
+}
+
+func enumValueCase(rp *Reporter) *Checks {
+	return &Checks{
+		Enum: func(node *sourcewalk.EnumNode) {
+			for idx, option := range node.Schema.Options {
+				if !screamingSnakeCase.MatchString(option.Name) {
+					source := node.Source.Child("options", strconv.Itoa(idx))
+					rp.ReportFix(source, APIRenameFix(source, strcase.ToScreamingSnake(option.Name)),
+						"enum option %q should be SCREAMING_SNAKE_CASE", option.Name)
+				}
+			}
+		},
+	}
+}
+
+// fieldNameCase allows either snake_case or lowerCamelCase, the first field
+// name in the file which is only one of them sets the case for the file. The
+// field name is the JSON name, so the fixes change the API.
+func fieldNameCase(rp *Reporter) *Checks {
+	var fileCase string
+	return &Checks{
+		File: func(*sourcewalk.FileNode) {
+			fileCase = ""
+		},
+		Property: func(node *sourcewalk.PropertyNode) {
+			name := node.Schema.Name
+			isSnake := snakeCase.MatchString(name)
+			isCamel := lowerCamelCase.MatchString(name)
+			switch {
+			case isSnake && isCamel:
+				// a single word, either case
+			case isSnake:
+				if fileCase == "" {
+					fileCase = "snake_case"
+				} else if fileCase != "snake_case" {
+					rp.ReportFix(node.Source, APIRenameFix(node.Source, strcase.ToLowerCamel(name)),
+						"field name %q is snake_case, the file uses %s", name, fileCase)
+				}
+			case isCamel:
+				if fileCase == "" {
+					fileCase = "lowerCamelCase"
+				} else if fileCase != "lowerCamelCase" {
+					rp.ReportFix(node.Source, APIRenameFix(node.Source, strcase.ToSnake(name)),
+						"field name %q is lowerCamelCase, the file uses %s", name, fileCase)
+				}
+			default:
+				var fix *errpos.Fix
+				if fileCase == "lowerCamelCase" {
+					fix = APIRenameFix(node.Source, strcase.ToLowerCamel(name))
+				} else {
+					fix = APIRenameFix(node.Source, strcase.ToSnake(name))
+				}
+				rp.ReportFix(node.Source, fix, "field name %q should be snake_case or lowerCamelCase", name)
+			}
+		},
+	}
+}
+
+func missingDescription(rp *Reporter) *Checks {
+	return &Checks{
+		File: func(file *sourcewalk.FileNode) {
+			for idx, element := range file.Elements {
+				source := file.Source.Child("elements", strconv.Itoa(idx))
+				switch element := element.Type.(type) {
+				case *sourcedef_j5pb.RootElement_Object:
+					if element.Object.Def.Description == "" {
+						rp.Report(source.Child("object"), "object %q has no description", element.Object.Def.Name)
+					}
+				case *sourcedef_j5pb.RootElement_Oneof:
+					if element.Oneof.Def.Description == "" {
+						rp.Report(source.Child("oneof"), "oneof %q has no description", element.Oneof.Def.Name)
+					}
+				case *sourcedef_j5pb.RootElement_Enum:
+					if element.Enum.Description == "" {
+						rp.Report(source.Child("enum"), "enum %q has no description", element.Enum.Name)
+					}
+				case *sourcedef_j5pb.RootElement_Entity:
+					if element.Entity.Description == "" {
+						rp.Report(source.Child("entity"), "entity %q has no description", element.Entity.Name)
+					}
+				case *sourcedef_j5pb.RootElement_Service:
+					if element.Service.Description == "" {
+						rp.Report(source.Child("service"), "service %q has no description", element.Service.GetName())
+					}
+				}
+			}
+		},
+	}
+}
+
+// unusedNested matches references by name, ignoring the package, so a
+// reference to a schema of the same name in another package counts as a use.
+func unusedNested(rp *Reporter) *Checks {
+	type nestedSchema struct {
+		name string
+		pos  *errpos.Position
+	}
+	declared := []nestedSchema{}
+	referenced := map[string]bool{}
+
+	addRef := func(field *sourcewalk.FieldNode) {
+		for field != nil {
+			if field.Ref != nil {
+				referenced[field.Ref.Schema] = true
+			}
+			field = field.Items
+		}
+	}
+
+	return &Checks{
+		Nested: func(nameInPackage string, source sourcewalk.SourceNode) {
+			declared = append(declared, nestedSchema{
+				name: nameInPackage,
+				pos:  rp.Position(source),
+			})
+		},
+		Property: func(node *sourcewalk.PropertyNode) {
+			addRef(&node.Field)
+		},
+		Done: func() {
+			for _, schema := range declared {
+				if referenced[schema.name] {
+					continue
+				}
+				_, shortName, _ := cutLast(schema.name, ".")
+				if referenced[shortName] {
+					continue
+				}
+				rp.ReportPos(schema.pos, "nested schema %q is not used", schema.name)
+			}
+		},
+	}
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	idx := strings.LastIndex(s, sep)
+	if idx < 0 {
+		return "", s, false
+	}
+	return s[:idx], s[idx+len(sep):], true
+}
+
+func entityNoCommands(rp *Reporter) *Checks {
+	return &Checks{
+		File: func(file *sourcewalk.FileNode) {
+			for idx, element := range file.Elements {
+				entity := element.GetEntity()
+				if entity == nil || len(entity.Commands) > 0 {
+					continue
+				}
+				source := file.Source.Child("elements", strconv.Itoa(idx), "entity")
+				rp.Report(source, "entity %q has no commands", entity.Name)
+			}
+		},
+	}
+}
+
+var pathParam = regexp.MustCompile(`^(:.*|\{.*\})$`)
+
+// duplicateHTTPPath compares paths with parameters replaced, as /foo/:id and
+// /foo/:fooId route the same requests.
+func duplicateHTTPPath(rp *Reporter) *Checks {
+	seen := map[string]*errpos.Position{}
+	return &Checks{
+		Service: func(node *sourcewalk.ServiceNode) {
+			for idx, method := range node.Methods {
+				parts := strings.Split(path.Clean("/"+method.ResolvedPath), "/")
+				for idx, part := range parts {
+					if pathParam.MatchString(part) {
+						parts[idx] = "*"
+					}
+				}
+				key := fmt.Sprintf("%s %s", method.Schema.HttpMethod.ShortString(), strings.Join(parts, "/"))
+
+				pos := rp.Position(node.Source.Child("methods", strconv.Itoa(idx)))
+				if first, ok := seen[key]; ok {
+					rp.ReportPos(pos, "%s %s is also used at %s", method.Schema.HttpMethod.ShortString(), method.ResolvedPath, first.String())
+					continue
+				}
+				seen[key] = pos
+			}
+		},
+	}
+}
@@ -0,0 +1,68 @@
+package j5lint
+
+import (
+	"strings"
+)
+
+const (
+	// ignoreComment suppresses the rules on the line of the comment, and the
+	// line after, e.g. `// j5lint:ignore FIELD_NAME_CASE, MISSING_DESCRIPTION`
+	ignoreComment = "j5lint:ignore"
+
+	// ignoreFileComment suppresses the rules for the whole file.
+	ignoreFileComment = "j5lint:ignore-file"
+)
+
+type suppressions struct {
+	file  map[string]bool
+	lines map[int]map[string]bool
+}
+
+func (sup *suppressions) ignores(rule string, line int) bool {
+	if sup.file[rule] {
+		return true
+	}
+	return sup.lines[line][rule]
+}
+
+// parseSuppressions reads the ignore comments from the raw file. Lines are 0
+// based, as in errpos.
+func parseSuppressions(data string) *suppressions {
+	sup := &suppressions{
+		file:  map[string]bool{},
+		lines: map[int]map[string]bool{},
+	}
+
+	for idx, line := range strings.Split(data, "\n") {
+		_, comment, ok := strings.Cut(line, "//")
+		if !ok {
+			continue
+		}
+		comment = strings.TrimSpace(comment)
+
+		if rules, ok := strings.CutPrefix(comment, ignoreFileComment); ok {
+			for _, rule := range splitRules(rules) {
+				sup.file[rule] = true
+			}
+			continue
+		}
+
+		if rules, ok := strings.CutPrefix(comment, ignoreComment); ok {
+			for _, line := range []int{idx, idx + 1} {
+				if sup.lines[line] == nil {
+					sup.lines[line] = map[string]bool{}
+				}
+				for _, rule := range splitRules(rules) {
+					sup.lines[line][rule] = true
+				}
+			}
+		}
+	}
+	return sup
+}
+
+func splitRules(rules string) []string {
+	return strings.FieldsFunc(rules, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
@@ -0,0 +1,119 @@
+package j5lint
+
+import (
+	"github.com/pentops/j5build/internal/j5s/sourcewalk"
+)
+
+// walker visits every node of a file, calling the checks of each rule.
+type walker struct {
+	checks []*Checks
+}
+
+var _ sourcewalk.FileVisitor = &walker{}
+var _ sourcewalk.PropertyVisitor = &walker{}
+
+func (ww *walker) VisitFile(node *sourcewalk.FileNode) error {
+	for _, check := range ww.checks {
+		if check.File != nil {
+			check.File(node)
+		}
+	}
+	return node.RangeRootElements(ww)
+}
+
+func (ww *walker) VisitObject(node *sourcewalk.ObjectNode) error {
+	for _, check := range ww.checks {
+		if check.Object != nil {
+			check.Object(node)
+		}
+	}
+	if err := node.RangeProperties(ww); err != nil {
+		return err
+	}
+	return node.RangeNestedSchemas(&nestedWalker{walker: ww})
+}
+
+func (ww *walker) VisitOneof(node *sourcewalk.OneofNode) error {
+	for _, check := range ww.checks {
+		if check.Oneof != nil {
+			check.Oneof(node)
+		}
+	}
+	if err := node.RangeProperties(ww); err != nil {
+		return err
+	}
+	return node.RangeNestedSchemas(&nestedWalker{walker: ww})
+}
+
+func (ww *walker) VisitEnum(node *sourcewalk.EnumNode) error {
+	for _, check := range ww.checks {
+		if check.Enum != nil {
+			check.Enum(node)
+		}
+	}
+	return nil
+}
+
+func (ww *walker) VisitProperty(node *sourcewalk.PropertyNode) error {
+	for _, check := range ww.checks {
+		if check.Property != nil {
+			check.Property(node)
+		}
+	}
+	return nil
+}
+
+func (ww *walker) VisitTopicFile(node *sourcewalk.TopicFileNode) error {
+	return node.Accept(ww)
+}
+
+func (ww *walker) VisitTopic(node *sourcewalk.TopicNode) error {
+	for _, check := range ww.checks {
+		if check.Topic != nil {
+			check.Topic(node)
+		}
+	}
+	return nil
+}
+
+func (ww *walker) VisitServiceFile(node *sourcewalk.ServiceFileNode) error {
+	return node.Accept(ww)
+}
+
+func (ww *walker) VisitService(node *sourcewalk.ServiceNode) error {
+	for _, check := range ww.checks {
+		if check.Service != nil {
+			check.Service(node)
+		}
+	}
+	return nil
+}
+
+// nestedWalker visits the schemas block of an object or oneof, calling Nested
+// before the usual checks.
+type nestedWalker struct {
+	*walker
+}
+
+func (nw *nestedWalker) nested(nameInPackage string, source sourcewalk.SourceNode) {
+	for _, check := range nw.checks {
+		if check.Nested != nil {
+			check.Nested(nameInPackage, source)
+		}
+	}
+}
+
+func (nw *nestedWalker) VisitObject(node *sourcewalk.ObjectNode) error {
+	nw.nested(node.NameInPackage(), node.Source)
+	return nw.walker.VisitObject(node)
+}
+
+func (nw *nestedWalker) VisitOneof(node *sourcewalk.OneofNode) error {
+	nw.nested(node.NameInPackage(), node.Source)
+	return nw.walker.VisitOneof(node)
+}
+
+func (nw *nestedWalker) VisitEnum(node *sourcewalk.EnumNode) error {
+	nw.nested(node.NameInPackage(), node.Source)
+	return nw.walker.VisitEnum(node)
+}
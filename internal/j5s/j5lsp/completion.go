@@ -0,0 +1,91 @@
+package j5lsp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/pentops/j5build/internal/bcl/genlsp"
+	"github.com/pentops/j5build/internal/j5s/j5convert"
+	"go.lsp.dev/protocol"
+)
+
+var _ genlsp.Completer = &Workspace{}
+
+// reTypeReference matches a type reference being typed at the end of a line,
+// e.g. `field bar object:ba` or `ref = bar.B`.
+var reTypeReference = regexp.MustCompile(`(?:\b(object|oneof|enum):|\bref\s*=?\s*)([A-Za-z0-9_.]*)$`)
+
+// Completion suggests the types which can be referenced from the document after
+// an `object:`, `oneof:`, `enum:` or `ref`. Block and attribute names are
+// completed from the schema by genlsp.
+func (ws *Workspace) Completion(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position) ([]protocol.CompletionItem, error) {
+	lines := strings.Split(doc.Text, "\n")
+	if int(pos.Line) >= len(lines) {
+		return []protocol.CompletionItem{}, nil
+	}
+	line := lines[pos.Line]
+	prefix := line[:genlsp.LineOffset(line, pos.Character)]
+
+	match := reTypeReference.FindStringSubmatch(prefix)
+	if match == nil {
+		return []protocol.CompletionItem{}, nil
+	}
+	kind := match[1]
+
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	// The line being typed would not parse, the rest of the file is enough
+	// for the imports.
+	lines[pos.Line] = ""
+	ps, file, err := ws.packageSet(ctx, &protocol.TextDocumentItem{
+		URI:  doc.URI,
+		Text: strings.Join(lines, "\n"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := ps.TypesInScope(ctx, file.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []protocol.CompletionItem{}
+	for _, scoped := range types {
+		if !typeMatchesKind(scoped.Type, kind) {
+			continue
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:  scoped.Name,
+			Kind:   completionKind(scoped.Type),
+			Detail: scoped.Type.Package,
+			Documentation: protocol.MarkupContent{
+				Kind:  protocol.Markdown,
+				Value: hoverText(scoped.Type),
+			},
+		})
+	}
+	return items, nil
+}
+
+func typeMatchesKind(typeRef *j5convert.TypeRef, kind string) bool {
+	switch kind {
+	case "object":
+		return typeRef.MessageRef != nil && !typeRef.MessageRef.Oneof
+	case "oneof":
+		return typeRef.MessageRef != nil && typeRef.MessageRef.Oneof
+	case "enum":
+		return typeRef.EnumRef != nil
+	default:
+		return true
+	}
+}
+
+func completionKind(typeRef *j5convert.TypeRef) protocol.CompletionItemKind {
+	if typeRef.EnumRef != nil {
+		return protocol.CompletionItemKindEnum
+	}
+	return protocol.CompletionItemKindClass
+}
@@ -0,0 +1,119 @@
+package j5lsp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/bcl/genlsp"
+	"github.com/pentops/j5build/internal/j5s/protobuild"
+	"github.com/pentops/log.go/log"
+	"go.lsp.dev/protocol"
+)
+
+var _ genlsp.ProjectLinter = &Workspace{}
+
+// LintProject lints the document with the packages of its bundle, reporting
+// unresolved references, link errors and the style rules of the bundle.
+// Errors are mapped back to the file they occurred in, which may not be the
+// document.
+func (ws *Workspace) LintProject(ctx context.Context, doc *protocol.TextDocumentItem) (map[protocol.DocumentURI][]protocol.Diagnostic, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ps, file, err := ws.packageSet(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	byFile := map[protocol.DocumentURI][]protocol.Diagnostic{
+		doc.URI: {},
+	}
+
+	lintRules := ws.bundles[file.bundle.DirInRepo()].lintRules
+
+	lintErr, err := protobuild.LintFile(ctx, ps, file.filename, doc.Text, lintRules...)
+	if err != nil {
+		if ews, ok := errpos.AsErrorsWithSource(err); ok {
+			lintErr = ews
+		} else {
+			// Not positional, e.g. a dependency failed to load, shown at the
+			// top of the document.
+			log.WithError(ctx, err).Error("LintFile")
+			byFile[doc.URI] = append(byFile[doc.URI], protocol.Diagnostic{
+				Message:  err.Error(),
+				Severity: protocol.DiagnosticSeverityError,
+				Source:   "j5",
+			})
+			return byFile, nil
+		}
+	}
+
+	if lintErr == nil {
+		return byFile, nil
+	}
+
+	for _, err := range lintErr.Errors {
+		uri, diag := ws.lintDiagnostic(ctx, doc, file, err)
+		byFile[uri] = append(byFile[uri], diag)
+	}
+
+	return byFile, nil
+}
+
+// lintDiagnostic converts an error from linting the document to a diagnostic
+// in the document it occurred in.
+func (ws *Workspace) lintDiagnostic(ctx context.Context, doc *protocol.TextDocumentItem, file *bundleFile, err *errpos.Err) (protocol.DocumentURI, protocol.Diagnostic) {
+	uri := doc.URI
+	text := doc.Text
+	inFile := false
+	generated := ""
+	if err.Pos != nil && err.Pos.Filename != nil {
+		uri = ws.errorURI(doc.URI, file, *err.Pos.Filename)
+		if strings.HasSuffix(*err.Pos.Filename, ".j5s.proto") {
+			generated = *err.Pos.Filename
+		}
+		inFile = *err.Pos.Filename != "" && generated == ""
+		if uri != doc.URI {
+			text = ws.fileText(ctx, file.bundle, *err.Pos.Filename)
+		}
+	}
+	diag := errDiagnostic(text, err)
+	if inFile {
+		// fixes for generated files would not apply to the source
+		diag.Data = genlsp.FixData(text, err.Fix)
+	}
+	if generated != "" {
+		// The position is in the generated proto text, not the document, so
+		// the error is shown at the top, naming the generated file.
+		diag.Range = protocol.Range{}
+		diag.Message = generated + ": " + diag.Message
+	}
+	return uri, diag
+}
+
+// errorURI maps the filename of an error to a document. Errors in generated
+// files are reported against the linted source, which produced them, without
+// their position.
+func (ws *Workspace) errorURI(docURI protocol.DocumentURI, file *bundleFile, filename string) protocol.DocumentURI {
+	if filename == "" || filename == file.filename || strings.HasSuffix(filename, ".j5s.proto") {
+		return docURI
+	}
+	return ws.fileURI(file.bundle, filename)
+}
+
+// errDiagnostic converts an error in the text to a diagnostic.
+func errDiagnostic(text string, err *errpos.Err) protocol.Diagnostic {
+	diag := protocol.Diagnostic{
+		Message:  err.Err.Error(),
+		Severity: protocol.DiagnosticSeverity(err.Severity.LSPSeverity()),
+		Source:   "j5",
+	}
+	if err.Rule != "" {
+		diag.Code = err.Rule
+	}
+	if err.Pos != nil {
+		diag.Range = toRange(text, *err.Pos)
+	}
+	return diag
+}
@@ -0,0 +1,147 @@
+package j5lsp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/bcl/genlsp"
+	"github.com/pentops/j5build/internal/j5s/j5convert"
+	"github.com/pentops/j5build/internal/j5s/protobuild"
+	"go.lsp.dev/protocol"
+)
+
+var _ genlsp.Navigator = &Workspace{}
+
+func (ws *Workspace) symbolAt(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position) (*protobuild.PackageSet, *bundleFile, *protobuild.Symbol, error) {
+	ps, file, err := ws.packageSet(ctx, doc)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	symbol, err := ps.SymbolAt(ctx, file.filename, genlsp.ToPoint(doc.Text, pos))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return ps, file, symbol, nil
+}
+
+func (ws *Workspace) Definition(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position) ([]protocol.Location, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	_, file, symbol, err := ws.symbolAt(ctx, doc, pos)
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return []protocol.Location{}, nil
+	}
+
+	loc, ok := ws.declarationLocation(ctx, file, symbol.Type)
+	if !ok {
+		return []protocol.Location{}, nil
+	}
+	return []protocol.Location{loc}, nil
+}
+
+func (ws *Workspace) Hover(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position) (*protocol.Hover, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	_, _, symbol, err := ws.symbolAt(ctx, doc, pos)
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return nil, nil
+	}
+
+	symbolRange := toRange(doc.Text, symbol.Position)
+	return &protocol.Hover{
+		Contents: protocol.MarkupContent{
+			Kind:  protocol.Markdown,
+			Value: hoverText(symbol.Type),
+		},
+		Range: &symbolRange,
+	}, nil
+}
+
+func (ws *Workspace) References(ctx context.Context, doc *protocol.TextDocumentItem, pos protocol.Position, includeDeclaration bool) ([]protocol.Location, error) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	ps, file, symbol, err := ws.symbolAt(ctx, doc, pos)
+	if err != nil {
+		return nil, err
+	}
+	if symbol == nil {
+		return []protocol.Location{}, nil
+	}
+
+	refs, err := ps.FindReferences(ctx, symbol.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]protocol.Location, 0, len(refs)+1)
+	if includeDeclaration {
+		if loc, ok := ws.declarationLocation(ctx, file, symbol.Type); ok {
+			locations = append(locations, loc)
+		}
+	}
+	for _, ref := range refs {
+		locations = append(locations, protocol.Location{
+			URI:   ws.fileURI(file.bundle, ref.Filename),
+			Range: toRange(ws.fileText(ctx, file.bundle, ref.Filename), ref.Position),
+		})
+	}
+	return locations, nil
+}
+
+// declarationLocation returns the location of a type declared in a j5s file in
+// the same bundle. Types from dependencies and proto files have no location.
+func (ws *Workspace) declarationLocation(ctx context.Context, file *bundleFile, typeRef *j5convert.TypeRef) (protocol.Location, bool) {
+	if typeRef.SourceFilename == "" || typeRef.Position == nil {
+		return protocol.Location{}, false
+	}
+	return protocol.Location{
+		URI:   ws.fileURI(file.bundle, typeRef.SourceFilename),
+		Range: toRange(ws.fileText(ctx, file.bundle, typeRef.SourceFilename), *typeRef.Position),
+	}, true
+}
+
+func hoverText(typeRef *j5convert.TypeRef) string {
+	kind := "type"
+	switch {
+	case typeRef.EnumRef != nil:
+		kind = "enum"
+	case typeRef.MessageRef != nil && typeRef.MessageRef.Oneof:
+		kind = "oneof"
+	case typeRef.MessageRef != nil:
+		kind = "object"
+	}
+
+	lines := []string{
+		fmt.Sprintf("**%s** `%s`", kind, typeRef.Name),
+		"",
+		fmt.Sprintf("package `%s`", typeRef.Package),
+	}
+	if typeRef.Description != "" {
+		lines = append(lines, "", typeRef.Description)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// toRange converts a source position in the text, which has an inclusive end
+// column, to an LSP range.
+func toRange(text string, pos errpos.Position) protocol.Range {
+	return protocol.Range{
+		Start: genlsp.ToPosition(text, pos.Start),
+		End: genlsp.ToPosition(text, errpos.Point{
+			Line:   pos.End.Line,
+			Column: pos.End.Column + 1,
+		}),
+	}
+}
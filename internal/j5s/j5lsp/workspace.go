@@ -0,0 +1,273 @@
+package j5lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pentops/j5build/internal/j5s/j5lint"
+	"github.com/pentops/j5build/internal/j5s/protobuild"
+	"github.com/pentops/j5build/internal/source"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// Workspace resolves j5s documents to the bundle and packages they belong to.
+// A PackageSet is kept per bundle, with the text of edited documents in place
+// of the files on disk, and the local packages are reloaded when a document
+// changes.
+type Workspace struct {
+	root    string
+	srcRoot *source.RepoRoot
+
+	lock    sync.Mutex
+	bundles map[string]*bundleState
+}
+
+func NewWorkspace(ctx context.Context, root string) (*Workspace, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	resolver, err := source.NewEnvResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	srcRoot, err := source.NewFSRepoRoot(ctx, os.DirFS(root), resolver.WithLocalRoot(root))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Workspace{
+		root:    root,
+		srcRoot: srcRoot,
+		bundles: map[string]*bundleState{},
+	}, nil
+}
+
+type bundleState struct {
+	bundle    source.Bundle
+	deps      source.DependencySet
+	overlay   *overlayFiles
+	packages  *protobuild.PackageSet
+	lintRules []j5lint.Rule
+}
+
+// bundleFile is a document located in a bundle.
+type bundleFile struct {
+	bundle   source.Bundle
+	filename string // relative to the bundle root
+}
+
+// findBundle returns the bundle containing the document. Bundles may be
+// nested, so the deepest bundle directory wins.
+func (ws *Workspace) findBundle(uri protocol.DocumentURI) (*bundleFile, error) {
+	fullPath := uri.Filename()
+	var found *bundleFile
+	foundDepth := -1
+	for _, bundle := range ws.srcRoot.AllBundles() {
+		bundleDir := filepath.Join(ws.root, bundle.DirInRepo())
+		rel, err := filepath.Rel(bundleDir, fullPath)
+		if err != nil {
+			continue
+		}
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		depth := strings.Count(filepath.Clean(bundleDir), string(filepath.Separator))
+		if depth <= foundDepth {
+			continue
+		}
+		found = &bundleFile{
+			bundle:   bundle,
+			filename: filepath.ToSlash(rel),
+		}
+		foundDepth = depth
+	}
+	if found == nil {
+		return nil, fmt.Errorf("file %s not found in any bundle", fullPath)
+	}
+	return found, nil
+}
+
+// fileURI returns the URI of a file in a bundle.
+func (ws *Workspace) fileURI(bundle source.Bundle, filename string) protocol.DocumentURI {
+	fullPath := filepath.Join(ws.root, bundle.DirInRepo(), filepath.FromSlash(filename))
+	return uri.File(fullPath)
+}
+
+// CloseDocument drops the edited text of a closed document, so the file on
+// disk is used again.
+func (ws *Workspace) CloseDocument(ctx context.Context, docURI protocol.DocumentURI) {
+	ws.lock.Lock()
+	defer ws.lock.Unlock()
+
+	file, err := ws.findBundle(docURI)
+	if err != nil {
+		return
+	}
+	state, ok := ws.bundles[file.bundle.DirInRepo()]
+	if !ok {
+		return
+	}
+	if state.overlay.removeFile(file.filename) {
+		state.fileChanged(file.filename)
+	}
+}
+
+// fileChanged drops what the PackageSet read from the file, or the whole set
+// if that fails.
+func (state *bundleState) fileChanged(filename string) {
+	if state.packages == nil {
+		return
+	}
+	if err := state.packages.FileChanged(filename); err != nil {
+		state.packages = nil
+	}
+}
+
+func (ws *Workspace) bundleState(ctx context.Context, bundle source.Bundle) (*bundleState, error) {
+	state, ok := ws.bundles[bundle.DirInRepo()]
+	if ok {
+		return state, nil
+	}
+
+	deps, err := bundle.GetDependencies(ctx, ws.srcRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleConfig, err := bundle.J5Config()
+	if err != nil {
+		return nil, err
+	}
+
+	lintRules, err := j5lint.RulesFromConfig(bundleConfig.GetLint())
+	if err != nil {
+		return nil, err
+	}
+
+	state = &bundleState{
+		bundle:    bundle,
+		deps:      deps,
+		lintRules: lintRules,
+		overlay: &overlayFiles{
+			LocalFileSource: localFiles,
+			files:           map[string][]byte{},
+		},
+	}
+	ws.bundles[bundle.DirInRepo()] = state
+	return state, nil
+}
+
+// packageSet returns the PackageSet for the bundle containing the document.
+// The caller must hold the workspace lock.
+func (ws *Workspace) packageSet(ctx context.Context, doc *protocol.TextDocumentItem) (*protobuild.PackageSet, *bundleFile, error) {
+	file, err := ws.findBundle(doc.URI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state, err := ws.bundleState(ctx, file.bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if state.overlay.setFile(file.filename, doc.Text) {
+		state.fileChanged(file.filename)
+	}
+
+	if state.packages == nil {
+		ps, err := protobuild.NewPackageSet(state.deps, state.overlay)
+		if err != nil {
+			return nil, nil, err
+		}
+		state.packages = ps
+	}
+
+	return state.packages, file, nil
+}
+
+// fileText returns the text of a file in the bundle, edited or on disk, to
+// convert its positions. Unreadable files are empty, so columns are used as
+// they are. The caller must hold the workspace lock.
+func (ws *Workspace) fileText(ctx context.Context, bundle source.Bundle, filename string) string {
+	state, ok := ws.bundles[bundle.DirInRepo()]
+	if !ok {
+		return ""
+	}
+	data, err := state.overlay.GetLocalFile(ctx, filename)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// overlayFiles serves edited documents in place of the files on disk.
+type overlayFiles struct {
+	protobuild.LocalFileSource
+	files map[string][]byte
+}
+
+// setFile sets the content of a file, returning true if it changed.
+func (of *overlayFiles) setFile(filename string, data string) bool {
+	existing, ok := of.files[filename]
+	if ok && string(existing) == data {
+		return false
+	}
+	of.files[filename] = []byte(data)
+	return true
+}
+
+// removeFile drops the content of a file, returning true if it was set.
+func (of *overlayFiles) removeFile(filename string) bool {
+	if _, ok := of.files[filename]; !ok {
+		return false
+	}
+	delete(of.files, filename)
+	return true
+}
+
+func (of *overlayFiles) GetLocalFile(ctx context.Context, filename string) ([]byte, error) {
+	if data, ok := of.files[filename]; ok {
+		return data, nil
+	}
+	return of.LocalFileSource.GetLocalFile(ctx, filename)
+}
+
+// ListSourceFiles includes edited documents which are not yet saved to disk.
+func (of *overlayFiles) ListSourceFiles(ctx context.Context, root string) ([]string, error) {
+	files, err := of.LocalFileSource.ListSourceFiles(ctx, root)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		// A new package directory may only have unsaved documents.
+		return nil, err
+	}
+	listed := map[string]bool{}
+	for _, filename := range files {
+		listed[filename] = true
+	}
+	prefix := strings.ReplaceAll(root, ".", "/") + "/"
+	for filename := range of.files {
+		if listed[filename] || !strings.HasPrefix(filename, prefix) {
+			continue
+		}
+		if strings.HasSuffix(filename, ".j5s") || strings.HasSuffix(filename, ".proto") && !strings.HasSuffix(filename, ".j5s.proto") {
+			files = append(files, filename)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
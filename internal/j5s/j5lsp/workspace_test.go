@@ -0,0 +1,492 @@
+package j5lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/bcl/genlsp"
+	"go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+type testProject struct {
+	root string
+}
+
+func newTestProject(t *testing.T) *testProject {
+	t.Helper()
+	t.Setenv("J5_REGISTRY", "http://localhost:1")
+	t.Setenv("J5_CACHE_DIR", t.TempDir())
+	tp := &testProject{root: t.TempDir()}
+	tp.writeFile(t, "j5.yaml",
+		"bundles:",
+		"  - name: main",
+		"    dir: proto",
+	)
+	tp.writeFile(t, "proto/j5.yaml",
+		"packages:",
+		"  - name: foo.v1",
+		"  - name: bar.v1",
+	)
+	return tp
+}
+
+func (tp *testProject) writeFile(t *testing.T, filename string, lines ...string) {
+	t.Helper()
+	fullPath := filepath.Join(tp.root, filename)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fullPath, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (tp *testProject) doc(filename string, lines ...string) *protocol.TextDocumentItem {
+	return &protocol.TextDocumentItem{
+		URI:  uri.File(filepath.Join(tp.root, filename)),
+		Text: strings.Join(lines, "\n") + "\n",
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	ctx := context.Background()
+	tp := newTestProject(t)
+
+	tp.writeFile(t, "proto/bar/v1/bar.j5s",
+		"package bar.v1",
+		"",
+		"object Bar {",
+		"  | The Bar",
+		"  field id string",
+		"}",
+	)
+
+	fooLines := []string{
+		"package foo.v1",
+		"",
+		"import bar.v1:bar",
+		"",
+		"object Foo {",
+		"  field bar object:bar.Bar",
+		"}",
+	}
+	tp.writeFile(t, "proto/foo/v1/foo.j5s", fooLines...)
+
+	ws, err := NewWorkspace(ctx, tp.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	barDoc := tp.doc("proto/bar/v1/bar.j5s",
+		"package bar.v1",
+		"",
+		"object Bar {",
+		"  | The Bar",
+		"  field id string",
+		"}",
+	)
+
+	t.Run("definition", func(t *testing.T) {
+		locs, err := ws.Definition(ctx, tp.doc("proto/foo/v1/foo.j5s", fooLines...), protocol.Position{Line: 5, Character: 22})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(locs) != 1 {
+			t.Fatalf("expected one location, got %v", locs)
+		}
+		if locs[0].URI != barDoc.URI || locs[0].Range.Start.Line != 2 {
+			t.Errorf("unexpected location %v", locs[0])
+		}
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		hover, err := ws.Hover(ctx, tp.doc("proto/foo/v1/foo.j5s", fooLines...), protocol.Position{Line: 5, Character: 22})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hover == nil {
+			t.Fatal("expected hover")
+		}
+		if !strings.Contains(hover.Contents.Value, "The Bar") || !strings.Contains(hover.Contents.Value, "bar.v1") {
+			t.Errorf("unexpected hover %q", hover.Contents.Value)
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		locs, err := ws.References(ctx, barDoc, protocol.Position{Line: 2, Character: 8}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(locs) != 1 || locs[0].Range.Start.Line != 5 {
+			t.Fatalf("unexpected references %v", locs)
+		}
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		doc := tp.doc("proto/foo/v1/foo.j5s",
+			"package foo.v1",
+			"",
+			"import bar.v1:bar",
+			"",
+			"object Foo {",
+			"  field bar object:",
+			"}",
+			"",
+			"enum Status {",
+			"  option ACTIVE",
+			"}",
+		)
+		items, err := ws.Completion(ctx, doc, protocol.Position{Line: 5, Character: 19})
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		if strings.Join(labels, ",") != "Foo,bar.Bar" {
+			t.Errorf("unexpected completions %v", labels)
+		}
+	})
+
+	t.Run("completion utf16", func(t *testing.T) {
+		// the emoji is two UTF-16 code units and four bytes
+		doc := tp.doc("proto/foo/v1/foo.j5s",
+			"package foo.v1",
+			"",
+			"import bar.v1:bar",
+			"",
+			"object Foo {",
+			"  field 😀 object:",
+			"}",
+		)
+		items, err := ws.Completion(ctx, doc, protocol.Position{Line: 5, Character: 18})
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		if strings.Join(labels, ",") != "Foo,bar.Bar" {
+			t.Errorf("unexpected completions %v", labels)
+		}
+	})
+
+	t.Run("lint clean", func(t *testing.T) {
+		diagnostics, err := ws.LintProject(ctx, tp.doc("proto/foo/v1/foo.j5s", fooLines...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for uri, fileDiagnostics := range diagnostics {
+			if len(fileDiagnostics) > 0 {
+				t.Errorf("unexpected diagnostics for %s: %v", uri, fileDiagnostics)
+			}
+		}
+	})
+
+	t.Run("lint unsaved", func(t *testing.T) {
+		doc := tp.doc("proto/foo/v1/foo.j5s",
+			"package foo.v1",
+			"",
+			"import bar.v1:bar",
+			"",
+			"object Foo {",
+			"  field bar object:bar.Baz",
+			"}",
+		)
+		diagnostics, err := ws.LintProject(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileDiagnostics := diagnostics[doc.URI]
+		if len(fileDiagnostics) != 1 {
+			t.Fatalf("expected one diagnostic, got %v", diagnostics)
+		}
+		if fileDiagnostics[0].Range.Start.Line != 5 {
+			t.Errorf("unexpected range %v", fileDiagnostics[0].Range)
+		}
+		if !strings.Contains(fileDiagnostics[0].Message, "Baz") {
+			t.Errorf("unexpected message %q", fileDiagnostics[0].Message)
+		}
+	})
+
+	t.Run("lint fix", func(t *testing.T) {
+		doc := tp.doc("proto/foo/v1/foo.j5s",
+			"package foo.v1",
+			"",
+			"object Foo {",
+			"  field bar object:bar.v1.Bar",
+			"}",
+		)
+		diagnostics, err := ws.LintProject(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileDiagnostics := diagnostics[doc.URI]
+		if len(fileDiagnostics) != 1 {
+			t.Fatalf("expected one diagnostic, got %v", diagnostics)
+		}
+		fix, ok := fileDiagnostics[0].Data.(*genlsp.DiagnosticFix)
+		if !ok {
+			t.Fatalf("expected a fix, got %v", fileDiagnostics[0].Data)
+		}
+		if len(fix.Edits) != 1 || fix.Edits[0].NewText != "\nimport bar.v1\n" || fix.Edits[0].Range.Start.Line != 1 {
+			t.Errorf("unexpected fix %q %v", fix.Title, fix.Edits)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		// an unsaved edit removing Bar breaks foo
+		_, err := ws.LintProject(ctx, tp.doc("proto/bar/v1/bar.j5s",
+			"package bar.v1",
+			"",
+			"object Baz {",
+			"  field id string",
+			"}",
+		))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagnostics, err := ws.LintProject(ctx, tp.doc("proto/foo/v1/foo.j5s", fooLines...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(diagnostics[tp.doc("proto/foo/v1/foo.j5s").URI]) == 0 {
+			t.Fatalf("expected diagnostics for the unsaved bar")
+		}
+
+		ws.CloseDocument(ctx, barDoc.URI)
+		diagnostics, err = ws.LintProject(ctx, tp.doc("proto/foo/v1/foo.j5s", fooLines...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for uri, fileDiagnostics := range diagnostics {
+			if len(fileDiagnostics) > 0 {
+				t.Errorf("unexpected diagnostics for %s after close: %v", uri, fileDiagnostics)
+			}
+		}
+	})
+
+	t.Run("unsaved partials", func(t *testing.T) {
+		// partials are read from documents which are not on disk, and again
+		// when the document changes.
+		partialLines := func(field string) []string {
+			return []string{
+				"package foo.v1",
+				"",
+				".partial object common {",
+				"  field " + field + " string",
+				"}",
+			}
+		}
+		_, err := ws.LintProject(ctx, tp.doc("proto/foo/v1/partials.j5s", partialLines("name")...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		doc := tp.doc("proto/foo/v1/foo.j5s",
+			"package foo.v1",
+			"",
+			"object Foo {",
+			"  .include common",
+			"  field name string",
+			"}",
+		)
+		diagnostics, err := ws.LintProject(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileDiagnostics := diagnostics[doc.URI]
+		if len(fileDiagnostics) != 1 || !strings.Contains(fileDiagnostics[0].Message, "declared more than once") {
+			t.Fatalf("expected a duplicate field from the unsaved partial, got %v", diagnostics)
+		}
+
+		_, err = ws.LintProject(ctx, tp.doc("proto/foo/v1/partials.j5s", partialLines("other")...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagnostics, err = ws.LintProject(ctx, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for uri, fileDiagnostics := range diagnostics {
+			if len(fileDiagnostics) > 0 {
+				t.Errorf("unexpected diagnostics for %s after the partial changed: %v", uri, fileDiagnostics)
+			}
+		}
+	})
+}
+
+func TestLintGeneratedFile(t *testing.T) {
+	ctx := context.Background()
+	tp := newTestProject(t)
+	fooLines := []string{
+		"package foo.v1",
+		"",
+		"object Foo {",
+		"  field id string",
+		"}",
+	}
+	tp.writeFile(t, "proto/foo/v1/foo.j5s", fooLines...)
+
+	ws, err := NewWorkspace(ctx, tp.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := tp.doc("proto/foo/v1/foo.j5s", fooLines...)
+	_, file, err := ws.packageSet(ctx, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The position is in the proto text generated from the document, which
+	// does not match the lines of the document.
+	uri, diag := ws.lintDiagnostic(ctx, doc, file, &errpos.Err{
+		Err: fmt.Errorf("bad option"),
+		Pos: &errpos.Position{
+			Filename: gl.Ptr("foo/v1/foo.j5s.proto"),
+			Start:    errpos.Point{Line: 9, Column: 2},
+			End:      errpos.Point{Line: 9, Column: 8},
+		},
+	})
+	if uri != doc.URI {
+		t.Errorf("expected the document, got %s", uri)
+	}
+	if diag.Range != (protocol.Range{}) {
+		t.Errorf("expected no range, got %v", diag.Range)
+	}
+	if diag.Message != "foo/v1/foo.j5s.proto: bad option" {
+		t.Errorf("unexpected message %q", diag.Message)
+	}
+}
+
+func TestWorkspaceUTF16(t *testing.T) {
+	ctx := context.Background()
+	tp := newTestProject(t)
+
+	barLines := []string{
+		"package bar.v1",
+		"",
+		"object Bar {",
+		"  field id string",
+		"}",
+	}
+	tp.writeFile(t, "proto/bar/v1/bar.j5s", barLines...)
+
+	// each emoji is two UTF-16 code units and one rune, bar.Bar starts at
+	// rune 28, character 30.
+	fooLines := []string{
+		"package foo.v1",
+		"",
+		"import bar.v1:bar",
+		"",
+		"object Foo {",
+		"  /* 😀😀 */ field bar object:bar.Bar",
+		"}",
+	}
+	tp.writeFile(t, "proto/foo/v1/foo.j5s", fooLines...)
+
+	ws, err := NewWorkspace(ctx, tp.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fooDoc := tp.doc("proto/foo/v1/foo.j5s", fooLines...)
+	wantRange := protocol.Range{
+		Start: protocol.Position{Line: 5, Character: 30},
+		End:   protocol.Position{Line: 5, Character: 37},
+	}
+
+	t.Run("hover", func(t *testing.T) {
+		// the last character of bar.Bar
+		hover, err := ws.Hover(ctx, fooDoc, protocol.Position{Line: 5, Character: 36})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hover == nil {
+			t.Fatal("expected hover")
+		}
+		if *hover.Range != wantRange {
+			t.Errorf("unexpected range %v", hover.Range)
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		locs, err := ws.References(ctx, tp.doc("proto/bar/v1/bar.j5s", barLines...), protocol.Position{Line: 2, Character: 8}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(locs) != 1 || locs[0].URI != fooDoc.URI {
+			t.Fatalf("unexpected references %v", locs)
+		}
+		if locs[0].Range != wantRange {
+			t.Errorf("unexpected range %v", locs[0].Range)
+		}
+	})
+}
+
+func TestWorkspacePaths(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("J5_REGISTRY", "http://localhost:1")
+	t.Setenv("J5_CACHE_DIR", t.TempDir())
+
+	// nested bundles, listed outer first, in a path which must be escaped
+	tp := &testProject{root: filepath.Join(t.TempDir(), "my repo #1")}
+	tp.writeFile(t, "j5.yaml",
+		"bundles:",
+		"  - name: outer",
+		"    dir: proto",
+		"  - name: inner",
+		"    dir: proto/inner",
+	)
+	tp.writeFile(t, "proto/j5.yaml",
+		"packages:",
+		"  - name: foo.v1",
+	)
+	tp.writeFile(t, "proto/inner/j5.yaml",
+		"packages:",
+		"  - name: bar.v1",
+	)
+	barLines := []string{
+		"package bar.v1",
+		"",
+		"object Bar {",
+		"  field id string",
+		"  field other object:Bar",
+		"}",
+	}
+	tp.writeFile(t, "proto/inner/bar/v1/bar.j5s", barLines...)
+
+	ws, err := NewWorkspace(ctx, tp.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := tp.doc("proto/inner/bar/v1/bar.j5s", barLines...)
+	file, err := ws.findBundle(doc.URI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.bundle.DirInRepo() != "proto/inner" || file.filename != "bar/v1/bar.j5s" {
+		t.Errorf("unexpected bundle %q file %q", file.bundle.DirInRepo(), file.filename)
+	}
+
+	locs, err := ws.Definition(ctx, doc, protocol.Position{Line: 4, Character: 22})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locs) != 1 || locs[0].URI != doc.URI {
+		t.Fatalf("unexpected locations %v, want %s", locs, doc.URI)
+	}
+	if !strings.Contains(string(locs[0].URI), "my%20repo%20%231") {
+		t.Errorf("expected an escaped URI, got %s", locs[0].URI)
+	}
+}
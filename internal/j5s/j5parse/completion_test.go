@@ -0,0 +1,76 @@
+package j5parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/j5build/internal/bcl"
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletion(t *testing.T) {
+	pp, err := bcl.NewParser(J5SchemaSpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := strings.Join([]string{
+		"package foo.v1",
+		"",
+		"entity Foo {",
+		"  ", // 3
+		"  data bar ",
+		"}",
+		"",
+		"service Foo {",
+		"  method Bar {",
+		"    httpMethod = GET", // 9
+		"  }",
+		"}",
+		"", // 12
+	}, "\n")
+
+	complete := func(t *testing.T, line, column int) map[string]bcl.CompletionKind {
+		t.Helper()
+		completions, err := pp.CompletionsAt(src, FileStub("foo/v1/foo.j5s"), errpos.Point{Line: line, Column: column})
+		if err != nil {
+			t.Fatal(err)
+		}
+		byLabel := map[string]bcl.CompletionKind{}
+		for _, completion := range completions {
+			byLabel[completion.Label] = completion.Kind
+		}
+		return byLabel
+	}
+
+	t.Run("root", func(t *testing.T) {
+		got := complete(t, 12, 0)
+		for _, name := range []string{"object", "entity", "enum", "oneof", "service", "import"} {
+			assert.Equal(t, bcl.CompleteBlock, got[name], name)
+		}
+	})
+
+	t.Run("entity body", func(t *testing.T) {
+		got := complete(t, 3, 2)
+		for _, name := range []string{"key", "data", "event", "status"} {
+			assert.Equal(t, bcl.CompleteBlock, got[name], name)
+		}
+		assert.Equal(t, bcl.CompleteAttribute, got["description"])
+		assert.NotContains(t, got, "field")
+	})
+
+	t.Run("type select", func(t *testing.T) {
+		got := complete(t, 4, 11)
+		for _, name := range []string{"string", "object", "key", "array"} {
+			assert.Equal(t, bcl.CompleteTypeSelect, got[name], name)
+		}
+	})
+
+	t.Run("enum value", func(t *testing.T) {
+		got := complete(t, 9, 17)
+		for _, name := range []string{"GET", "POST"} {
+			assert.Equal(t, bcl.CompleteValue, got[name], name)
+		}
+	})
+}
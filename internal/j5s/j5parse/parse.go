@@ -21,6 +21,15 @@ func NewParser() (*Parser, error) {
 	return &Parser{bcl: p}, nil
 }
 
+// WithPartials returns a copy of the parser which reads '.partial'
+// definitions in other files and packages from src, for '.include' and
+// '.import' directives.
+func (p *Parser) WithPartials(src bcl.PartialSource) *Parser {
+	bclParser := *p.bcl
+	bclParser.Partials = src
+	return &Parser{bcl: &bclParser}
+}
+
 func (p *Parser) fileStub(sourceFilename string) *sourcedef_j5pb.SourceFile {
 	dirName, _ := path.Split(sourceFilename)
 	dirName = strings.TrimSuffix(dirName, "/")
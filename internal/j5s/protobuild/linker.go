@@ -8,6 +8,7 @@ import (
 	"github.com/bufbuild/protocompile/options"
 	"github.com/bufbuild/protocompile/parser"
 	"github.com/bufbuild/protocompile/reporter"
+	"github.com/bufbuild/protocompile/sourceinfo"
 	"github.com/pentops/j5build/internal/j5s/j5convert"
 	"github.com/pentops/log.go/log"
 	"google.golang.org/protobuf/proto"
@@ -19,6 +20,10 @@ type searchLinker struct {
 	symbols  *linker.Symbols
 	Reporter reporter.Reporter
 	resolver fileSource
+
+	// protoSourceInfo generates the source info, with comments, of parsed
+	// proto files, see PackageSet.ProtoSourceInfo.
+	protoSourceInfo bool
 }
 
 func newLinker(src fileSource, errs reporter.Reporter) *searchLinker {
@@ -102,23 +107,41 @@ func (ll *searchLinker) _linkNewResult(ctx context.Context, result *SearchResult
 }
 
 func (ll *searchLinker) resultToFile(ctx context.Context, result parser.Result) (linker.File, error) {
+	handler := reporter.NewHandler(ll.Reporter)
+
+	fileNode := result.AST()
+	withSourceInfo := ll.protoSourceInfo && fileNode != nil && result.FileDescriptorProto().SourceCodeInfo == nil
+	if withSourceInfo {
+		// The parse result is shared, the source info is set on a descriptor
+		// built again from the AST.
+		var err error
+		result, err = parser.ResultFromAST(fileNode, true, handler)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	desc := result.FileDescriptorProto()
 	deps, err := ll.loadDependencies(ctx, desc)
 	if err != nil {
 		return nil, err
 	}
 
-	handler := reporter.NewHandler(ll.Reporter)
 	linked, err := linker.Link(result, deps, ll.symbols, handler)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = options.InterpretOptions(linked, handler)
+	optsIndex, err := options.InterpretOptions(linked, handler)
 	if err != nil {
 		return nil, err
 	}
 
+	if withSourceInfo {
+		desc.SourceCodeInfo = sourceinfo.GenerateSourceInfo(fileNode, optsIndex, sourceinfo.WithExtraComments())
+		linked.PopulateSourceCodeInfo()
+	}
+
 	linked.CheckForUnusedImports(handler)
 	return linked, nil
 }
@@ -6,10 +6,13 @@ import (
 
 	"github.com/pentops/j5build/internal/bcl/errpos"
 	"github.com/pentops/j5build/internal/j5s/j5convert"
+	"github.com/pentops/j5build/internal/j5s/j5lint"
 	"github.com/pentops/log.go/log"
 )
 
-func LintFile(ctx context.Context, ps PackageSrc, filename string, fileData string) (*errpos.ErrorsWithSource, error) {
+// LintFile reports the compile errors for a single file, then runs the style
+// rules when it compiles.
+func LintFile(ctx context.Context, ps PackageSrc, filename string, fileData string, rules ...j5lint.Rule) (*errpos.ErrorsWithSource, error) {
 	pkgName, isLocal, err := ps.PackageForLocalFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("packageForFile %s: %w", filename, err)
@@ -58,6 +61,9 @@ func LintFile(ctx context.Context, ps PackageSrc, filename string, fileData stri
 		} else if srcFile.J5Source != nil {
 			descs, err := j5convert.ConvertJ5File(pkg, srcFile.J5Source)
 			if err != nil {
+				if ep, ok := errpos.AsErrorsWithSource(errpos.AddSourceFile(err, filename, fileData)); ok {
+					return ep, nil
+				}
 				return nil, fmt.Errorf("convertJ5File %s: %w", srcFile.Filename, err)
 			}
 
@@ -76,7 +82,7 @@ func LintFile(ctx context.Context, ps PackageSrc, filename string, fileData stri
 				if err != nil {
 					return nil, fmt.Errorf("linking j5 file %s: %w", filename, err)
 				}
-				if errs.HasAny() {
+				if errs.HasErrors() {
 					return convertLintErrors(outputName, "", errs)
 				}
 			}
@@ -85,25 +91,56 @@ func LintFile(ctx context.Context, ps PackageSrc, filename string, fileData stri
 		}
 	}
 
+	if sourceFile.J5Source != nil && !errs.HasErrors() {
+		findings, err := j5lint.Lint([]*j5lint.File{{
+			Filename: filename,
+			Data:     fileData,
+			Source:   sourceFile.J5Source,
+		}}, rules)
+		if err != nil {
+			return nil, err
+		}
+		errs.Warnings = append(errs.Warnings, findings...)
+	}
+
 	return convertLintErrors(filename, fileData, errs)
 }
 
-func LintAll(ctx context.Context, ps PackageSrc) (*errpos.ErrorsWithSource, error) {
+// LintAll reports the compile errors for all local packages, then runs the
+// style rules over all j5s files when there are none.
+func LintAll(ctx context.Context, ps PackageSrc, rules ...j5lint.Rule) (*errpos.ErrorsWithSource, error) {
 	allPackages := ps.ListLocalPackages()
 	errs := &ErrCollector{}
 	linker := newLinker(ps, errs)
 
+	// Warnings don't stop linting, they are reported with the errors, or at
+	// the end.
+	warnings := []*errpos.Err{}
+
+	lintFiles := []*j5lint.File{}
+
 	for _, pkgName := range allPackages {
 		// LoadLocalPackage parses both BCL and Proto files, but does not fully link.
-		pkg, errs, err := ps.LoadLocalPackage(ctx, pkgName)
+		pkg, pkgErrs, err := ps.LoadLocalPackage(ctx, pkgName)
 		if err != nil {
 			if ep, ok := errpos.AsErrorsWithSource(err); ok {
 				return ep, nil
 			}
 			return nil, fmt.Errorf("loadLocalPackage %s: %w", pkgName, err)
 		}
-		if errs.HasAny() {
-			return convertLintErrors("", "", errs)
+		if pkgErrs.HasErrors() {
+			return convertLintErrors("", "", pkgErrs)
+		}
+		warnings = append(warnings, pkgErrs.Warnings...)
+
+		for _, srcFile := range pkg.SourceFiles {
+			if srcFile.J5Source != nil {
+				lintFiles = append(lintFiles, &j5lint.File{
+					Filename: srcFile.Filename,
+					Data:     string(srcFile.RawSource),
+					Source:   srcFile.J5Source,
+				})
+			}
 		}
 
 		for _, file := range pkg.Files {
@@ -111,7 +148,8 @@ func LintAll(ctx context.Context, ps PackageSrc) (*errpos.ErrorsWithSource, erro
 			if err != nil {
 				return nil, fmt.Errorf("linking file %s: %w", file.Summary.SourceFilename, err)
 			}
-			if errs.HasAny() {
+			if errs.HasErrors() {
+				errs.Warnings = append(warnings, errs.Warnings...)
 				if file.Summary.SourceFilename == linked.Path() {
 					data, err := ps.GetLocalFileContent(ctx, file.Summary.SourceFilename)
 					if err != nil {
@@ -125,8 +163,41 @@ func LintAll(ctx context.Context, ps PackageSrc) (*errpos.ErrorsWithSource, erro
 		}
 
 	}
-	return convertLintErrors("", "", errs)
 
+	findings, err := j5lint.Lint(lintFiles, rules)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, findings...)
+
+	errs.Warnings = append(warnings, errs.Warnings...)
+
+	// Warnings in a single file can show the source.
+	filename := warningsFile(errs.Warnings)
+	if filename == "" {
+		return convertLintErrors("", "", errs)
+	}
+	data, err := ps.GetLocalFileContent(ctx, filename)
+	if err != nil {
+		return nil, fmt.Errorf("getRawFile %s: %w", filename, err)
+	}
+	return convertLintErrors(filename, data, errs)
+
+}
+
+// warningsFile returns the filename when all warnings are in the same file.
+func warningsFile(warnings []*errpos.Err) string {
+	filename := ""
+	for _, warning := range warnings {
+		if warning.Pos == nil || warning.Pos.Filename == nil {
+			return ""
+		}
+		if filename != "" && *warning.Pos.Filename != filename {
+			return ""
+		}
+		filename = *warning.Pos.Filename
+	}
+	return filename
 }
 
 func convertLintErrors(filename string, fileData string, errs *ErrCollector) (*errpos.ErrorsWithSource, error) {
@@ -56,6 +56,7 @@ func TestLintSingleProto(t *testing.T) {
 		name    string
 		body    []string
 		wantErr string
+		wantFix string
 	}{{
 		name: "unused import",
 		body: []string{
@@ -64,6 +65,16 @@ func TestLintSingleProto(t *testing.T) {
 			"}",
 		},
 		wantErr: "not used",
+	}, {
+		name: "enum zero suffix",
+		body: []string{
+			"enum Status {",
+			"  STATUS_NONE = 0;",
+			"  STATUS_ACTIVE = 1;",
+			"}",
+		},
+		wantErr: "suffix UNSPECIFIED",
+		wantFix: "Rename to STATUS_UNSPECIFIED (changes the API)",
 	}} {
 		t.Run(tc.name, func(t *testing.T) {
 			tf := newTestFiles()
@@ -84,6 +95,15 @@ func TestLintSingleProto(t *testing.T) {
 			if !strings.Contains(msg, tc.wantErr) {
 				t.Fatalf("Expected error containing %q, got %q", tc.wantErr, msg)
 			}
+			if tc.wantFix != "" {
+				fix := es.Errors[0].Fix
+				if fix == nil {
+					t.Fatalf("Expected fix %q, got nil", tc.wantFix)
+				}
+				if fix.Title != tc.wantFix {
+					t.Fatalf("Expected fix %q, got %q", tc.wantFix, fix.Title)
+				}
+			}
 		})
 	}
 }
@@ -94,6 +114,7 @@ func TestLintSingleJ5(t *testing.T) {
 		name    string
 		body    []string
 		wantErr string
+		wantFix string
 	}{{
 		name: "unused import",
 		body: []string{
@@ -102,6 +123,27 @@ func TestLintSingleJ5(t *testing.T) {
 			"}",
 		},
 		wantErr: "not used",
+		wantFix: "Remove import j5.messaging.v1",
+	}, {
+		name: "duplicate field",
+		body: []string{
+			"object Foo {",
+			"  field bar string",
+			"  field bar string",
+			"}",
+		},
+		wantErr: "more than once",
+		wantFix: "Rename to bar2",
+	}, {
+		name: "duplicate object",
+		body: []string{
+			"object Foo {",
+			"}",
+			"object Foo {",
+			"}",
+		},
+		wantErr: "more than once",
+		wantFix: "Rename to Foo2",
 	}} {
 
 		t.Run(tc.name, func(t *testing.T) {
@@ -123,6 +165,15 @@ func TestLintSingleJ5(t *testing.T) {
 			if !strings.Contains(msg, tc.wantErr) {
 				t.Fatalf("Expected error containing %q, got %q", tc.wantErr, msg)
 			}
+			if tc.wantFix != "" {
+				fix := es.Errors[0].Fix
+				if fix == nil {
+					t.Fatalf("Expected fix %q, got nil", tc.wantFix)
+				}
+				if fix.Title != tc.wantFix {
+					t.Fatalf("Expected fix %q, got %q", tc.wantFix, fix.Title)
+				}
+			}
 
 		})
 	}
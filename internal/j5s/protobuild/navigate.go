@@ -0,0 +1,209 @@
+package protobuild
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+	"github.com/pentops/j5build/internal/j5s/j5convert"
+)
+
+// Symbol is a type declared in, or referenced from, a local source file.
+type Symbol struct {
+	// Position of the declaration or reference in the searched file.
+	Position errpos.Position
+
+	// Type is the declaration of the type, which may be in another file or
+	// package.
+	Type *j5convert.TypeRef
+}
+
+// Reference is a type reference in a local source file.
+type Reference struct {
+	Filename string
+	Position errpos.Position
+}
+
+// ScopedType is a type which can be referenced from a local source file.
+type ScopedType struct {
+	// Name of the type as written in the file, qualified by the import alias
+	// for types in other packages.
+	Name string
+	Type *j5convert.TypeRef
+}
+
+// SymbolAt returns the type declared or referenced at the point in a local j5s
+// file, or nil if there is no type at the point.
+func (ps *PackageSet) SymbolAt(ctx context.Context, filename string, point errpos.Point) (*Symbol, error) {
+	pkgName, isLocal, err := ps.PackageForLocalFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("packageForFile %s: %w", filename, err)
+	}
+	if !isLocal {
+		return nil, fmt.Errorf("file %s is not a local bundle file", filename)
+	}
+
+	pkg, _, err := ps.LoadLocalPackage(ctx, pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceFile *SourceFile
+	for _, search := range pkg.SourceFiles {
+		if search.Filename == filename {
+			sourceFile = search
+			break
+		}
+	}
+	if sourceFile == nil {
+		return nil, fmt.Errorf("source file %s not found in package %s", filename, pkgName)
+	}
+	if sourceFile.J5Source == nil {
+		// Proto files are not indexed.
+		return nil, nil
+	}
+
+	for _, ref := range sourceFile.Summary.References {
+		if ref.Position == nil || !positionContains(*ref.Position, point) {
+			continue
+		}
+		typeRef, err := pkg.ResolveType(ref.Ref.Package, ref.Ref.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return &Symbol{
+			Position: *ref.Position,
+			Type:     typeRef,
+		}, nil
+	}
+
+	for _, export := range sourceFile.Summary.Exports {
+		if export.Position == nil {
+			continue
+		}
+		// The position of a declaration is the whole block, only the header
+		// line is the symbol.
+		if export.Position.Start.Line != point.Line || point.Column < export.Position.Start.Column {
+			continue
+		}
+		return &Symbol{
+			Position: *export.Position,
+			Type:     export,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// FindReferences returns all references to the type from local j5s files.
+func (ps *PackageSet) FindReferences(ctx context.Context, typeRef *j5convert.TypeRef) ([]*Reference, error) {
+	refs := []*Reference{}
+	for _, pkgName := range ps.ListLocalPackages() {
+		pkg, _, err := ps.LoadLocalPackage(ctx, pkgName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sourceFile := range pkg.SourceFiles {
+			if sourceFile.J5Source == nil {
+				continue
+			}
+			for _, ref := range sourceFile.Summary.References {
+				if ref.Position == nil {
+					continue
+				}
+				if ref.Ref.Package != typeRef.Package || ref.Ref.Schema != typeRef.Name {
+					continue
+				}
+				refs = append(refs, &Reference{
+					Filename: sourceFile.Filename,
+					Position: *ref.Position,
+				})
+			}
+		}
+	}
+	return refs, nil
+}
+
+// TypesInScope returns the types which can be referenced from a local j5s file.
+// Only the summaries of the local package are used, so the file does not need
+// to link.
+func (ps *PackageSet) TypesInScope(ctx context.Context, filename string) ([]*ScopedType, error) {
+	pkgName, isLocal, err := ps.PackageForLocalFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("packageForFile %s: %w", filename, err)
+	}
+	if !isLocal {
+		return nil, fmt.Errorf("file %s is not a local bundle file", filename)
+	}
+
+	fileNames, err := ps.localResolver.listPackageFiles(ctx, pkgName, ps.SubPackageProtos)
+	if err != nil {
+		return nil, err
+	}
+
+	rb := newResolveBaton()
+	types := []*ScopedType{}
+	var thisFile *SourceFile
+	for _, fileName := range fileNames {
+		sourceFile, err := ps.localResolver.getFile(ctx, fileName, rb.errs)
+		if err != nil {
+			return nil, err
+		}
+		if fileName == filename {
+			thisFile = sourceFile
+		}
+		for _, export := range sourceFile.Summary.Exports {
+			types = append(types, &ScopedType{
+				Name: export.Name,
+				Type: export,
+			})
+		}
+	}
+
+	if thisFile == nil || thisFile.J5Source == nil {
+		return types, nil
+	}
+
+	aliases, err := j5convert.ImportAliases(thisFile.J5Source)
+	if err != nil {
+		return nil, err
+	}
+
+	for alias, importPkg := range aliases {
+		if importPkg == pkgName {
+			continue
+		}
+		pkg, err := ps.loadPackage(ctx, rb, importPkg)
+		if err != nil {
+			// The import may be mistyped or the package broken, neither of
+			// which should hide the rest.
+			continue
+		}
+		for _, export := range pkg.Exports {
+			types = append(types, &ScopedType{
+				Name: alias + "." + export.Name,
+				Type: export,
+			})
+		}
+	}
+
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Name < types[j].Name
+	})
+	return types, nil
+}
+
+func positionContains(pos errpos.Position, point errpos.Point) bool {
+	if point.Line < pos.Start.Line || point.Line > pos.End.Line {
+		return false
+	}
+	if point.Line == pos.Start.Line && point.Column < pos.Start.Column {
+		return false
+	}
+	if point.Line == pos.End.Line && point.Column > pos.End.Column {
+		return false
+	}
+	return true
+}
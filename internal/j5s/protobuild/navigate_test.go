@@ -0,0 +1,106 @@
+package protobuild
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pentops/j5build/internal/bcl/errpos"
+)
+
+func TestNavigate(t *testing.T) {
+	tf := newTestFiles()
+
+	tf.tAddJ5SFile("foo/v1/foo.j5s",
+		"import bar.v1:bar",
+		"object Foo {",
+		"  field bar object:bar.Bar",
+		"  field baz object:Baz",
+		"}",
+		"object Baz {",
+		"  | Baz Description",
+		"}",
+	)
+
+	tf.tAddJ5SFile("bar/v1/bar.j5s",
+		"object Bar {",
+		"  field baz object:Bar",
+		"}",
+	)
+
+	ps, err := NewPackageSet(newTestDeps(), tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	symbolAt := func(t *testing.T, filename string, line, column int) *Symbol {
+		t.Helper()
+		sym, err := ps.SymbolAt(ctx, filename, errpos.Point{Line: line, Column: column})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sym == nil {
+			t.Fatalf("no symbol at %s %d:%d", filename, line, column)
+		}
+		return sym
+	}
+
+	t.Run("local ref", func(t *testing.T) {
+		sym := symbolAt(t, "foo/v1/foo.j5s", 4, 20)
+		if sym.Type.Package != "foo.v1" || sym.Type.Name != "Baz" {
+			t.Fatalf("unexpected type %s.%s", sym.Type.Package, sym.Type.Name)
+		}
+		if sym.Type.SourceFilename != "foo/v1/foo.j5s" {
+			t.Errorf("unexpected source file %q", sym.Type.SourceFilename)
+		}
+		if sym.Type.Description != "Baz Description" {
+			t.Errorf("unexpected description %q", sym.Type.Description)
+		}
+		if sym.Type.Position.Start.Line != 6 {
+			t.Errorf("expected declaration on line 6, got %s", sym.Type.Position)
+		}
+	})
+
+	t.Run("imported ref", func(t *testing.T) {
+		sym := symbolAt(t, "foo/v1/foo.j5s", 3, 22)
+		if sym.Type.Package != "bar.v1" || sym.Type.Name != "Bar" {
+			t.Fatalf("unexpected type %s.%s", sym.Type.Package, sym.Type.Name)
+		}
+		if sym.Type.SourceFilename != "bar/v1/bar.j5s" {
+			t.Errorf("unexpected source file %q", sym.Type.SourceFilename)
+		}
+	})
+
+	t.Run("declaration", func(t *testing.T) {
+		sym := symbolAt(t, "bar/v1/bar.j5s", 1, 8)
+		if sym.Type.Name != "Bar" {
+			t.Fatalf("unexpected type %s", sym.Type.Name)
+		}
+	})
+
+	t.Run("nothing", func(t *testing.T) {
+		sym, err := ps.SymbolAt(ctx, "foo/v1/foo.j5s", errpos.Point{Line: 4, Column: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sym != nil {
+			t.Fatalf("expected no symbol, got %s", sym.Type.Name)
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		sym := symbolAt(t, "bar/v1/bar.j5s", 1, 8)
+		refs, err := ps.FindReferences(ctx, sym.Type)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := map[string]int{}
+		for _, ref := range refs {
+			found[ref.Filename] = ref.Position.Start.Line
+		}
+		if len(refs) != 2 || found["foo/v1/foo.j5s"] != 3 || found["bar/v1/bar.j5s"] != 2 {
+			t.Fatalf("unexpected references %v", found)
+		}
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/bufbuild/protocompile/linker"
 	"github.com/pentops/j5build/gen/j5/sourcedef/v1/sourcedef_j5pb"
+	"github.com/pentops/j5build/internal/bcl/errpos"
 	"github.com/pentops/j5build/internal/j5s/j5convert"
 	"github.com/pentops/log.go/log"
 	"golang.org/x/exp/maps"
@@ -142,6 +143,18 @@ type PackageSet struct {
 	dependencyResolver *dependencyResolver
 	localResolver      *sourceResolver
 
+	// SubPackageProtos includes hand-written proto files in sub-package
+	// directories, e.g. foo/v1/service/foo.proto in foo.v1, where j5s puts
+	// the files it generates. Used to convert proto packages to j5s, bundles
+	// build without them. Set before loading packages.
+	SubPackageProtos bool
+
+	// ProtoSourceInfo generates the source info, with comments, of parsed
+	// proto files, which is otherwise left unset so images do not change.
+	// Used to carry comments when converting proto packages to j5s. Set
+	// before loading packages.
+	ProtoSourceInfo bool
+
 	Packages map[string]*Package
 }
 
@@ -179,6 +192,22 @@ func (ps *PackageSet) LoadLocalPackage(ctx context.Context, pkgName string) (*Pa
 	return pkg, rb.errs, nil
 }
 
+// FileChanged drops the state read from a local file which has changed, so
+// it is read again from the LocalFileSource. Local packages may include
+// partials and types from each other, so all of them are reloaded, packages
+// from dependencies are kept.
+func (ps *PackageSet) FileChanged(filename string) error {
+	if err := ps.localResolver.fileChanged(filename); err != nil {
+		return err
+	}
+	for name := range ps.Packages {
+		if ps.localResolver.isLocalPackage(name) {
+			delete(ps.Packages, name)
+		}
+	}
+	return nil
+}
+
 func (ps *PackageSet) ListLocalPackages() []string {
 	return ps.localResolver.ListPackages()
 }
@@ -266,7 +295,7 @@ func (ps *PackageSet) resolveDependencies(ctx context.Context, rb *resolveBaton,
 
 func (ps *PackageSet) loadLocalPackage(ctx context.Context, rb *resolveBaton, name string) (*Package, error) {
 
-	fileNames, err := ps.localResolver.listPackageFiles(ctx, name)
+	fileNames, err := ps.localResolver.listPackageFiles(ctx, name, ps.SubPackageProtos)
 	if err != nil {
 		return nil, fmt.Errorf("package files for %s: %w", name, err)
 	}
@@ -294,6 +323,7 @@ func (ps *PackageSet) loadLocalPackage(ctx context.Context, rb *resolveBaton, na
 		} else if srcFile.J5Source != nil {
 			descs, err := j5convert.ConvertJ5File(pkg, srcFile.J5Source)
 			if err != nil {
+				err = errpos.AddSourceFile(err, srcFile.Filename, string(srcFile.RawSource))
 				return nil, fmt.Errorf("convertJ5File %s: %w", srcFile.Filename, err)
 			}
 
@@ -356,5 +386,6 @@ func (ps *PackageSet) CompilePackage(ctx context.Context, packageName string) (l
 	errs := &ErrCollector{}
 
 	cc := newLinker(ps, errs)
+	cc.protoSourceInfo = ps.ProtoSourceInfo
 	return cc.resolveAll(ctx, filenames)
 }
@@ -269,3 +269,164 @@ func assertEqualLines(t testing.TB, wantLines, gotLines []string) {
 		}
 	}
 }
+
+func TestPartialAcrossPackages(t *testing.T) {
+	tf := newTestFiles()
+
+	tf.tAddJ5SFile("common/v1/partials.j5s",
+		".partial object timestamps {",
+		"  .export",
+		"  field createdAt timestamp",
+		"}",
+	)
+
+	tf.tAddJ5SFile("foo/v1/foo.j5s",
+		".import common.v1 as common",
+		"object Foo {",
+		"  field id string",
+		"  .include common.timestamps",
+		"}",
+	)
+
+	td := newTestDeps()
+
+	files := testCompile(t, tf, td, "foo.v1")
+	file := files.expectFile(t, "foo/v1/foo.j5s.proto")
+
+	msg := file.Messages().ByName("Foo")
+	if msg == nil {
+		t.Fatal("expected message Foo")
+	}
+	if msg.Fields().ByName("created_at") == nil {
+		t.Fatalf("expected field created_at from the partial")
+	}
+}
+
+func TestPartialFromDependency(t *testing.T) {
+	tf := newTestFiles()
+	tf.tAddJ5SFile("foo/v1/foo.j5s",
+		".import external.v1 as ext",
+		"object Foo {",
+		"  field id string",
+		"  .include ext.timestamps",
+		"}",
+	)
+
+	ps, err := NewPackageSet(newTestDeps(), tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = ps.CompilePackage(context.Background(), "foo.v1")
+	if err == nil {
+		t.Fatal("expected an error for partials from a dependency")
+	}
+	if !strings.Contains(err.Error(), "only supported from local packages") {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+}
+
+func TestPartialFileChanged(t *testing.T) {
+	ctx := context.Background()
+	tf := newTestFiles()
+
+	tf.tAddJ5SFile("common/v1/partials.j5s",
+		".partial object timestamps {",
+		"  .export",
+		"  field createdAt timestamp",
+		"}",
+	)
+
+	tf.tAddJ5SFile("foo/v1/foo.j5s",
+		".import common.v1 as common",
+		"object Foo {",
+		"  field id string",
+		"  .include common.timestamps",
+		"}",
+	)
+
+	ps, err := NewPackageSet(newTestDeps(), tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fooFields := func() map[string]bool {
+		t.Helper()
+		out, err := ps.CompilePackage(ctx, "foo.v1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fields := map[string]bool{}
+		for _, file := range out {
+			msg := file.Messages().ByName("Foo")
+			if msg == nil {
+				continue
+			}
+			for i := range msg.Fields().Len() {
+				fields[string(msg.Fields().Get(i).Name())] = true
+			}
+		}
+		return fields
+	}
+
+	if fields := fooFields(); !fields["created_at"] || fields["updated_at"] {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	tf.tAddJ5SFile("common/v1/partials.j5s",
+		".partial object timestamps {",
+		"  .export",
+		"  field createdAt timestamp",
+		"  field updatedAt timestamp",
+		"}",
+	)
+	if err := ps.FileChanged("common/v1/partials.j5s"); err != nil {
+		t.Fatal(err)
+	}
+
+	if fields := fooFields(); !fields["created_at"] || !fields["updated_at"] {
+		t.Fatalf("expected the changed partial, got fields %v", fields)
+	}
+}
+
+func TestProtoSourceInfo(t *testing.T) {
+	tf := newTestFiles()
+	tf.tAddProtoFile("local/v1/bar.proto",
+		"// Bar is a thing",
+		"message Bar {",
+		"  string f1 = 1;",
+		"}",
+	)
+	td := newTestDeps()
+
+	compile := func(sourceInfo bool) linker.File {
+		t.Helper()
+		ps, err := NewPackageSet(td, tf)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		ps.ProtoSourceInfo = sourceInfo
+		out, err := ps.CompilePackage(context.Background(), "local.v1")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, file := range out {
+			if file.Path() == "local/v1/bar.proto" {
+				return file
+			}
+		}
+		t.Fatal("bar.proto not compiled")
+		return nil
+	}
+
+	// images built without the option are unchanged
+	if locs := compile(false).SourceLocations(); locs.Len() != 0 {
+		t.Errorf("expected no source locations, got %d", locs.Len())
+	}
+
+	file := compile(true)
+	loc := file.SourceLocations().ByDescriptor(file.Messages().ByName("Bar"))
+	if loc.LeadingComments != " Bar is a thing\n" {
+		t.Errorf("unexpected comment %q", loc.LeadingComments)
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"strings"
 
 	proto_parser "github.com/bufbuild/protocompile/parser"
+	"github.com/bufbuild/protocompile/sourceinfo"
+	"github.com/iancoleman/strcase"
+	"github.com/pentops/j5build/internal/bcl/errpos"
 	"github.com/pentops/j5build/internal/j5s/j5convert"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
@@ -36,7 +40,12 @@ func protoToDescriptor(_ context.Context, filename string, data []byte, errs *Er
 		return nil, nil, err
 	}
 
-	summary, err := buildSummaryFromDescriptor(result.FileDescriptorProto(), errs)
+	// Source info is only added when linking, the summary needs it to
+	// position warnings.
+	desc := proto.Clone(result.FileDescriptorProto()).(*descriptorpb.FileDescriptorProto)
+	desc.SourceCodeInfo = sourceinfo.GenerateSourceInfo(fileNode, nil)
+
+	summary, err := buildSummaryFromDescriptor(desc, errs)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -108,7 +117,15 @@ func buildEnumRef(file *descriptorpb.FileDescriptorProto, idx int32, enumDescrip
 		if strings.HasSuffix(unspecifiedVal, suffix) {
 			trimPrefix = strings.TrimSuffix(unspecifiedVal, suffix)
 		} else {
-			errs.WarnProtoDesc(file, []int32{5, idx}, fmt.Errorf("enum value 0 should have suffix %s", suffix))
+			err := fmt.Errorf("enum value 0 should have suffix %s", suffix)
+			// 5: enum_type, 2: value, 1: name
+			newName := strcase.ToScreamingSnake(enumDescriptor.GetName()) + "_" + suffix
+			fix := protoDescReplaceFix(file, []int32{5, idx, 2, 0, 1}, fmt.Sprintf("Rename to %s (changes the API)", newName), newName)
+			if fix != nil {
+				fix.ChangesAPI = true
+				err = errpos.AddFix(err, fix)
+			}
+			errs.WarnProtoDesc(file, []int32{5, idx}, RuleEnumZeroSuffix, err)
 			// proceed without prefix.
 		}
 	}
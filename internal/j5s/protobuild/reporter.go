@@ -46,16 +46,51 @@ func pathsEqual(a, b []int32) bool {
 	return true
 }
 
-func (ec *ErrCollector) WarnProtoDesc(file *descriptorpb.FileDescriptorProto, path []int32, err error) {
-	var loc *descriptorpb.SourceCodeInfo_Location
-	if file.SourceCodeInfo != nil {
-		for _, l := range file.SourceCodeInfo.Location {
-			if pathsEqual(l.Path, path) {
-				loc = l
-				break
-			}
+// Rule codes for warnings raised while building, errors use the rule of the
+// command reporting them.
+const (
+	RuleCompileWarning = "COMPILE_WARNING"
+	RuleEnumZeroSuffix = "ENUM_ZERO_SUFFIX"
+)
+
+func protoDescLocation(file *descriptorpb.FileDescriptorProto, path []int32) *descriptorpb.SourceCodeInfo_Location {
+	if file.SourceCodeInfo == nil {
+		return nil
+	}
+	for _, l := range file.SourceCodeInfo.Location {
+		if pathsEqual(l.Path, path) {
+			return l
 		}
 	}
+	return nil
+}
+
+// protoDescReplaceFix replaces the span at the path, nil when the file has no
+// source info for the path.
+func protoDescReplaceFix(file *descriptorpb.FileDescriptorProto, path []int32, title string, newText string) *errpos.Fix {
+	loc := protoDescLocation(file, path)
+	if loc == nil {
+		return nil
+	}
+	edit := errpos.Edit{NewText: newText}
+	switch len(loc.Span) {
+	case 4:
+		edit.Start = errpos.Point{Line: int(loc.Span[0]), Column: int(loc.Span[1])}
+		edit.End = errpos.Point{Line: int(loc.Span[2]), Column: int(loc.Span[3])}
+	case 3:
+		edit.Start = errpos.Point{Line: int(loc.Span[0]), Column: int(loc.Span[1])}
+		edit.End = errpos.Point{Line: int(loc.Span[0]), Column: int(loc.Span[2])}
+	default:
+		return nil
+	}
+	return &errpos.Fix{
+		Title: title,
+		Edits: []errpos.Edit{edit},
+	}
+}
+
+func (ec *ErrCollector) WarnProtoDesc(file *descriptorpb.FileDescriptorProto, path []int32, rule string, err error) {
+	loc := protoDescLocation(file, path)
 
 	pos := &errpos.Position{
 		Filename: gl.Ptr(file.GetName()),
@@ -76,19 +111,25 @@ func (ec *ErrCollector) WarnProtoDesc(file *descriptorpb.FileDescriptorProto, pa
 	}
 
 	ec.Warnings = append(ec.Warnings, &errpos.Err{
-		Pos: pos,
-		Err: err,
+		Pos:      pos,
+		Err:      err,
+		Severity: errpos.SeverityWarning,
+		Rule:     rule,
+		Fix:      errpos.GetErrorFix(err),
 	})
 }
 
-func (ec *ErrCollector) WarnPos(pos *errpos.Position, err error) {
+func (ec *ErrCollector) WarnPos(pos *errpos.Position, rule string, err error) {
 	ec.Warnings = append(ec.Warnings, &errpos.Err{
-		Pos: pos,
-		Err: err,
+		Pos:      pos,
+		Err:      err,
+		Severity: errpos.SeverityWarning,
+		Rule:     rule,
+		Fix:      errpos.GetErrorFix(err),
 	})
 }
 
-func (ec *ErrCollector) WarnProto(desc protoreflect.Descriptor, err error) {
+func (ec *ErrCollector) WarnProto(desc protoreflect.Descriptor, rule string, err error) {
 	file := desc.ParentFile()
 	loc := file.SourceLocations().ByDescriptor(desc)
 	// may be zero value
@@ -104,7 +145,10 @@ func (ec *ErrCollector) WarnProto(desc protoreflect.Descriptor, err error) {
 				Column: int(loc.EndColumn),
 			},
 		},
-		Err: err,
+		Err:      err,
+		Severity: errpos.SeverityWarning,
+		Rule:     rule,
+		Fix:      errpos.GetErrorFix(err),
 	})
 
 }
@@ -121,9 +165,17 @@ func (ec *ErrCollector) Error(err reporter.ErrorWithPos) error {
 
 // Warning implements reporter.Reporter
 func (ec *ErrCollector) Warning(err reporter.ErrorWithPos) {
-	ec.Warnings = append(ec.Warnings, convertError(err))
+	warning := convertError(err)
+	warning.Severity = errpos.SeverityWarning
+	warning.Rule = RuleCompileWarning
+	ec.Warnings = append(ec.Warnings, warning)
 }
 
 func (ec *ErrCollector) HasAny() bool {
 	return len(ec.Errors) > 0 || len(ec.Warnings) > 0
 }
+
+// HasErrors is true when there are errors, not only warnings.
+func (ec *ErrCollector) HasErrors() bool {
+	return len(ec.Errors) > 0
+}
@@ -6,11 +6,13 @@ import (
 	"io/fs"
 	"path"
 	"strings"
+	"sync"
 
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/bcl"
 	"github.com/pentops/j5build/internal/bcl/errpos"
 	"github.com/pentops/j5build/internal/j5s/j5convert"
 	"github.com/pentops/j5build/internal/j5s/j5parse"
-	"github.com/pentops/j5build/internal/source"
 	"github.com/pentops/log.go/log"
 )
 
@@ -20,7 +22,15 @@ type LocalFileSource interface {
 	ListSourceFiles(ctx context.Context, pkgName string) ([]string, error)
 }
 
-func NewBundleResolver(ctsx context.Context, bundle source.Bundle) (LocalFileSource, error) {
+// Bundle is the part of source.Bundle used to read local files, the source
+// package compiles j5s files for source images so can't be imported here.
+type Bundle interface {
+	DirInRepo() string
+	J5Config() (*config_j5pb.BundleConfigFile, error)
+	FS() fs.FS
+}
+
+func NewBundleResolver(ctsx context.Context, bundle Bundle) (LocalFileSource, error) {
 
 	bundleDir := bundle.DirInRepo()
 
@@ -110,6 +120,9 @@ type sourceResolver struct {
 	j5Parser          *j5parse.Parser
 	localPrefixes     []string
 	localPackageNames map[string]struct{}
+
+	partialsLock sync.Mutex
+	partials     map[string][]*bcl.Partial
 }
 
 func newSourceResolver(localFiles LocalFileSource) (*sourceResolver, error) {
@@ -134,8 +147,8 @@ func newSourceResolver(localFiles LocalFileSource) (*sourceResolver, error) {
 		bundleFiles:       localFiles,
 		localPackageNames: localPackageNames,
 		localPrefixes:     localPrefixes,
+		partials:          map[string][]*bcl.Partial{},
 	}
-
 	return sr, nil
 }
 
@@ -161,7 +174,10 @@ func (sr *sourceResolver) isLocalPackage(pkgName string) bool {
 	return ok
 }
 
-func (sr *sourceResolver) listPackageFiles(ctx context.Context, pkgName string) ([]string, error) {
+// listPackageFiles lists the j5s and proto files of the package, in the
+// package directory. With subPackageProtos, hand-written proto files in
+// sub-package directories are included too.
+func (sr *sourceResolver) listPackageFiles(ctx context.Context, pkgName string, subPackageProtos bool) ([]string, error) {
 	root := strings.ReplaceAll(pkgName, ".", "/")
 
 	files, err := sr.bundleFiles.ListSourceFiles(ctx, root)
@@ -174,7 +190,7 @@ func (sr *sourceResolver) listPackageFiles(ctx context.Context, pkgName string)
 			continue
 		}
 		dir := path.Dir(f)
-		if dir != root {
+		if dir != root && !(subPackageProtos && isSubPackageFile(f, pkgName)) {
 			continue
 		}
 		filtered = append(filtered, f)
@@ -182,6 +198,89 @@ func (sr *sourceResolver) listPackageFiles(ctx context.Context, pkgName string)
 	return filtered, nil
 }
 
+// isSubPackageFile returns true for hand-written proto files in a sub-package
+// of the j5 package, e.g. foo/v1/service/foo.proto in foo.v1, which is where
+// j5s puts the services and topics it generates.
+func isSubPackageFile(filename string, pkgName string) bool {
+	if !strings.HasSuffix(filename, ".proto") {
+		return false
+	}
+	pkg, sub, err := j5convert.SplitPackageFromFilename(filename)
+	if err != nil {
+		return false
+	}
+	return sub != "" && pkg == pkgName
+}
+
+// partialSource reads partials for a single parse, with the context of the
+// parse.
+type partialSource struct {
+	ctx context.Context
+	sr  *sourceResolver
+}
+
+var _ bcl.PartialSource = partialSource{}
+
+func (ps partialSource) PackagePartials(pkgName string) ([]*bcl.Partial, error) {
+	return ps.sr.packagePartials(ps.ctx, pkgName)
+}
+
+// packagePartials reads the partials from all j5s files in a local package.
+// The partials are cached until fileChanged is called for a file in the
+// package.
+func (sr *sourceResolver) packagePartials(ctx context.Context, pkgName string) ([]*bcl.Partial, error) {
+	if !sr.isLocalPackage(pkgName) {
+		return nil, fmt.Errorf("partials are only supported from local packages, %q is not local", pkgName)
+	}
+
+	sr.partialsLock.Lock()
+	partials, ok := sr.partials[pkgName]
+	sr.partialsLock.Unlock()
+	if ok {
+		return partials, nil
+	}
+
+	files, err := sr.listPackageFiles(ctx, pkgName, false)
+	if err != nil {
+		return nil, err
+	}
+
+	partials = []*bcl.Partial{}
+	for _, filename := range files {
+		if !strings.HasSuffix(filename, ".j5s") {
+			continue
+		}
+		data, err := sr.getFileContent(ctx, filename)
+		if err != nil {
+			return nil, err
+		}
+		filePartials, err := bcl.ReadPartials(filename, string(data))
+		if err != nil {
+			return nil, err
+		}
+		partials = append(partials, filePartials...)
+	}
+
+	sr.partialsLock.Lock()
+	sr.partials[pkgName] = partials
+	sr.partialsLock.Unlock()
+	return partials, nil
+}
+
+// fileChanged drops the cached partials of the package containing the file.
+func (sr *sourceResolver) fileChanged(filename string) error {
+	pkgName, isLocal, err := sr.packageForFile(filename)
+	if err != nil {
+		return err
+	}
+	if isLocal {
+		sr.partialsLock.Lock()
+		delete(sr.partials, pkgName)
+		sr.partialsLock.Unlock()
+	}
+	return nil
+}
+
 func (sr *sourceResolver) getFileContent(ctx context.Context, sourceFilename string) ([]byte, error) {
 	return sr.bundleFiles.GetLocalFile(ctx, sourceFilename)
 }
@@ -205,9 +304,10 @@ func (sr *sourceResolver) getFile(ctx context.Context, sourceFilename string, ec
 	return nil, fmt.Errorf("unsupported file type: %s", sourceFilename)
 }
 
-func (sr *sourceResolver) parseJ5s(_ context.Context, sourceFilename string, data []byte, ec *ErrCollector) (*SourceFile, error) {
+func (sr *sourceResolver) parseJ5s(ctx context.Context, sourceFilename string, data []byte, ec *ErrCollector) (*SourceFile, error) {
 
-	sourceFile, err := sr.j5Parser.ParseFile(sourceFilename, string(data))
+	j5Parser := sr.j5Parser.WithPartials(partialSource{ctx: ctx, sr: sr})
+	sourceFile, err := j5Parser.ParseFile(sourceFilename, string(data))
 	if err != nil {
 		return nil, errpos.AddSourceFile(err, sourceFilename, string(data))
 	}
@@ -96,3 +96,34 @@ func TestLocalResolver(t *testing.T) {
 		t.Fatalf("Expected local package, got external")
 	}
 }
+
+func TestListPackageFilesSubPackages(t *testing.T) {
+	ctx := context.Background()
+	tf := newTestFiles()
+	tf.tAddJ5SFile("local/v1/foo.j5s")
+	tf.tAddProtoFile("local/v1/bar.proto")
+	tf.tAddProtoFile("local/v1/service/foo.proto")
+	tf.localFiles["local/v1/service/foo.p.j5s.proto"] = []byte{}
+
+	sourceResolver, err := newSourceResolver(tf)
+	if err != nil {
+		t.Fatalf("FATAL: Unexpected error: %s", err.Error())
+	}
+
+	// Bundles build from the files in the package directory only.
+	files, err := sourceResolver.listPackageFiles(ctx, "local.v1", false)
+	if err != nil {
+		t.Fatalf("FATAL: Unexpected error: %s", err.Error())
+	}
+	if got := strings.Join(files, ","); got != "local/v1/bar.proto,local/v1/foo.j5s" {
+		t.Errorf("unexpected files %s", got)
+	}
+
+	files, err = sourceResolver.listPackageFiles(ctx, "local.v1", true)
+	if err != nil {
+		t.Fatalf("FATAL: Unexpected error: %s", err.Error())
+	}
+	if got := strings.Join(files, ","); got != "local/v1/bar.proto,local/v1/foo.j5s,local/v1/service/foo.proto" {
+		t.Errorf("unexpected files with sub-packages %s", got)
+	}
+}
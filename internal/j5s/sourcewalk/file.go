@@ -45,7 +45,7 @@ func (fn *FileNode) RangeRootElements(visitor FileVisitor) error {
 		switch element := element.Type.(type) {
 		case *sourcedef_j5pb.RootElement_Object:
 			source := source.child("object")
-			objectNode, err := newObjectNode(source.child("object"), nil, element.Object)
+			objectNode, err := newObjectNode(source, nil, element.Object)
 			if err != nil {
 				return wrapErr(source, err)
 			}
@@ -55,7 +55,7 @@ func (fn *FileNode) RangeRootElements(visitor FileVisitor) error {
 
 		case *sourcedef_j5pb.RootElement_Oneof:
 			source := source.child("oneof")
-			oneofNode, err := newOneofNode(source.child("oneof"), nil, element.Oneof)
+			oneofNode, err := newOneofNode(source, nil, element.Oneof)
 			if err != nil {
 				return wrapErr(source, err)
 			}
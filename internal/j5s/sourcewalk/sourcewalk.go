@@ -80,6 +80,19 @@ func (sn SourceNode) child(path ...string) SourceNode {
 	return walk
 }
 
+// Child returns the node at the path below this node. Parts of the path
+// missing from the source are virtual, at the position of the last parent
+// found.
+func (sn SourceNode) Child(path ...string) SourceNode {
+	return sn.child(path...)
+}
+
+// IsVirtual is true when the node is not written in the source, e.g. schemas
+// generated for an entity, the position is of the nearest parent which is.
+func (sn SourceNode) IsVirtual() bool {
+	return sn.virtual
+}
+
 func (sn SourceNode) GetPos() *errpos.Position {
 	return &errpos.Position{
 		Start: errpos.Point{
@@ -18,6 +18,11 @@ import (
 
 type Compiler struct {
 	Resolver protocompile.Resolver
+
+	// Generated files are printed from descriptors converted from j5s,
+	// where an import used only for options looks unused, so the warning is
+	// not logged.
+	Generated map[string]bool
 }
 
 func NewCompiler(resolver protocompile.Resolver) *Compiler {
@@ -40,6 +45,10 @@ func (cc *Compiler) CompileToLinkers(ctx context.Context, filenames []string) (l
 	}
 
 	warnings := func(err reporter.ErrorWithPos) {
+		var unusedImport linker.ErrorUnusedImport
+		if errors.As(err, &unusedImport) && cc.Generated[err.GetPosition().Filename] {
+			return
+		}
 		log.WithError(ctx, err).Warn("Compiler Warning (protosrc)")
 	}
 
@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/bufbuild/protocompile"
@@ -19,10 +20,22 @@ func NewFSResolver(fs fs.FS) protocompile.Resolver {
 	}
 }
 
-func ReadFSImage(ctx context.Context, bundleRoot fs.FS, includeFilenames []string, dependencies protocompile.Resolver) (*source_j5pb.SourceImage, error) {
+// ReadFSImage compiles the .proto files in the bundle, along with the
+// included files. When there are generated files, the source of .proto
+// files converted from j5s by filename, they replace the .j5s.proto files in
+// the bundle, which may be stale.
+func ReadFSImage(ctx context.Context, bundleRoot fs.FS, includeFilenames []string, generated map[string]string, dependencies protocompile.Resolver) (*source_j5pb.SourceImage, error) {
 
 	proseFiles := []*source_j5pb.ProseFile{}
 	filenames := includeFilenames
+
+	generatedFilenames := make([]string, 0, len(generated))
+	for filename := range generated {
+		generatedFilenames = append(generatedFilenames, filename)
+	}
+	sort.Strings(generatedFilenames)
+	filenames = append(filenames, generatedFilenames...)
+
 	err := fs.WalkDir(bundleRoot, ".", func(path string, info fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -32,6 +45,9 @@ func ReadFSImage(ctx context.Context, bundleRoot fs.FS, includeFilenames []strin
 
 		switch ext {
 		case ".proto":
+			if len(generated) > 0 && strings.HasSuffix(path, ".j5s.proto") {
+				return nil
+			}
 			filenames = append(filenames, path)
 			return nil
 
@@ -55,11 +71,16 @@ func ReadFSImage(ctx context.Context, bundleRoot fs.FS, includeFilenames []strin
 	}
 
 	resolver := protocompile.CompositeResolver{
+		generatedResolver(generated),
 		NewFSResolver(bundleRoot),
 		BuiltinResolver,
 		dependencies,
 	}
 	compiler := NewCompiler(resolver)
+	compiler.Generated = map[string]bool{}
+	for filename := range generated {
+		compiler.Generated[filename] = true
+	}
 	files, err := compiler.Compile(ctx, filenames)
 	if err != nil {
 		return nil, err
@@ -71,3 +92,13 @@ func ReadFSImage(ctx context.Context, bundleRoot fs.FS, includeFilenames []strin
 		SourceFilenames: filenames,
 	}, nil
 }
+
+func generatedResolver(files map[string]string) protocompile.Resolver {
+	return protocompile.ResolverFunc(func(filename string) (protocompile.SearchResult, error) {
+		source, ok := files[filename]
+		if !ok {
+			return protocompile.SearchResult{}, fs.ErrNotExist
+		}
+		return protocompile.SearchResult{Source: strings.NewReader(source)}, nil
+	})
+}
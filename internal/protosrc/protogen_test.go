@@ -46,7 +46,7 @@ func TestGenerateRequest(t *testing.T) {
 	})
 
 	ctx := context.Background()
-	img, err := ReadFSImage(ctx, src, []string{"file1.proto", "file2.proto"}, nil)
+	img, err := ReadFSImage(ctx, src, []string{"file1.proto", "file2.proto"}, nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,199 @@
+package registry
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/log.go/log"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxImageSize limits the body of an upload.
+const maxImageSize = 256 << 20
+
+// Server serves the registry HTTP API read by the j5 registry client:
+//
+//	GET /registry/v1/{owner}/{repo}/{version|branch}/image.bin
+//	PUT /registry/v1/{owner}/{repo}/{version}/image.bin
+//	GET /registry/v1/{owner}/{repo}/{branch}/version
+//	PUT /registry/v1/{owner}/{repo}/{branch}/version
+type Server struct {
+	store *FileStore
+
+	// Token, when set, is required as a bearer token for every request.
+	Token string
+}
+
+func NewServer(store *FileStore) *Server {
+	return &Server{
+		store: store,
+	}
+}
+
+type imagePath struct {
+	owner string
+	repo  string
+	ref   string // version or branch, may contain slashes
+	file  string
+}
+
+func parsePath(urlPath string) (*imagePath, bool) {
+	rest, ok := strings.CutPrefix(urlPath, "/registry/v1/")
+	if !ok {
+		return nil, false
+	}
+	parts := strings.Split(rest, "/")
+	if len(parts) < 4 {
+		return nil, false
+	}
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return nil, false
+		}
+	}
+	return &imagePath{
+		owner: parts[0],
+		repo:  parts[1],
+		ref:   strings.Join(parts[2:len(parts)-1], "/"),
+		file:  parts[len(parts)-1],
+	}, true
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.Token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := log.WithFields(r.Context(), map[string]interface{}{
+		"method": r.Method,
+		"path":   r.URL.Path,
+	})
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	req, ok := parsePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var err error
+	switch {
+	case req.file == "image.bin" && r.Method == http.MethodGet:
+		err = s.getImage(w, req)
+	case req.file == "image.bin" && r.Method == http.MethodPut:
+		err = s.putImage(w, r, req)
+	case req.file == "version" && r.Method == http.MethodGet:
+		err = s.getBranch(w, req)
+	case req.file == "version" && r.Method == http.MethodPut:
+		err = s.putBranch(w, r, req)
+	case req.file == "image.bin" || req.file == "version":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if err == nil {
+		log.Debug(ctx, "registry request")
+		return
+	}
+
+	status := http.StatusInternalServerError
+	var badRequest badRequestError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, ErrInvalid), errors.As(err, &badRequest):
+		status = http.StatusBadRequest
+	default:
+		log.WithError(ctx, err).Error("registry request failed")
+	}
+	http.Error(w, err.Error(), status)
+}
+
+type badRequestError struct {
+	error
+}
+
+func (s *Server) getImage(w http.ResponseWriter, req *imagePath) error {
+	data, _, err := s.store.Resolve(req.owner, req.repo, req.ref)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, err = w.Write(data)
+	return err
+}
+
+func (s *Server) putImage(w http.ResponseWriter, r *http.Request, req *imagePath) error {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImageSize))
+	if err != nil {
+		return badRequestError{fmt.Errorf("reading image: %w", err)}
+	}
+
+	img := &source_j5pb.SourceImage{}
+	if err := proto.Unmarshal(data, img); err != nil {
+		return badRequestError{fmt.Errorf("invalid image: %w", err)}
+	}
+
+	// The client reads the canonical version from the image, including when
+	// it was fetched by branch.
+	if img.Version == nil {
+		img.Version = &req.ref
+		data, err = proto.Marshal(img)
+		if err != nil {
+			return err
+		}
+	} else if *img.Version != req.ref {
+		return badRequestError{fmt.Errorf("image version %q does not match %q", *img.Version, req.ref)}
+	}
+
+	if err := s.store.PutImage(req.owner, req.repo, req.ref, data); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) getBranch(w http.ResponseWriter, req *imagePath) error {
+	version, err := s.store.GetBranch(req.owner, req.repo, req.ref)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, err = io.WriteString(w, version)
+	return err
+}
+
+func (s *Server) putBranch(w http.ResponseWriter, r *http.Request, req *imagePath) error {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1024))
+	if err != nil {
+		return badRequestError{fmt.Errorf("reading version: %w", err)}
+	}
+	version := strings.TrimSpace(string(data))
+	if version == "" {
+		return badRequestError{errors.New("empty version")}
+	}
+	if err := s.store.SetBranch(req.owner, req.repo, req.ref, version); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
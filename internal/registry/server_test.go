@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/internal/source"
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage(version string, packages ...string) *source_j5pb.SourceImage {
+	img := &source_j5pb.SourceImage{
+		SourceName: "registry/owner/repo",
+		Version:    gl.Ptr(version),
+	}
+	for _, pkg := range packages {
+		img.Packages = append(img.Packages, &source_j5pb.PackageInfo{Name: pkg})
+	}
+	return img
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	server := NewServer(store)
+	server.Token = "secret"
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	client, err := source.NewRegistryClient(httpServer.URL, "secret")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := client.PushImage(ctx, "owner", "repo", "v1", []string{"main"}, testImage("v1", "foo.v1")); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := client.PushImage(ctx, "owner", "repo", "v2", []string{"feature/bar"}, testImage("v2", "foo.v1", "bar.v1")); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	t.Run("by version", func(t *testing.T) {
+		img, err := client.GetImage(ctx, "owner", "repo", "v2")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, "v2", img.GetVersion())
+		assert.Len(t, img.Packages, 2)
+	})
+
+	t.Run("by branch", func(t *testing.T) {
+		img, err := client.LatestImage(ctx, "owner", "repo", nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, "v1", img.GetVersion())
+
+		img, err = client.LatestImage(ctx, "owner", "repo", gl.Ptr("feature/bar"))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, "v2", img.GetVersion())
+	})
+
+	t.Run("move branch", func(t *testing.T) {
+		if err := client.PushImage(ctx, "owner", "repo", "v2", []string{"main"}, testImage("v2", "foo.v1", "bar.v1")); err != nil {
+			t.Fatal(err.Error())
+		}
+		img, err := client.LatestImage(ctx, "owner", "repo", nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, "v2", img.GetVersion())
+	})
+
+	t.Run("immutable version", func(t *testing.T) {
+		err := client.PushImage(ctx, "owner", "repo", "v1", nil, testImage("v1", "other.v1"))
+		assert.ErrorContains(t, err, "409")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetImage(ctx, "owner", "repo", "v3")
+		assert.ErrorContains(t, err, "404")
+		_, err = client.GetImage(ctx, "owner", "missing", "main")
+		assert.ErrorContains(t, err, "404")
+	})
+
+	t.Run("branch to missing version", func(t *testing.T) {
+		req, err := http.NewRequest("PUT", httpServer.URL+"/registry/v1/owner/repo/main/version", strings.NewReader("v3"))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		noAuth, err := source.NewRegistryClient(httpServer.URL, "wrong")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		_, err = noAuth.GetImage(ctx, "owner", "repo", "v1")
+		assert.ErrorContains(t, err, "401")
+		err = noAuth.PushImage(ctx, "owner", "repo", "v4", nil, testImage("v4"))
+		assert.ErrorContains(t, err, "401")
+	})
+
+	t.Run("invalid paths", func(t *testing.T) {
+		for _, path := range []string{
+			"/registry/v1/owner/repo/image.bin",
+			"/registry/v1/owner/../repo/v1/image.bin",
+			"/registry/v1/owner/repo/v1/other",
+			"/other/v1/owner/repo/v1/image.bin",
+		} {
+			req, err := http.NewRequest("GET", httpServer.URL+path, nil)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			req.Header.Set("Authorization", "Bearer secret")
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			res.Body.Close()
+			assert.Equal(t, http.StatusNotFound, res.StatusCode, path)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("version already exists with different content")
+	ErrInvalid  = errors.New("invalid name")
+)
+
+// FileStore keeps images in a directory, as
+// {owner}/{repo}/versions/{version}/image.bin, and branches as
+// {owner}/{repo}/branches/{branch} holding the version. Versions and branches
+// are path escaped, so branches like feature/foo are a single file.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{
+		dir: dir,
+	}, nil
+}
+
+func (fs *FileStore) repoDir(owner, repo string) (string, error) {
+	for _, part := range []string{owner, repo} {
+		if part == "" || part == "." || part == ".." || strings.ContainsAny(part, `/\`) {
+			return "", fmt.Errorf("%w %q", ErrInvalid, part)
+		}
+	}
+	return filepath.Join(fs.dir, owner, repo), nil
+}
+
+func (fs *FileStore) imagePath(owner, repo, version string) (string, error) {
+	dir, err := fs.repoDir(owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "versions", escapeName(version), "image.bin"), nil
+}
+
+func (fs *FileStore) branchPath(owner, repo, branch string) (string, error) {
+	dir, err := fs.repoDir(owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "branches", escapeName(branch)), nil
+}
+
+// escapeName makes a version or branch a single, safe, path element.
+func escapeName(name string) string {
+	if name == "." || name == ".." {
+		// PathEscape leaves dots, and always escapes %, so this is unique.
+		return strings.ReplaceAll(name, ".", "%2E")
+	}
+	return url.PathEscape(name)
+}
+
+// GetImage returns the image data for the version.
+func (fs *FileStore) GetImage(owner, repo, version string) ([]byte, error) {
+	filename, err := fs.imagePath(owner, repo, version)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%s/%s@%s: %w", owner, repo, version, ErrNotFound)
+	}
+	return data, err
+}
+
+// PutImage stores the image data as the version. Versions are immutable, so
+// pushing the same data again succeeds and different data fails with
+// ErrConflict, including when pushes of the version race.
+func (fs *FileStore) PutImage(owner, repo, version string, data []byte) error {
+	filename, err := fs.imagePath(owner, repo, version)
+	if err != nil {
+		return err
+	}
+	err = createFileAtomic(filename, data)
+	if !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	existing, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(existing, data) {
+		return fmt.Errorf("%s/%s@%s: %w", owner, repo, version, ErrConflict)
+	}
+	return nil
+}
+
+// GetBranch returns the version the branch points to.
+func (fs *FileStore) GetBranch(owner, repo, branch string) (string, error) {
+	filename, err := fs.branchPath(owner, repo, branch)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%s/%s branch %s: %w", owner, repo, branch, ErrNotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SetBranch points the branch at the version, which must already exist.
+func (fs *FileStore) SetBranch(owner, repo, branch, version string) error {
+	imageFile, err := fs.imagePath(owner, repo, version)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(imageFile); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s/%s@%s: %w", owner, repo, version, ErrNotFound)
+	} else if err != nil {
+		return err
+	}
+
+	filename, err := fs.branchPath(owner, repo, branch)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filename, []byte(version+"\n"))
+}
+
+// Resolve returns the image for the version, or for the version the branch
+// points to when there is no such version, along with the version.
+func (fs *FileStore) Resolve(owner, repo, ref string) ([]byte, string, error) {
+	data, err := fs.GetImage(owner, repo, ref)
+	if err == nil {
+		return data, ref, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, "", err
+	}
+
+	version, err := fs.GetBranch(owner, repo, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err = fs.GetImage(owner, repo, version)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, version, nil
+}
+
+// writeFileAtomic writes the file through a temp file, replacing any
+// existing file.
+func writeFileAtomic(filename string, data []byte) error {
+	return writeTemp(filename, data, func(tmpName string) error {
+		return os.Rename(tmpName, filename)
+	})
+}
+
+// createFileAtomic writes the file through a temp file, failing with
+// os.ErrExist if the file exists. The link is the check, so only one of
+// concurrent writers creates the file.
+func createFileAtomic(filename string, data []byte) error {
+	return writeTemp(filename, data, func(tmpName string) error {
+		return os.Link(tmpName, filename)
+	})
+}
+
+// writeTemp writes the data to a temp file beside the file, which is removed
+// after publish moves or links it into place.
+func writeTemp(filename string, data []byte, publish func(tmpName string) error) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return publish(tmp.Name())
+}
@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutImageRace(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	const pushes = 20
+	errs := make([]error, pushes)
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for idx := range pushes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[idx] = store.PutImage("owner", "repo", "v1", []byte(fmt.Sprintf("image %d", idx)))
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	stored, err := store.GetImage("owner", "repo", "v1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	won := 0
+	for idx, err := range errs {
+		if err == nil {
+			won++
+			assert.Equal(t, fmt.Sprintf("image %d", idx), string(stored))
+			continue
+		}
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("push %d: unexpected error %s", idx, err)
+		}
+	}
+	assert.Equal(t, 1, won, "exactly one push should store the version")
+
+	assert.NoError(t, store.PutImage("owner", "repo", "v1", stored), "pushing the same data again succeeds")
+}
@@ -0,0 +1,98 @@
+package source
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+)
+
+// repoConfigFiles change the config of every bundle.
+var repoConfigFiles = func() map[string]bool {
+	files := map[string]bool{
+		"j5-lock.yaml": true,
+	}
+	for _, filename := range configPaths {
+		files[filename] = true
+	}
+	return files
+}()
+
+// AffectedBundles returns the bundles containing any of the changed files
+// (slash separated, relative to the repo root), and the local bundles which
+// depend on them, in load order. A change to the repo config affects every
+// bundle.
+func (src *RepoRoot) AffectedBundles(changed []string) []*bundleSource {
+	all := src.thisRepo.bundles
+	affected := map[string]bool{}
+
+	for _, filename := range changed {
+		if repoConfigFiles[filename] {
+			return all
+		}
+		if bundle := src.bundleForFile(filename); bundle != nil {
+			affected[bundle.refConfig.Name] = true
+		}
+	}
+
+	// bundles are loaded in order, local dependencies always come first.
+	out := make([]*bundleSource, 0, len(all))
+	for _, bundle := range all {
+		if !affected[bundle.refConfig.Name] {
+			for _, dep := range bundle.localDependencies() {
+				if affected[dep] {
+					affected[bundle.refConfig.Name] = true
+					break
+				}
+			}
+		}
+		if affected[bundle.refConfig.Name] {
+			out = append(out, bundle)
+		}
+	}
+	return out
+}
+
+// bundleForFile returns the bundle with the deepest directory containing the
+// file, the inline bundle contains the whole repo.
+func (src *RepoRoot) bundleForFile(filename string) *bundleSource {
+	var found *bundleSource
+	for _, bundle := range src.thisRepo.bundles {
+		dir := path.Clean(bundle.dirInRepo)
+		if dir != "." && filename != dir && !strings.HasPrefix(filename, dir+"/") {
+			continue
+		}
+		if found == nil || len(dir) > len(path.Clean(found.dirInRepo)) {
+			found = bundle
+		}
+	}
+	return found
+}
+
+// AffectedGenerators returns the generate blocks with a local input naming
+// one of the affected bundles. A change to the repo config affects every
+// generate block.
+func (src *RepoRoot) AffectedGenerators(changed []string) []*config_j5pb.GenerateConfig {
+	for _, filename := range changed {
+		if repoConfigFiles[filename] {
+			return src.thisRepo.config.Generate
+		}
+	}
+
+	names := map[string]bool{}
+	for _, bundle := range src.AffectedBundles(changed) {
+		names[bundle.refConfig.Name] = true
+	}
+
+	out := make([]*config_j5pb.GenerateConfig, 0)
+	for _, generator := range src.thisRepo.config.Generate {
+		for _, input := range generator.Inputs {
+			local, ok := input.Type.(*config_j5pb.Input_Local)
+			if ok && names[local.Local] {
+				out = append(out, generator)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package source
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffected(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("J5_CACHE_DIR", t.TempDir())
+	t.Setenv("J5_REGISTRY", "http://localhost:1")
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"j5.repo.yaml": `bundles:
+  - name: base
+    dir: proto/base
+  - name: api
+    dir: proto/api
+  - name: other
+    dir: proto/other
+generate:
+  - name: api
+    inputs:
+      - local: api
+    output: gen/api
+  - name: other
+    inputs:
+      - local: other
+    output: gen/other
+`,
+		"proto/base/j5.yaml":  "packages:\n  - name: base.v1\n",
+		"proto/api/j5.yaml":   "packages:\n  - name: api.v1\ndependencies:\n  - local: base\n",
+		"proto/other/j5.yaml": "packages:\n  - name: other.v1\n",
+	})
+
+	resolver, err := NewEnvResolver()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	src, err := NewFSRepoRoot(ctx, os.DirFS(root), resolver.WithLocalRoot(root))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	bundleNames := func(changed ...string) []string {
+		names := []string{}
+		for _, bundle := range src.AffectedBundles(changed) {
+			names = append(names, bundle.refConfig.Name)
+		}
+		return names
+	}
+
+	generatorNames := func(changed ...string) []string {
+		names := []string{}
+		for _, generator := range src.AffectedGenerators(changed) {
+			names = append(names, generator.Name)
+		}
+		return names
+	}
+
+	// Dependents of the changed bundle are rebuilt
+	assert.Equal(t, []string{"base", "api"}, bundleNames("proto/base/base/v1/base.j5s"))
+	assert.Equal(t, []string{"api"}, generatorNames("proto/base/base/v1/base.j5s"))
+
+	assert.Equal(t, []string{"other"}, bundleNames("proto/other/j5.yaml"))
+	assert.Equal(t, []string{"other"}, generatorNames("proto/other/j5.yaml"))
+
+	assert.Equal(t, []string{}, bundleNames("README.md"))
+	assert.Equal(t, []string{}, generatorNames("README.md"))
+
+	// Repo config changes everything
+	assert.Equal(t, []string{"base", "api", "other"}, bundleNames("j5.repo.yaml"))
+	assert.Equal(t, []string{"api", "other"}, generatorNames("j5-lock.yaml"))
+}
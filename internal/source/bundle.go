@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"path"
+	"strings"
 
 	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
 	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/j5build/internal/j5s/protobuild"
+	"github.com/pentops/j5build/internal/j5s/protoprint"
 	"github.com/pentops/j5build/internal/protosrc"
 	"github.com/pentops/log.go/log"
 )
@@ -166,7 +170,12 @@ func (bundle *bundleSource) readImageFromDir(ctx context.Context, resolver Input
 		includedFilenames = append(includedFilenames, included.SourceFilenames...)
 	}
 
-	img, err := protosrc.ReadFSImage(ctx, bundle.fs, includedFilenames, combinedDeps)
+	generated, err := bundle.compileJ5s(ctx, combinedDeps)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := protosrc.ReadFSImage(ctx, bundle.fs, includedFilenames, generated, combinedDeps)
 	if err != nil {
 		return nil, err
 	}
@@ -186,3 +195,61 @@ func (bundle *bundleSource) readImageFromDir(ctx context.Context, resolver Input
 	}
 	return img, nil
 }
+
+// generatedComment heads the printed j5s files, on one line like the header
+// genproto writes, so the source info matches the files genproto writes.
+const generatedComment = "Generated by j5build. DO NOT EDIT"
+
+// compileJ5s converts the j5s files in the bundle packages, returning the
+// .j5s.proto files which genproto would write, by filename. The image source
+// info then points to lines in these files. Returns nil when the bundle has
+// no j5s files.
+func (bundle *bundleSource) compileJ5s(ctx context.Context, deps DependencySet) (map[string]string, error) {
+	hasJ5s := false
+	err := fs.WalkDir(bundle.fs, ".", func(pathname string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && path.Ext(pathname) == ".j5s" {
+			hasJ5s = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasJ5s {
+		return nil, nil
+	}
+
+	localFiles, err := protobuild.NewBundleResolver(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler, err := protobuild.NewPackageSet(deps, localFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]string{}
+	for _, pkg := range localFiles.ListPackages() {
+		out, err := compiler.CompilePackage(ctx, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("compile package %q: %w", pkg, err)
+		}
+
+		for _, file := range out {
+			if !strings.HasSuffix(file.Path(), ".j5s.proto") {
+				continue
+			}
+			printed, err := protoprint.PrintFile(ctx, file, generatedComment)
+			if err != nil {
+				return nil, err
+			}
+			files[file.Path()] = printed
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,108 @@
+package source
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestSourceImageJ5s(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("J5_CACHE_DIR", t.TempDir())
+	t.Setenv("J5_REGISTRY", "http://localhost:1")
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"j5.repo.yaml":      "bundles:\n  - name: api\n    dir: proto/api\n",
+		"proto/api/j5.yaml": "packages:\n  - name: api.v1\n",
+		"proto/api/api/v1/foo.j5s": `package api.v1
+
+object Foo {
+  | The Foo
+  field id string
+}
+`,
+		"proto/api/api/v1/bar.proto": `syntax = "proto3";
+package api.v1;
+import "api/v1/foo.j5s.proto";
+message Bar {
+  Foo foo = 1;
+}
+`,
+		// stale output from genproto, replaced by the j5s file
+		"proto/api/api/v1/foo.j5s.proto": `syntax = "proto3";
+package api.v1;
+message Stale {
+}
+`,
+	})
+
+	resolver, err := NewEnvResolver()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	src, err := NewFSRepoRoot(ctx, os.DirFS(root), resolver.WithLocalRoot(root))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	bundle, err := src.BundleSource("api")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	img, err := bundle.SourceImage(ctx, src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	files := map[string]*descriptorpb.FileDescriptorProto{}
+	for _, file := range img.File {
+		files[file.GetName()] = file
+	}
+	assert.ElementsMatch(t, []string{"api/v1/foo.j5s.proto", "api/v1/bar.proto"}, img.SourceFilenames)
+
+	fooFile, ok := files["api/v1/foo.j5s.proto"]
+	if !ok {
+		t.Fatalf("foo.j5s.proto not in image")
+	}
+	if assert.Len(t, fooFile.MessageType, 1) {
+		assert.Equal(t, "Foo", fooFile.MessageType[0].GetName())
+	}
+
+	var fooLoc *descriptorpb.SourceCodeInfo_Location
+	for _, loc := range fooFile.GetSourceCodeInfo().GetLocation() {
+		if len(loc.Path) == 2 && loc.Path[0] == 4 && loc.Path[1] == 0 {
+			fooLoc = loc
+		}
+	}
+	if fooLoc == nil {
+		t.Fatalf("no source location for Foo")
+	}
+	// The spans are in the printed .j5s.proto file, which the image names,
+	// not in the j5s source.
+	deps, err := bundle.GetDependencies(ctx, src)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	printed, err := bundle.compileJ5s(ctx, deps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	lines := strings.Split(printed["api/v1/foo.j5s.proto"], "\n")
+	if int(fooLoc.Span[0]) >= len(lines) {
+		t.Fatalf("span %v is outside the printed file", fooLoc.Span)
+	}
+	assert.Equal(t, "message Foo {", lines[fooLoc.Span[0]][fooLoc.Span[1]:])
+	assert.Equal(t, " The Foo\n", fooLoc.GetLeadingComments())
+
+	barFile, ok := files["api/v1/bar.proto"]
+	if !ok {
+		t.Fatalf("bar.proto not in image")
+	}
+	assert.Equal(t, ".api.v1.Foo", barFile.MessageType[0].Field[0].GetTypeName())
+}
@@ -0,0 +1,122 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/log.go/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const hashPrefix = "sha256:"
+
+// ImageHash is the digest of the image as recorded in the lock file. The
+// SourceName is excluded as the resolver fills it in when it is not set.
+//
+// The binary encoding is not stable across protobuf versions, even when
+// deterministic, so the digest is of the proto3 JSON mapping of the image,
+// re-encoded compact with sorted keys. Unknown fields are not included.
+func ImageHash(img *source_j5pb.SourceImage) (string, error) {
+	img = proto.Clone(img).(*source_j5pb.SourceImage)
+	img.SourceName = ""
+	data, err := protojson.Marshal(img)
+	if err != nil {
+		return "", err
+	}
+	data, err = canonicalJSON(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hashPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON re-encodes the JSON without whitespace and with object keys
+// sorted, protojson output is deliberately unstable. Numbers are kept as
+// written.
+func canonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var val interface{}
+	if err := dec.Decode(&val); err != nil {
+		return nil, err
+	}
+	return json.Marshal(val)
+}
+
+// verifyImageHash checks the image against the hash from the lock file, locks
+// without a hash are not checked.
+func verifyImageHash(img *source_j5pb.SourceImage, lockHash string) error {
+	if lockHash == "" {
+		return nil
+	}
+	if !strings.HasPrefix(lockHash, hashPrefix) {
+		return fmt.Errorf("unsupported lock hash %q, expecting %s<hex>", lockHash, hashPrefix)
+	}
+	got, err := ImageHash(img)
+	if err != nil {
+		return err
+	}
+	if got != lockHash {
+		return fmt.Errorf("integrity check failed: lock file has %s, image is %s. The version may have been overwritten, run latest-deps --update-hashes to trust the new content", lockHash, got)
+	}
+	return nil
+}
+
+// UpdateHashes returns a copy of the lock file with the hash of each registry
+// input set from the image in the registry at the locked version. The cache
+// is replaced with the fetched image.
+func (rr *Resolver) UpdateHashes(ctx context.Context, locks *config_j5pb.LockFile) (*config_j5pb.LockFile, error) {
+	locks = proto.Clone(locks).(*config_j5pb.LockFile)
+	for _, lock := range locks.Inputs {
+		ref, ok := strings.CutPrefix(lock.Name, "registry/")
+		if !ok {
+			// dir and git versions are already content addresses
+			continue
+		}
+		owner, repoName, ok := strings.Cut(ref, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid registry lock name %q", lock.Name)
+		}
+
+		client, err := rr.remoteClient()
+		if err != nil {
+			return nil, fmt.Errorf("updating hash of %s: %w", lock.Name, err)
+		}
+
+		img, err := client.GetImage(ctx, owner, repoName, lock.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		hash, err := ImageHash(img)
+		if err != nil {
+			return nil, err
+		}
+
+		if lock.Hash != "" && lock.Hash != hash {
+			log.WithFields(ctx, map[string]interface{}{
+				"dep":     lock.Name,
+				"version": lock.Version,
+				"oldHash": lock.Hash,
+				"newHash": hash,
+			}).Warn("Resolver: lock hash changed")
+		}
+		lock.Hash = hash
+
+		if rr.j5Cache != nil {
+			if err := rr.j5Cache.put(ctx, lock.Name, lock.Version, img); err != nil {
+				log.WithError(ctx, err).Error("failed to cache input")
+			}
+		}
+	}
+	return locks, nil
+}
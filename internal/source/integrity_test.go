@@ -0,0 +1,202 @@
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestImageHashGolden(t *testing.T) {
+	img := &source_j5pb.SourceImage{
+		SourceName:      "ignored",
+		Version:         gl.Ptr("v1"),
+		SourceFilenames: []string{"foo/v1/foo.proto"},
+		Packages: []*source_j5pb.PackageInfo{{
+			Name:  "foo.v1",
+			Label: "Foo",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("foo/v1/foo.proto"),
+			Package: proto.String("foo.v1"),
+			Syntax:  proto.String("proto3"),
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Foo"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:   proto.String("id"),
+					Number: proto.Int32(1),
+					Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+				}},
+			}},
+		}},
+	}
+
+	// Lock files record this value, it must not change when the protobuf
+	// library does.
+	hash, err := ImageHash(img)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "sha256:04cdf2678a00a437b6f66e02873440b810bf7aaa69ca4b9d94f99a0b83aa049d", hash)
+}
+
+func TestLockHashes(t *testing.T) {
+	ctx := context.Background()
+
+	original := &source_j5pb.SourceImage{
+		Version:         gl.Ptr("v1"),
+		SourceFilenames: []string{"original.proto"},
+	}
+	overwritten := &source_j5pb.SourceImage{
+		Version:         gl.Ptr("v1"),
+		SourceFilenames: []string{"overwritten.proto"},
+	}
+
+	registry := testRegistry{
+		"pentops/other@main": original,
+		"pentops/other@v1":   original,
+	}
+	cache := &j5Cache{
+		dir: t.TempDir(),
+	}
+	resolver := &Resolver{
+		regClient: registry,
+		j5Cache:   cache,
+	}
+
+	input := &config_j5pb.Input{
+		Type: &config_j5pb.Input_Registry_{
+			Registry: &config_j5pb.Input_Registry{
+				Owner: "pentops",
+				Name:  "other",
+			},
+		},
+	}
+
+	locks, err := resolver.LatestLocks(ctx, []*config_j5pb.Input{input})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !assert.Len(t, locks.Inputs, 1) {
+		return
+	}
+	originalHash := locks.Inputs[0].Hash
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", originalHash)
+
+	img, err := resolver.GetRemoteDependency(ctx, input, locks)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"original.proto"}, img.SourceFilenames)
+
+	// The tag is overwritten upstream, and the cache picks it up.
+	registry["pentops/other@v1"] = overwritten
+	if err := cache.put(ctx, "registry/pentops/other", "v1", overwritten); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = resolver.GetRemoteDependency(ctx, input, locks)
+	assert.ErrorContains(t, err, "integrity check failed")
+
+	uncached := &Resolver{
+		regClient: registry,
+	}
+	_, err = uncached.GetRemoteDependency(ctx, input, locks)
+	assert.ErrorContains(t, err, "integrity check failed")
+
+	// Without a hash in the lock, nothing is checked.
+	_, err = uncached.GetRemoteDependency(ctx, input, &config_j5pb.LockFile{
+		Inputs: []*config_j5pb.InputLock{{
+			Name:    "registry/pentops/other",
+			Version: "v1",
+		}},
+	})
+	assert.NoError(t, err)
+
+	updated, err := resolver.UpdateHashes(ctx, locks)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, originalHash, locks.Inputs[0].Hash, "input locks should not be modified")
+	assert.NotEqual(t, originalHash, updated.Inputs[0].Hash)
+	assert.Equal(t, "v1", updated.Inputs[0].Version)
+
+	img, err = resolver.GetRemoteDependency(ctx, input, updated)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"overwritten.proto"}, img.SourceFilenames)
+}
+
+func TestLockHashPinnedVersion(t *testing.T) {
+	ctx := context.Background()
+
+	original := &source_j5pb.SourceImage{
+		Version:         gl.Ptr("v1"),
+		SourceFilenames: []string{"original.proto"},
+	}
+	corrupted := &source_j5pb.SourceImage{
+		Version:         gl.Ptr("v1"),
+		SourceFilenames: []string{"corrupted.proto"},
+	}
+
+	hash, err := ImageHash(original)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	locks := &config_j5pb.LockFile{
+		Inputs: []*config_j5pb.InputLock{{
+			Name:    "registry/pentops/other",
+			Version: "v1",
+			Hash:    hash,
+		}},
+	}
+
+	cache := &j5Cache{
+		dir: t.TempDir(),
+	}
+	if err := cache.put(ctx, "registry/pentops/other", "v1", corrupted); err != nil {
+		t.Fatal(err.Error())
+	}
+	resolver := &Resolver{
+		regClient: testRegistry{"pentops/other@v1": original},
+		j5Cache:   cache,
+	}
+
+	pinned := func(version string) *config_j5pb.Input {
+		return &config_j5pb.Input{
+			Type: &config_j5pb.Input_Registry_{
+				Registry: &config_j5pb.Input_Registry{
+					Owner:   "pentops",
+					Name:    "other",
+					Version: gl.Ptr(version),
+				},
+			},
+		}
+	}
+
+	_, err = resolver.GetRemoteDependency(ctx, pinned("v1"), locks)
+	assert.ErrorContains(t, err, "integrity check failed")
+
+	uncached := &Resolver{
+		regClient: testRegistry{"pentops/other@v1": corrupted},
+	}
+	_, err = uncached.GetRemoteDependency(ctx, pinned("v1"), locks)
+	assert.ErrorContains(t, err, "integrity check failed", "registry fetches are checked too")
+
+	// A pinned version other than the locked one has no hash to check.
+	if err := cache.put(ctx, "registry/pentops/other", "v2", corrupted); err != nil {
+		t.Fatal(err.Error())
+	}
+	img, err := resolver.GetRemoteDependency(ctx, pinned("v2"), locks)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, []string{"corrupted.proto"}, img.SourceFilenames)
+}
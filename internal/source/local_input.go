@@ -0,0 +1,386 @@
+package source
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/log.go/log"
+)
+
+// WithLocalRoot returns a copy of the resolver which resolves dir and git
+// inputs, relative paths are resolved from dir. Resolvers without a local
+// root reject them, so a server building untrusted repos never reads the
+// local filesystem.
+func (rr *Resolver) WithLocalRoot(dir string) *Resolver {
+	cp := *rr
+	cp.localRoot = dir
+	return &cp
+}
+
+func (rr *Resolver) localPath(path string) (string, error) {
+	if rr.localRoot == "" {
+		return "", fmt.Errorf("local inputs are not supported by this resolver")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(rr.localRoot, path)
+	}
+	return filepath.Abs(path)
+}
+
+// localInputName is the name of the input in the lock file, built from the
+// config as written so that it is the same on every machine.
+func localInputName(input *config_j5pb.Input) string {
+	switch st := input.Type.(type) {
+	case *config_j5pb.Input_Dir_:
+		name := "dir/" + filepath.ToSlash(st.Dir.Path)
+		if st.Dir.Bundle != "" {
+			name += "#" + st.Dir.Bundle
+		}
+		return name
+
+	case *config_j5pb.Input_Git_:
+		name := "git/" + filepath.ToSlash(st.Git.Repo)
+		if st.Git.Dir != "" {
+			name += "//" + st.Git.Dir
+		}
+		name += "@" + gitRef(st.Git)
+		if st.Git.Bundle != "" {
+			name += "#" + st.Git.Bundle
+		}
+		return name
+
+	default:
+		return ""
+	}
+}
+
+func gitRef(input *config_j5pb.Input_Git) string {
+	if input.Ref == "" {
+		return "HEAD"
+	}
+	return input.Ref
+}
+
+type localChainKey struct{}
+
+// getLocalDependency builds a bundle from another repo on the filesystem.
+// Images from git are cached by the tree hash. Dir inputs are built every
+// time: the content hash does not cover dependencies resolved when building,
+// and every edit to a working directory would leave another cache entry.
+func (rr *Resolver) getLocalDependency(ctx context.Context, input *config_j5pb.Input, locks *config_j5pb.LockFile) (*source_j5pb.SourceImage, error) {
+	name := localInputName(input)
+	ctx = log.WithField(ctx, "bundle", name)
+
+	var repoDir, bundleName, version, cacheKey string
+	var extract func(dest string) error
+
+	switch st := input.Type.(type) {
+	case *config_j5pb.Input_Dir_:
+		path, err := rr.localPath(st.Dir.Path)
+		if err != nil {
+			return nil, err
+		}
+		contentHash, err := dirContentHash(path)
+		if err != nil {
+			return nil, fmt.Errorf("hashing %s: %w", path, err)
+		}
+		if lockVersion := getInputLockVersion(locks, name); lockVersion != nil && *lockVersion != contentHash {
+			log.WithField(ctx, "lockVersion", *lockVersion).Warn("Resolver: local input has changed since it was locked")
+		}
+		repoDir = path
+		bundleName = st.Dir.Bundle
+		version = contentHash
+
+	case *config_j5pb.Input_Git_:
+		repo, err := rr.localPath(st.Git.Repo)
+		if err != nil {
+			return nil, err
+		}
+		ref := gitRef(st.Git)
+		if lockVersion := getInputLockVersion(locks, name); lockVersion != nil {
+			log.WithField(ctx, "lockVersion", *lockVersion).Debug("Resolver: using lock version")
+			ref = *lockVersion
+		}
+		commit, err := gitRevParse(ctx, repo, ref+"^{commit}")
+		if err != nil {
+			return nil, err
+		}
+		treeish := commit + ":" + st.Git.Dir
+		tree, err := gitRevParse(ctx, repo, treeish)
+		if err != nil {
+			return nil, err
+		}
+		bundleName = st.Git.Bundle
+		version = commit
+		cacheKey = hashStrings(tree, bundleName)
+		extract = func(dest string) error {
+			return ExtractGitTree(ctx, repo, treeish, dest)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported local input type %T", input.Type)
+	}
+
+	if cacheKey != "" {
+		if cached, ok := rr.getCachedInput(ctx, "local", cacheKey); ok {
+			log.Debug(ctx, "Resolver: using cached input")
+			cached.SourceName = name
+			return cached, nil
+		}
+	}
+
+	if extract != nil {
+		tmp, err := os.MkdirTemp("", "j5-git-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		if err := extract(tmp); err != nil {
+			return nil, err
+		}
+		repoDir = tmp
+	}
+
+	img, err := rr.buildLocalBundle(ctx, repoDir, bundleName)
+	if err != nil {
+		return nil, fmt.Errorf("building %s: %w", name, err)
+	}
+	img.SourceName = name
+	img.Version = gl.Ptr(version)
+
+	if rr.j5Cache != nil && cacheKey != "" {
+		if err := rr.j5Cache.put(ctx, "local", cacheKey, img); err != nil {
+			log.WithError(ctx, err).Error("failed to cache input")
+		}
+	}
+	return img, nil
+}
+
+func (rr *Resolver) buildLocalBundle(ctx context.Context, repoDir string, bundleName string) (*source_j5pb.SourceImage, error) {
+	chain, _ := ctx.Value(localChainKey{}).([]string)
+	for _, seen := range chain {
+		if seen == repoDir {
+			return nil, fmt.Errorf("local input cycle: %s", strings.Join(append(chain, repoDir), " -> "))
+		}
+	}
+	ctx = context.WithValue(ctx, localChainKey{}, append(chain, repoDir))
+
+	root, err := NewFSRepoRoot(ctx, os.DirFS(repoDir), rr.WithLocalRoot(repoDir))
+	if err != nil {
+		return nil, err
+	}
+
+	bundles := root.thisRepo.bundles
+	var bundle *bundleSource
+	if bundleName == "" && len(bundles) == 1 {
+		bundle = bundles[0]
+	} else {
+		bundle = root.thisRepo.bundleByName(bundleName)
+	}
+	if bundle == nil {
+		return nil, fmt.Errorf("bundle %q not found", bundleName)
+	}
+
+	return bundle.SourceImage(ctx, root)
+}
+
+// latestLocalLock returns the lock for the current state of a local input.
+func (rr *Resolver) latestLocalLock(ctx context.Context, input *config_j5pb.Input) (*config_j5pb.InputLock, error) {
+	lock := &config_j5pb.InputLock{
+		Name: localInputName(input),
+	}
+
+	switch st := input.Type.(type) {
+	case *config_j5pb.Input_Dir_:
+		path, err := rr.localPath(st.Dir.Path)
+		if err != nil {
+			return nil, err
+		}
+		lock.Version, err = dirContentHash(path)
+		if err != nil {
+			return nil, fmt.Errorf("hashing %s: %w", path, err)
+		}
+
+	case *config_j5pb.Input_Git_:
+		repo, err := rr.localPath(st.Git.Repo)
+		if err != nil {
+			return nil, err
+		}
+		lock.Version, err = gitRevParse(ctx, repo, gitRef(st.Git)+"^{commit}")
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported local input type %T", input.Type)
+	}
+	return lock, nil
+}
+
+// dirContentHash hashes the names and content of the files in the directory,
+// skipping hidden files and directories such as .git.
+func dirContentHash(dir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		hash.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hashStrings(vals ...string) string {
+	hash := sha256.New()
+	for _, val := range vals {
+		fmt.Fprintf(hash, "%s\x00", val)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func gitRevParse(ctx context.Context, repo, rev string) (string, error) {
+	out, err := runGit(ctx, repo, "rev-parse", "--verify", rev)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// GitVersion is the state of the checkout of a git repo, to version the
+// images built from it.
+type GitVersion struct {
+	Commit string
+
+	// Tag is the latest version tag pointing at the commit, if any.
+	Tag string
+
+	// Branch is empty when HEAD is detached.
+	Branch string
+
+	// Dirty is set when there are uncommitted changes, so the commit does not
+	// match the files.
+	Dirty bool
+}
+
+func ReadGitVersion(ctx context.Context, repo string) (*GitVersion, error) {
+	commit, err := gitRevParse(ctx, repo, "HEAD^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	version := &GitVersion{
+		Commit: commit,
+	}
+
+	tags, err := runGit(ctx, repo, "tag", "--points-at", "HEAD", "--sort=-version:refname")
+	if err != nil {
+		return nil, err
+	}
+	if tag, _, _ := strings.Cut(string(tags), "\n"); tag != "" {
+		version.Tag = strings.TrimSpace(tag)
+	}
+
+	branch, err := runGit(ctx, repo, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if name := strings.TrimSpace(string(branch)); name != "HEAD" {
+		version.Branch = name
+	}
+
+	status, err := runGit(ctx, repo, "status", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	version.Dirty = len(bytes.TrimSpace(status)) > 0
+
+	return version, nil
+}
+
+func runGit(ctx context.Context, repo string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// ExtractGitTree writes the files of the tree-ish in the git repository to
+// dest, e.g. `main:proto` for the proto directory of the main branch.
+func ExtractGitTree(ctx context.Context, repo, treeish, dest string) error {
+	out, err := runGit(ctx, repo, "archive", "--format=tar", treeish)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(bytes.NewReader(out))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package source
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+}
+
+func testRepoFiles(field string) map[string]string {
+	return map[string]string{
+		"j5.yaml": "packages:\n  - name: other.v1\n",
+		"other/v1/other.proto": `syntax = "proto3";
+package other.v1;
+message Other {
+  string ` + field + ` = 1;
+}
+`,
+	}
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+}
+
+func TestLocalInputs(t *testing.T) {
+	ctx := context.Background()
+	cacheDir := t.TempDir()
+	t.Setenv("J5_CACHE_DIR", cacheDir)
+	t.Setenv("J5_REGISTRY", "http://localhost:1")
+
+	root := t.TempDir()
+	otherDir := filepath.Join(root, "other")
+	writeTestFiles(t, otherDir, testRepoFiles("name"))
+
+	resolver, err := NewEnvResolver()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	dirInput := &config_j5pb.Input{
+		Type: &config_j5pb.Input_Dir_{
+			Dir: &config_j5pb.Input_Dir{
+				Path: "other",
+			},
+		},
+	}
+
+	t.Run("no local root", func(t *testing.T) {
+		_, err := resolver.GetRemoteDependency(ctx, dirInput, nil)
+		assert.Error(t, err)
+	})
+
+	local := resolver.WithLocalRoot(root)
+
+	t.Run("dir", func(t *testing.T) {
+		img, err := local.GetRemoteDependency(ctx, dirInput, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, "dir/other", img.SourceName)
+		assert.Equal(t, []string{"other/v1/other.proto"}, img.SourceFilenames)
+
+		locks, err := local.LatestLocks(ctx, []*config_j5pb.Input{dirInput})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if assert.Len(t, locks.Inputs, 1) {
+			assert.Equal(t, "dir/other", locks.Inputs[0].Name)
+			assert.Equal(t, img.GetVersion(), locks.Inputs[0].Version)
+		}
+
+		// Dir inputs are not cached, the next build sees the change.
+		_, err = os.Stat(filepath.Join(cacheDir, "local"))
+		assert.True(t, os.IsNotExist(err), "expected no local cache entries")
+		writeTestFiles(t, otherDir, testRepoFiles("changed"))
+		img, err = local.GetRemoteDependency(ctx, dirInput, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if assert.Len(t, img.File, 1) {
+			assert.Equal(t, "changed", img.File[0].MessageType[0].Field[0].GetName())
+		}
+		writeTestFiles(t, otherDir, testRepoFiles("name"))
+	})
+
+	t.Run("git", func(t *testing.T) {
+		runTestGit(t, otherDir, "init", "-q")
+		runTestGit(t, otherDir, "add", ".")
+		runTestGit(t, otherDir, "commit", "-q", "-m", "first")
+
+		gitInput := &config_j5pb.Input{
+			Type: &config_j5pb.Input_Git_{
+				Git: &config_j5pb.Input_Git{
+					Repo: "other",
+				},
+			},
+		}
+
+		locks, err := local.LatestLocks(ctx, []*config_j5pb.Input{gitInput})
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !assert.Len(t, locks.Inputs, 1) {
+			return
+		}
+		assert.Equal(t, "git/other@HEAD", locks.Inputs[0].Name)
+		firstCommit := locks.Inputs[0].Version
+
+		// Change the working copy and commit, the lock keeps the first commit.
+		writeTestFiles(t, otherDir, testRepoFiles("renamed"))
+		runTestGit(t, otherDir, "commit", "-q", "-am", "second")
+
+		img, err := local.GetRemoteDependency(ctx, gitInput, locks)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.Equal(t, firstCommit, img.GetVersion())
+		if assert.Len(t, img.File, 1) {
+			assert.Equal(t, "name", img.File[0].MessageType[0].Field[0].GetName())
+		}
+
+		img, err = local.GetRemoteDependency(ctx, gitInput, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		assert.NotEqual(t, firstCommit, img.GetVersion())
+		if assert.Len(t, img.File, 1) {
+			assert.Equal(t, "renamed", img.File[0].MessageType[0].Field[0].GetName())
+		}
+	})
+}
+
+func TestReadGitVersion(t *testing.T) {
+	ctx := context.Background()
+	repo := t.TempDir()
+	writeTestFiles(t, repo, testRepoFiles("name"))
+	runTestGit(t, repo, "init", "-q", "-b", "main")
+	runTestGit(t, repo, "add", ".")
+	runTestGit(t, repo, "commit", "-q", "-m", "first")
+
+	version, err := ReadGitVersion(ctx, repo)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Len(t, version.Commit, 40)
+	assert.Equal(t, "", version.Tag)
+	assert.Equal(t, "main", version.Branch)
+	assert.False(t, version.Dirty)
+
+	runTestGit(t, repo, "tag", "v1.0.0")
+	runTestGit(t, repo, "tag", "v1.1.0")
+	runTestGit(t, repo, "checkout", "-q", "--detach")
+	writeTestFiles(t, repo, testRepoFiles("other"))
+
+	version, err = ReadGitVersion(ctx, repo)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "v1.1.0", version.Tag)
+	assert.Equal(t, "", version.Branch)
+	assert.True(t, version.Dirty)
+}
@@ -1,6 +1,7 @@
 package source
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -32,7 +33,9 @@ func NewRegistryClient(remote string, authToken string) (*registryClient, error)
 	}, nil
 }
 
-func envRegistryClient() (*registryClient, error) {
+// NewEnvRegistryClient builds a client for $J5_REGISTRY, authenticated with
+// $J5_REGISTRY_TOKEN when set.
+func NewEnvRegistryClient() (*registryClient, error) {
 	addr := os.Getenv("J5_REGISTRY")
 	if addr == "" {
 		return nil, fmt.Errorf("$J5_REGISTRY not set")
@@ -94,3 +97,61 @@ func (rc *registryClient) GetImage(ctx context.Context, owner, repoName, version
 
 	return apiDef, nil
 }
+
+// PushImage uploads the image as the version, then points each branch at the
+// version. The image is stored as-is, so the version and package info should
+// already be set.
+func (rc *registryClient) PushImage(ctx context.Context, owner, repoName, version string, branches []string, img *source_j5pb.SourceImage) error {
+	if rc == nil {
+		return fmt.Errorf("registry client not set")
+	}
+
+	fullName := fmt.Sprintf("registry/v1/%s/%s", owner, repoName)
+	ctx = log.WithFields(ctx, map[string]interface{}{
+		"bundle":  fullName,
+		"version": version,
+	})
+
+	data, err := proto.Marshal(img)
+	if err != nil {
+		return fmt.Errorf("marshalling image: %w", err)
+	}
+
+	imageURL := fmt.Sprintf("%s/%s/%s/image.bin", rc.remote, fullName, version)
+	if err := rc.put(ctx, imageURL, "application/octet-stream", data); err != nil {
+		return err
+	}
+	log.Info(ctx, "pushed image")
+
+	for _, branch := range branches {
+		branchURL := fmt.Sprintf("%s/%s/%s/version", rc.remote, fullName, branch)
+		if err := rc.put(ctx, branchURL, "text/plain", []byte(version)); err != nil {
+			return err
+		}
+		log.WithField(ctx, "branch", branch).Info("updated branch")
+	}
+
+	return nil
+}
+
+func (rc *registryClient) put(ctx context.Context, url string, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating registry push request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if rc.auth != "" {
+		req.Header.Set("Authorization", rc.auth)
+	}
+
+	res, err := rc.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("pushing to registry: %q %w", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("pushing to registry: %q %s %q", url, res.Status, string(msg))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package source
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPushImage(t *testing.T) {
+	ctx := context.Background()
+
+	puts := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err.Error())
+		}
+		puts[r.URL.Path] = body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	img := &source_j5pb.SourceImage{
+		SourceName: "registry/owner/repo",
+		Version:    gl.Ptr("v1.2.3"),
+		Packages: []*source_j5pb.PackageInfo{{
+			Name: "foo.v1",
+		}},
+	}
+
+	client, err := NewRegistryClient(server.URL, "secret")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = client.PushImage(ctx, "owner", "repo", "v1.2.3", []string{"main"}, img)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	assert.Len(t, puts, 2)
+	assert.Equal(t, "v1.2.3", string(puts["/registry/v1/owner/repo/main/version"]))
+
+	pushed := &source_j5pb.SourceImage{}
+	if err := proto.Unmarshal(puts["/registry/v1/owner/repo/v1.2.3/image.bin"], pushed); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.True(t, proto.Equal(img, pushed))
+
+	unauthorized, err := NewRegistryClient(server.URL, "")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	err = unauthorized.PushImage(ctx, "owner", "repo", "v1.2.4", nil, img)
+	assert.ErrorContains(t, err, "401")
+}
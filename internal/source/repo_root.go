@@ -88,6 +88,37 @@ func (src *RepoRoot) ListAllDependencies() ([]*config_j5pb.Input, error) {
 	return allDeps, nil
 }
 
+// ListAllPluginImages lists the docker images of the plugins used to generate
+// and publish, after resolving plugin references and overrides.
+func (src *RepoRoot) ListAllPluginImages() ([]string, error) {
+	plugins := []*config_j5pb.BuildPlugin{}
+	for _, generate := range src.thisRepo.config.Generate {
+		plugins = append(plugins, generate.Plugins...)
+	}
+	for _, bundle := range src.thisRepo.bundles {
+		cfg, err := bundle.J5Config()
+		if err != nil {
+			return nil, fmt.Errorf("bundle %q: %w", bundle.DebugName(), err)
+		}
+		for _, publish := range cfg.Publish {
+			plugins = append(plugins, publish.Plugins...)
+		}
+	}
+
+	images := []string{}
+	for _, plugin := range plugins {
+		if plugin.Docker != nil {
+			images = append(images, plugin.Docker.Image)
+		}
+	}
+	return images, nil
+}
+
+// LockFile is the parsed j5-lock.yaml of the repo, empty when there is none.
+func (src *RepoRoot) LockFile() *config_j5pb.LockFile {
+	return src.thisRepo.lockFile
+}
+
 func (src *RepoRoot) GetSourceImage(ctx context.Context, input *config_j5pb.Input) (*source_j5pb.SourceImage, error) {
 	if local, ok := input.Type.(*config_j5pb.Input_Local); ok {
 		bundle := src.thisRepo.bundleByName(local.Local)
@@ -190,6 +221,9 @@ func (src *RepoRoot) newRepo(debugName string, repoRoot fs.FS) (*repo, error) {
 				Packages:     config.Packages,
 				Options:      config.Options,
 				Dependencies: config.Dependencies,
+				Breaking:     config.Breaking,
+				Structure:    config.Structure,
+				Lint:         config.Lint,
 			},
 		})
 	}
@@ -255,6 +289,30 @@ func (src *RepoRoot) CombinedSourceImage(ctx context.Context, inputs []*config_j
 	return fullImage, nil
 }
 
+// CombinedStructure merges the structure config of the local bundles in the
+// inputs with the repo's own, so that generators use the service rules of
+// the bundles they build.
+func (src *RepoRoot) CombinedStructure(inputs []*config_j5pb.Input) (*config_j5pb.StructureConfig, error) {
+	combined := &config_j5pb.StructureConfig{}
+	for _, input := range inputs {
+		local, ok := input.Type.(*config_j5pb.Input_Local)
+		if !ok {
+			continue
+		}
+		bundle := src.thisRepo.bundleByName(local.Local)
+		if bundle == nil {
+			return nil, fmt.Errorf("bundle %q not found", local.Local)
+		}
+		cfg, err := bundle.J5Config()
+		if err != nil {
+			return nil, err
+		}
+		combined.Services = append(combined.Services, cfg.GetStructure().GetServices()...)
+	}
+	combined.Services = append(combined.Services, src.thisRepo.config.GetStructure().GetServices()...)
+	return combined, nil
+}
+
 func (src *RepoRoot) BundleDependencies(ctx context.Context, name string) (DependencySet, error) {
 	bs, err := src.BundleSource(name)
 	if err != nil {
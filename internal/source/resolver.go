@@ -3,6 +3,7 @@ package source
 import (
 	"context"
 	"fmt"
+	"os"
 
 	"github.com/pentops/golib/gl"
 	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
@@ -18,6 +19,13 @@ type RegistryClient interface {
 type Resolver struct {
 	regClient RegistryClient
 	j5Cache   *j5Cache
+
+	// localRoot is the directory relative dir and git inputs are resolved
+	// from, local inputs are rejected when empty. Vendored images are read
+	// from VendorDir within it.
+	localRoot string
+
+	offline bool
 }
 
 func NewResolver(regClient RegistryClient) (*Resolver, error) {
@@ -26,21 +34,29 @@ func NewResolver(regClient RegistryClient) (*Resolver, error) {
 	}, nil
 }
 
+// NewEnvResolver builds a resolver from $J5_REGISTRY and $J5_CACHE_DIR.
+// Without a registry, or with $J5_OFFLINE set, only vendored and cached
+// images can be resolved.
 func NewEnvResolver() (*Resolver, error) {
-	regClient, err := envRegistryClient()
-	if err != nil {
-		return nil, err
+	resolver := &Resolver{
+		offline: os.Getenv("J5_OFFLINE") != "",
+	}
+
+	if os.Getenv("J5_REGISTRY") != "" {
+		regClient, err := NewEnvRegistryClient()
+		if err != nil {
+			return nil, err
+		}
+		resolver.regClient = regClient
 	}
 
 	cache, err := newJ5Cache()
 	if err != nil {
 		return nil, err
 	}
+	resolver.j5Cache = cache
 
-	return &Resolver{
-		regClient: regClient,
-		j5Cache:   cache,
-	}, nil
+	return resolver, nil
 }
 
 func (rr *Resolver) GetRemoteDependency(ctx context.Context, input *config_j5pb.Input, locks *config_j5pb.LockFile) (*source_j5pb.SourceImage, error) {
@@ -53,13 +69,21 @@ func (rr *Resolver) GetRemoteDependency(ctx context.Context, input *config_j5pb.
 			repoName:  st.Registry.Name,
 			version:   st.Registry.Version,
 			reference: coalesce(st.Registry.Reference, gl.Ptr("main")),
-		}, rr.regClient, locks)
+		}, locks)
 		if err != nil {
 			return nil, fmt.Errorf("resolving remote %s:%s : %w", st.Registry.Owner, st.Registry.Name, err)
 		}
 
 		return img, nil
 
+	case *config_j5pb.Input_Dir_, *config_j5pb.Input_Git_:
+		img, err := rr.getLocalDependency(ctx, input, locks)
+		if err != nil {
+			return nil, fmt.Errorf("resolving local %s: %w", localInputName(input), err)
+		}
+
+		return img, nil
+
 	default:
 		return nil, fmt.Errorf("unsupported source type %T", input.Type)
 	}
@@ -74,24 +98,42 @@ type cacheSpec struct {
 	reference *string
 }
 
-func (rr *Resolver) cacheDance(ctx context.Context, spec cacheSpec, source RegistryClient, locks *config_j5pb.LockFile) (*source_j5pb.SourceImage, error) {
+func (rr *Resolver) cacheDance(ctx context.Context, spec cacheSpec, locks *config_j5pb.LockFile) (*source_j5pb.SourceImage, error) {
 
 	fullName := fmt.Sprintf("%s/%s/%s", spec.repoType, spec.owner, spec.repoName)
 	ctx = log.WithField(ctx, "bundle", fullName)
 	var version *string
+	lock := getInputLock(locks, fullName)
 	if spec.version != nil {
 		version = gl.Ptr(*spec.version)
 		ctx = log.WithField(ctx, "specVersion", *version)
-	} else if lockVersion := getInputLockVersion(locks, fullName); lockVersion != nil {
-		ctx = log.WithField(ctx, "lockVersion", *lockVersion)
+	} else if lock != nil {
+		ctx = log.WithField(ctx, "lockVersion", lock.Version)
 		log.Debug(ctx, "Resolver: using lock version")
-		version = gl.Ptr(*lockVersion)
+		version = gl.Ptr(lock.Version)
+	}
+
+	// The lock hash applies whenever the locked version is fetched, including
+	// when the version is pinned in the config.
+	var lockHash string
+	if lock != nil && version != nil && lock.Version == *version {
+		lockHash = lock.Hash
 	}
 
 	// only use cache if version is explicit, otherwise needs to pull latest
 	if version != nil {
+		if vendored, ok := rr.getVendoredInput(ctx, fullName, *version); ok {
+			log.Debug(ctx, "Resolver: using vendored input")
+			if err := verifyImageHash(vendored, lockHash); err != nil {
+				return nil, fmt.Errorf("vendored %s@%s: %w", fullName, *version, err)
+			}
+			return vendored, nil
+		}
 		if cached, ok := rr.getCachedInput(ctx, fullName, *version); ok {
 			log.Debug(ctx, "Resolver: using cached input")
+			if err := verifyImageHash(cached, lockHash); err != nil {
+				return nil, fmt.Errorf("cached %s@%s: %w", fullName, *version, err)
+			}
 			return cached, nil
 		}
 	}
@@ -106,10 +148,21 @@ func (rr *Resolver) cacheDance(ctx context.Context, spec cacheSpec, source Regis
 	ctx = log.WithField(ctx, "depVersion", *version)
 	log.Debug(ctx, "Resolver: cache miss")
 
+	source, err := rr.remoteClient()
+	if err != nil {
+		if spec.version == nil && getInputLockVersion(locks, fullName) == nil {
+			return nil, fmt.Errorf("%w: no locked version", err)
+		}
+		return nil, fmt.Errorf("%w: version %s is not vendored or cached", err, *version)
+	}
+
 	img, err := source.GetImage(ctx, spec.owner, spec.repoName, *version)
 	if err != nil {
 		return nil, err
 	}
+	if err := verifyImageHash(img, lockHash); err != nil {
+		return nil, fmt.Errorf("registry %s@%s: %w", fullName, *version, err)
+	}
 	if img.SourceName == "" {
 		img.SourceName = fullName
 	}
@@ -129,7 +182,6 @@ func (src *Resolver) LatestLocks(ctx context.Context, deps []*config_j5pb.Input)
 	seen := map[string]struct{}{}
 	for _, dep := range deps {
 		var spec *cacheSpec
-		var resolver RegistryClient
 		switch st := dep.Type.(type) {
 		case *config_j5pb.Input_Registry_:
 			spec = &cacheSpec{
@@ -138,7 +190,22 @@ func (src *Resolver) LatestLocks(ctx context.Context, deps []*config_j5pb.Input)
 				repoName:  st.Registry.Name,
 				reference: coalesce(st.Registry.Reference, gl.Ptr("main")),
 			}
-			resolver = src.regClient
+
+		case *config_j5pb.Input_Dir_, *config_j5pb.Input_Git_:
+			lock, err := src.latestLocalLock(ctx, dep)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := seen[lock.Name]; ok {
+				continue
+			}
+			seen[lock.Name] = struct{}{}
+			log.WithFields(ctx, map[string]interface{}{
+				"dep":         lock.Name,
+				"lockVersion": lock.Version,
+			}).Info("Resolver: adding lock")
+			lockFile.Inputs = append(lockFile.Inputs, lock)
+			continue
 
 		default:
 			continue
@@ -150,6 +217,11 @@ func (src *Resolver) LatestLocks(ctx context.Context, deps []*config_j5pb.Input)
 		}
 		seen[fullName] = struct{}{}
 
+		resolver, err := src.remoteClient()
+		if err != nil {
+			return nil, fmt.Errorf("latest version of %s: %w", fullName, err)
+		}
+
 		img, err := resolver.LatestImage(ctx, spec.owner, spec.repoName, spec.reference)
 		if err != nil {
 			return nil, err
@@ -171,9 +243,15 @@ func (src *Resolver) LatestLocks(ctx context.Context, deps []*config_j5pb.Input)
 		})
 		log.Info(ctx, "Resolver: adding lock")
 
+		hash, err := ImageHash(img)
+		if err != nil {
+			return nil, err
+		}
+
 		lock := &config_j5pb.InputLock{
 			Name:    fullName,
 			Version: *img.Version,
+			Hash:    hash,
 		}
 
 		lockFile.Inputs = append(lockFile.Inputs, lock)
@@ -182,18 +260,25 @@ func (src *Resolver) LatestLocks(ctx context.Context, deps []*config_j5pb.Input)
 
 }
 
-func getInputLockVersion(locks *config_j5pb.LockFile, name string) *string {
+func getInputLock(locks *config_j5pb.LockFile, name string) *config_j5pb.InputLock {
 	if locks == nil {
 		return nil
 	}
 	for _, dep := range locks.Inputs {
 		if dep.Name == name {
-			return gl.Ptr(dep.Version)
+			return dep
 		}
 	}
 	return nil
 }
 
+func getInputLockVersion(locks *config_j5pb.LockFile, name string) *string {
+	if lock := getInputLock(locks, name); lock != nil {
+		return gl.Ptr(lock.Version)
+	}
+	return nil
+}
+
 func coalesce[T any](vals ...*T) *T {
 	for _, val := range vals {
 		if val != nil {
@@ -0,0 +1,123 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/pentops/log.go/log"
+)
+
+// VendorDir is the directory, relative to the repo root, holding vendored
+// dependency images. It has the same layout as the j5 cache.
+const VendorDir = ".j5/vendor"
+
+var errRegistryNotSet = errors.New("$J5_REGISTRY not set")
+
+// WithOffline returns a copy of the resolver which only uses vendored and
+// cached images, and fails rather than calling the registry.
+func (rr *Resolver) WithOffline() *Resolver {
+	cp := *rr
+	cp.offline = true
+	return &cp
+}
+
+func (rr *Resolver) getVendoredInput(ctx context.Context, name, version string) (*source_j5pb.SourceImage, bool) {
+	if rr.localRoot == "" {
+		return nil, false
+	}
+	vendor := &j5Cache{
+		dir: filepath.Join(rr.localRoot, VendorDir),
+	}
+	image, ok := vendor.tryGet(ctx, name, version)
+	if !ok {
+		return nil, false
+	}
+	if image.SourceName == "" {
+		image.SourceName = name
+	}
+	return image, true
+}
+
+// remoteClient returns the client to fetch images which are not vendored
+// or cached.
+func (rr *Resolver) remoteClient() (RegistryClient, error) {
+	if rr.offline {
+		return nil, errors.New("offline")
+	}
+	if rr.regClient == nil {
+		return nil, errRegistryNotSet
+	}
+	return rr.regClient, nil
+}
+
+// Vendor writes the locked version of every registry dependency of the repo
+// to dir, replacing anything already there. Dir and git inputs are built from
+// the filesystem so are not vendored.
+func (src *RepoRoot) Vendor(ctx context.Context, dir string) error {
+	allDeps, err := src.ListAllDependencies()
+	if err != nil {
+		return err
+	}
+
+	type vendoredImage struct {
+		name    string
+		version string
+		img     *source_j5pb.SourceImage
+	}
+
+	images := []vendoredImage{}
+	seen := map[string]struct{}{}
+	for _, dep := range allDeps {
+		st, ok := dep.Type.(*config_j5pb.Input_Registry_)
+		if !ok {
+			continue
+		}
+
+		fullName := fmt.Sprintf("registry/%s/%s", st.Registry.Owner, st.Registry.Name)
+		version := st.Registry.Version
+		if version == nil {
+			version = getInputLockVersion(src.thisRepo.lockFile, fullName)
+		}
+		if version == nil {
+			return fmt.Errorf("%s is not locked, run latest-deps first", fullName)
+		}
+
+		key := fullName + "@" + *version
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		img, err := src.resolver.GetRemoteDependency(ctx, dep, src.thisRepo.lockFile)
+		if err != nil {
+			return err
+		}
+		images = append(images, vendoredImage{
+			name:    fullName,
+			version: *version,
+			img:     img,
+		})
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	vendor := &j5Cache{
+		dir: dir,
+	}
+	for _, vendored := range images {
+		log.WithFields(ctx, map[string]interface{}{
+			"dep":     vendored.name,
+			"version": vendored.version,
+		}).Info("Vendoring dependency")
+		if err := vendor.put(ctx, vendored.name, vendored.version, vendored.img); err != nil {
+			return fmt.Errorf("vendoring %s: %w", vendored.name, err)
+		}
+	}
+	return nil
+}
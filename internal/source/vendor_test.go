@@ -0,0 +1,111 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pentops/golib/gl"
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRegistry map[string]*source_j5pb.SourceImage
+
+func (tr testRegistry) GetImage(ctx context.Context, owner, repoName, version string) (*source_j5pb.SourceImage, error) {
+	img, ok := tr[fmt.Sprintf("%s/%s@%s", owner, repoName, version)]
+	if !ok {
+		return nil, fmt.Errorf("image %s/%s@%s not found", owner, repoName, version)
+	}
+	return img, nil
+}
+
+func (tr testRegistry) LatestImage(ctx context.Context, owner, repoName string, reference *string) (*source_j5pb.SourceImage, error) {
+	branch := "main"
+	if reference != nil {
+		branch = *reference
+	}
+	return tr.GetImage(ctx, owner, repoName, branch)
+}
+
+func TestVendor(t *testing.T) {
+	ctx := context.Background()
+
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"j5.yaml":      "packages:\n  - name: test.v1\ndependencies:\n  - registry:\n      owner: pentops\n      name: other\n",
+		"j5-lock.yaml": "inputs:\n  - name: registry/pentops/other\n    version: v1\n",
+	})
+
+	registry := testRegistry{
+		"pentops/other@v1": &source_j5pb.SourceImage{
+			Version: gl.Ptr("v1"),
+		},
+	}
+
+	online := (&Resolver{regClient: registry}).WithLocalRoot(root)
+	repoRoot, err := NewFSRepoRoot(ctx, os.DirFS(root), online)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := repoRoot.Vendor(ctx, filepath.Join(root, VendorDir)); err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.FileExists(t, filepath.Join(root, VendorDir, "registry/pentops/other/v1/src.img"))
+
+	offline := (&Resolver{}).WithLocalRoot(root).WithOffline()
+	locks := &config_j5pb.LockFile{
+		Inputs: []*config_j5pb.InputLock{{
+			Name:    "registry/pentops/other",
+			Version: "v1",
+		}},
+	}
+	input := &config_j5pb.Input{
+		Type: &config_j5pb.Input_Registry_{
+			Registry: &config_j5pb.Input_Registry{
+				Owner: "pentops",
+				Name:  "other",
+			},
+		},
+	}
+
+	img, err := offline.GetRemoteDependency(ctx, input, locks)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	assert.Equal(t, "v1", img.GetVersion())
+	assert.Equal(t, "registry/pentops/other", img.SourceName)
+
+	// Not vendored at the requested version
+	locks.Inputs[0].Version = "v2"
+	_, err = offline.GetRemoteDependency(ctx, input, locks)
+	assert.ErrorContains(t, err, "not vendored or cached")
+
+	// Not locked
+	_, err = offline.GetRemoteDependency(ctx, input, nil)
+	assert.ErrorContains(t, err, "no locked version")
+}
+
+func TestEnvResolverWithoutRegistry(t *testing.T) {
+	t.Setenv("J5_CACHE_DIR", t.TempDir())
+	t.Setenv("J5_REGISTRY", "")
+
+	resolver, err := NewEnvResolver()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = resolver.LatestLocks(context.Background(), []*config_j5pb.Input{{
+		Type: &config_j5pb.Input_Registry_{
+			Registry: &config_j5pb.Input_Registry{
+				Owner: "pentops",
+				Name:  "other",
+			},
+		},
+	}})
+	assert.ErrorIs(t, err, errRegistryNotSet)
+}
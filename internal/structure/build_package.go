@@ -11,6 +11,7 @@ import (
 	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
 	"github.com/pentops/j5/lib/j5schema"
 	"github.com/pentops/j5/lib/patherr"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
@@ -19,7 +20,28 @@ import (
 	"google.golang.org/protobuf/types/descriptorpb"
 )
 
-func APIFromImage(image *source_j5pb.SourceImage) (*source_j5pb.API, error) {
+// API is the source API of an image, along with the state entity event
+// services, which source_j5pb has no type for.
+type API struct {
+	Source *source_j5pb.API
+	Events []*EventService
+}
+
+// EventService is a service classified as EVENTS. Each method receives the
+// event of a single state entity, so the methods are carried as topic
+// messages, with the full name of the event message as the schema.
+type EventService struct {
+	Package    string // versioned package, e.g. "foo.v1"
+	SubPackage string
+	Name       string
+	EntityName string // as in the event's psm option, e.g. "foo"
+	Messages   []*source_j5pb.TopicMessage
+}
+
+// APIFromImage builds the API structure for the packages of the image.
+// Services are classified by the rules in order, falling back to the default
+// name suffixes.
+func APIFromImage(image *source_j5pb.SourceImage, rules ...ServiceRule) (*API, error) {
 
 	bb := packageSet{
 		wantPackages: map[string]bool{},
@@ -41,7 +63,12 @@ func APIFromImage(image *source_j5pb.SourceImage) (*source_j5pb.API, error) {
 		return nil, fmt.Errorf("new files: %w", err)
 	}
 
-	if err := bb.addStructure(descFiles); err != nil {
+	classifier, err := newServiceClassifier(descFiles, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bb.addStructure(descFiles, classifier); err != nil {
 		return nil, err
 	}
 
@@ -62,9 +89,12 @@ func APIFromImage(image *source_j5pb.SourceImage) (*source_j5pb.API, error) {
 	return bb.toAPI(), nil
 }
 
-func (b packageSet) toAPI() *source_j5pb.API {
-	return &source_j5pb.API{
-		Packages: b.packages,
+func (b packageSet) toAPI() *API {
+	return &API{
+		Source: &source_j5pb.API{
+			Packages: b.packages,
+		},
+		Events: b.events,
 	}
 }
 
@@ -86,7 +116,7 @@ func (bb *packageSet) addSchemas(descFiles *protoregistry.Files, selector func(f
 	}
 	return nil
 }
-func (b packageSet) addStructure(descFiles *protoregistry.Files) error {
+func (b *packageSet) addStructure(descFiles *protoregistry.Files, classifier *serviceClassifier) error {
 
 	services := make([]protoreflect.ServiceDescriptor, 0)
 
@@ -109,28 +139,42 @@ func (b packageSet) addStructure(descFiles *protoregistry.Files) error {
 			continue
 		}
 
+		classified, err := classifier.classify(service)
+		if err != nil {
+			return patherr.Wrap(err, string(service.FullName()))
+		}
+		if classified.kind == config_j5pb.ServiceKind_IGNORE {
+			continue
+		}
+
 		pkg, err := b.getSubPackage(packageID)
 		if err != nil {
 			return patherr.Wrap(err, "service", string(service.Name()))
 		}
 
-		name := string(service.Name())
-		if strings.HasSuffix(name, "Service") || strings.HasSuffix(name, "Sandbox") {
+		switch classified.kind {
+		case config_j5pb.ServiceKind_SERVICE:
 			built, err := buildService(service)
 			if err != nil {
-				return patherr.Wrap(err, "service", name)
+				return fmt.Errorf("classified as service by %s: %w", classified.rule, patherr.Wrap(err, string(service.FullName())))
 			}
 			pkg.Services = append(pkg.Services, built)
-		} else if strings.HasSuffix(name, "Events") {
-			// ignore for now.
-		} else if strings.HasSuffix(name, "Topic") {
+		case config_j5pb.ServiceKind_EVENTS:
+			built, err := buildEvents(service)
+			if err != nil {
+				return fmt.Errorf("classified as events by %s: %w", classified.rule, patherr.Wrap(err, string(service.FullName())))
+			}
+			built.Package = packageID.packageName
+			built.SubPackage = pkg.Name
+			b.events = append(b.events, built)
+		case config_j5pb.ServiceKind_TOPIC:
 			built, err := buildTopic(service)
 			if err != nil {
-				return patherr.Wrap(err, string(service.FullName()))
+				return fmt.Errorf("classified as topic by %s: %w", classified.rule, patherr.Wrap(err, string(service.FullName())))
 			}
 			pkg.Topics = append(pkg.Topics, built)
-		} else {
-			return fmt.Errorf("unsupported service name %q", name)
+		default:
+			return fmt.Errorf("%s: unsupported service kind %s", classified.rule, classified.kind)
 		}
 	}
 	return nil
@@ -139,6 +183,7 @@ func (b packageSet) addStructure(descFiles *protoregistry.Files) error {
 
 type packageSet struct {
 	packages     []*source_j5pb.Package
+	events       []*EventService
 	wantPackages map[string]bool
 }
 
@@ -251,31 +296,61 @@ func splitPackageParts(packageName string) (*packageID, error) {
 	}
 }
 
-/*
-func buildEvents(src protoreflect.ServiceDescriptor) ([]*j5schema.Event, error) {
-	events := make([]*j5package.Event, 0)
+// buildEvents builds an Events service, each method receives the event
+// message of the same state entity. The message schema is the full name, as
+// the event is in the entity's package rather than the service's.
+func buildEvents(src protoreflect.ServiceDescriptor) (*EventService, error) {
 	methods := src.Methods()
+	if methods.Len() == 0 {
+		return nil, fmt.Errorf("events service has no methods")
+	}
+	events := &EventService{
+		Name:     string(src.Name()),
+		Messages: make([]*source_j5pb.TopicMessage, 0, methods.Len()),
+	}
 	for ii := 0; ii < methods.Len(); ii++ {
 		method := methods.Get(ii)
-
-		schema, err := refs.SchemaReflect(method.Input())
+		entity, err := eventMethodEntity(method)
 		if err != nil {
-			return nil, fmt.Errorf("method %s: %w", method.FullName(), err)
-		}
-		objSchema, ok := schema.(*j5schema.ObjectSchema)
-		if !ok {
-			return nil, fmt.Errorf("method %s: expected object schema", method.FullName())
+			return nil, patherr.Wrap(err, "method", string(method.Name()))
 		}
-
-		eventSpec := &j5schema.Event{
-			Name:   string(method.Name()),
-			Schema: objSchema,
+		if events.EntityName == "" {
+			events.EntityName = entity
+		} else if entity != events.EntityName {
+			return nil, patherr.Wrap(fmt.Errorf("event is for entity %q, other methods are for %q", entity, events.EntityName), "method", string(method.Name()))
 		}
 
-		events = append(events, eventSpec)
+		events.Messages = append(events.Messages, &source_j5pb.TopicMessage{
+			Name:         string(method.Name()),
+			Schema:       string(method.Input().FullName()),
+			FullGrpcName: string(method.FullName()),
+		})
 	}
 	return events, nil
-}*/
+}
+
+// eventMethodEntity returns the name of the entity which the method input is
+// the event message for.
+func eventMethodEntity(method protoreflect.MethodDescriptor) (string, error) {
+	output := method.Output()
+	if output.FullName() != "google.protobuf.Empty" {
+		return "", fmt.Errorf("j5 events output message must be google.protobuf.Empty, got %q", output.FullName())
+	}
+
+	input := method.Input()
+	psmExt, ok := proto.GetExtension(input.Options(), ext_j5pb.E_Psm).(*ext_j5pb.PSMOptions)
+	if !ok || psmExt == nil {
+		return "", fmt.Errorf("j5 events input message %q is not a state entity event", input.FullName())
+	}
+	if psmExt.EntityPart != nil {
+		if *psmExt.EntityPart != schema_j5pb.EntityPart_EVENT {
+			return "", fmt.Errorf("j5 events input message %q is the entity %s, not the event", input.FullName(), psmExt.EntityPart)
+		}
+	} else if !strings.HasSuffix(string(input.Name()), "Event") {
+		return "", fmt.Errorf("j5 events input message %q is not a state entity event", input.FullName())
+	}
+	return psmExt.EntityName, nil
+}
 
 func buildService(src protoreflect.ServiceDescriptor) (*source_j5pb.Service, error) {
 
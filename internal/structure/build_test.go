@@ -7,15 +7,19 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/pentops/flowtest/prototest"
 	"github.com/pentops/j5/gen/j5/client/v1/client_j5pb"
+	"github.com/pentops/j5/gen/j5/ext/v1/ext_j5pb"
 	"github.com/pentops/j5/gen/j5/schema/v1/schema_j5pb"
 	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/genproto/googleapis/api/annotations"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/testing/protocmp"
 	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 func TestPackageSplit(t *testing.T) {
@@ -320,10 +324,11 @@ func TestBuildPath(t *testing.T) {
 
 	sourceImage := testImage()
 
-	apiSource, err := APIFromImage(sourceImage)
+	api, err := APIFromImage(sourceImage)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
+	apiSource := api.Source
 
 	t.Run("Reflect Direct", func(t *testing.T) {
 		if len(apiSource.Packages) != 1 {
@@ -483,3 +488,130 @@ func assertEqualProto(t *testing.T, want, got proto.Message) {
 		t.Error(diff)
 	}
 }
+
+func psmMessage(name string, entityName string, fields ...*descriptorpb.FieldDescriptorProto) *descriptorpb.DescriptorProto {
+	msg := &descriptorpb.DescriptorProto{
+		Name:    proto.String(name),
+		Field:   fields,
+		Options: &descriptorpb.MessageOptions{},
+	}
+	proto.SetExtension(msg.Options, ext_j5pb.E_Psm, &ext_j5pb.PSMOptions{
+		EntityName: entityName,
+	})
+	return msg
+}
+
+func testEventsImage(serviceName, inputType string) *source_j5pb.SourceImage {
+	keysField := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String("keys"),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		Number:   proto.Int32(1),
+		TypeName: proto.String(".test.v1.FooKeys"),
+	}
+	return &source_j5pb.SourceImage{
+		Packages: []*source_j5pb.PackageInfo{{
+			Label: "Test",
+			Name:  "test.v1",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto), {
+				Syntax:  proto.String("proto3"),
+				Name:    proto.String("test/v1/test.proto"),
+				Package: proto.String("test.v1"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					psmMessage("FooKeys", "foo", &descriptorpb.FieldDescriptorProto{
+						Name:   proto.String("foo_id"),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Number: proto.Int32(1),
+					}),
+					psmMessage("FooEvent", "foo", keysField),
+				},
+			}, {
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("test/v1/topic/events.proto"),
+				Package:    proto.String("test.v1.topic"),
+				Dependency: []string{"google/protobuf/empty.proto", "test/v1/test.proto"},
+				Service: []*descriptorpb.ServiceDescriptorProto{{
+					Name: proto.String(serviceName),
+					Method: []*descriptorpb.MethodDescriptorProto{{
+						Name:       proto.String("Event"),
+						InputType:  proto.String(inputType),
+						OutputType: proto.String(".google.protobuf.Empty"),
+					}},
+				}},
+			}},
+	}
+}
+
+func TestBuildEvents(t *testing.T) {
+	api, err := APIFromImage(testEventsImage("FooEvents", ".test.v1.FooEvent"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	apiSource := api.Source
+	if len(apiSource.Packages) != 1 {
+		t.Fatalf("unexpected packages: %d", len(apiSource.Packages))
+	}
+	if len(apiSource.Packages[0].SubPackages) != 1 {
+		t.Fatalf("unexpected subpackages: %d", len(apiSource.Packages[0].SubPackages))
+	}
+	if topics := apiSource.Packages[0].SubPackages[0].Topics; len(topics) != 0 {
+		t.Errorf("events service should not be a topic, got %d topics", len(topics))
+	}
+
+	if len(api.Events) != 1 {
+		t.Fatalf("unexpected event services: %d", len(api.Events))
+	}
+	events := api.Events[0]
+	assert.Equal(t, "test.v1", events.Package)
+	assert.Equal(t, "topic", events.SubPackage)
+	assert.Equal(t, "FooEvents", events.Name)
+	assert.Equal(t, "foo", events.EntityName)
+	if len(events.Messages) != 1 {
+		t.Fatalf("unexpected methods: %d", len(events.Messages))
+	}
+	assertEqualProto(t, &source_j5pb.TopicMessage{
+		Name:         "Event",
+		Schema:       "test.v1.FooEvent",
+		FullGrpcName: "test.v1.topic.FooEvents.Event",
+	}, events.Messages[0])
+
+	t.Run("Not Event", func(t *testing.T) {
+		_, err := APIFromImage(testEventsImage("FooEvents", ".test.v1.FooKeys"))
+		if err == nil {
+			t.Fatal("expected error for non event input")
+		}
+		t.Log(err.Error())
+	})
+
+	t.Run("No Methods", func(t *testing.T) {
+		img := testEventsImage("FooEvents", ".test.v1.FooEvent")
+		img.File[2].Service[0].Method = nil
+		_, err := APIFromImage(img)
+		if err == nil {
+			t.Fatal("expected error for an events service with no methods")
+		}
+		t.Log(err.Error())
+	})
+
+	t.Run("By Rule", func(t *testing.T) {
+		rules, err := RulesFromConfig(&config_j5pb.StructureConfig{
+			Services: []*config_j5pb.ServiceRule{{
+				Name: "test.v1.topic.FooFeed",
+				Kind: config_j5pb.ServiceKind_EVENTS,
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		api, err := APIFromImage(testEventsImage("FooFeed", ".test.v1.FooEvent"), rules...)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(api.Events) != 1 || api.Events[0].Name != "FooFeed" {
+			t.Fatalf("expected FooFeed as an event service, got %v", api.Events)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package structure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// ServiceRule classifies services matching exactly one of Suffix, Option or
+// Name as Kind.
+type ServiceRule struct {
+	Suffix string
+	Option protoreflect.FullName
+	Name   protoreflect.FullName
+	Kind   config_j5pb.ServiceKind
+}
+
+func (rule ServiceRule) String() string {
+	switch {
+	case rule.Suffix != "":
+		return fmt.Sprintf("suffix %q", rule.Suffix)
+	case rule.Option != "":
+		return fmt.Sprintf("option %q", rule.Option)
+	default:
+		return fmt.Sprintf("name %q", rule.Name)
+	}
+}
+
+// RulesFromConfig validates the service rules from j5.yaml, a nil config has
+// no rules, using only the default name suffixes.
+func RulesFromConfig(cfg *config_j5pb.StructureConfig) ([]ServiceRule, error) {
+	rules := make([]ServiceRule, 0, len(cfg.GetServices()))
+	for idx, src := range cfg.GetServices() {
+		rule := ServiceRule{
+			Suffix: src.Suffix,
+			Option: protoreflect.FullName(src.Option),
+			Name:   protoreflect.FullName(src.Name),
+			Kind:   src.Kind,
+		}
+
+		set := 0
+		for _, val := range []string{src.Suffix, src.Option, src.Name} {
+			if val != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, fmt.Errorf("service rule %d: expecting exactly one of suffix, option or name, got %d", idx+1, set)
+		}
+		if rule.Option != "" && !rule.Option.IsValid() {
+			return nil, fmt.Errorf("service rule %d (%s): invalid option name", idx+1, rule)
+		}
+		if rule.Name != "" && !rule.Name.IsValid() {
+			return nil, fmt.Errorf("service rule %d (%s): invalid service name", idx+1, rule)
+		}
+		if rule.Kind == config_j5pb.ServiceKind_UNSPECIFIED {
+			return nil, fmt.Errorf("service rule %d (%s): kind is required", idx+1, rule)
+		}
+		if _, ok := config_j5pb.ServiceKind_name[int32(rule.Kind)]; !ok {
+			return nil, fmt.Errorf("service rule %d (%s): unknown kind %d", idx+1, rule, rule.Kind)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// defaultRules are the name conventions used when no configured rule
+// matches.
+var defaultRules = []ServiceRule{
+	{Suffix: "Service", Kind: config_j5pb.ServiceKind_SERVICE},
+	{Suffix: "Sandbox", Kind: config_j5pb.ServiceKind_SERVICE},
+	{Suffix: "Events", Kind: config_j5pb.ServiceKind_EVENTS},
+	{Suffix: "Topic", Kind: config_j5pb.ServiceKind_TOPIC},
+}
+
+type serviceClassifier struct {
+	rules []ServiceRule
+
+	// option field numbers, resolved against the image
+	options map[protoreflect.FullName]protoreflect.FieldNumber
+}
+
+func newServiceClassifier(descFiles *protoregistry.Files, rules []ServiceRule) (*serviceClassifier, error) {
+	sc := &serviceClassifier{
+		rules:   rules,
+		options: map[protoreflect.FullName]protoreflect.FieldNumber{},
+	}
+
+	for idx, rule := range rules {
+		if rule.Option == "" {
+			continue
+		}
+		desc, err := descFiles.FindDescriptorByName(rule.Option)
+		if err != nil {
+			desc, err = protoregistry.GlobalFiles.FindDescriptorByName(rule.Option)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("service rule %d (%s): option not found", idx+1, rule)
+		}
+		ext, ok := desc.(protoreflect.ExtensionDescriptor)
+		if !ok || ext.ContainingMessage().FullName() != "google.protobuf.ServiceOptions" {
+			return nil, fmt.Errorf("service rule %d (%s): not a service option", idx+1, rule)
+		}
+		sc.options[rule.Option] = ext.Number()
+	}
+
+	return sc, nil
+}
+
+// Classifier classifies services by the rules as APIFromImage does, for
+// exports which read services from the image directly.
+type Classifier struct {
+	sc *serviceClassifier
+}
+
+// NewClassifier resolves the option rules against the files.
+func NewClassifier(descFiles *protoregistry.Files, rules ...ServiceRule) (*Classifier, error) {
+	sc, err := newServiceClassifier(descFiles, rules)
+	if err != nil {
+		return nil, err
+	}
+	return &Classifier{sc: sc}, nil
+}
+
+// Kind returns the kind of the service, from the first rule which matches.
+func (c *Classifier) Kind(service protoreflect.ServiceDescriptor) (config_j5pb.ServiceKind, error) {
+	classified, err := c.sc.classify(service)
+	if err != nil {
+		return config_j5pb.ServiceKind_UNSPECIFIED, err
+	}
+	return classified.kind, nil
+}
+
+// classifiedService is the kind for a service, and the rule which matched it
+// for errors.
+type classifiedService struct {
+	kind config_j5pb.ServiceKind
+	rule string
+}
+
+func (sc *serviceClassifier) classify(service protoreflect.ServiceDescriptor) (*classifiedService, error) {
+	for idx, rule := range sc.rules {
+		if sc.matches(rule, service) {
+			return &classifiedService{
+				kind: rule.Kind,
+				rule: fmt.Sprintf("service rule %d (%s)", idx+1, rule),
+			}, nil
+		}
+	}
+
+	for _, rule := range defaultRules {
+		if sc.matches(rule, service) {
+			return &classifiedService{
+				kind: rule.Kind,
+				rule: fmt.Sprintf("default rule (%s)", rule),
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported service name %q, matches no service rule or default suffix (Service, Sandbox, Events, Topic)", service.Name())
+}
+
+func (sc *serviceClassifier) matches(rule ServiceRule, service protoreflect.ServiceDescriptor) bool {
+	switch {
+	case rule.Suffix != "":
+		return strings.HasSuffix(string(service.Name()), rule.Suffix)
+	case rule.Name != "":
+		return service.FullName() == rule.Name
+	case rule.Option != "":
+		return hasOption(service.Options().ProtoReflect(), rule.Option, sc.options[rule.Option])
+	}
+	return false
+}
+
+// hasOption checks for the extension by name where the extension type is
+// linked in, otherwise by field number in the unknown fields, as options from
+// the image are not resolved.
+func hasOption(opts protoreflect.Message, name protoreflect.FullName, number protoreflect.FieldNumber) bool {
+	found := false
+	opts.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.FullName() == name {
+			found = true
+			return false
+		}
+		return true
+	})
+	if found {
+		return true
+	}
+
+	unknown := opts.GetUnknown()
+	for len(unknown) > 0 {
+		num, typ, n := protowire.ConsumeTag(unknown)
+		if n < 0 {
+			return false
+		}
+		if num == number {
+			return true
+		}
+		unknown = unknown[n:]
+		n = protowire.ConsumeFieldValue(num, typ, unknown)
+		if n < 0 {
+			return false
+		}
+		unknown = unknown[n:]
+	}
+	return false
+}
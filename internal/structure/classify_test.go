@@ -0,0 +1,187 @@
+package structure
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pentops/j5/gen/j5/source/v1/source_j5pb"
+	"github.com/pentops/j5build/gen/j5/config/v1/config_j5pb"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// testThirdPartyImage has services which do not follow the naming
+// conventions. FooAPI is a topic, VendorAdmin is marked with the
+// test.v1.internal option, which is not linked in so is an unknown field.
+func testThirdPartyImage() *source_j5pb.SourceImage {
+	internalOpts := &descriptorpb.ServiceOptions{}
+	internalOpts.ProtoReflect().SetUnknown(protowire.AppendVarint(protowire.AppendTag(nil, 50001, protowire.VarintType), 1))
+
+	return &source_j5pb.SourceImage{
+		Packages: []*source_j5pb.PackageInfo{{
+			Name: "test.v1",
+		}},
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(emptypb.File_google_protobuf_empty_proto),
+			protodesc.ToFileDescriptorProto(descriptorpb.File_google_protobuf_descriptor_proto), {
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("test/v1/options.proto"),
+				Package:    proto.String("test.v1"),
+				Dependency: []string{"google/protobuf/descriptor.proto"},
+				Extension: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("internal"),
+					Number:   proto.Int32(50001),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_BOOL.Enum(),
+					Extendee: proto.String(".google.protobuf.ServiceOptions"),
+				}},
+			}, {
+				Syntax:     proto.String("proto3"),
+				Name:       proto.String("test/v1/topic/foo.proto"),
+				Package:    proto.String("test.v1.topic"),
+				Dependency: []string{"google/protobuf/empty.proto"},
+				MessageType: []*descriptorpb.DescriptorProto{{
+					Name: proto.String("FooMessage"),
+				}},
+				Service: []*descriptorpb.ServiceDescriptorProto{{
+					Name: proto.String("FooAPI"),
+					Method: []*descriptorpb.MethodDescriptorProto{{
+						Name:       proto.String("Foo"),
+						InputType:  proto.String(".test.v1.topic.FooMessage"),
+						OutputType: proto.String(".google.protobuf.Empty"),
+					}},
+				}, {
+					Name:    proto.String("VendorAdmin"),
+					Options: internalOpts,
+					Method: []*descriptorpb.MethodDescriptorProto{{
+						Name:       proto.String("Reset"),
+						InputType:  proto.String(".google.protobuf.Empty"),
+						OutputType: proto.String(".google.protobuf.Empty"),
+					}},
+				}},
+			}},
+	}
+}
+
+func TestServiceRules(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		_, err := APIFromImage(testThirdPartyImage())
+		if err == nil {
+			t.Fatal("expected error for unsupported service name")
+		}
+		if !strings.Contains(err.Error(), "unsupported service name") {
+			t.Errorf("unexpected error: %s", err)
+		}
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		rules, err := RulesFromConfig(&config_j5pb.StructureConfig{
+			Services: []*config_j5pb.ServiceRule{{
+				Option: "test.v1.internal",
+				Kind:   config_j5pb.ServiceKind_IGNORE,
+			}, {
+				Suffix: "API",
+				Kind:   config_j5pb.ServiceKind_TOPIC,
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		api, err := APIFromImage(testThirdPartyImage(), rules...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		subPkgs := api.Source.Packages[0].SubPackages
+		if len(subPkgs) != 1 {
+			t.Fatalf("unexpected subpackages: %d", len(subPkgs))
+		}
+		assertEqualProto(t, &source_j5pb.SubPackage{
+			Name: "topic",
+			Topics: []*source_j5pb.Topic{{
+				Name: "FooAPI",
+				Messages: []*source_j5pb.TopicMessage{{
+					Name:         "Foo",
+					Schema:       "FooMessage",
+					FullGrpcName: "test.v1.topic.FooAPI.Foo",
+				}},
+			}},
+			Schemas: subPkgs[0].Schemas,
+		}, subPkgs[0])
+	})
+
+	t.Run("Rule Fails", func(t *testing.T) {
+		rules, err := RulesFromConfig(&config_j5pb.StructureConfig{
+			Services: []*config_j5pb.ServiceRule{{
+				Name: "test.v1.topic.VendorAdmin",
+				Kind: config_j5pb.ServiceKind_TOPIC,
+			}, {
+				Suffix: "API",
+				Kind:   config_j5pb.ServiceKind_TOPIC,
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = APIFromImage(testThirdPartyImage(), rules...)
+		if err == nil {
+			t.Fatal("expected error building VendorAdmin as a topic")
+		}
+		if !strings.Contains(err.Error(), `service rule 1 (name "test.v1.topic.VendorAdmin")`) {
+			t.Errorf("error does not name the rule: %s", err)
+		}
+	})
+
+	t.Run("Unknown Option", func(t *testing.T) {
+		rules, err := RulesFromConfig(&config_j5pb.StructureConfig{
+			Services: []*config_j5pb.ServiceRule{{
+				Option: "test.v1.missing",
+				Kind:   config_j5pb.ServiceKind_IGNORE,
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = APIFromImage(testThirdPartyImage(), rules...)
+		if err == nil {
+			t.Fatal("expected error for missing option")
+		}
+		t.Log(err.Error())
+	})
+}
+
+func TestRulesFromConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		rule *config_j5pb.ServiceRule
+	}{{
+		name: "no match",
+		rule: &config_j5pb.ServiceRule{Kind: config_j5pb.ServiceKind_SERVICE},
+	}, {
+		name: "two matches",
+		rule: &config_j5pb.ServiceRule{Suffix: "API", Name: "foo.v1.FooAPI", Kind: config_j5pb.ServiceKind_SERVICE},
+	}, {
+		name: "no kind",
+		rule: &config_j5pb.ServiceRule{Suffix: "API"},
+	}, {
+		name: "invalid name",
+		rule: &config_j5pb.ServiceRule{Name: "foo..API", Kind: config_j5pb.ServiceKind_SERVICE},
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := RulesFromConfig(&config_j5pb.StructureConfig{
+				Services: []*config_j5pb.ServiceRule{tc.rule},
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.HasPrefix(err.Error(), "service rule 1") {
+				t.Errorf("error does not name the rule: %s", err)
+			}
+		})
+	}
+}